@@ -21,6 +21,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -61,6 +62,7 @@ var (
 	metadataNamespace string
 	outputDirectory   string
 	noConfig          bool
+	dropObsolete      bool
 	crdTemplate       string
 	crdGroup          string
 	moduleSearchPath  string
@@ -74,6 +76,7 @@ func init() {
 	opt.StringVarLong(&crdName, "crd-name", 'n', "specify crd name for openapiv3 schema")
 	opt.StringVarLong(&outputDirectory, "output-dir", 'd', "specify output directory name for generating openapiv3 schema. Defaults to current directory.")
 	opt.BoolVarLong(&noConfig, "no-config", 'o', "enable crd generation with config false. An example could be querying operational status.")
+	opt.BoolVarLong(&dropObsolete, "drop-obsolete", 0, "leave out nodes with status obsolete from the crd schema.")
 	opt.StringVarLong(&crdTemplate, "crd-template", 'l', "specify template file to generate the crd schema.")
 	opt.StringVarLong(&metadataNamespace, "metadata-namespace", 'm', "specify metadata namespace to generate the crd metadata.")
 	opt.StringVarLong(&crdGroup, "group", 'u', "specify group name for crd creation.")
@@ -183,7 +186,7 @@ func doCrd(w io.Writer, entries []*yang.Entry, filename string, dependencies []s
 		generateStatus(crdOptions, processEntry)
 	}
 
-	if err := generateMetadata(filename, dependencies, metadataNamespace, crdOptions); err != nil {
+	if err := generateMetadata(filename, moduleDependencies(entries, filename, dependencies), metadataNamespace, crdOptions); err != nil {
 		fmt.Fprintf(os.Stderr, "generating metadata failed with error: %s\n", err.Error())
 		os.Exit(1)
 	}
@@ -248,19 +251,25 @@ func generateStatusFields(builder *strings.Builder, processEntry *yang.Entry, pr
 
 	sort.Strings(names)
 
-	readOnlyRootNode := processEntry.ReadOnly()
+	// The status fields are the children that are in the operational
+	// datastore but not in the running one, all of them if the root node
+	// is not configuration.
+	operational, errs := processEntry.View(yang.Operational)
+	exitIfError(errs)
+	running, errs := processEntry.View(yang.Running)
+	exitIfError(errs)
 
 	for _, name := range names {
-		if readOnlyRootNode { //all nodes below it are read-only if root node is read-only
-			WriteCrd(indent.NewWriter(builder, indent.GetPrefix(prefixLen)), processEntry.Dir[name])
-		} else if processEntry.Dir[name].ReadOnly() {
-			// take only the children of root nodes that are config false
+		if operational == nil || operational.Dir[name] == nil {
+			continue
+		}
+		if running == nil || running.Dir[name] == nil {
 			WriteCrd(indent.NewWriter(builder, indent.GetPrefix(prefixLen)), processEntry.Dir[name])
 		}
 	}
 
 	if property {
-		if readOnlyRootNode {
+		if running == nil {
 			emitCrdRequired(builder, processEntry, "")
 		}
 
@@ -360,7 +369,7 @@ func executeTemplate(options *CrdOptions, spec, status string) {
 
 // Write writes e, formatted, and all of its children, to w.
 func WriteCrd(w io.Writer, e *yang.Entry) {
-	if e.RPC != nil {
+	if e.RPC != nil || dropped(e) {
 		return
 	}
 
@@ -401,6 +410,14 @@ func WriteCrd(w io.Writer, e *yang.Entry) {
 	fmt.Fprintf(w, "%s:\n", name)
 	prefixLen += 2
 
+	if e.Status == yang.StatusDeprecated {
+		description := "Deprecated."
+		if e.Description != "" {
+			description = "Deprecated: " + e.Description
+		}
+		fmt.Fprintf(w, "  description: %s\n", strconv.Quote(description))
+	}
+
 	switch {
 	case e.Dir == nil && e.ListAttr != nil:
 		fmt.Fprintln(w, "  items:")
@@ -453,25 +470,55 @@ func emitCrdRequired(w io.Writer, e *yang.Entry, prefix string) {
 		return
 	}
 
-	required := strings.Split(strings.TrimSpace(e.Key), " ")
-	fmt.Fprintf(w, "%srequired:\n", prefix)
+	var required []string
+	for _, field := range strings.Fields(e.Key) {
+		if !dropped(e.Dir[field]) {
+			required = append(required, field)
+		}
+	}
+	if len(required) == 0 {
+		return
+	}
 
+	fmt.Fprintf(w, "%srequired:\n", prefix)
 	for _, field := range required {
 		fmt.Fprintf(w, "%s- %s\n", prefix, yang.CamelCase(field, false))
 	}
 }
 
+// dropped returns true if e is left out of the crd schema because it is
+// obsolete.
+func dropped(e *yang.Entry) bool {
+	return dropObsolete && e != nil && e.Status == yang.StatusObsolete
+}
+
 func emitCrdType(w io.Writer, e *yang.Entry, prefix string) {
-	if e == nil || e.Type == nil || e.Type.Root.Name == "" {
+	// A leafref has the type of the leaf it references.
+	var t *yang.YangType
+	if e != nil {
+		t = e.Type
+		if lt, err := e.LeafrefType(); err == nil {
+			t = lt
+		}
+	}
+
+	if t == nil || t.Root.Name == "" {
 		fmt.Fprintf(w, "%stype: string\n", prefix)
 
 		return
 	}
 
-	if e.Type.Kind == yang.Yenum {
-		names := e.Type.Enum.Names()
+	if t.Kind == yang.Yenum {
+		var names []string
+		for _, n := range t.Enum.Names() {
+			if !dropObsolete || t.Enum.Status(n) != yang.StatusObsolete {
+				names = append(names, n)
+			}
+		}
 
-		fmt.Fprintf(w, "%senum:\n", prefix)
+		if len(names) > 0 {
+			fmt.Fprintf(w, "%senum:\n", prefix)
+		}
 		for _, n := range names {
 			name := BooleanToStringMap[strings.ToLower(n)]
 			if name == "" {
@@ -485,7 +532,21 @@ func emitCrdType(w io.Writer, e *yang.Entry, prefix string) {
 		return
 	}
 
-	crdType, ok := TypeMap[e.Type.Root.Name]
+	// An identityref is one of the module qualified names of the
+	// identities derived from all of its bases.
+	if t.Kind == yang.Yidentityref {
+		if names := t.AllowedIdentities(); len(names) > 0 {
+			fmt.Fprintf(w, "%senum:\n", prefix)
+			for _, n := range names {
+				fmt.Fprintf(w, "%s- %s\n", prefix, n)
+			}
+		}
+
+		fmt.Fprintf(w, "%stype: string\n", prefix)
+		return
+	}
+
+	crdType, ok := TypeMap[t.Root.Name]
 	if !ok {
 		crdType = "string"
 	}
@@ -493,13 +554,13 @@ func emitCrdType(w io.Writer, e *yang.Entry, prefix string) {
 	fmt.Fprintf(w, "%stype: %s\n", prefix, crdType)
 
 	// add ranges for integers
-	if crdType == "integer" && len(e.Type.Range) == 1 && e.Type.Range[0].Valid() {
-		min, err := e.Type.Range[0].Min.Int()
+	if crdType == "integer" && len(t.Range) == 1 && t.Range[0].Valid() {
+		min, err := t.Range[0].Min.Int()
 		if err != nil {
 			return
 		}
 
-		max, err := e.Type.Range[0].Max.Int()
+		max, err := t.Range[0].Max.Int()
 		if err != nil {
 			return
 		}
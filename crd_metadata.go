@@ -9,6 +9,9 @@ import (
 	"path"
 	"strings"
 	"text/template"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yangdeps"
 )
 
 const (
@@ -43,6 +46,29 @@ type yangMetadata struct {
 	ModelSearchPath       string
 }
 
+// moduleDependencies returns the names of the files of the modules that the
+// module read from filename depends on, directly or indirectly, and of the
+// modules that augment or deviate it, in the order they must be loaded.  The
+// other files named on the command line, dependencies, are returned if the
+// module is not found.
+func moduleDependencies(entries []*yang.Entry, filename string, dependencies []string) []string {
+	if len(entries) == 0 {
+		return dependencies
+	}
+	m, ok := entries[0].Node.(*yang.Module)
+	if !ok {
+		return dependencies
+	}
+	files, err := yangdeps.Build(m.Modules).SchemaFiles(sourceName(filename))
+	if err != nil {
+		return dependencies
+	}
+	for i, f := range files {
+		files[i] = path.Base(f)
+	}
+	return files
+}
+
 func generateMetadata(filename string, dependencies []string, namespace string, options *CrdOptions) error {
 	if namespace == "" {
 		namespace = "default"
@@ -0,0 +1,103 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+func TestModuleDependencies(t *testing.T) {
+	ms := yang.NewModules()
+	for _, m := range []struct {
+		file, content string
+	}{{
+		file: "dir/types.yang",
+		content: `module types {
+  namespace "urn:types";
+  prefix t;
+  typedef name { type string; }
+}`,
+	}, {
+		file: "dir/sys.yang",
+		content: `module sys {
+  namespace "urn:sys";
+  prefix s;
+  import types { prefix t; }
+  container system { leaf name { type t:name; } }
+}`,
+	}, {
+		file: "dir/sys-ext.yang",
+		content: `module sys-ext {
+  namespace "urn:sys-ext";
+  prefix x;
+  import sys { prefix s; }
+  augment /s:system { leaf mtu { type uint16; } }
+}`,
+	}, {
+		file: "dir/sys-dev.yang",
+		content: `module sys-dev {
+  namespace "urn:sys-dev";
+  prefix d;
+  import sys { prefix s; }
+  deviation /s:system/s:name { deviate not-supported; }
+}`,
+	}} {
+		if err := ms.Parse(m.content, m.file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	entries := []*yang.Entry{yang.ToEntry(ms.Modules["sys"])}
+	cmdline := []string{"dir/sys-ext.yang", "dir/sys-dev.yang"}
+
+	tests := []struct {
+		desc     string
+		entries  []*yang.Entry
+		filename string
+		want     []string
+	}{{
+		desc:     "augmented and deviated",
+		entries:  entries,
+		filename: "dir/sys.yang",
+		want:     []string{"types.yang", "sys-dev.yang", "sys-ext.yang"},
+	}, {
+		desc:     "unknown module",
+		entries:  entries,
+		filename: "dir/other.yang",
+		want:     cmdline,
+	}, {
+		desc:     "not a module",
+		entries:  []*yang.Entry{entries[0].Dir["system"]},
+		filename: "dir/sys.yang",
+		want:     cmdline,
+	}, {
+		desc:     "no entries",
+		filename: "dir/sys.yang",
+		want:     cmdline,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := moduleDependencies(tt.entries, tt.filename, cmdline)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("moduleDependencies (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+var (
+	// datastore is the NMDA datastore whose view of the modules is
+	// formatted, if any.
+	datastore string
+	// datastoreDeviations are the modules whose deviations only apply to
+	// the schema of a datastore, as DATASTORE:MODULE.
+	datastoreDeviations []string
+)
+
+// parseDatastoreDeviations returns datastoreDeviations as the
+// DatastoreDeviations parse option.
+func parseDatastoreDeviations() (map[yang.Datastore][]string, error) {
+	if len(datastoreDeviations) == 0 {
+		return nil, nil
+	}
+	devs := map[yang.Datastore][]string{}
+	for _, dd := range datastoreDeviations {
+		// The datastore may be qualified by its module name.
+		i := strings.LastIndex(dd, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid datastore deviation %q, want DATASTORE:MODULE", dd)
+		}
+		d, err := yang.ParseDatastore(dd[:i])
+		if err != nil {
+			return nil, err
+		}
+		devs[d] = append(devs[d], dd[i+1:])
+	}
+	return devs, nil
+}
+
+// datastoreViews returns the views of entries in datastore, or entries if no
+// datastore is set.
+func datastoreViews(entries []*yang.Entry) ([]*yang.Entry, []error) {
+	if datastore == "" {
+		return entries, nil
+	}
+	d, err := yang.ParseDatastore(datastore)
+	if err != nil {
+		return nil, []error{err}
+	}
+	var views []*yang.Entry
+	var errs []error
+	for _, e := range entries {
+		v, verrs := e.View(d)
+		errs = append(errs, verrs...)
+		if v != nil {
+			views = append(views, v)
+		}
+	}
+	return views, errs
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yangdeps"
+	"github.com/pborman/getopt"
+)
+
+var (
+	// depsFormat is the format of the dependency graph.
+	depsFormat = "tree"
+	// depsModule is the module whose file closure is listed.
+	depsModule string
+)
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:  "deps",
+		f:     doDeps,
+		help:  "display the import, include, augment and deviation graph of the modules, its cycles, load order and the files a module needs",
+		flags: flags,
+	})
+	flags.EnumVarLong(&depsFormat, "deps-format", 0, []string{"tree", "dot", "json"}, "format of the graph: tree, dot or json", "FORMAT")
+	flags.StringVarLong(&depsModule, "deps-module", 0, "list the files MODULE needs, by default the module of the first SOURCE", "MODULE")
+}
+
+// depsReport is the JSON form of the deps format.
+type depsReport struct {
+	*yangdeps.Graph
+	Cycles    [][]string `json:"cycles,omitempty"`
+	LoadOrder []string   `json:"loadOrder"`
+	Module    string     `json:"module,omitempty"`
+	Files     []string   `json:"files,omitempty"`
+}
+
+func doDeps(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if len(entries) == 0 {
+		return
+	}
+	g := yangdeps.Build(entries[0].Node.(*yang.Module).Modules)
+
+	module := depsModule
+	if module == "" && filename != "" && g.Module(sourceName(filename)) != nil {
+		module = sourceName(filename)
+	}
+	var files []string
+	if module != "" {
+		var err error
+		if files, err = g.Files(module); err != nil {
+			exitIfError([]error{err})
+		}
+	}
+
+	switch depsFormat {
+	case "dot":
+		if err := g.WriteDOT(w); err != nil {
+			exitIfError([]error{err})
+		}
+	case "json":
+		b, err := json.MarshalIndent(&depsReport{
+			Graph:     g,
+			Cycles:    g.Cycles(),
+			LoadOrder: g.LoadOrder(),
+			Module:    module,
+			Files:     files,
+		}, "", "  ")
+		if err != nil {
+			exitIfError([]error{err})
+		}
+		fmt.Fprintf(w, "%s\n", b)
+	default:
+		var roots []string
+		if module != "" {
+			roots = append(roots, module)
+		}
+		if err := g.WriteTree(w, roots...); err != nil {
+			exitIfError([]error{err})
+		}
+		for _, c := range g.Cycles() {
+			fmt.Fprintf(w, "\ncycle: %s\n", strings.Join(c, " "))
+		}
+		fmt.Fprintf(w, "\nload order: %s\n", strings.Join(g.LoadOrder(), " "))
+		if module != "" {
+			fmt.Fprintf(w, "\nfiles needed by %s:\n", module)
+			for _, f := range files {
+				fmt.Fprintf(w, "  %s\n", f)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file writes the errors found in the YANG sources as text, one per
+// line, or, for CI systems, as a JSON list of diagnostics or a SARIF 2.1.0
+// log, https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// diagnosticsFormat is the format errors are written in: text, json or
+// sarif.
+var diagnosticsFormat = "text"
+
+// diagnostics are the diagnostics reported so far when diagnosticsFormat is
+// not text.  They are written as one document by flushDiagnostics.
+var diagnostics yang.Diagnostics
+
+// reportErrors writes errs to w when diagnosticsFormat is text, otherwise it
+// adds them to diagnostics.
+func reportErrors(w io.Writer, errs []error) {
+	if diagnosticsFormat == "text" {
+		for _, err := range errs {
+			fmt.Fprintln(w, err)
+		}
+		return
+	}
+	diagnostics = append(diagnostics, yang.ToDiagnostics(errs...)...)
+}
+
+// flushDiagnostics writes the diagnostics reported so far to w in
+// diagnosticsFormat.  Nothing is written when there are none.
+func flushDiagnostics(w io.Writer) {
+	if len(diagnostics) == 0 {
+		return
+	}
+	var v interface{} = diagnostics
+	if diagnosticsFormat == "sarif" {
+		v = sarifLog(diagnostics)
+	}
+	diagnostics = nil
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", b)
+}
+
+// The subset of the SARIF object model written by sarifLog.
+type (
+	sarif struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		RuleID           string          `json:"ruleId"`
+		Level            string          `json:"level"`
+		Message          sarifMessage    `json:"message"`
+		Locations        []sarifLocation `json:"locations,omitempty"`
+		RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		ID               int                   `json:"id,omitempty"`
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+		Message          *sarifMessage         `json:"message,omitempty"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// sarifLocationOf returns l as a SARIF location, nil if its file is not
+// known.
+func sarifLocationOf(l yang.Location) *sarifLocation {
+	if l.File == "" {
+		return nil
+	}
+	sl := &sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: l.File},
+	}}
+	if l.Line > 0 {
+		sl.PhysicalLocation.Region = &sarifRegion{StartLine: l.Line, StartColumn: l.Col}
+	}
+	return sl
+}
+
+// sarifLog returns ds as a SARIF log with one run, one rule per diagnostic
+// code.
+func sarifLog(ds yang.Diagnostics) *sarif {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "goyang",
+			InformationURI: "https://github.com/karthick18/goyang",
+		}},
+		Results: []sarifResult{},
+	}
+	codes := map[yang.Code]bool{}
+	for _, d := range ds {
+		codes[d.Code] = true
+		r := sarifResult{
+			RuleID:  string(d.Code),
+			Level:   "error",
+			Message: sarifMessage{Text: d.Message},
+		}
+		if d.Severity == yang.SeverityWarning {
+			r.Level = "warning"
+		}
+		if l := sarifLocationOf(d.Location); l != nil {
+			r.Locations = []sarifLocation{*l}
+		}
+		for i, rl := range d.Related {
+			if l := sarifLocationOf(rl.Location); l != nil {
+				l.ID = i + 1
+				l.Message = &sarifMessage{Text: rl.Message}
+				r.RelatedLocations = append(r.RelatedLocations, *l)
+			}
+		}
+		run.Results = append(run.Results, r)
+	}
+	var ids []string
+	for c := range codes {
+		ids = append(ids, string(c))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id})
+	}
+	return &sarif{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
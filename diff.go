@@ -0,0 +1,104 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yangdiff"
+	"github.com/pborman/getopt"
+)
+
+var (
+	diffOldPaths []string
+	diffNewPaths []string
+	diffJSON     bool
+)
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:       "diff",
+		f:          doDiff,
+		standalone: true,
+		help:       "compare the old and new revisions of the named modules and report backwards incompatible changes, exiting with status 1 if there are any",
+		flags:      flags,
+	})
+	flags.ListVarLong(&diffOldPaths, "old-path", 0, "comma separated list of directories holding the old revision of the modules", "DIR[,DIR...]")
+	flags.ListVarLong(&diffNewPaths, "new-path", 0, "comma separated list of directories holding the new revision of the modules", "DIR[,DIR...]")
+	flags.BoolVarLong(&diffJSON, "json", 0, "write the report as JSON")
+}
+
+// diffModules returns the processed modules found in paths, reading the
+// named modules with the parse options of the main load.  Modules that are
+// not in paths are looked for in the --path directories.
+func diffModules(paths, names []string) (*yang.Modules, []error) {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			return nil, []error{err}
+		}
+	}
+	ms := newModules(paths...)
+	var errs []error
+	for _, err := range ms.ReadAll(names...) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ms, ms.Process()
+}
+
+func doDiff(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if len(diffOldPaths) == 0 || len(diffNewPaths) == 0 {
+		exitIfError([]error{fmt.Errorf("diff: --old-path and --new-path are required")})
+		return
+	}
+	var names []string
+	for _, arg := range getopt.Args() {
+		names = append(names, sourceName(arg))
+	}
+	if len(names) == 0 {
+		exitIfError([]error{fmt.Errorf("diff: no modules named")})
+		return
+	}
+	oldMods, errs := diffModules(diffOldPaths, names)
+	exitIfError(errs)
+	newMods, errs := diffModules(diffNewPaths, names)
+	exitIfError(errs)
+
+	r := yangdiff.CompareModules(oldMods, newMods, names...)
+	if diffJSON {
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			exitIfError([]error{err})
+			return
+		}
+		fmt.Fprintf(w, "%s\n", b)
+	} else {
+		for _, c := range r.Changes {
+			fmt.Fprintln(w, c)
+		}
+	}
+	if r.Breaking {
+		stop(1)
+	}
+}
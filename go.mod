@@ -3,7 +3,6 @@ module github.com/karthick18/goyang
 go 1.14
 
 require (
-	github.com/clbanning/mxj/v2 v2.5.6
 	github.com/google/go-cmp v0.4.0
 	github.com/kylelemons/godebug v1.1.0
 	github.com/openconfig/gnmi v0.0.0-20200414194230-1597cc0f2600
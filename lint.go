@@ -0,0 +1,113 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yanglint"
+	"github.com/pborman/getopt"
+)
+
+var (
+	lintRules      []string
+	lintDisable    []string
+	lintSeverities []string
+	lintList       bool
+)
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:  "lint",
+		f:     doLint,
+		help:  "check the modules named on the command line against the RFC 8407 and OpenConfig style guidelines",
+		flags: flags,
+	})
+	flags.ListVarLong(&lintRules, "rules", 0, "only run the lint rules RULE, all rules are run by default", "RULE[,RULE...]")
+	flags.ListVarLong(&lintDisable, "disable", 0, "suppress the lint rules RULE, or RULE@MODULE for only MODULE", "RULE[@MODULE][,...]")
+	flags.ListVarLong(&lintSeverities, "severity", 0, "set the severity, error or warning, of lint rules", "RULE=SEVERITY[,...]")
+	flags.BoolVarLong(&lintList, "list-rules", 0, "list the lint rules and exit")
+}
+
+// A lintError is a lint diagnostic whose text names its rule.
+type lintError struct {
+	d *yang.Diagnostic
+}
+
+func (e lintError) Error() string { return fmt.Sprintf("%s [%s]", e.d.Error(), e.d.Code) }
+func (e lintError) Unwrap() error { return e.d }
+
+// sourceName returns the name of the module in the SOURCE name, which may
+// be a module name or a file name with a revision.
+func sourceName(name string) string {
+	name = filepath.Base(name)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".yang"), ".yin")
+	return strings.SplitN(name, "@", 2)[0]
+}
+
+func doLint(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if lintList {
+		for _, r := range yanglint.Rules() {
+			fmt.Fprintf(w, "%-24s %-8s %s\n", r.Name, r.Severity, r.Doc)
+		}
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	m, ok := entries[0].Node.(*yang.Module)
+	if !ok {
+		exitIfError([]error{fmt.Errorf("%s: %s is not a module", yang.Source(entries[0].Node), entries[0].Name)})
+		return
+	}
+	ms := m.Modules
+	var mods []*yang.Module
+	for _, name := range append([]string{filename}, dependencies...) {
+		name = sourceName(name)
+		if m := ms.Modules[name]; m != nil {
+			mods = append(mods, m)
+		} else if m := ms.SubModules[name]; m != nil {
+			mods = append(mods, m)
+		}
+	}
+	severities, err := yanglint.ParseSeverities(lintSeverities...)
+	if err != nil {
+		exitIfError([]error{err})
+	}
+	ds, err := yanglint.Lint(mods, &yanglint.Config{
+		Rules:    lintRules,
+		Disable:  lintDisable,
+		Severity: severities,
+	})
+	if err != nil {
+		exitIfError([]error{err})
+	}
+	errs := make([]error, len(ds))
+	failed := false
+	for i, d := range ds {
+		errs[i] = lintError{d}
+		failed = failed || d.Severity == yang.SeverityError
+	}
+	reportErrors(w, errs)
+	flushDiagnostics(w)
+	if failed {
+		stop(1)
+	}
+}
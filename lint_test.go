@@ -0,0 +1,37 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestSourceName(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		want string
+	}{
+		{desc: "module name", in: "sys", want: "sys"},
+		{desc: "yang file", in: "models/sys.yang", want: "sys"},
+		{desc: "yin file", in: "models/sys.yin", want: "sys"},
+		{desc: "revision", in: "sys@2021-01-01.yin", want: "sys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := sourceName(tt.in); got != tt.want {
+				t.Errorf("sourceName(%q): got %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/karthick18/goyang/pkg/lsp"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// lspCommand returns true, after removing it from os.Args, if the command
+// is "goyang lsp".  The options of the command are those of goyang.
+func lspCommand() bool {
+	if len(os.Args) < 2 || os.Args[1] != "lsp" {
+		return false
+	}
+	os.Args = append(os.Args[:1], os.Args[2:]...)
+	return true
+}
+
+// runLSP runs the YANG language server on standard input and output,
+// searching the directories and using the features of ms.
+func runLSP(ms *yang.Modules) {
+	s := &lsp.Server{Paths: ms.Path, Features: ms.ParseOptions.Features}
+	if err := s.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		stop(1)
+	}
+	stop(0)
+}
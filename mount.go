@@ -0,0 +1,84 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yanglib"
+)
+
+var (
+	// schemaMounts is the ietf-yang-schema-mount document listing the
+	// mount points, if any.
+	schemaMounts string
+	// mounts are the schemas to mount, as MODULE:LABEL=FILE where FILE is
+	// a yang-library document.
+	mounts []string
+)
+
+// mountSchemas mounts the schemas given by mounts in ms, which must have
+// been processed.  Every mount point of the schemaMounts document must be
+// given a schema.  Mount points not in the document, or all of them when
+// there is none, are mounted with config true.
+func mountSchemas(ms *yang.Modules) []error {
+	if len(mounts) == 0 {
+		if schemaMounts != "" {
+			return []error{fmt.Errorf("--schema-mounts requires --mount")}
+		}
+		return nil
+	}
+	sm := &yanglib.SchemaMounts{}
+	if schemaMounts != "" {
+		b, err := ioutil.ReadFile(schemaMounts)
+		if err != nil {
+			return []error{err}
+		}
+		if sm, err = yanglib.ParseSchemaMounts(b); err != nil {
+			return []error{fmt.Errorf("%s: %v", schemaMounts, err)}
+		}
+	}
+	listed := map[string]bool{}
+	for _, p := range sm.MountPoints {
+		listed[p.Key()] = true
+	}
+
+	libraries := map[string]*yanglib.Library{}
+	for _, m := range mounts {
+		i := strings.Index(m, "=")
+		j := strings.Index(m, ":")
+		if i < 0 || j < 0 || j > i {
+			return []error{fmt.Errorf("invalid mount %q, want MODULE:LABEL=FILE", m)}
+		}
+		key, file := m[:i], m[i+1:]
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return []error{err}
+		}
+		l, err := yanglib.Parse(b)
+		if err != nil {
+			return []error{fmt.Errorf("%s: %v", file, err)}
+		}
+		libraries[key] = l
+		if !listed[key] {
+			listed[key] = true
+			sm.MountPoints = append(sm.MountPoints, &yanglib.MountPoint{Module: key[:j], Label: key[j+1:]})
+		}
+	}
+	return sm.Mount(ms, libraries)
+}
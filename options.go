@@ -16,3 +16,12 @@ func (d *defaultFileOption) Name() string {
 func (d *defaultFileOption) Options() string {
 	return ""
 }
+
+// fileNames returns the names of the files in fopts.
+func fileNames(fopts []FileOption) []string {
+	names := make([]string, len(fopts))
+	for i, fopt := range fopts {
+		names[i] = fopt.Name()
+	}
+	return names
+}
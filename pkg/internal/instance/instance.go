@@ -0,0 +1,116 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package instance holds the helpers shared by the packages that encode,
+// decode and validate instance data against yang.Entry schema trees.
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// Module returns the name of the module that defines the namespace of e,
+// or "" if it is not known.
+func Module(e *yang.Entry) string {
+	m, err := e.InstantiatingModule()
+	if err != nil {
+		return ""
+	}
+	return m
+}
+
+// LeafType returns the type of the leaf or leaf-list e and the entry that
+// defines it, which is the leaf or leaf-list ultimately referenced when e is
+// a leafref.  The leafref e is returned as is if its chain of references
+// cannot be resolved or loops.
+func LeafType(e *yang.Entry) (*yang.YangType, *yang.Entry) {
+	if _, err := e.LeafrefType(); err != nil {
+		return e.Type, e
+	}
+	for e.Type != nil && e.Type.Kind == yang.Yleafref {
+		t, err := e.LeafrefTarget()
+		if err != nil {
+			break
+		}
+		e = t
+	}
+	return e.Type, e
+}
+
+// SplitName splits the qualified name s, as in module:name or prefix:name,
+// into its qualifier and name.
+func SplitName(s string) (string, string) {
+	if i := strings.Index(s, ":"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return "", s
+}
+
+// SortedKeys returns the keys of m in sorted order.
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Describe returns a description of the decoded JSON value x for error
+// messages, such as string "abc" or number 5.
+func Describe(x interface{}) string {
+	switch x := x.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", x)
+	case json.Number:
+		return "number " + x.String()
+	case float64:
+		return "number " + strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return fmt.Sprintf("boolean %t", x)
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+// TopLevel returns the schema entry and module name of the top level node
+// named by k, a member name that is qualified with the name of its module
+// unless the name is unique across modules.  The entry is nil if there is
+// no such node, or if k is not qualified and ambiguous is true.
+func TopLevel(modules []*yang.Entry, k string) (schema *yang.Entry, mod string, ambiguous bool) {
+	qual, name := SplitName(k)
+	for _, m := range modules {
+		if qual != "" && m.Name != qual {
+			continue
+		}
+		if c := m.DataChild(name); c != nil {
+			if schema != nil {
+				return nil, "", true
+			}
+			schema, mod = c, m.Name
+		}
+	}
+	return schema, mod, false
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instance
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+func TestTopLevel(t *testing.T) {
+	ms := yang.NewModules()
+	for name, src := range map[string]string{
+		"a": `module a { namespace "urn:a"; prefix a; container top; container only-a; }`,
+		"b": `module b { namespace "urn:b"; prefix b; container top; }`,
+	} {
+		if err := ms.Parse(src, name+".yang"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	modules := []*yang.Entry{yang.ToEntry(ms.Modules["a"]), yang.ToEntry(ms.Modules["b"])}
+
+	tests := []struct {
+		desc          string
+		in            string
+		wantMod       string
+		wantAmbiguous bool
+	}{{
+		desc:    "qualified",
+		in:      "b:top",
+		wantMod: "b",
+	}, {
+		desc:    "unique",
+		in:      "only-a",
+		wantMod: "a",
+	}, {
+		desc:          "ambiguous",
+		in:            "top",
+		wantAmbiguous: true,
+	}, {
+		desc: "unknown",
+		in:   "b:only-a",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			schema, mod, ambiguous := TopLevel(modules, tt.in)
+			if mod != tt.wantMod || ambiguous != tt.wantAmbiguous || (schema != nil) != (tt.wantMod != "") {
+				t.Errorf("TopLevel(%q): got %v, %q, %t, want module %q, ambiguous %t", tt.in, schema, mod, ambiguous, tt.wantMod, tt.wantAmbiguous)
+			}
+			if schema != nil && Module(schema) != tt.wantMod {
+				t.Errorf("Module: got %q, want %q", Module(schema), tt.wantMod)
+			}
+		})
+	}
+}
+
+func TestLeafType(t *testing.T) {
+	ms := yang.NewModules()
+	// The loop would otherwise be reported by Process.
+	ms.ParseOptions.IgnoreModuleResolveErrors = true
+	if err := ms.Parse(`module l {
+  namespace "urn:l";
+  prefix l;
+  leaf a { type leafref { path "../b"; } }
+  leaf b { type leafref { path "../c"; } }
+  leaf c { type int8; }
+  leaf x { type leafref { path "../y"; } }
+  leaf y { type leafref { path "../x"; } }
+}`, "l.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	dir := yang.ToEntry(ms.Modules["l"]).Dir
+
+	tests := []struct {
+		desc     string
+		in       string
+		want     string
+		wantKind yang.TypeKind
+	}{
+		{"not a leafref", "c", "c", yang.Yint8},
+		{"chain", "a", "c", yang.Yint8},
+		{"loop", "x", "x", yang.Yleafref},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			typ, e := LeafType(dir[tt.in])
+			if e.Name != tt.want || typ.Kind != tt.wantKind {
+				t.Errorf("LeafType(%s): got %s, %s, want %s, %s", tt.in, e.Name, typ.Kind, tt.want, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{nil, "null"},
+		{"a", `string "a"`},
+		{json.Number("1.50"), "number 1.50"},
+		{2.5, "number 2.5"},
+		{true, "boolean true"},
+		{[]interface{}{}, "array"},
+		{map[string]interface{}{}, "object"},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.in); got != tt.want {
+			t.Errorf("Describe(%#v): got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,585 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+// This file analyzes a document with yang.Modules and answers the
+// definition, hover, symbol and completion requests from the result.
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// An analysis is the result of parsing and processing a document.
+type analysis struct {
+	ms    *yang.Modules
+	mod   *yang.Module // module or submodule of the document, nil if it did not parse
+	diags yang.Diagnostics
+
+	// nodes maps the statements of all modules to the nodes built from
+	// them.
+	nodes map[*yang.Statement]yang.Node
+}
+
+// analyze parses and processes d, along with the other open documents and
+// the modules they import and include.
+func (s *Server) analyze(d *document) *analysis {
+	ms := yang.NewModules()
+	ms.ParseOptions.Features = s.Features
+	ms.AddPath(filepath.Dir(d.path))
+	ms.AddPath(s.Paths...)
+	a := &analysis{ms: ms, nodes: map[*yang.Statement]yang.Node{}}
+	if err := ms.Parse(d.text, d.path); err != nil {
+		a.diags = yang.ToDiagnostics(err)
+		return a
+	}
+	for _, o := range s.docs {
+		if o != d {
+			// Errors in o are reported when o is analyzed.
+			ms.Parse(o.text, o.path)
+		}
+	}
+	a.diags = yang.ToDiagnostics(ms.Process()...)
+
+	seen := map[yang.Node]bool{}
+	for _, mods := range []map[string]*yang.Module{ms.Modules, ms.SubModules} {
+		for _, m := range mods {
+			if a.mod == nil && yang.NodeLocation(m).File == d.path {
+				a.mod = m
+			}
+			addNodes(m, a.nodes, seen)
+		}
+	}
+	return a
+}
+
+// statementType is the type of *yang.Statement.
+var statementType = reflect.TypeOf(&yang.Statement{})
+
+// nodeType is the type of yang.Node.
+var nodeType = reflect.TypeOf((*yang.Node)(nil)).Elem()
+
+// addNodes adds n and the nodes below it to nodes, by statement.
+func addNodes(n yang.Node, nodes map[*yang.Statement]yang.Node, seen map[yang.Node]bool) {
+	if seen[n] {
+		return
+	}
+	seen[n] = true
+	if s := n.Statement(); s != nil {
+		nodes[s] = n
+	}
+	v := reflect.ValueOf(n).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		// Only follow the fields of substatements, not the Parent and
+		// Statement fields or fields set while resolving.
+		if ft.Tag.Get("yang") == "" || ft.Type == statementType || ft.Type.Kind() == reflect.Interface {
+			continue
+		}
+		f := v.Field(i)
+		switch {
+		case ft.Type.Kind() == reflect.Ptr && ft.Type.Implements(nodeType):
+			if !f.IsNil() {
+				addNodes(f.Interface().(yang.Node), nodes, seen)
+			}
+		case ft.Type.Kind() == reflect.Slice && ft.Type.Elem() != statementType && ft.Type.Elem().Implements(nodeType):
+			for j := 0; j < f.Len(); j++ {
+				if e := f.Index(j); !e.IsNil() {
+					addNodes(e.Interface().(yang.Node), nodes, seen)
+				}
+			}
+		}
+	}
+}
+
+// before returns true if the statement s starts before or at the 1 based
+// line and col.
+func before(s *yang.Statement, line, col int) bool {
+	l := yang.NodeLocation(s)
+	return l.Line < line || l.Line == line && l.Col <= col
+}
+
+// statementsAt returns the statements of the document enclosing the 1 based
+// line and col, from the module statement down.
+func (a *analysis) statementsAt(line, col int) []*yang.Statement {
+	if a == nil || a.mod == nil {
+		return nil
+	}
+	chain := []*yang.Statement{a.mod.Source}
+	for s := a.mod.Source; ; {
+		var next *yang.Statement
+		for _, ss := range s.SubStatements() {
+			if !before(ss, line, col) {
+				break
+			}
+			next = ss
+		}
+		if next == nil {
+			return chain
+		}
+		chain = append(chain, next)
+		s = next
+	}
+}
+
+// substatement returns the substatement of s with keyword and argument
+// name, nil if there is none.
+func substatement(s *yang.Statement, keyword, name string) *yang.Statement {
+	if s == nil {
+		return nil
+	}
+	for _, ss := range s.SubStatements() {
+		if ss.Keyword == keyword && ss.Argument == name {
+			return ss
+		}
+	}
+	return nil
+}
+
+// argument returns the argument of the first substatement of s with
+// keyword, "" if there is none.
+func argument(s *yang.Statement, keyword string) string {
+	for _, ss := range s.SubStatements() {
+		if ss.Keyword == keyword {
+			return ss.Argument
+		}
+	}
+	return ""
+}
+
+// splitPrefix returns the prefix and name of a prefixed identifier.
+func splitPrefix(s string) (string, string) {
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return "", s
+}
+
+// family returns the module m belongs to followed by all the submodules it
+// includes.
+func (a *analysis) family(m *yang.Module) []*yang.Module {
+	if m.Kind() == "submodule" && m.BelongsTo != nil {
+		if bm := a.ms.Modules[m.BelongsTo.Name]; bm != nil {
+			m = bm
+		}
+	}
+	mods := []*yang.Module{m}
+	seen := map[*yang.Module]bool{m: true}
+	for i := 0; i < len(mods); i++ {
+		for _, in := range mods[i].Include {
+			if in.Module != nil && !seen[in.Module] {
+				seen[in.Module] = true
+				mods = append(mods, in.Module)
+			}
+		}
+	}
+	return mods
+}
+
+// lookup returns the statement with keyword defining the, possibly
+// prefixed, name used within chain, nil if it is not found.  Definitions
+// nested in chain are found first, then those at the top of the module and
+// its submodules or, with a prefix, of the imported module.
+func (a *analysis) lookup(chain []*yang.Statement, keyword, name string) *yang.Statement {
+	prefix, name := splitPrefix(name)
+	mods := a.family(a.mod)
+	if prefix != "" && prefix != a.mod.GetPrefix() {
+		m := yang.FindModuleByPrefix(a.mod, prefix)
+		if m == nil {
+			return nil
+		}
+		mods = a.family(m)
+	} else {
+		for i := len(chain) - 1; i > 0; i-- {
+			if s := substatement(chain[i], keyword, name); s != nil {
+				return s
+			}
+		}
+	}
+	for _, m := range mods {
+		if s := substatement(m.Source, keyword, name); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// prefixStatement returns the statement defining prefix in the document: an
+// import, or the module's own prefix or belongs-to statement.
+func (a *analysis) prefixStatement(prefix string) *yang.Statement {
+	for _, s := range a.mod.Source.SubStatements() {
+		switch s.Keyword {
+		case "prefix":
+			if s.Argument == prefix {
+				return s
+			}
+		case "import", "belongs-to":
+			if argument(s, "prefix") == prefix {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// schemaNode returns the statement of the schema node named by the
+// absolute schema node identifier path, nil if it is not found.
+func (a *analysis) schemaNode(path string) *yang.Statement {
+	if !strings.HasPrefix(path, "/") {
+		return nil
+	}
+	e := yang.ToEntry(a.mod).Find(path)
+	if e == nil || e.Node == nil {
+		return nil
+	}
+	return e.Node.Statement()
+}
+
+// definitionAt returns the statement defining what is named at the 1 based
+// line and col, nil if there is nothing or it is not found.
+func (a *analysis) definitionAt(d *document, line, col int) *yang.Statement {
+	chain := a.statementsAt(line, col)
+	if len(chain) == 0 {
+		return nil
+	}
+	word, start := d.word(line, col)
+	if word == "" {
+		return nil
+	}
+	prefix, _ := splitPrefix(word)
+	if prefix != "" && col <= start+len([]rune(prefix)) {
+		return a.prefixStatement(prefix)
+	}
+	s := chain[len(chain)-1]
+	switch s.Keyword {
+	case "uses":
+		return a.lookup(chain, "grouping", word)
+	case "type":
+		if _, ok := yang.BaseTypedefs[word]; ok {
+			return nil
+		}
+		return a.lookup(chain, "typedef", word)
+	case "base":
+		return a.lookup(chain, "identity", word)
+	case "if-feature":
+		return a.lookup(chain, "feature", word)
+	case "augment", "deviation":
+		// Resolve the path up to the node under the cursor.
+		l := []rune(d.line(line))
+		end := start - 1 + len([]rune(word))
+		begin := start - 1
+		for begin > 0 && (isWordRune(l[begin-1]) || l[begin-1] == '/') {
+			begin--
+		}
+		return a.schemaNode(string(l[begin:end]))
+	case "import", "include", "belongs-to":
+		if word != s.Argument {
+			return nil
+		}
+		for _, m := range []map[string]*yang.Module{a.ms.Modules, a.ms.SubModules} {
+			if m := m[word]; m != nil {
+				return m.Source
+			}
+		}
+	}
+	return nil
+}
+
+// describe returns the markdown shown when hovering over s.
+func (a *analysis) describe(s *yang.Statement) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "```yang\n%s %s\n```\n", s.Keyword, s.Argument)
+	switch n := a.nodes[s].(type) {
+	case *yang.Leaf:
+		fmt.Fprintf(&b, "\ntype: %s\n", typeSummary(n.Type))
+	case *yang.LeafList:
+		fmt.Fprintf(&b, "\ntype: %s\n", typeSummary(n.Type))
+	case *yang.Typedef:
+		fmt.Fprintf(&b, "\ntype: %s\n", typeSummary(n.Type))
+	case *yang.Type:
+		fmt.Fprintf(&b, "\n%s\n", typeSummary(n))
+	}
+	if m := yang.RootNode(a.nodes[s]); m != nil && s != m.Source {
+		fmt.Fprintf(&b, "\n%s %s\n", m.Kind(), m.Name)
+	}
+	if desc := argument(s, "description"); desc != "" {
+		fmt.Fprintf(&b, "\n%s\n", desc)
+	}
+	return b.String()
+}
+
+// typeSummary returns the name of t and, when resolved, the built-in type
+// it derives from and its restrictions.
+func typeSummary(t *yang.Type) string {
+	if t == nil {
+		return ""
+	}
+	y := t.YangType
+	if y == nil {
+		return t.Name
+	}
+	s := t.Name
+	if kind := y.Kind.String(); kind != s {
+		s += " (" + kind + ")"
+	}
+	var r []string
+	switch {
+	case y.Kind == yang.Yidentityref && y.IdentityBase != nil:
+		for _, b := range y.IdentityBases {
+			r = append(r, "base "+b.Name)
+		}
+	case y.Kind == yang.Yleafref:
+		r = append(r, "path "+y.Path)
+	case y.Kind == yang.Yenum && y.Enum != nil:
+		r = append(r, "enum "+strings.Join(y.Enum.Names(), " | "))
+	case y.Kind == yang.Ybits && y.Bit != nil:
+		r = append(r, "bits "+strings.Join(y.Bit.Names(), " "))
+	case y.Kind == yang.Yunion:
+		var ts []string
+		for _, u := range y.Type {
+			ts = append(ts, u.Name)
+		}
+		r = append(r, "union "+strings.Join(ts, " | "))
+	}
+	if len(y.Range) > 0 {
+		r = append(r, "range "+y.Range.String())
+	}
+	if len(y.Length) > 0 {
+		r = append(r, "length "+y.Length.String())
+	}
+	for _, p := range y.Pattern {
+		r = append(r, fmt.Sprintf("pattern %q", p))
+	}
+	if y.Units != "" {
+		r = append(r, "units "+y.Units)
+	}
+	if len(r) > 0 {
+		s += ", " + strings.Join(r, ", ")
+	}
+	return s
+}
+
+// definition returns the location of the definition of what is named at p.
+func (s *Server) definition(d *document, p Position) *Location {
+	line, col := d.lineCol(p)
+	def := d.good.definitionAt(d, line, col)
+	if def == nil {
+		return nil
+	}
+	return s.location(yang.NodeLocation(def))
+}
+
+// hover returns the description of the definition of what is named at p,
+// or of the statement at p.
+func (s *Server) hover(d *document, p Position) *Hover {
+	a := d.good
+	line, col := d.lineCol(p)
+	def := a.definitionAt(d, line, col)
+	if def == nil {
+		chain := a.statementsAt(line, col)
+		if len(chain) == 0 {
+			return nil
+		}
+		if def = chain[len(chain)-1]; yang.NodeLocation(def).Line != line {
+			return nil
+		}
+	}
+	word, start := d.word(line, col)
+	h := &Hover{Contents: MarkupContent{Kind: "markdown", Value: a.describe(def)}}
+	if word != "" {
+		r := Range{Start: d.position(line, start), End: d.position(line, start+len([]rune(word)))}
+		h.Range = &r
+	}
+	return h
+}
+
+// symbolKinds maps the keywords of the statements shown as document
+// symbols to their symbol kind.
+var symbolKinds = map[string]int{
+	"module":       symbolModule,
+	"submodule":    symbolModule,
+	"container":    symbolStruct,
+	"list":         symbolArray,
+	"leaf":         symbolField,
+	"leaf-list":    symbolArray,
+	"anydata":      symbolVariable,
+	"anyxml":       symbolVariable,
+	"choice":       symbolEnum,
+	"case":         symbolEnumMember,
+	"grouping":     symbolClass,
+	"typedef":      symbolTypeParam,
+	"identity":     symbolConstant,
+	"feature":      symbolBoolean,
+	"extension":    symbolInterface,
+	"rpc":          symbolFunction,
+	"action":       symbolMethod,
+	"input":        symbolObject,
+	"output":       symbolObject,
+	"notification": symbolEvent,
+	"augment":      symbolNamespace,
+	"deviation":    symbolNamespace,
+}
+
+// symbols returns the document symbols of d, a tree of its data
+// definitions and definitions.
+func (s *Server) symbols(d *document) []*DocumentSymbol {
+	a := d.good
+	if a == nil || a.mod == nil {
+		return []*DocumentSymbol{}
+	}
+	var symbols func(ss []*yang.Statement) []*DocumentSymbol
+	symbols = func(ss []*yang.Statement) []*DocumentSymbol {
+		var ds []*DocumentSymbol
+		for _, st := range ss {
+			kind, ok := symbolKinds[st.Keyword]
+			if !ok {
+				continue
+			}
+			l := yang.NodeLocation(st)
+			start := d.position(l.Line, l.Col)
+			ds = append(ds, &DocumentSymbol{
+				Name:           st.Argument,
+				Detail:         st.Keyword,
+				Kind:           kind,
+				Range:          Range{Start: start, End: d.end(l.Line, l.Col)},
+				SelectionRange: d.tokenRange(l.Line, l.Col),
+				Children:       symbols(st.SubStatements()),
+			})
+		}
+		return ds
+	}
+	return symbols([]*yang.Statement{a.mod.Source})
+}
+
+// keywords are the YANG keywords proposed as completions.
+var keywords = []string{
+	"action", "anydata", "anyxml", "argument", "augment", "base",
+	"belongs-to", "bit", "case", "choice", "config", "contact",
+	"container", "default", "description", "deviate", "deviation",
+	"enum", "error-app-tag", "error-message", "extension", "feature",
+	"fraction-digits", "grouping", "identity", "if-feature", "import",
+	"include", "input", "key", "leaf", "leaf-list", "length", "list",
+	"mandatory", "max-elements", "min-elements", "modifier", "module",
+	"must", "namespace", "notification", "ordered-by", "organization",
+	"output", "path", "pattern", "position", "prefix", "presence",
+	"range", "reference", "refine", "require-instance", "revision",
+	"revision-date", "rpc", "status", "submodule", "type", "typedef",
+	"unique", "units", "uses", "value", "when", "yang-version",
+	"yin-element",
+}
+
+// completionKinds maps the keywords of the statements defining the names
+// proposed as completions to their completion kind.
+var completionKinds = map[string]int{
+	"grouping": completionClass,
+	"typedef":  completionTypeParam,
+	"identity": completionConstant,
+	"feature":  completionProperty,
+}
+
+// completion returns the completions at p: the groupings after uses, the
+// types after type, the identities after base, the features after
+// if-feature and otherwise the keywords.
+func (s *Server) completion(d *document, p Position) []CompletionItem {
+	line, col := d.lineCol(p)
+	text := []rune(d.line(line))
+	if col-1 < len(text) {
+		text = text[:col-1]
+	}
+	// Only the text of the statement being written matters.
+	stmt := string(text)
+	if i := strings.LastIndexAny(stmt, "{;}"); i >= 0 {
+		stmt = stmt[i+1:]
+	}
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 || len(fields) == 1 && !strings.HasSuffix(stmt, " ") && !strings.HasSuffix(stmt, "\t") {
+		items := make([]CompletionItem, len(keywords))
+		for i, k := range keywords {
+			items[i] = CompletionItem{Label: k, Kind: completionKeyword}
+		}
+		return items
+	}
+	var keyword string
+	switch fields[0] {
+	case "uses":
+		keyword = "grouping"
+	case "type":
+		keyword = "typedef"
+	case "base":
+		keyword = "identity"
+	case "if-feature":
+		keyword = "feature"
+	default:
+		return []CompletionItem{}
+	}
+	items := d.good.inScope(d.good.statementsAt(line, col), keyword)
+	if keyword == "typedef" {
+		var names []string
+		for name := range yang.BaseTypedefs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			items = append(items, CompletionItem{Label: name, Kind: completionKeyword, Detail: "built-in type"})
+		}
+	}
+	return items
+}
+
+// inScope returns the names of the statements with keyword that can be
+// used within chain: those nested in chain and at the top of the module
+// and its submodules without a prefix, and those of imported modules with
+// their prefix.
+func (a *analysis) inScope(chain []*yang.Statement, keyword string) []CompletionItem {
+	items := []CompletionItem{}
+	if a == nil || a.mod == nil {
+		return items
+	}
+	seen := map[string]bool{}
+	add := func(prefix string, m *yang.Module, ss []*yang.Statement) {
+		for _, s := range ss {
+			label := s.Argument
+			if prefix != "" {
+				label = prefix + ":" + label
+			}
+			if s.Keyword != keyword || seen[label] {
+				continue
+			}
+			seen[label] = true
+			items = append(items, CompletionItem{Label: label, Kind: completionKinds[keyword], Detail: keyword + " in " + m.Name})
+		}
+	}
+	for i := len(chain) - 1; i > 0; i-- {
+		add("", a.mod, chain[i].SubStatements())
+	}
+	for _, m := range a.family(a.mod) {
+		add("", m, m.Source.SubStatements())
+	}
+	for _, in := range a.mod.Import {
+		if in.Module == nil || in.Prefix == nil {
+			continue
+		}
+		for _, m := range a.family(in.Module) {
+			add(in.Prefix.Name, m, m.Source.SubStatements())
+		}
+	}
+	return items
+}
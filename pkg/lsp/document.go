@@ -0,0 +1,194 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+// This file converts between the 1 based lines and columns of yang
+// statements, which count runes, and protocol positions, which count UTF-16
+// code units.
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A document is a YANG file, usually one open in the editor.
+type document struct {
+	uri  string
+	path string
+	text string
+
+	lines []string  // text split at newlines
+	last  *analysis // analysis of text
+	good  *analysis // last analysis of a text that parsed
+}
+
+func (d *document) setText(text string) {
+	d.text = text
+	d.lines = strings.Split(text, "\n")
+}
+
+// line returns line n, 1 based, of d without its line ending.
+func (d *document) line(n int) string {
+	if n < 1 || n > len(d.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(d.lines[n-1], "\r")
+}
+
+// utf16Len returns the number of UTF-16 code units of rs.
+func utf16Len(rs []rune) int {
+	n := 0
+	for _, r := range rs {
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
+
+// position returns the position of the 1 based line and col.
+func (d *document) position(line, col int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	l := []rune(d.line(line))
+	switch {
+	case col < 1:
+		col = 1
+	case col > len(l)+1:
+		col = len(l) + 1
+	}
+	return Position{Line: line - 1, Character: utf16Len(l[:col-1])}
+}
+
+// lineCol returns the 1 based line and col of p.
+func (d *document) lineCol(p Position) (line, col int) {
+	l := []rune(d.line(p.Line + 1))
+	n := 0
+	for col < len(l) && n < p.Character {
+		n += utf16Len(l[col : col+1])
+		col++
+	}
+	return p.Line + 1, col + 1
+}
+
+// isWordRune returns true if r can be part of a, possibly prefixed,
+// identifier.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.:", r)
+}
+
+// word returns the, possibly prefixed, identifier at the 1 based line and
+// col and the column it starts at.
+func (d *document) word(line, col int) (string, int) {
+	l := []rune(d.line(line))
+	i := col - 1
+	if i < 0 || i > len(l) {
+		return "", col
+	}
+	start, end := i, i
+	for start > 0 && isWordRune(l[start-1]) {
+		start--
+	}
+	for end < len(l) && isWordRune(l[end]) {
+		end++
+	}
+	return string(l[start:end]), start + 1
+}
+
+// tokenRange returns the range of the token that starts at the 1 based
+// line and col, or of the character at line and col if there is no token.
+func (d *document) tokenRange(line, col int) Range {
+	start := d.position(line, col)
+	l := []rune(d.line(line))
+	end := col - 1
+	for end >= 0 && end < len(l) && !unicode.IsSpace(l[end]) && !strings.ContainsRune(";{}", l[end]) {
+		end++
+	}
+	if end <= col-1 {
+		return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + 1}}
+	}
+	return Range{Start: start, End: d.position(line, end+1)}
+}
+
+// offset returns the byte offset in the text of the 1 based line and col.
+func (d *document) offset(line, col int) int {
+	if line < 1 || line > len(d.lines) {
+		return len(d.text)
+	}
+	off := 0
+	for _, l := range d.lines[:line-1] {
+		off += len(l) + 1
+	}
+	l := []rune(d.lines[line-1])
+	if col > len(l)+1 {
+		col = len(l) + 1
+	}
+	if col > 1 {
+		off += len(string(l[:col-1]))
+	}
+	return off
+}
+
+// offsetPosition returns the position of the byte offset off in the text.
+func (d *document) offsetPosition(off int) Position {
+	line := strings.Count(d.text[:off], "\n") + 1
+	lineStart := strings.LastIndex(d.text[:off], "\n") + 1
+	return d.position(line, len([]rune(d.text[lineStart:off]))+1)
+}
+
+// end returns the position just after the statement that starts at the 1
+// based line and col, its terminating semicolon or closing brace.  Quoted
+// strings and comments are skipped.
+func (d *document) end(line, col int) Position {
+	t := d.text
+	depth := 0
+	for i := d.offset(line, col); i < len(t); i++ {
+		switch c := t[i]; {
+		case c == '"':
+			for i++; i < len(t) && t[i] != '"'; i++ {
+				if t[i] == '\\' {
+					i++
+				}
+			}
+		case c == '\'':
+			if j := strings.IndexByte(t[i+1:], '\''); j >= 0 {
+				i += j + 1
+			}
+		case strings.HasPrefix(t[i:], "//"):
+			if j := strings.IndexByte(t[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(t)
+			}
+		case strings.HasPrefix(t[i:], "/*"):
+			if j := strings.Index(t[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(t)
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth <= 0 {
+				return d.offsetPosition(i + 1)
+			}
+		case c == ';' && depth == 0:
+			return d.offsetPosition(i + 1)
+		}
+	}
+	return d.offsetPosition(len(t))
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPositions(t *testing.T) {
+	d := &document{}
+	d.setText("module m {\r\n\tdescription \"\U0001F600 é\"; leaf x { type string; }\n}")
+	tests := []struct {
+		desc      string
+		line, col int
+		want      Position
+	}{
+		{"start", 1, 1, Position{0, 0}},
+		{"tab", 2, 2, Position{1, 1}},
+		{"after surrogate pair", 2, 16, Position{1, 16}},
+		{"past the end", 1, 100, Position{0, 10}},
+	}
+	for _, tt := range tests {
+		got := d.position(tt.line, tt.col)
+		if got != tt.want {
+			t.Errorf("%s: position(%d, %d) = %v, want %v", tt.desc, tt.line, tt.col, got, tt.want)
+		}
+		if tt.desc == "past the end" {
+			continue
+		}
+		if line, col := d.lineCol(got); line != tt.line || col != tt.col {
+			t.Errorf("%s: lineCol(%v) = %d, %d, want %d, %d", tt.desc, got, line, col, tt.line, tt.col)
+		}
+	}
+
+	if w, start := d.word(2, 22); w != "leaf" || start != 21 {
+		t.Errorf("word(2, 22) = %q, %d, want leaf, 21", w, start)
+	}
+	if diff := cmp.Diff(Range{Start: Position{1, 1}, End: Position{1, 12}}, d.tokenRange(2, 2)); diff != "" {
+		t.Errorf("tokenRange (-want, +got):\n%s", diff)
+	}
+	if got, want := d.end(2, 2), (Position{1, 20}); got != want {
+		t.Errorf("end of description: got %v, want %v", got, want)
+	}
+	if got, want := d.end(2, 21), (Position{1, 44}); got != want {
+		t.Errorf("end of leaf: got %v, want %v", got, want)
+	}
+	if got, want := d.end(1, 1), (Position{2, 1}); got != want {
+		t.Errorf("end of module: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lsp implements a Language Server Protocol server for YANG.  It
+// provides diagnostics, go-to-definition, hover, document symbols and
+// completion for the YANG documents open in an editor.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// A Server is a YANG language server.  The modules and submodules imported
+// and included by a document are searched for in the directory of the
+// document, then in Paths.  Open documents are used in place of the files
+// they were read from.
+type Server struct {
+	Paths    []string
+	Features yang.FeatureSet // features passed to yang.ParseOptions
+
+	mu       sync.Mutex // serializes writes to w
+	w        io.Writer
+	docs     map[string]*document // open documents by URI
+	shutdown bool
+}
+
+// Serve reads requests and notifications from r and writes the responses
+// and notifications to w until it reads the exit notification or r is
+// closed.  Serve returns an error if r cannot be read or if the client
+// exits without shutting the server down first.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	s.docs = map[string]*document{}
+	br := bufio.NewReader(r)
+	for {
+		m, err := readMessage(br)
+		var rerr *responseError
+		switch {
+		case err == io.EOF:
+			return nil
+		case errors.As(err, &rerr):
+			s.reply(nil, nil, err)
+			continue
+		case err != nil:
+			return err
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		s.handle(m)
+	}
+}
+
+// handle handles the request or notification m.
+func (s *Server) handle(m *message) {
+	var result interface{}
+	var err error
+	switch m.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // full
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{":"},
+				},
+			},
+			"serverInfo": map[string]string{"name": "goyang"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "initialized", "textDocument/didSave", "$/cancelRequest", "$/setTrace":
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err = decode(m.Params, &p); err == nil {
+			err = s.open(p.TextDocument.URI, p.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err = decode(m.Params, &p); err == nil && len(p.ContentChanges) > 0 {
+			// With full synchronization the last change is the text.
+			err = s.open(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err = decode(m.Params, &p); err == nil {
+			delete(s.docs, p.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		}
+	case "textDocument/definition", "textDocument/hover", "textDocument/completion":
+		var p textDocumentPositionParams
+		if err = decode(m.Params, &p); err != nil {
+			break
+		}
+		d := s.docs[p.TextDocument.URI]
+		if d == nil {
+			err = &responseError{Code: codeInvalidParams, Message: "document not open: " + p.TextDocument.URI}
+			break
+		}
+		switch m.Method {
+		case "textDocument/definition":
+			if l := s.definition(d, p.Position); l != nil {
+				result = l
+			}
+		case "textDocument/hover":
+			if h := s.hover(d, p.Position); h != nil {
+				result = h
+			}
+		default:
+			result = s.completion(d, p.Position)
+		}
+	case "textDocument/documentSymbol":
+		var p documentSymbolParams
+		if err = decode(m.Params, &p); err != nil {
+			break
+		}
+		d := s.docs[p.TextDocument.URI]
+		if d == nil {
+			err = &responseError{Code: codeInvalidParams, Message: "document not open: " + p.TextDocument.URI}
+			break
+		}
+		result = s.symbols(d)
+	default:
+		err = &responseError{Code: codeMethodNotFound, Message: "method not found: " + m.Method}
+	}
+	if m.ID != nil {
+		s.reply(m.ID, result, err)
+	}
+}
+
+// decode decodes the params of a message into v.
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// reply writes the response to the request with id.
+func (s *Server) reply(id *json.RawMessage, result interface{}, err error) {
+	m := &message{ID: id}
+	if id == nil {
+		null := json.RawMessage("null")
+		m.ID = &null
+	}
+	if err != nil {
+		var rerr *responseError
+		if !errors.As(err, &rerr) {
+			rerr = &responseError{Code: codeInvalidRequest, Message: err.Error()}
+		}
+		m.Error = rerr
+	} else if m.Result, err = json.Marshal(result); err != nil {
+		m.Result = nil
+		m.Error = &responseError{Code: codeInvalidRequest, Message: err.Error()}
+	}
+	s.write(m)
+}
+
+// notify writes the notification method with params.
+func (s *Server) notify(method string, params interface{}) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	s.write(&message{Method: method, Params: b})
+}
+
+func (s *Server) write(m *message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeMessage(s.w, m)
+}
+
+// open sets the text of the document uri and publishes its diagnostics.
+func (s *Server) open(uri, text string) error {
+	path, err := uriPath(uri)
+	if err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	d := s.docs[uri]
+	if d == nil {
+		d = &document{uri: uri, path: path}
+		s.docs[uri] = d
+	}
+	d.setText(text)
+	d.last = s.analyze(d)
+	if d.last.mod != nil {
+		d.good = d.last
+	}
+	s.publish(d)
+	return nil
+}
+
+// publish publishes the diagnostics of d found by its last analysis.
+func (s *Server) publish(d *document) {
+	diags := []Diagnostic{}
+	seen := map[string]bool{}
+	for _, yd := range d.last.diags {
+		if yd.File != d.path || seen[yd.Error()] {
+			continue
+		}
+		seen[yd.Error()] = true
+		ld := Diagnostic{
+			Range:    d.tokenRange(yd.Line, yd.Col),
+			Severity: severityError,
+			Code:     string(yd.Code),
+			Source:   "goyang",
+			Message:  yd.Message,
+		}
+		if yd.Severity == yang.SeverityWarning {
+			ld.Severity = severityWarning
+		}
+		for _, r := range yd.Related {
+			if l := s.location(r.Location); l != nil {
+				ld.RelatedInformation = append(ld.RelatedInformation, DiagnosticRelatedInformation{Location: *l, Message: r.Message})
+			}
+		}
+		diags = append(diags, ld)
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Range.Start, diags[j].Range.Start
+		return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+	})
+	s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: d.uri, Diagnostics: diags})
+}
+
+// location returns l as a protocol location spanning the token at l, or
+// nil if the file of l cannot be read.
+func (s *Server) location(l yang.Location) *Location {
+	if l.File == "" {
+		return nil
+	}
+	d := s.document(l.File)
+	if d == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.tokenRange(l.Line, l.Col)}
+}
+
+// document returns the open document for path, or a document read from
+// the file path.  Nil is returned if path cannot be read.
+func (s *Server) document(path string) *document {
+	for _, d := range s.docs {
+		if d.path == path {
+			return d
+		}
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	d := &document{uri: pathURI(path), path: path}
+	d.setText(string(b))
+	return d
+}
+
+// uriPath returns the file path of the file URI uri.
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q: %s", u.Scheme, uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// pathURI returns the file URI of path.
+func pathURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,326 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const typesModule = `module types {
+  namespace "urn:types";
+  prefix t;
+  typedef name {
+    type string { length "1..64"; }
+    description "A name.";
+  }
+  identity proto;
+  identity tcp { base proto; }
+  grouping addr { leaf ip { type string; } }
+}
+`
+
+const sysModule = `module sys {
+  namespace "urn:sys";
+  prefix s;
+  import types { prefix t; }
+  grouping g {
+    description "Local grouping.";
+    leaf x { type t:name; }
+  }
+  container system {
+    uses g;
+    uses t:addr;
+    leaf proto { type identityref { base t:proto; } }
+    leaf bad { type no-such; }
+  }
+  augment /s:system {
+    leaf extra { type string; }
+  }
+}
+`
+
+// A request is a request, or a notification when id is 0, sent to the
+// server.
+type request struct {
+	id     int
+	method string
+	params interface{}
+}
+
+// session runs a Server, searching dir, on reqs followed by shutdown and
+// exit.  It returns the results and errors of the requests by id and the
+// params of the diagnostics published.
+func session(t *testing.T, dir string, reqs []request) (map[int]json.RawMessage, map[int]*responseError, []publishDiagnosticsParams) {
+	t.Helper()
+	var in bytes.Buffer
+	reqs = append(reqs, request{id: 1000, method: "shutdown"}, request{method: "exit"})
+	for _, r := range reqs {
+		m := &message{Method: r.method}
+		if r.id != 0 {
+			id := json.RawMessage(fmt.Sprint(r.id))
+			m.ID = &id
+		}
+		if r.params != nil {
+			b, err := json.Marshal(r.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Params = b
+		}
+		if err := writeMessage(&in, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	s := &Server{Paths: []string{dir}}
+	if err := s.Serve(&in, &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	results := map[int]json.RawMessage{}
+	errs := map[int]*responseError{}
+	var diags []publishDiagnosticsParams
+	r := bufio.NewReader(&out)
+	for {
+		m, err := readMessage(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading response: %v", err)
+		}
+		switch {
+		case m.Method == "textDocument/publishDiagnostics":
+			var p publishDiagnosticsParams
+			if err := json.Unmarshal(m.Params, &p); err != nil {
+				t.Fatal(err)
+			}
+			diags = append(diags, p)
+		default:
+			var id int
+			json.Unmarshal(*m.ID, &id)
+			if m.Error != nil {
+				errs[id] = m.Error
+			} else {
+				results[id] = m.Result
+			}
+		}
+	}
+	return results, errs, diags
+}
+
+// at returns the params of a request at line and character, zero based,
+// of uri.
+func at(uri string, line, char int) interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     Position{Line: line, Character: char},
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "types.yang"), []byte(typesModule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sysURI := pathURI(filepath.Join(dir, "sys.yang"))
+	typesURI := pathURI(filepath.Join(dir, "types.yang"))
+	fixed := strings.Replace(sysModule, "    leaf bad { type no-such; }\n", "", 1)
+
+	results, errs, diags := session(t, dir, []request{
+		{id: 1, method: "initialize", params: map[string]interface{}{}},
+		{method: "initialized", params: map[string]interface{}{}},
+		{method: "textDocument/didOpen", params: map[string]interface{}{
+			"textDocument": textDocumentItem{URI: sysURI, Version: 1, Text: sysModule},
+		}},
+		{method: "textDocument/didChange", params: map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": sysURI, "version": 2},
+			"contentChanges": []map[string]string{{"text": fixed}},
+		}},
+		{id: 2, method: "textDocument/definition", params: at(sysURI, 9, 9)},
+		{id: 3, method: "textDocument/definition", params: at(sysURI, 10, 11)},
+		{id: 4, method: "textDocument/definition", params: at(sysURI, 10, 9)},
+		{id: 5, method: "textDocument/definition", params: at(sysURI, 6, 20)},
+		{id: 6, method: "textDocument/definition", params: at(sysURI, 11, 44)},
+		{id: 7, method: "textDocument/definition", params: at(sysURI, 13, 14)},
+		{id: 8, method: "textDocument/definition", params: at(sysURI, 3, 10)},
+		{id: 9, method: "textDocument/definition", params: at(sysURI, 8, 3)},
+		{id: 10, method: "textDocument/hover", params: at(sysURI, 6, 9)},
+		{id: 11, method: "textDocument/hover", params: at(sysURI, 9, 9)},
+		{id: 12, method: "textDocument/completion", params: at(sysURI, 9, 9)},
+		{id: 13, method: "textDocument/completion", params: at(sysURI, 6, 18)},
+		{id: 14, method: "textDocument/completion", params: at(sysURI, 9, 4)},
+		{id: 15, method: "textDocument/documentSymbol", params: map[string]interface{}{
+			"textDocument": map[string]string{"uri": sysURI},
+		}},
+		{method: "textDocument/didClose", params: map[string]interface{}{
+			"textDocument": map[string]string{"uri": sysURI},
+		}},
+		{id: 16, method: "no/such/method"},
+	})
+
+	if len(diags) != 3 {
+		t.Fatalf("got %d diagnostics notifications, want 3", len(diags))
+	}
+	wantDiag := []Diagnostic{{
+		Range:    Range{Start: Position{Line: 12, Character: 15}, End: Position{Line: 12, Character: 19}},
+		Severity: severityError,
+		Code:     "unresolved",
+		Source:   "goyang",
+		Message:  "unknown type: s:no-such",
+	}}
+	if diff := cmp.Diff(wantDiag, diags[0].Diagnostics); diff != "" {
+		t.Errorf("diagnostics of the opened document (-want, +got):\n%s", diff)
+	}
+	for i, p := range diags[1:] {
+		if len(p.Diagnostics) != 0 {
+			t.Errorf("notification %d: got diagnostics %v, want none", i+1, p.Diagnostics)
+		}
+	}
+
+	var init struct {
+		Capabilities map[string]interface{}
+	}
+	if err := json.Unmarshal(results[1], &init); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"definitionProvider", "hoverProvider", "documentSymbolProvider", "completionProvider"} {
+		if init.Capabilities[c] == nil {
+			t.Errorf("initialize: capability %s not set", c)
+		}
+	}
+
+	for _, tt := range []struct {
+		desc string
+		id   int
+		want *Location
+	}{
+		{"local grouping", 2, &Location{URI: sysURI, Range: Range{Start: Position{4, 2}, End: Position{4, 10}}}},
+		{"imported grouping", 3, &Location{URI: typesURI, Range: Range{Start: Position{9, 2}, End: Position{9, 10}}}},
+		{"prefix", 4, &Location{URI: sysURI, Range: Range{Start: Position{3, 2}, End: Position{3, 8}}}},
+		{"typedef", 5, &Location{URI: typesURI, Range: Range{Start: Position{3, 2}, End: Position{3, 9}}}},
+		{"identity", 6, &Location{URI: typesURI, Range: Range{Start: Position{7, 2}, End: Position{7, 10}}}},
+		{"augment target", 7, &Location{URI: sysURI, Range: Range{Start: Position{8, 2}, End: Position{8, 11}}}},
+		{"imported module", 8, &Location{URI: typesURI, Range: Range{Start: Position{0, 0}, End: Position{0, 6}}}},
+		{"nothing", 9, nil},
+	} {
+		var got *Location
+		if err := json.Unmarshal(results[tt.id], &got); err != nil {
+			t.Fatalf("%s: %v", tt.desc, err)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: definition (-want, +got):\n%s", tt.desc, diff)
+		}
+	}
+
+	for _, tt := range []struct {
+		desc string
+		id   int
+		want []string
+	}{
+		{"leaf", 10, []string{"leaf x", "type: t:name (string), length 1..64"}},
+		{"uses", 11, []string{"grouping g", "Local grouping."}},
+	} {
+		var got Hover
+		if err := json.Unmarshal(results[tt.id], &got); err != nil {
+			t.Fatalf("%s: %v", tt.desc, err)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(got.Contents.Value, s) {
+				t.Errorf("%s: hover %q does not contain %q", tt.desc, got.Contents.Value, s)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		desc    string
+		id      int
+		want    []string
+		notWant []string
+	}{
+		{"groupings", 12, []string{"g", "t:addr"}, []string{"t:name", "container"}},
+		{"types", 13, []string{"t:name", "string", "identityref"}, []string{"g"}},
+		{"keywords", 14, []string{"container", "uses"}, []string{"g"}},
+	} {
+		var items []CompletionItem
+		if err := json.Unmarshal(results[tt.id], &items); err != nil {
+			t.Fatalf("%s: %v", tt.desc, err)
+		}
+		labels := map[string]bool{}
+		for _, i := range items {
+			labels[i.Label] = true
+		}
+		for _, l := range tt.want {
+			if !labels[l] {
+				t.Errorf("%s: completion %s missing", tt.desc, l)
+			}
+		}
+		for _, l := range tt.notWant {
+			if labels[l] {
+				t.Errorf("%s: unexpected completion %s", tt.desc, l)
+			}
+		}
+	}
+
+	var symbols []*DocumentSymbol
+	if err := json.Unmarshal(results[15], &symbols); err != nil {
+		t.Fatal(err)
+	}
+	var names func(ds []*DocumentSymbol) []string
+	names = func(ds []*DocumentSymbol) []string {
+		var s []string
+		for _, d := range ds {
+			s = append(s, d.Detail+" "+d.Name)
+			s = append(s, names(d.Children)...)
+		}
+		return s
+	}
+	wantNames := []string{
+		"module sys",
+		"grouping g", "leaf x",
+		"container system", "leaf proto",
+		"augment /s:system", "leaf extra",
+	}
+	if diff := cmp.Diff(wantNames, names(symbols)); diff != "" {
+		t.Errorf("document symbols (-want, +got):\n%s", diff)
+	}
+	if got, want := symbols[0].Children[1].Range, (Range{Start: Position{8, 2}, End: Position{12, 3}}); got != want {
+		t.Errorf("container system: got range %v, want %v", got, want)
+	}
+
+	for id, err := range errs {
+		if id != 16 {
+			t.Errorf("request %d failed: %s", id, err.Message)
+		}
+	}
+	if err := errs[16]; err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unknown method: got error %v, want method not found", err)
+	}
+}
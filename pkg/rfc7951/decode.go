@@ -0,0 +1,301 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rfc7951
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// Unmarshal decodes the RFC 7951 JSON document b into a tree of Go values
+// using the schema found in modules, a list of module Entry trees.  Top level
+// names in the result are qualified with their module name.
+func Unmarshal(modules []*yang.Entry, b []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var raw map[string]interface{}
+	if err := d.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return Decode(modules, raw)
+}
+
+// Decode converts raw, an RFC 7951 JSON document as decoded by encoding/json,
+// into a tree of Go values.  Numbers in raw may be either float64 or
+// json.Number.  Top level names in the result are qualified with their module
+// name.
+func Decode(modules []*yang.Entry, raw map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, k := range instance.SortedKeys(raw) {
+		schema, mod, err := topLevel(modules, k)
+		if err != nil {
+			return nil, err
+		}
+		path := "/" + mod + ":" + schema.Name
+		v, err := decodeNode(schema, mod, path, raw[k])
+		if err != nil {
+			return nil, err
+		}
+		out[mod+":"+schema.Name] = v
+	}
+	return out, nil
+}
+
+// decodeNode decodes x, the JSON value of the node schema in module mod at
+// path.
+func decodeNode(schema *yang.Entry, mod, path string, x interface{}) (interface{}, error) {
+	switch {
+	case schema.IsLeafList():
+		items, ok := x.([]interface{})
+		if !ok {
+			return nil, errorf(path, "leaf-list value must be an array")
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := decodeLeaf(schema, path, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case schema.IsLeaf():
+		return decodeLeaf(schema, path, x)
+	case schema.IsList():
+		items, ok := x.([]interface{})
+		if !ok {
+			return nil, errorf(path, "list value must be an array")
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errorf(path, "list entry must be an object")
+			}
+			v, err := decodeMembers(schema, mod, path, m)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case schema.Kind == yang.AnyDataEntry || schema.Kind == yang.AnyXMLEntry:
+		return x, nil
+	}
+	m, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, errorf(path, "%s value must be an object", schema.Node.Kind())
+	}
+	return decodeMembers(schema, mod, path, m)
+}
+
+// decodeMembers decodes the members of the JSON object m.
+func decodeMembers(schema *yang.Entry, mod, path string, m map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, k := range instance.SortedKeys(m) {
+		c, cmod, err := child(schema, path, k)
+		if err != nil {
+			return nil, err
+		}
+		if _, name := instance.SplitName(k); name == k && cmod != mod {
+			return nil, errorf(path+"/"+k, "member name must be qualified with module %s", cmod)
+		}
+		if _, ok := out[c.Name]; ok {
+			return nil, errorf(path+"/"+k, "duplicate member")
+		}
+		v, err := decodeNode(c, cmod, path+"/"+k, m[k])
+		if err != nil {
+			return nil, err
+		}
+		out[c.Name] = v
+	}
+	return out, nil
+}
+
+// decodeLeaf decodes x, the JSON value of the leaf or leaf-list schema.
+func decodeLeaf(schema *yang.Entry, path string, x interface{}) (interface{}, error) {
+	t, target := instance.LeafType(schema)
+	v, err := decodeValue(target, t, x)
+	if err != nil {
+		return nil, errorf(path, "%v", err)
+	}
+	return v, nil
+}
+
+// decodeValue decodes the JSON value x of type t, which is the type of the
+// leaf or leaf-list schema.
+func decodeValue(schema *yang.Entry, t *yang.YangType, x interface{}) (interface{}, error) {
+	if t == nil {
+		return x, nil
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		var s string
+		switch x := x.(type) {
+		case json.Number:
+			s = x.String()
+		case float64:
+			s = strconv.FormatFloat(x, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("%s value must be a number, got %s", t.Kind, instance.Describe(x))
+		}
+		return parseInt(t.Kind, s)
+	case yang.Yint64, yang.Yuint64:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s value must be a string, got %s", t.Kind, instance.Describe(x))
+		}
+		return parseInt(t.Kind, s)
+	case yang.Ydecimal64:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("decimal64 value must be a string, got %s", instance.Describe(x))
+		}
+		n, err := yang.ParseDecimal(s, uint8(t.FractionDigits))
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal64 value %q: %v", s, err)
+		}
+		return n, nil
+	case yang.Ystring, yang.YinstanceIdentifier:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s value must be a string, got %s", t.Kind, instance.Describe(x))
+		}
+		return s, nil
+	case yang.Ybool:
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("boolean value must be true or false, got %s", instance.Describe(x))
+		}
+		return b, nil
+	case yang.Yempty:
+		if a, ok := x.([]interface{}); !ok || len(a) != 1 || a[0] != nil {
+			return nil, fmt.Errorf("empty value must be [null], got %s", instance.Describe(x))
+		}
+		return Empty{}, nil
+	case yang.Yenum:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("enumeration value must be a string, got %s", instance.Describe(x))
+		}
+		if !t.Enum.IsDefined(s) {
+			return nil, fmt.Errorf("%q is not a valid enum", s)
+		}
+		return s, nil
+	case yang.Ybits:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("bits value must be a string, got %s", instance.Describe(x))
+		}
+		bits := []string{}
+		for _, b := range strings.Fields(s) {
+			if !t.Bit.IsDefined(b) {
+				return nil, fmt.Errorf("%q is not a valid bit", b)
+			}
+			bits = append(bits, b)
+		}
+		return bits, nil
+	case yang.Ybinary:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("binary value must be a string, got %s", instance.Describe(x))
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 binary value %q", s)
+		}
+		return b, nil
+	case yang.Yidentityref:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("identityref value must be a string, got %s", instance.Describe(x))
+		}
+		return resolveIdentity(t, instance.Module(schema), s)
+	case yang.Yleafref:
+		// The leafref could not be resolved, keep the value as is.
+		return x, nil
+	case yang.Yunion:
+		var errs []string
+		for _, m := range t.Type {
+			v, err := decodeValue(schema, m, x)
+			if err == nil {
+				return v, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return nil, fmt.Errorf("%s does not match any member of the union: %s", instance.Describe(x), strings.Join(errs, "; "))
+	}
+	return x, nil
+}
+
+// parseInt parses s as an integer of kind k and returns it as the matching
+// Go type.
+func parseInt(k yang.TypeKind, s string) (interface{}, error) {
+	var bits int
+	signed := true
+	switch k {
+	case yang.Yint8:
+		bits = 8
+	case yang.Yint16:
+		bits = 16
+	case yang.Yint32:
+		bits = 32
+	case yang.Yint64:
+		bits = 64
+	case yang.Yuint8:
+		bits, signed = 8, false
+	case yang.Yuint16:
+		bits, signed = 16, false
+	case yang.Yuint32:
+		bits, signed = 32, false
+	case yang.Yuint64:
+		bits, signed = 64, false
+	}
+	if signed {
+		i, err := strconv.ParseInt(s, 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", k, s)
+		}
+		switch bits {
+		case 8:
+			return int8(i), nil
+		case 16:
+			return int16(i), nil
+		case 32:
+			return int32(i), nil
+		}
+		return i, nil
+	}
+	u, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q", k, s)
+	}
+	switch bits {
+	case 8:
+		return uint8(u), nil
+	case 16:
+		return uint16(u), nil
+	case 32:
+		return uint32(u), nil
+	}
+	return u, nil
+}
@@ -0,0 +1,359 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rfc7951
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// Marshal returns the RFC 7951 JSON encoding of data, a tree of Go values,
+// using the schema found in modules, a list of module Entry trees.
+func Marshal(modules []*yang.Entry, data map[string]interface{}) ([]byte, error) {
+	raw, err := Encode(modules, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(raw)
+}
+
+// MarshalIndent is like Marshal but indents the output as json.MarshalIndent
+// does.
+func MarshalIndent(modules []*yang.Entry, data map[string]interface{}, prefix, indent string) ([]byte, error) {
+	raw, err := Encode(modules, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(raw, prefix, indent)
+}
+
+// Encode converts data, a tree of Go values, into the form of an RFC 7951 JSON
+// document that can be passed to json.Marshal.  Member names are qualified
+// with their module name at the top level and wherever the namespace
+// changes.  Numbers are returned as json.Number.  Values are checked against
+// the range, length and pattern restrictions of their types, and decimal64
+// values are written in their canonical form.
+func Encode(modules []*yang.Entry, data map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, k := range instance.SortedKeys(data) {
+		schema, mod, err := topLevel(modules, k)
+		if err != nil {
+			return nil, err
+		}
+		name := mod + ":" + schema.Name
+		if _, ok := out[name]; ok {
+			return nil, errorf("/"+name, "duplicate member")
+		}
+		v, err := encodeNode(schema, mod, "/"+name, data[k])
+		if err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+// encodeNode encodes the value x of the node schema in module mod at path.
+func encodeNode(schema *yang.Entry, mod, path string, x interface{}) (interface{}, error) {
+	switch {
+	case schema.IsLeafList():
+		items, ok := toSlice(x)
+		if !ok {
+			return nil, errorf(path, "leaf-list value must be a slice")
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := encodeLeaf(schema, path, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case schema.IsLeaf():
+		return encodeLeaf(schema, path, x)
+	case schema.IsList():
+		items, ok := toSlice(x)
+		if !ok {
+			return nil, errorf(path, "list value must be a slice")
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errorf(path, "list entry must be a map[string]interface{}")
+			}
+			v, err := encodeMembers(schema, mod, path, m)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case schema.Kind == yang.AnyDataEntry || schema.Kind == yang.AnyXMLEntry:
+		return x, nil
+	}
+	m, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, errorf(path, "%s value must be a map[string]interface{}", schema.Node.Kind())
+	}
+	return encodeMembers(schema, mod, path, m)
+}
+
+// encodeMembers encodes the children, m, of an instance of schema.
+func encodeMembers(schema *yang.Entry, mod, path string, m map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, k := range instance.SortedKeys(m) {
+		c, cmod, err := child(schema, path, k)
+		if err != nil {
+			return nil, err
+		}
+		name := qualify(mod, cmod, c.Name)
+		if _, ok := out[name]; ok {
+			return nil, errorf(path+"/"+name, "duplicate member")
+		}
+		v, err := encodeNode(c, cmod, path+"/"+name, m[k])
+		if err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+// toSlice returns x, which must be a slice, as a []interface{}.
+func toSlice(x interface{}) ([]interface{}, bool) {
+	if s, ok := x.([]interface{}); ok {
+		return s, true
+	}
+	v := reflect.ValueOf(x)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	s := make([]interface{}, v.Len())
+	for i := range s {
+		s[i] = v.Index(i).Interface()
+	}
+	return s, true
+}
+
+func encodeLeaf(schema *yang.Entry, path string, x interface{}) (interface{}, error) {
+	t, target := instance.LeafType(schema)
+	v, err := encodeValue(target, t, x, false)
+	if err != nil {
+		return nil, errorf(path, "%v", err)
+	}
+	return v, nil
+}
+
+// encodeValue returns the JSON value of x, a value of type t, which is the
+// type of the leaf or leaf-list schema.  When strict is set, as it is for
+// the members of a union, x must have the Go type that Decode returns for t,
+// so that a decoded union value is encoded using the same member.
+func encodeValue(schema *yang.Entry, t *yang.YangType, x interface{}, strict bool) (interface{}, error) {
+	if t == nil {
+		return x, nil
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yint64, yang.Yuint64:
+		s, ok := intString(x, strict)
+		if !ok {
+			return nil, fmt.Errorf("%s value must be an integer, got %T", t.Kind, x)
+		}
+		if _, err := t.ParseValue(s); err != nil {
+			return nil, err
+		}
+		if t.Kind == yang.Yint64 || t.Kind == yang.Yuint64 {
+			return s, nil
+		}
+		return json.Number(s), nil
+	case yang.Ydecimal64:
+		var s string
+		switch x := x.(type) {
+		case yang.Number:
+			s = x.String()
+		case float64:
+			if strict {
+				return nil, fmt.Errorf("decimal64 value must be a yang.Number, got %T", x)
+			}
+			s = strconv.FormatFloat(x, 'f', -1, 64)
+		case string, json.Number:
+			if strict {
+				return nil, fmt.Errorf("decimal64 value must be a yang.Number, got %T", x)
+			}
+			s = fmt.Sprint(x)
+		default:
+			return nil, fmt.Errorf("decimal64 value must be a yang.Number, got %T", x)
+		}
+		return t.FormatCanonical(s)
+	case yang.Ystring:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s value must be a string, got %T", t.Kind, x)
+		}
+		if _, err := t.ParseValue(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yang.YinstanceIdentifier:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s value must be a string, got %T", t.Kind, x)
+		}
+		return s, nil
+	case yang.Ybool:
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("boolean value must be a bool, got %T", x)
+		}
+		return b, nil
+	case yang.Yempty:
+		switch x.(type) {
+		case Empty:
+		case nil, bool:
+			if strict {
+				return nil, fmt.Errorf("empty value must be Empty, got %T", x)
+			}
+			if x == false {
+				return nil, fmt.Errorf("empty value must be Empty, got false")
+			}
+		default:
+			return nil, fmt.Errorf("empty value must be Empty, got %T", x)
+		}
+		return []interface{}{nil}, nil
+	case yang.Yenum:
+		switch v := x.(type) {
+		case string:
+			if !t.Enum.IsDefined(v) {
+				return nil, fmt.Errorf("%q is not a valid enum", v)
+			}
+			return v, nil
+		default:
+			if s, ok := intString(x, true); ok && !strict {
+				i, err := strconv.ParseInt(s, 10, 64)
+				if name, ok := t.Enum.ValueMap()[i]; ok && err == nil {
+					return name, nil
+				}
+				return nil, fmt.Errorf("%s is not a valid enum value", s)
+			}
+		}
+		return nil, fmt.Errorf("enumeration value must be a string, got %T", x)
+	case yang.Ybits:
+		var bits []string
+		switch v := x.(type) {
+		case []string:
+			bits = v
+		case string:
+			if strict {
+				return nil, fmt.Errorf("bits value must be a []string, got %T", x)
+			}
+			bits = strings.Fields(v)
+		default:
+			return nil, fmt.Errorf("bits value must be a []string, got %T", x)
+		}
+		for _, b := range bits {
+			if !t.Bit.IsDefined(b) {
+				return nil, fmt.Errorf("%q is not a valid bit", b)
+			}
+		}
+		return strings.Join(bits, " "), nil
+	case yang.Ybinary:
+		var s string
+		switch v := x.(type) {
+		case []byte:
+			s = base64.StdEncoding.EncodeToString(v)
+		case string:
+			if _, err := base64.StdEncoding.DecodeString(v); err != nil || strict {
+				return nil, fmt.Errorf("binary value must be a []byte or base64 string")
+			}
+			s = v
+		default:
+			return nil, fmt.Errorf("binary value must be a []byte, got %T", x)
+		}
+		if _, err := t.ParseValue(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yang.Yidentityref:
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("identityref value must be a string, got %T", x)
+		}
+		return resolveIdentity(t, instance.Module(schema), s)
+	case yang.Yleafref:
+		// The leafref could not be resolved, keep the value as is.
+		return x, nil
+	case yang.Yunion:
+		var errs []string
+		for _, m := range t.Type {
+			v, err := encodeValue(schema, m, x, true)
+			if err == nil {
+				return v, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return nil, fmt.Errorf("%T value does not match any member of the union: %s", x, strings.Join(errs, "; "))
+	}
+	return x, nil
+}
+
+// intString returns the Go integer x in decimal.  Unless strict is set,
+// integral float64 values and strings are also accepted.
+func intString(x interface{}, strict bool) (string, bool) {
+	switch x := x.(type) {
+	case int:
+		return strconv.FormatInt(int64(x), 10), true
+	case int8:
+		return strconv.FormatInt(int64(x), 10), true
+	case int16:
+		return strconv.FormatInt(int64(x), 10), true
+	case int32:
+		return strconv.FormatInt(int64(x), 10), true
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(x), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(x), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(x), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(x), 10), true
+	case uint64:
+		return strconv.FormatUint(x, 10), true
+	}
+	if strict {
+		return "", false
+	}
+	switch x := x.(type) {
+	case float64:
+		if x == math.Trunc(x) && !math.IsInf(x, 0) {
+			return strconv.FormatFloat(x, 'f', -1, 64), true
+		}
+	case json.Number:
+		return x.String(), true
+	case string:
+		return x, true
+	}
+	return "", false
+}
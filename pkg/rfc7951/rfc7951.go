@@ -0,0 +1,130 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rfc7951 encodes and decodes YANG instance data as JSON, as defined
+// by RFC 7951, using the yang.Entry trees of the modules that define the data.
+//
+// Decoded data is a tree of Go values.  Containers and list entries are
+// map[string]interface{} keyed by node name without a module qualifier,
+// lists and leaf-lists are []interface{}, and leaves hold a typed value:
+//
+//	int8, int16, int32, int64    int8, int16, int32, int64
+//	uint8, uint16, uint32, uint64  uint8, uint16, uint32, uint64
+//	decimal64                    yang.Number
+//	string, instance-identifier  string
+//	boolean                      bool
+//	empty                        Empty
+//	enumeration                  string, the enum name
+//	bits                         []string, in the order given
+//	binary                       []byte
+//	identityref                  string, in the form module:identity
+//	leafref                      the type of the referenced leaf
+//	union                        the type of the first matching member
+//
+// The top level of a tree may use either qualified or unqualified names.
+// The values of anydata and anyxml nodes are left as decoded by
+// encoding/json.
+//
+// Marshal accepts the same tree, and also accepts any Go integer type for
+// integers, float64 and strings for decimal64, and a space separated string
+// for bits.  Names may optionally be qualified with their module name.
+package rfc7951
+
+import (
+	"fmt"
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// Empty is the value of a leaf of type empty.
+type Empty struct{}
+
+// An Error is an encoding or decoding error of the data at Path.
+type Error struct {
+	Path    string // data path of the node
+	Message string // description of the error
+}
+
+func (e *Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+func errorf(path, format string, args ...interface{}) error {
+	return &Error{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// topLevel returns the schema entry and module name of the top level node
+// named by the possibly qualified name k.
+func topLevel(modules []*yang.Entry, k string) (*yang.Entry, string, error) {
+	schema, mod, ambiguous := instance.TopLevel(modules, k)
+	switch {
+	case ambiguous:
+		return nil, "", errorf("/"+k, "ambiguous top level node, a module name is required")
+	case schema == nil:
+		return nil, "", errorf("/"+k, "unknown top level node")
+	}
+	return schema, mod, nil
+}
+
+// child returns the data child of schema named by the possibly qualified
+// name k, and the module of the child.
+func child(schema *yang.Entry, path, k string) (*yang.Entry, string, error) {
+	mod, name := instance.SplitName(k)
+	c := schema.DataChild(name)
+	if c == nil {
+		return nil, "", errorf(path+"/"+k, "unknown element")
+	}
+	cmod := instance.Module(c)
+	if mod != "" && mod != cmod {
+		return nil, "", errorf(path+"/"+k, "unknown element")
+	}
+	return c, cmod, nil
+}
+
+// qualify returns the member name of the node name in module mod whose
+// parent is in module parent.
+func qualify(parent, mod, name string) string {
+	if parent != mod {
+		return mod + ":" + name
+	}
+	return name
+}
+
+// resolveIdentity returns the module qualified name of the identity named by
+// s, which is derived from all the bases of t.  An unqualified name is in the
+// module mod, or if there is no such identity, any unique identity of that
+// name.
+func resolveIdentity(t *yang.YangType, mod, s string) (string, error) {
+	if t.IdentityBase == nil {
+		return "", fmt.Errorf("identityref has no base")
+	}
+	m, name := instance.SplitName(s)
+	var found []string
+	for _, q := range t.AllowedIdentities() {
+		im, n := instance.SplitName(q)
+		if n != name {
+			continue
+		}
+		switch {
+		case m != "" && im == m, m == "" && im == mod:
+			return q, nil
+		case m == "":
+			found = append(found, q)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%q is not derived from identity %s", s, t.IdentityBase.Name)
+}
@@ -0,0 +1,455 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rfc7951
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var testModules = map[string]string{
+	"base": `
+module base {
+  prefix b;
+  namespace "urn:base";
+  yang-version 1.1;
+
+  identity animal;
+  identity dog { base animal; }
+
+  container top {
+    leaf small { type int8; }
+    leaf big { type int64; }
+    leaf ubig { type uint64; }
+    leaf ratio { type decimal64 { fraction-digits 2; } }
+    leaf level { type decimal64 { fraction-digits 1; range "0 .. 1"; } }
+    leaf pct { type uint8 { range "0..100"; } }
+    leaf name { type string; }
+    leaf code { type string { length "1..4"; pattern "[a-z]+"; } }
+    leaf hash { type binary { length "2"; } }
+    leaf flag { type boolean; }
+    leaf nothing { type empty; }
+    leaf color { type enumeration { enum red; enum green { value 5; } } }
+    leaf perms { type bits { bit read; bit write; } }
+    leaf blob { type binary; }
+    leaf pet { type identityref { base animal; } }
+    leaf either { type union { type int32; type enumeration { enum none; } type string; } }
+    leaf ref { type leafref { path "../big"; } }
+    leaf-list nums { type uint32; }
+    list item {
+      key "id";
+      leaf id { type uint64; }
+      leaf value { type string; }
+    }
+    anydata extra;
+  }
+}
+`,
+	"aug": `
+module aug {
+  prefix a;
+  namespace "urn:aug";
+  import base { prefix b; }
+
+  identity cat { base b:animal; }
+
+  augment "/b:top" {
+    container more {
+      leaf count { type uint16; }
+      leaf pet { type identityref { base b:animal; } }
+    }
+  }
+}
+`,
+}
+
+func testEntries(t *testing.T) []*yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	for name, src := range testModules {
+		if err := ms.Parse(src, name+".yang"); err != nil {
+			t.Fatalf("could not parse module %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process modules: %v", errs)
+	}
+	return []*yang.Entry{
+		yang.ToEntry(ms.Modules["base"]),
+		yang.ToEntry(ms.Modules["aug"]),
+	}
+}
+
+// jsonDiff returns the difference between the JSON documents want and got.
+func jsonDiff(t *testing.T, want string, got []byte) string {
+	t.Helper()
+	var w, g interface{}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("bad want JSON: %v", err)
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("bad JSON %s: %v", got, err)
+	}
+	return cmp.Diff(w, g)
+}
+
+func TestRoundTrip(t *testing.T) {
+	entries := testEntries(t)
+
+	tests := []struct {
+		desc string
+		in   string
+	}{{
+		desc: "all types",
+		in: `{
+  "base:top": {
+    "small": -128,
+    "big": "-9223372036854775808",
+    "ubig": "18446744073709551615",
+    "ratio": "-0.05",
+    "name": "hello",
+    "flag": false,
+    "nothing": [null],
+    "color": "green",
+    "perms": "write read",
+    "blob": "AAEC",
+    "pet": "base:dog",
+    "ref": "12",
+    "nums": [1, 4294967295],
+    "item": [{"id": "1", "value": "one"}, {"id": "2"}],
+    "extra": {"anything": [1, "two", {"three": null}]}
+  }
+}`,
+	}, {
+		desc: "namespace boundary",
+		in: `{
+  "base:top": {
+    "aug:more": {"count": 65535, "pet": "aug:cat"}
+  }
+}`,
+	}, {
+		desc: "union int",
+		in:   `{"base:top": {"either": 7}}`,
+	}, {
+		desc: "union enum",
+		in:   `{"base:top": {"either": "none"}}`,
+	}, {
+		desc: "union string",
+		in:   `{"base:top": {"either": "7"}}`,
+	}, {
+		desc: "empty bits",
+		in:   `{"base:top": {"perms": ""}}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			data, err := Unmarshal(entries, []byte(tt.in))
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got, err := Marshal(entries, data)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if diff := jsonDiff(t, tt.in, got); diff != "" {
+				t.Errorf("round trip (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMountRoundTrip(t *testing.T) {
+	mounted := testEntries(t)[0].Modules()
+	ms := yang.NewModules()
+	for name, src := range map[string]string{
+		"ietf-yang-schema-mount": `module ietf-yang-schema-mount {
+  namespace "urn:ietf:params:xml:ns:yang:ietf-yang-schema-mount";
+  prefix yangmnt;
+  extension mount-point { argument label; }
+}`,
+		"host": `module host {
+  namespace "urn:host";
+  prefix h;
+  import ietf-yang-schema-mount { prefix yangmnt; }
+  list device {
+    key name;
+    leaf name { type string; }
+    container root { yangmnt:mount-point root; }
+  }
+}`,
+	} {
+		if err := ms.Parse(src, name+".yang"); err != nil {
+			t.Fatalf("could not parse module %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process modules: %v", errs)
+	}
+	if err := ms.Mount(&yang.Mount{Module: "host", Label: "root", Schema: mounted}); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+	entries := []*yang.Entry{yang.ToEntry(ms.Modules["host"])}
+
+	in := `{
+  "host:device": [{
+    "name": "r1",
+    "root": {
+      "base:top": {"pet": "base:dog", "aug:more": {"pet": "aug:cat"}}
+    }
+  }]
+}`
+	data, err := Unmarshal(entries, []byte(in))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	got, err := Marshal(entries, data)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if diff := jsonDiff(t, in, got); diff != "" {
+		t.Errorf("round trip (-want, +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	entries := testEntries(t)
+
+	in := `{
+  "base:top": {
+    "small": 5,
+    "big": "-7",
+    "ratio": "1.5",
+    "nothing": [null],
+    "perms": "read write",
+    "blob": "AAE=",
+    "pet": "base:dog",
+    "either": 9,
+    "ref": "3",
+    "nums": [1, 2],
+    "aug:more": {"count": 3, "pet": "cat"}
+  }
+}`
+	want := map[string]interface{}{
+		"base:top": map[string]interface{}{
+			"small":   int8(5),
+			"big":     int64(-7),
+			"ratio":   yang.Number{Value: 150, FractionDigits: 2},
+			"nothing": Empty{},
+			"perms":   []string{"read", "write"},
+			"blob":    []byte{0, 1},
+			"pet":     "base:dog",
+			"either":  int32(9),
+			"ref":     int64(3),
+			"nums":    []interface{}{uint32(1), uint32(2)},
+			"more": map[string]interface{}{
+				"count": uint16(3),
+				"pet":   "aug:cat",
+			},
+		},
+	}
+	got, err := Unmarshal(entries, []byte(in))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unmarshal (-want, +got):\n%s", diff)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	entries := testEntries(t)
+
+	tests := []struct {
+		desc    string
+		in      map[string]interface{}
+		want    string
+		wantErr string
+	}{{
+		desc: "go values",
+		in: map[string]interface{}{
+			"top": map[string]interface{}{
+				"small":   3,
+				"big":     int64(1) << 40,
+				"ubig":    uint64(1),
+				"ratio":   0.5,
+				"nothing": true,
+				"color":   5,
+				"perms":   "read",
+				"blob":    []byte("hi"),
+				"pet":     "dog",
+				"either":  "none",
+				"nums":    []uint32{1, 2},
+				"item":    []map[string]interface{}{{"id": uint64(1)}},
+				"more":    map[string]interface{}{"pet": "cat"},
+			},
+		},
+		want: `{
+  "base:top": {
+    "small": 3,
+    "big": "1099511627776",
+    "ubig": "1",
+    "ratio": "0.5",
+    "nothing": [null],
+    "color": "green",
+    "perms": "read",
+    "blob": "aGk=",
+    "pet": "base:dog",
+    "either": "none",
+    "nums": [1, 2],
+    "item": [{"id": "1"}],
+    "aug:more": {"pet": "aug:cat"}
+  }
+}`,
+	}, {
+		desc: "qualified input names",
+		in: map[string]interface{}{
+			"base:top": map[string]interface{}{
+				"base:name": "x",
+				"aug:more":  map[string]interface{}{"aug:count": 1},
+			},
+		},
+		want: `{"base:top": {"name": "x", "aug:more": {"count": 1}}}`,
+	}, {
+		desc:    "int out of range",
+		in:      map[string]interface{}{"top": map[string]interface{}{"small": 128}},
+		wantErr: `/base:top/small: value 128 is outside of range -128..127`,
+	}, {
+		desc:    "int outside of the range of the type",
+		in:      map[string]interface{}{"top": map[string]interface{}{"pct": 101}},
+		wantErr: `/base:top/pct: value 101 is outside of range 0..100`,
+	}, {
+		desc:    "decimal64 outside of the range of the type",
+		in:      map[string]interface{}{"top": map[string]interface{}{"level": 1.5}},
+		wantErr: `/base:top/level: value 1.5 is outside of range`,
+	}, {
+		desc:    "string length",
+		in:      map[string]interface{}{"top": map[string]interface{}{"code": "abcde"}},
+		wantErr: `/base:top/code: length 5 of "abcde" is outside of length 1..4`,
+	}, {
+		desc:    "string pattern",
+		in:      map[string]interface{}{"top": map[string]interface{}{"code": "AB"}},
+		wantErr: `/base:top/code: "AB" does not match pattern "[a-z]+"`,
+	}, {
+		desc:    "binary length",
+		in:      map[string]interface{}{"top": map[string]interface{}{"hash": []byte("abc")}},
+		wantErr: `/base:top/hash: length 3 of`,
+	}, {
+		desc:    "fractional int",
+		in:      map[string]interface{}{"top": map[string]interface{}{"small": 1.5}},
+		wantErr: "int8 value must be an integer",
+	}, {
+		desc:    "bad enum",
+		in:      map[string]interface{}{"top": map[string]interface{}{"color": "blue"}},
+		wantErr: `"blue" is not a valid enum`,
+	}, {
+		desc:    "bad bit",
+		in:      map[string]interface{}{"top": map[string]interface{}{"perms": []string{"exec"}}},
+		wantErr: `"exec" is not a valid bit`,
+	}, {
+		desc:    "bad identity",
+		in:      map[string]interface{}{"top": map[string]interface{}{"pet": "base:animal"}},
+		wantErr: "is not derived from identity animal",
+	}, {
+		desc:    "union mismatch",
+		in:      map[string]interface{}{"top": map[string]interface{}{"either": true}},
+		wantErr: "does not match any member of the union",
+	}, {
+		desc:    "unknown node",
+		in:      map[string]interface{}{"top": map[string]interface{}{"bogus": 1}},
+		wantErr: "/base:top/bogus: unknown element",
+	}, {
+		desc:    "wrong module",
+		in:      map[string]interface{}{"top": map[string]interface{}{"base:more": 1}},
+		wantErr: "/base:top/base:more: unknown element",
+	}, {
+		desc:    "unknown top level",
+		in:      map[string]interface{}{"bogus": 1},
+		wantErr: "/bogus: unknown top level node",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Marshal(entries, tt.in)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Marshal: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := jsonDiff(t, tt.want, got); diff != "" {
+				t.Errorf("Marshal (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	entries := testEntries(t)
+
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc:    "int64 as number",
+		in:      `{"base:top": {"big": 1}}`,
+		wantErr: "/base:top/big: int64 value must be a string, got number 1",
+	}, {
+		desc:    "int8 as string",
+		in:      `{"base:top": {"small": "1"}}`,
+		wantErr: `int8 value must be a number, got string "1"`,
+	}, {
+		desc:    "decimal64 as number",
+		in:      `{"base:top": {"ratio": 1.5}}`,
+		wantErr: "decimal64 value must be a string",
+	}, {
+		desc:    "empty as true",
+		in:      `{"base:top": {"nothing": true}}`,
+		wantErr: "empty value must be [null], got boolean true",
+	}, {
+		desc:    "bad base64",
+		in:      `{"base:top": {"blob": "!!"}}`,
+		wantErr: `invalid base64 binary value "!!"`,
+	}, {
+		desc:    "unqualified namespace change",
+		in:      `{"base:top": {"more": {}}}`,
+		wantErr: "/base:top/more: member name must be qualified with module aug",
+	}, {
+		desc:    "duplicate member",
+		in:      `{"base:top": {"name": "a", "base:name": "b"}}`,
+		wantErr: "duplicate member",
+	}, {
+		desc:    "list not an array",
+		in:      `{"base:top": {"item": {}}}`,
+		wantErr: "/base:top/item: list value must be an array",
+	}, {
+		desc:    "bad JSON",
+		in:      `{"base:top": `,
+		wantErr: "unexpected EOF",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Unmarshal(entries, []byte(tt.in))
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("Unmarshal: %s", diff)
+			}
+		})
+	}
+}
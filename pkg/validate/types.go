@@ -0,0 +1,121 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+// This file checks leaf values against their types using the RFC 7951 JSON
+// encoding of each type, see https://tools.ietf.org/html/rfc7951#section-6.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// checkValue returns an error if x, the JSON value of the leaf or leaf-list
+// schema, is not a valid value of type t.
+func checkValue(schema *yang.Entry, t *yang.YangType, x interface{}) error {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		n, ok := x.(json.Number)
+		if !ok {
+			if f, isFloat := x.(float64); isFloat {
+				n, ok = json.Number(valueString(f)), true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s value must be a number, got %s", t.Kind, instance.Describe(x))
+		}
+		return parseValue(t, string(n))
+	case yang.Yint64, yang.Yuint64, yang.Ydecimal64, yang.Ystring, yang.Ybinary, yang.Yenum, yang.Ybits:
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("%s value must be a string, got %s", t.Kind, instance.Describe(x))
+		}
+		return parseValue(t, s)
+	case yang.Ybool:
+		if _, ok := x.(bool); !ok {
+			return fmt.Errorf("boolean value must be true or false, got %s", instance.Describe(x))
+		}
+	case yang.Yempty:
+		if a, ok := x.([]interface{}); !ok || len(a) != 1 || a[0] != nil {
+			return fmt.Errorf("empty value must be [null], got %s", instance.Describe(x))
+		}
+	case yang.Yidentityref:
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("identityref value must be a string, got %s", instance.Describe(x))
+		}
+		return checkIdentity(schema, t, s)
+	case yang.YinstanceIdentifier:
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("instance-identifier value must be a string, got %s", instance.Describe(x))
+		}
+		return parseValue(t, s)
+	case yang.Yleafref:
+		// LeafrefType fails if the chain of leafrefs loops, which
+		// would otherwise recurse forever.
+		if _, err := schema.LeafrefType(); err != nil {
+			return err
+		}
+		target, err := schema.LeafrefTarget()
+		if err != nil {
+			return err
+		}
+		return checkValue(target, target.Type, x)
+	case yang.Yunion:
+		var errs []string
+		for _, m := range t.Type {
+			err := checkValue(schema, m, x)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s does not match any member of the union: %s", instance.Describe(x), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// parseValue checks that s is the lexical representation of a value of t.
+func parseValue(t *yang.YangType, s string) error {
+	_, err := t.ParseValue(s)
+	return err
+}
+
+// checkIdentity checks that the identity named by s, in the form
+// module:identity, is derived from all the bases of t.  The module may be
+// omitted if it is the module of the leaf.
+func checkIdentity(schema *yang.Entry, t *yang.YangType, s string) error {
+	mod, name := instance.SplitName(s)
+	if mod == "" {
+		mod = instance.Module(schema)
+	}
+	if t.IdentityBase == nil {
+		return nil
+	}
+	if ms := schema.Modules(); ms != nil {
+		if id := ms.FindIdentity(mod + ":" + name); id != nil && t.AllowsIdentity(id) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not derived from identity %s", s, t.IdentityBase.Name)
+}
@@ -0,0 +1,469 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validate checks instance data, decoded from RFC 7951 JSON, against
+// the yang.Entry schema trees of the modules that define it.
+//
+// Validation reports every violation found rather than stopping at the first
+// one.  Each violation is an *Error that carries the data path of the
+// offending node, e.g., /ietf-interfaces:interfaces/interface[name="eth0"]/mtu.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// Options control how data is validated.
+type Options struct {
+	// Config is set when the data is configuration data, in which case
+	// nodes that are config false must not be present.
+	Config bool
+}
+
+// An Error is a violation of the schema found in instance data.
+type Error struct {
+	Path    string // data path of the node in violation
+	Message string // description of the violation
+}
+
+func (e *Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// validator holds the state of a single validation.
+type validator struct {
+	opts Options
+	errs []error
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate validates data, the decoded form of an RFC 7951 JSON document,
+// against modules, a list of module Entry trees.  Top level member names
+// must be qualified with their module name unless the name is unique across
+// modules.  Validate returns the list of violations found, or nil if data is
+// valid.  The violations are found by visiting the members of each object in
+// sorted order of their names, not in document order.  A nil opts is the
+// same as the zero Options.
+func Validate(modules []*yang.Entry, data map[string]interface{}, opts *Options) []error {
+	v := &validator{}
+	if opts != nil {
+		v.opts = *opts
+	}
+	// The data tree is used to evaluate when statements, the errors
+	// building it are found again below.
+	root, _ := yang.NewDataTree(modules, data)
+	for _, k := range instance.SortedKeys(data) {
+		schema, mod, ambiguous := instance.TopLevel(modules, k)
+		_, name := instance.SplitName(k)
+		path := "/" + mod + ":" + name
+		switch {
+		case ambiguous:
+			v.errorf("/"+k, "ambiguous top level node, a module name is required")
+		case schema == nil:
+			v.errorf("/"+k, "unknown top level node")
+		default:
+			v.node(schema, path, data[k], root)
+		}
+	}
+	return v.errs
+}
+
+// ParseJSON decodes the RFC 7951 JSON document in b for use with Validate.
+// Numbers are decoded as json.Number to preserve their exact text.
+func ParseJSON(b []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var data map[string]interface{}
+	if err := d.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// node validates the value x of the node described by schema at path.
+// parent is the data node of the parent of x, or nil if it is not known.
+func (v *validator) node(schema *yang.Entry, path string, x interface{}, parent *yang.DataNode) {
+	if v.opts.Config && schema.ReadOnly() {
+		v.errorf(path, "config false node in configuration data")
+		return
+	}
+	switch {
+	case schema.IsLeafList():
+		items, ok := x.([]interface{})
+		if !ok {
+			v.errorf(path, "leaf-list value must be an array")
+			return
+		}
+		seen := map[string]bool{}
+		for _, item := range items {
+			if err := checkValue(schema, schema.Type, item); err != nil {
+				v.errorf(path, "%v", err)
+				continue
+			}
+			s := valueString(item)
+			if seen[s] && !schema.ReadOnly() {
+				v.errorf(path, "duplicate leaf-list value %q", s)
+			}
+			seen[s] = true
+		}
+		v.count(schema, path, len(items))
+	case schema.IsLeaf():
+		if err := checkValue(schema, schema.Type, x); err != nil {
+			v.errorf(path, "%v", err)
+		}
+	case schema.IsList():
+		items, ok := x.([]interface{})
+		if !ok {
+			v.errorf(path, "list value must be an array")
+			return
+		}
+		v.list(schema, path, items, parent)
+	case schema.Kind == yang.AnyDataEntry || schema.Kind == yang.AnyXMLEntry:
+		// Any value is valid.
+	default:
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "%s value must be an object", schema.Node.Kind())
+			return
+		}
+		v.members(schema, path, m, dataInstance(parent, schema, 0))
+	}
+}
+
+// members validates the members of the JSON object m, which is an instance
+// of schema at path, and checks that all mandatory nodes are present.  dn
+// is the data node of m, or nil if it is not known.
+func (v *validator) members(schema *yang.Entry, path string, m map[string]interface{}, dn *yang.DataNode) {
+	present := map[*yang.Entry]bool{}
+	ns := instance.Module(schema)
+	for _, k := range instance.SortedKeys(m) {
+		mod, name := instance.SplitName(k)
+		c := schema.DataChild(name)
+		cpath := path + "/" + k
+		if c == nil || (mod != "" && instance.Module(c) != mod) {
+			v.errorf(cpath, "unknown element")
+			continue
+		}
+		if mod == "" && instance.Module(c) != ns {
+			v.errorf(cpath, "member name must be qualified with module %s", instance.Module(c))
+		}
+		present[c] = true
+		v.node(c, cpath, m[k], dn)
+	}
+	v.mandatory(schema, path, present, dn)
+}
+
+// mandatory reports the mandatory descendants of schema that are missing.
+// present holds the children of schema that have instances and dn is the
+// data node of the instance of schema, or of its closest ancestor that is a
+// data node if schema is a choice or case, or nil if it is not known.
+// Descendants whose when statement is false are not required.
+func (v *validator) mandatory(schema *yang.Entry, path string, present map[*yang.Entry]bool, dn *yang.DataNode) {
+	for _, name := range sortedEntries(schema.Dir) {
+		c := schema.Dir[name]
+		if v.opts.Config && c.ReadOnly() {
+			continue
+		}
+		if !present[c] && whenFalse(c, dn) {
+			continue
+		}
+		switch {
+		case c.IsChoice():
+			var cases []string
+			for _, cn := range sortedEntries(c.Dir) {
+				if hasData(c.Dir[cn], present) {
+					cases = append(cases, cn)
+				}
+			}
+			switch {
+			case len(cases) > 1:
+				v.errorf(path, "data from multiple cases of choice %s: %s", c.Name, strings.Join(cases, ", "))
+			case len(cases) == 1:
+				v.mandatory(c.Dir[cases[0]], path, present, dn)
+			case c.Mandatory == yang.TSTrue:
+				v.errorf(path, "missing mandatory choice %s", c.Name)
+			}
+		case present[c]:
+		case c.IsLeaf() && c.Mandatory == yang.TSTrue, c.Kind == yang.AnyDataEntry && c.Mandatory == yang.TSTrue,
+			c.Kind == yang.AnyXMLEntry && c.Mandatory == yang.TSTrue:
+			v.errorf(path, "missing mandatory node %s", c.Name)
+		case c.IsList() || c.IsLeafList():
+			v.count(c, childPath(path, schema, c), 0)
+		case c.IsContainer() && !isPresence(c):
+			// A non-presence container exists when any of its
+			// descendants do, so its mandatory descendants are
+			// mandatory here.
+			v.mandatory(c, childPath(path, schema, c), nil, dummy(c, dn))
+		}
+	}
+}
+
+// whenFalse returns true if c has a when statement that is false.  parent
+// is the data node of the parent of c in instance data, or nil if it is not
+// known, in which case the when statement is not evaluated.
+func whenFalse(c *yang.Entry, parent *yang.DataNode) bool {
+	if parent == nil {
+		return false
+	}
+	x, err := c.WhenXPath()
+	if x == nil || err != nil {
+		return false
+	}
+	// The context node of the when statement of a choice or case is its
+	// closest ancestor that is a data node, that of a data node is the
+	// node itself, which does not exist here.
+	ctx := parent
+	if !c.IsChoice() && !c.IsCase() {
+		ctx = dummy(c, parent)
+	}
+	ok, err := x.EvaluateBool(ctx)
+	return err == nil && !ok
+}
+
+// dummy returns a data node for c, which has no instance, with no value and
+// no children, as used to evaluate when statements.  parent is the data node
+// of the parent of c, dummy returns nil if it is nil.
+func dummy(c *yang.Entry, parent *yang.DataNode) *yang.DataNode {
+	if parent == nil {
+		return nil
+	}
+	return &yang.DataNode{Schema: c, Module: instance.Module(c), Name: c.Name, Parent: parent}
+}
+
+// dataInstance returns the i'th child of the data node parent that is an
+// instance of schema, or nil if there is none.
+func dataInstance(parent *yang.DataNode, schema *yang.Entry, i int) *yang.DataNode {
+	if parent == nil {
+		return nil
+	}
+	for _, c := range parent.Children {
+		if c.Schema != schema {
+			continue
+		}
+		if i == 0 {
+			return c
+		}
+		i--
+	}
+	return nil
+}
+
+// hasData returns true if any child of the case or choice e has an instance.
+func hasData(e *yang.Entry, present map[*yang.Entry]bool) bool {
+	if present[e] {
+		return true
+	}
+	if e.IsChoice() || e.IsCase() {
+		for _, c := range e.Dir {
+			if hasData(c, present) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// count checks the number of instances, n, of the list or leaf-list schema
+// against its min-elements and max-elements.
+func (v *validator) count(schema *yang.Entry, path string, n int) {
+	if schema.ListAttr == nil {
+		return
+	}
+	switch {
+	case uint64(n) < schema.ListAttr.MinElements:
+		v.errorf(path, "too few elements: %d, min-elements is %d", n, schema.ListAttr.MinElements)
+	case uint64(n) > schema.ListAttr.MaxElements:
+		v.errorf(path, "too many elements: %d, max-elements is %d", n, schema.ListAttr.MaxElements)
+	}
+}
+
+// list validates the entries of a list, including the uniqueness of keys
+// and unique constraints.  parent is the data node of the parent of the
+// list, or nil if it is not known.
+func (v *validator) list(schema *yang.Entry, path string, items []interface{}, parent *yang.DataNode) {
+	keys := strings.Fields(schema.Key)
+	for i := range keys {
+		_, keys[i] = instance.SplitName(keys[i])
+	}
+	uniques := uniqueConstraints(schema)
+	seenKeys := map[string]bool{}
+	seenUnique := make([]map[string]bool, len(uniques))
+	for i := range seenUnique {
+		seenUnique[i] = map[string]bool{}
+	}
+
+	n := 0 // the number of entries that are objects
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "list entry must be an object")
+			continue
+		}
+		dn := dataInstance(parent, schema, n)
+		n++
+		epath := path
+		var kv []string
+		for _, k := range keys {
+			val, ok := lookup(m, k)
+			if !ok {
+				v.errorf(path, "list entry is missing key %s", k)
+				continue
+			}
+			kv = append(kv, valueString(val))
+			epath += fmt.Sprintf("[%s=%s]", k, strconv.Quote(valueString(val)))
+		}
+		if len(keys) > 0 && len(kv) == len(keys) {
+			k := strings.Join(kv, "\x00")
+			if seenKeys[k] {
+				v.errorf(epath, "duplicate list entry")
+			}
+			seenKeys[k] = true
+		}
+		for i, u := range uniques {
+			var vals []string
+			for _, p := range u {
+				val, ok := lookupPath(m, p)
+				if !ok {
+					vals = nil
+					break
+				}
+				vals = append(vals, valueString(val))
+			}
+			if vals == nil {
+				continue
+			}
+			k := strings.Join(vals, "\x00")
+			if seenUnique[i][k] {
+				v.errorf(epath, "unique constraint %q violated", strings.Join(joinPaths(u), " "))
+			}
+			seenUnique[i][k] = true
+		}
+		v.members(schema, epath, m, dn)
+	}
+	if !v.opts.Config || !schema.ReadOnly() {
+		v.count(schema, path, len(items))
+	}
+}
+
+// uniqueConstraints returns the unique statements of the list schema, each
+// as a list of descendant paths split into their unprefixed components.
+func uniqueConstraints(schema *yang.Entry) [][][]string {
+	l, ok := schema.Node.(*yang.List)
+	if !ok {
+		return nil
+	}
+	var uniques [][][]string
+	for _, u := range l.Unique {
+		var paths [][]string
+		for _, p := range strings.Fields(u.Name) {
+			var parts []string
+			for _, part := range strings.Split(p, "/") {
+				_, name := instance.SplitName(part)
+				parts = append(parts, name)
+			}
+			paths = append(paths, parts)
+		}
+		uniques = append(uniques, paths)
+	}
+	return uniques
+}
+
+func joinPaths(paths [][]string) []string {
+	var s []string
+	for _, p := range paths {
+		s = append(s, strings.Join(p, "/"))
+	}
+	return s
+}
+
+// lookup returns the member of m named name, with or without a module
+// qualifier.
+func lookup(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if _, n := instance.SplitName(k); n == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// lookupPath returns the value found by following the member names in path
+// from m.
+func lookupPath(m map[string]interface{}, path []string) (interface{}, bool) {
+	var v interface{} = m
+	for _, p := range path {
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = lookup(o, p); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// childPath returns the path of c, a data child of parent, which is at path.
+func childPath(path string, parent, c *yang.Entry) string {
+	if instance.Module(parent) != instance.Module(c) {
+		return path + "/" + instance.Module(c) + ":" + c.Name
+	}
+	return path + "/" + c.Name
+}
+
+// isPresence returns true if e is a presence container.
+func isPresence(e *yang.Entry) bool {
+	c, ok := e.Node.(*yang.Container)
+	return ok && c.Presence != nil
+}
+
+// valueString returns the string form of the JSON leaf value x.
+func valueString(x interface{}) string {
+	switch x := x.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case []interface{}:
+		if len(x) == 1 && x[0] == nil {
+			return ""
+		}
+	}
+	return fmt.Sprint(x)
+}
+
+func sortedEntries(m map[string]*yang.Entry) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,365 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+const testModule = `
+module val {
+  prefix v;
+  namespace "urn:val";
+  yang-version 1.1;
+
+  identity animal;
+  identity dog { base animal; }
+  identity poodle { base dog; }
+  identity rock;
+
+  typedef percent {
+    type uint8 { range "0..100"; }
+  }
+
+  container top {
+    leaf name { type string { length "1..8"; pattern "[a-z]+"; } }
+    leaf code { type string { pattern "\\p{Lu}+"; pattern "X.*" { modifier invert-match; } } }
+    leaf count { type int32 { range "1..10 | 20"; } }
+    leaf big { type uint64; }
+    leaf ratio { type decimal64 { fraction-digits 2; range "0 .. 1"; } }
+    leaf pct { type percent; }
+    leaf flag { type boolean; }
+    leaf nothing { type empty; }
+    leaf color { type enumeration { enum red; enum green; } }
+    leaf perms { type bits { bit read; bit write; } }
+    leaf pet { type identityref { base animal; } }
+    leaf blob { type binary { length "2"; } }
+    leaf either { type union { type int8; type enumeration { enum none; } } }
+    leaf ref { type leafref { path "../count"; } }
+    leaf state { type string; config false; }
+    leaf-list tags { type string; max-elements 2; }
+    list item {
+      key "id";
+      unique "label";
+      min-elements 1;
+      leaf id { type string; }
+      leaf label { type string; }
+      leaf required { type string; mandatory true; }
+    }
+    choice transport {
+      mandatory true;
+      leaf tcp { type empty; }
+      case udp {
+        leaf udp-port { type uint16; }
+        leaf udp-mode { type string; mandatory true; }
+      }
+    }
+    container sub {
+      leaf must-have { type string; mandatory true; }
+    }
+    container opt {
+      presence "optional";
+      leaf must-have { type string; mandatory true; }
+    }
+  }
+}
+`
+
+const validData = `{
+  "val:top": {
+    "name": "abc",
+    "count": 20,
+    "big": "18446744073709551615",
+    "ratio": "0.50",
+    "pct": 100,
+    "flag": true,
+    "nothing": [null],
+    "color": "green",
+    "perms": "read write",
+    "pet": "val:poodle",
+    "blob": "AAE=",
+    "either": "none",
+    "ref": 3,
+    "tags": ["a", "b"],
+    "item": [
+      {"id": "1", "label": "one", "required": "x"},
+      {"id": "2", "label": "two", "required": "y"}
+    ],
+    "tcp": [null],
+    "sub": {"must-have": "here"}
+  }
+}`
+
+func testEntries(t *testing.T) []*yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	if err := ms.Parse(testModule, "val.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	return []*yang.Entry{yang.ToEntry(ms.Modules["val"])}
+}
+
+func TestValidate(t *testing.T) {
+	entries := testEntries(t)
+
+	tests := []struct {
+		desc string
+		in   string
+		opts *Options
+		want []string
+	}{{
+		desc: "valid",
+		in:   validData,
+	}, {
+		desc: "valid config",
+		in:   validData,
+		opts: &Options{Config: true},
+	}, {
+		desc: "unqualified top level name",
+		in:   `{"top": {"tcp": [null], "item": [{"id": "1", "required": "x"}], "sub": {"must-have": "x"}}}`,
+	}, {
+		desc: "unknown nodes",
+		in:   `{"val:bottom": 1, "val:top": {"tcp": [null], "item": [{"id": "1", "required": "x"}], "sub": {"must-have": "x"}, "nope": 1, "other:name": "a"}}`,
+		want: []string{
+			"/val:bottom: unknown top level node",
+			"/val:top/nope: unknown element",
+			"/val:top/other:name: unknown element",
+		},
+	}, {
+		desc: "type errors",
+		in: `{"val:top": {
+  "tcp": [null], "item": [{"id": "1", "required": "x"}], "sub": {"must-have": "x"},
+  "name": "ABC",
+  "count": 11,
+  "big": 5,
+  "ratio": "1.5",
+  "pct": 101,
+  "flag": "true",
+  "nothing": null,
+  "color": "blue",
+  "perms": "read read",
+  "pet": "val:rock",
+  "blob": "AAAA",
+  "either": "all",
+  "ref": "3",
+  "state": "up",
+  "tags": ["a", "b", "a"]
+}}`,
+		want: []string{
+			`/val:top/big: uint64 value must be a string, got number 5`,
+			`/val:top/blob: length 3 of "AAAA" is outside of length 2`,
+			`/val:top/color: "blue" is not a valid enum, allowed values are green, red`,
+			`/val:top/count: value 11 is outside of range 1..10|20`,
+			`/val:top/either: string "all" does not match any member of the union: int8 value must be a number, got string "all"; "all" is not a valid enum, allowed values are none`,
+			`/val:top/flag: boolean value must be true or false, got string "true"`,
+			`/val:top/name: "ABC" does not match pattern "[a-z]+"`,
+			`/val:top/nothing: empty value must be [null], got null`,
+			`/val:top/pct: value 101 is outside of range 0..100`,
+			`/val:top/perms: bit "read" is repeated`,
+			`/val:top/pet: "val:rock" is not derived from identity animal`,
+			`/val:top/ratio: value 1.5 is outside of range 0.00..1.00`,
+			`/val:top/ref: int32 value must be a number, got string "3"`,
+			`/val:top/tags: duplicate leaf-list value "a"`,
+			`/val:top/tags: too many elements: 3, max-elements is 2`,
+		},
+	}, {
+		desc: "config false in config data",
+		in:   `{"val:top": {"tcp": [null], "item": [{"id": "1", "required": "x"}], "sub": {"must-have": "x"}, "state": "up"}}`,
+		opts: &Options{Config: true},
+		want: []string{"/val:top/state: config false node in configuration data"},
+	}, {
+		desc: "missing mandatory nodes",
+		in:   `{"val:top": {"opt": {}}}`,
+		want: []string{
+			"/val:top/opt: missing mandatory node must-have",
+			"/val:top/item: too few elements: 0, min-elements is 1",
+			"/val:top/sub: missing mandatory node must-have",
+			"/val:top: missing mandatory choice transport",
+		},
+	}, {
+		desc: "choice",
+		in:   `{"val:top": {"tcp": [null], "udp-port": 5, "item": [{"id": "1", "required": "x"}], "sub": {"must-have": "x"}}}`,
+		want: []string{
+			"/val:top: data from multiple cases of choice transport: tcp, udp",
+		},
+	}, {
+		desc: "list errors",
+		in: `{"val:top": {"tcp": [null], "sub": {"must-have": "x"}, "item": [
+  {"id": "1", "label": "a", "required": "x"},
+  {"id": "1", "label": "b", "required": "x"},
+  {"id": "2", "label": "a"},
+  {"label": "c", "required": "x"},
+  "nope"
+]}}`,
+		want: []string{
+			`/val:top/item[id="1"]: duplicate list entry`,
+			`/val:top/item[id="2"]: unique constraint "label" violated`,
+			`/val:top/item[id="2"]: missing mandatory node required`,
+			`/val:top/item: list entry is missing key id`,
+			`/val:top/item: list entry must be an object`,
+		},
+	}, {
+		desc: "patterns",
+		in:   `{"val:top": {"tcp": [null], "item": [{"id": "1", "required": "x"}], "sub": {"must-have": "x"}, "code": "XYZ"}}`,
+		want: []string{`/val:top/code: "XYZ" matches inverted pattern "X.*"`},
+	}, {
+		desc: "wrong structure",
+		in:   `{"val:top": {"tcp": [null], "sub": "x", "item": {"id": "1"}, "tags": "a"}}`,
+		want: []string{
+			"/val:top/item: list value must be an array",
+			"/val:top/sub: container value must be an object",
+			"/val:top/tags: leaf-list value must be an array",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			data, err := ParseJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("ParseJSON: %v", err)
+			}
+			var got []string
+			for _, err := range Validate(entries, data, tt.opts) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateWhen(t *testing.T) {
+	ms := yang.NewModules()
+	if err := ms.Parse(`module w {
+  prefix w;
+  namespace "urn:w";
+
+  container top {
+    leaf kind { type string; }
+    leaf vlan {
+      when "../kind = 'vlan'";
+      type uint16;
+      mandatory true;
+    }
+    container ext {
+      when "../kind = 'ext'";
+      leaf x { type string; mandatory true; }
+    }
+    choice ch {
+      when "kind = 'ch'";
+      mandatory true;
+      leaf a { type string; }
+      leaf b { type string; }
+    }
+    list l {
+      key id;
+      leaf id { type string; }
+      leaf kind { type string; }
+      leaf tag {
+        when "../kind = 'tagged'";
+        type string;
+        mandatory true;
+      }
+    }
+  }
+}`, "w.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	entries := []*yang.Entry{yang.ToEntry(ms.Modules["w"])}
+
+	tests := []struct {
+		desc string
+		in   string
+		want []string
+	}{{
+		desc: "all when false",
+		in:   `{"w:top": {"kind": "none"}}`,
+	}, {
+		desc: "leaf",
+		in:   `{"w:top": {"kind": "vlan"}}`,
+		want: []string{"/w:top: missing mandatory node vlan"},
+	}, {
+		desc: "non-presence container",
+		in:   `{"w:top": {"kind": "ext"}}`,
+		want: []string{"/w:top/ext: missing mandatory node x"},
+	}, {
+		desc: "choice",
+		in:   `{"w:top": {"kind": "ch"}}`,
+		want: []string{"/w:top: missing mandatory choice ch"},
+	}, {
+		desc: "list entries",
+		in:   `{"w:top": {"kind": "none", "l": [{"id": "1", "kind": "tagged"}, {"id": "2"}, {"id": "3", "kind": "tagged", "tag": "t"}]}}`,
+		want: []string{`/w:top/l[id="1"]: missing mandatory node tag`},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			data, err := ParseJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("ParseJSON: %v", err)
+			}
+			var got []string
+			for _, err := range Validate(entries, data, nil) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateLeafrefLoop(t *testing.T) {
+	ms := yang.NewModules()
+	// Processing reports the loop unless resolve errors are ignored.
+	ms.ParseOptions.IgnoreModuleResolveErrors = true
+	if err := ms.Parse(`module lp {
+  prefix l;
+  namespace "urn:lp";
+
+  container top {
+    leaf a { type leafref { path "../b"; } }
+    leaf b { type leafref { path "../a"; } }
+  }
+}`, "lp.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	entries := []*yang.Entry{yang.ToEntry(ms.Modules["lp"])}
+
+	data, err := ParseJSON([]byte(`{"lp:top": {"a": "x"}}`))
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	var got []string
+	for _, err := range Validate(entries, data, nil) {
+		got = append(got, err.Error())
+	}
+	if diff := cmp.Diff([]string{"/lp:top/a: /lp/top/a: leafref loop"}, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
@@ -107,6 +107,11 @@ func buildASTWithTypeDict(stmt *Statement, types *typeDictionary) (Node, error)
 	if err != nil {
 		return nil, err
 	}
+	if m, ok := v.Interface().(*Module); ok {
+		if err := buildStructures(m, types); err != nil {
+			return nil, err
+		}
+	}
 	return v.Interface().(Node), nil
 }
 
@@ -182,25 +187,25 @@ func build(stmt *Statement, parent reflect.Value, types *typeDictionary) (v refl
 			// Keyword is not known but it has a prefix so it might
 			// be an extension.
 			if y.addext == nil {
-				return nilValue, fmt.Errorf("%s: no extension function", ss.Location())
+				return nilValue, statementDiagnosticf(ss, CodeUnknownStatement, "no extension function")
 			}
 			y.addext(ss, v, parent)
 		default:
-			return nilValue, fmt.Errorf("%s: unknown %s field: %s", ss.Location(), stmt.Keyword, ss.Keyword)
+			return nilValue, statementDiagnosticf(ss, CodeUnknownStatement, "unknown %s field: %s", stmt.Keyword, ss.Keyword)
 		}
 	}
 
 	// Make sure all of our required field are there.
 	for _, r := range y.required {
 		if !found[r] {
-			return nilValue, fmt.Errorf("%s: missing required %s field: %s", stmt.Location(), stmt.Keyword, r)
+			return nilValue, statementDiagnosticf(stmt, CodeMissingStatement, "missing required %s field: %s", stmt.Keyword, r)
 		}
 	}
 
 	// Make sure required fields based on our keyword are there (module vs submodule)
 	for _, r := range y.sRequired[stmt.Keyword] {
 		if !found[r] {
-			return nilValue, fmt.Errorf("%s: missing required %s field: %s", stmt.Location(), stmt.Keyword, r)
+			return nilValue, statementDiagnosticf(stmt, CodeMissingStatement, "missing required %s field: %s", stmt.Keyword, r)
 		}
 	}
 
@@ -211,10 +216,16 @@ func build(stmt *Statement, parent reflect.Value, types *typeDictionary) (v refl
 		}
 		for _, r := range or {
 			if found[r] {
-				return nilValue, fmt.Errorf("%s: unknown %s field: %s", stmt.Location(), stmt.Keyword, r)
+				return nilValue, statementDiagnosticf(stmt, CodeUnknownStatement, "unknown %s field: %s", stmt.Keyword, r)
 			}
 		}
 	}
+
+	// A YANG 1.1 identityref may have several bases, IdentityBase is
+	// the first one.
+	if t, ok := v.Interface().(*Type); ok && len(t.IdentityBases) > 0 {
+		t.IdentityBase = t.IdentityBases[0]
+	}
 	return v, nil
 }
 
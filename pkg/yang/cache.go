@@ -0,0 +1,594 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements a cache of processed Modules.  SaveCache writes the
+// whole graph reachable from a Modules, the Module nodes, the Entry trees,
+// types, identities and the Statements they came from, to a file in a cache
+// directory.  LoadCache reads it back when the files the modules were read
+// from have not changed, which is much faster than parsing and processing
+// them again.
+//
+// The graph is written by walking it with reflection.  Each pointer is
+// written the first time it is found and referred to by number after that,
+// so the shared and circular references of the graph are kept.  The file
+// starts with a hash of the layout of the Go types in the graph, a cache
+// written by a different version of this package is not used.
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// cacheMagic starts every cache file.
+const cacheMagic = "goyang compiled schema cache\n"
+
+// A cacheSource is a file that was read into a Modules.  A cache is only
+// valid while looking up Name still finds File and its contents still have
+// the same Hash.
+type cacheSource struct {
+	Name string // the name passed to findFile
+	File string // the file findFile found
+	Hash [sha256.Size]byte
+}
+
+// addSource records that looking up name found file, which contains data.
+func (ms *Modules) addSource(name, file, data string) {
+	ms.pathMu.Lock()
+	defer ms.pathMu.Unlock()
+	ms.sources = append(ms.sources, cacheSource{Name: name, File: file, Hash: sha256.Sum256([]byte(data))})
+}
+
+// cacheFile returns the name of the file in dir that caches the modules
+// read from names with the Path and ParseOptions of ms.
+func (ms *Modules) cacheFile(dir string, names []string) string {
+	ms.pathMu.Lock()
+	defer ms.pathMu.Unlock()
+	h := sha256.New()
+	fmt.Fprintf(h, "%q\n%q\n%#v\n", names, ms.Path, ms.ParseOptions)
+	return filepath.Join(dir, fmt.Sprintf("%x.cache", h.Sum(nil)))
+}
+
+// SaveCache writes the processed modules in ms to the cache directory dir,
+// creating it if needed, so that a later LoadCache of the same names finds
+// them.  The names are the names passed to Read or ReadAll.  Only modules
+// that were read from files and processed without errors can be cached.
+func (ms *Modules) SaveCache(dir string, names ...string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	switch {
+	case ms.parsed:
+		return errors.New("cache: modules added by Parse cannot be cached")
+	case !ms.hasProcessed || ms.processed != ms.added || len(ms.processErrs) > 0:
+		return errors.New("cache: modules have not been processed without errors")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := &cacheEncoder{
+		w:     w,
+		ptrs:  map[cachePtr]uint64{{reflect.ValueOf(ms).Pointer(), reflect.TypeOf(ms)}: 1},
+		types: map[reflect.Type]uint64{},
+	}
+	w.WriteString(cacheMagic)
+	w.Write(cacheFingerprint())
+	if err := enc.encode(reflect.ValueOf(ms.sources)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := enc.encode(reflect.ValueOf(ms).Elem()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), ms.cacheFile(dir, names))
+}
+
+// LoadCache loads the modules read from names, with the Path and ParseOptions
+// of ms, from the cache directory dir.  It returns false if there is no
+// cache for them or the files they were read from have changed, in which
+// case they should be read and processed as usual.  ms must not have read
+// any modules.  Once LoadCache returns true, ms holds the modules as they
+// were when SaveCache was called and Process does not need to be called.
+func (ms *Modules) LoadCache(dir string, names ...string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.added > 0 || ms.parsed {
+		return false, errors.New("cache: modules have already been read")
+	}
+
+	f, err := os.Open(ms.cacheFile(dir, names))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	header := make([]byte, len(cacheMagic)+sha256.Size)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(cacheMagic)]) != cacheMagic {
+		return false, fmt.Errorf("cache: %s is not a cache file", f.Name())
+	}
+	if string(header[len(cacheMagic):]) != string(cacheFingerprint()) {
+		// Written by a different version of this package.
+		return false, nil
+	}
+
+	dec := &cacheDecoder{r: r}
+	var sources []cacheSource
+	if err := dec.decode(reflect.ValueOf(&sources).Elem()); err != nil {
+		return false, fmt.Errorf("cache: %s: %v", f.Name(), err)
+	}
+	for _, s := range sources {
+		file, data, err := ms.findFile(s.Name)
+		if err != nil || file != s.File || sha256.Sum256([]byte(data)) != s.Hash {
+			return false, nil
+		}
+	}
+
+	// The graph is read into a new Modules, references to the Modules
+	// refer to ms, and only copied into ms once it has all been read.
+	nms := reflect.New(reflect.TypeOf(ms).Elem())
+	dec.ptrs = []reflect.Value{reflect.ValueOf(ms)}
+	if err := dec.decode(nms.Elem()); err != nil {
+		return false, fmt.Errorf("cache: %s: %v", f.Name(), err)
+	}
+	copyFields(reflect.ValueOf(ms).Elem(), nms.Elem())
+	return true, nil
+}
+
+// copyFields sets the fields of the struct dst to those of src, other than
+// the fields that are locks.
+func copyFields(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		if isLock(dst.Type().Field(i).Type) {
+			continue
+		}
+		cacheField(dst, i).Set(cacheField(src, i))
+	}
+}
+
+// isLock returns true if values of type t must not be copied.
+func isLock(t reflect.Type) bool {
+	return t.PkgPath() == "sync"
+}
+
+// cacheField returns field i of the addressable struct v, which may be set even
+// if the field is not exported.
+func cacheField(v reflect.Value, i int) reflect.Value {
+	f := v.Field(i)
+	if f.CanSet() {
+		return f
+	}
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+var regexpType = reflect.TypeOf(&regexp.Regexp{})
+
+// cacheTypes holds the types that may be found in an interface of the
+// graph, by name.
+var cacheTypes struct {
+	once   sync.Once
+	byName map[string]reflect.Type
+	hash   []byte
+}
+
+func initCacheTypes() {
+	cacheTypes.byName = map[string]reflect.Type{}
+	add := func(t reflect.Type) { cacheTypes.byName[t.String()] = t }
+	for t := range typeMap {
+		add(t)
+	}
+	for _, v := range []interface{}{
+		&Statement{}, &ErrorNode{}, &Value{},
+		"", false, 0, int64(0), uint64(0), float64(0),
+		[]string(nil), []interface{}(nil), map[string]interface{}(nil),
+	} {
+		add(reflect.TypeOf(v))
+	}
+
+	// The fingerprint describes every type that can be in the graph.
+	var names []string
+	for n := range cacheTypes.byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	seen := map[reflect.Type]bool{}
+	describeType(&b, reflect.TypeOf(&Modules{}), seen)
+	for _, n := range names {
+		describeType(&b, cacheTypes.byName[n], seen)
+	}
+	h := sha256.Sum256([]byte(b.String()))
+	cacheTypes.hash = h[:]
+}
+
+// cacheType returns the type named name that may be in an interface, or nil.
+func cacheType(name string) reflect.Type {
+	cacheTypes.once.Do(initCacheTypes)
+	return cacheTypes.byName[name]
+}
+
+// cacheFingerprint returns the hash of the layout of the types in the graph.
+func cacheFingerprint() []byte {
+	cacheTypes.once.Do(initCacheTypes)
+	return cacheTypes.hash
+}
+
+// describeType writes a description of the layout of t to b.
+func describeType(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	fmt.Fprintf(b, "%s/%s", t, t.Kind())
+	if seen[t] {
+		b.WriteString(";")
+		return
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		b.WriteString("(")
+		describeType(b, t.Elem(), seen)
+		b.WriteString(")")
+	case reflect.Map:
+		b.WriteString("(")
+		describeType(b, t.Key(), seen)
+		describeType(b, t.Elem(), seen)
+		b.WriteString(")")
+	case reflect.Struct:
+		if isLock(t) || t == regexpType.Elem() {
+			break
+		}
+		b.WriteString("{")
+		for i := 0; i < t.NumField(); i++ {
+			b.WriteString(t.Field(i).Name + " ")
+			describeType(b, t.Field(i).Type, seen)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString(";")
+}
+
+// A cachePtr identifies a pointer by its address and type.
+type cachePtr struct {
+	p uintptr
+	t reflect.Type
+}
+
+// A cacheEncoder writes a graph of values.
+type cacheEncoder struct {
+	w     *bufio.Writer
+	ptrs  map[cachePtr]uint64     // numbers of the pointers written
+	types map[reflect.Type]uint64 // numbers of the interface types written
+	buf   [binary.MaxVarintLen64]byte
+}
+
+func (e *cacheEncoder) uint(u uint64) {
+	e.w.Write(e.buf[:binary.PutUvarint(e.buf[:], u)])
+}
+
+func (e *cacheEncoder) int(i int64) {
+	e.w.Write(e.buf[:binary.PutVarint(e.buf[:], i)])
+}
+
+func (e *cacheEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.w.WriteString(s)
+}
+
+// length writes the length of the slice or map v, 0 means nil.
+func (e *cacheEncoder) length(v reflect.Value) bool {
+	if v.IsNil() {
+		e.uint(0)
+		return false
+	}
+	e.uint(uint64(v.Len()) + 1)
+	return true
+}
+
+// encode writes v, which must have been reached through addressable values
+// or fields returned by field.
+func (e *cacheEncoder) encode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.uint(1)
+		} else {
+			e.uint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.uint(math.Float64bits(v.Float()))
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.uint(0)
+			return nil
+		}
+		if v.Type() == regexpType {
+			e.uint(1)
+			e.string(v.Interface().(*regexp.Regexp).String())
+			return nil
+		}
+		key := cachePtr{v.Pointer(), v.Type()}
+		if n, ok := e.ptrs[key]; ok {
+			e.uint(n)
+			return nil
+		}
+		n := uint64(len(e.ptrs) + 1)
+		e.ptrs[key] = n
+		e.uint(n)
+		return e.encode(v.Elem())
+	case reflect.Struct:
+		if isLock(v.Type()) {
+			return nil
+		}
+		if !v.CanAddr() {
+			c := reflect.New(v.Type()).Elem()
+			c.Set(v)
+			v = c
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if err := e.encode(cacheField(v, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if !e.length(v) {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.w.Write(v.Bytes())
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !e.length(v) {
+			return nil
+		}
+		for i := v.MapRange(); i.Next(); {
+			if err := e.encode(i.Key()); err != nil {
+				return err
+			}
+			if err := e.encode(i.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			e.uint(0)
+			return nil
+		}
+		c := v.Elem()
+		t := c.Type()
+		if n, ok := e.types[t]; ok {
+			e.uint(n)
+		} else {
+			if cacheType(t.String()) != t {
+				return fmt.Errorf("cache: cannot cache a value of type %s", t)
+			}
+			n := uint64(len(e.types) + 1)
+			e.types[t] = n
+			e.uint(n)
+			e.string(t.String())
+		}
+		return e.encode(c)
+	default:
+		// Functions and channels can only be cached when nil.
+		if v.IsNil() {
+			return nil
+		}
+		return fmt.Errorf("cache: cannot cache a value of type %s", v.Type())
+	}
+	return nil
+}
+
+// A cacheDecoder reads a graph of values written by a cacheEncoder.
+type cacheDecoder struct {
+	r     *bufio.Reader
+	ptrs  []reflect.Value
+	types []reflect.Type
+}
+
+func (d *cacheDecoder) uint() (uint64, error) {
+	return binary.ReadUvarint(d.r)
+}
+
+func (d *cacheDecoder) string() (string, error) {
+	n, err := d.uint()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decode reads the value of v, which must be settable.
+func (d *cacheDecoder) decode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		u, err := d.uint()
+		v.SetBool(u != 0)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := binary.ReadVarint(d.r)
+		v.SetInt(i)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := d.uint()
+		v.SetUint(u)
+		return err
+	case reflect.Float32, reflect.Float64:
+		u, err := d.uint()
+		v.SetFloat(math.Float64frombits(u))
+		return err
+	case reflect.String:
+		s, err := d.string()
+		v.SetString(s)
+		return err
+	case reflect.Ptr:
+		n, err := d.uint()
+		switch {
+		case err != nil || n == 0:
+			return err
+		case v.Type() == regexpType:
+			s, err := d.string()
+			if err != nil {
+				return err
+			}
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(re))
+			return nil
+		case n <= uint64(len(d.ptrs)):
+			p := d.ptrs[n-1]
+			if p.Type() != v.Type() {
+				return fmt.Errorf("pointer %d is a %s, not a %s", n, p.Type(), v.Type())
+			}
+			v.Set(p)
+			return nil
+		case n == uint64(len(d.ptrs))+1:
+			p := reflect.New(v.Type().Elem())
+			d.ptrs = append(d.ptrs, p)
+			v.Set(p)
+			return d.decode(p.Elem())
+		default:
+			return fmt.Errorf("invalid pointer %d", n)
+		}
+	case reflect.Struct:
+		if isLock(v.Type()) {
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if err := d.decode(cacheField(v, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		n, err := d.uint()
+		if err != nil || n == 0 {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), int(n-1), int(n-1))
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if _, err := io.ReadFull(d.r, s.Bytes()); err != nil {
+				return err
+			}
+			v.Set(s)
+			return nil
+		}
+		for i := 0; i < s.Len(); i++ {
+			if err := d.decode(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		n, err := d.uint()
+		if err != nil || n == 0 {
+			return err
+		}
+		t := v.Type()
+		m := reflect.MakeMapWithSize(t, int(n-1))
+		for i := uint64(1); i < n; i++ {
+			k := reflect.New(t.Key()).Elem()
+			if err := d.decode(k); err != nil {
+				return err
+			}
+			e := reflect.New(t.Elem()).Elem()
+			if err := d.decode(e); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	case reflect.Interface:
+		n, err := d.uint()
+		if err != nil || n == 0 {
+			return err
+		}
+		var t reflect.Type
+		switch {
+		case n <= uint64(len(d.types)):
+			t = d.types[n-1]
+		case n == uint64(len(d.types))+1:
+			name, err := d.string()
+			if err != nil {
+				return err
+			}
+			if t = cacheType(name); t == nil {
+				return fmt.Errorf("unknown type %s", name)
+			}
+			d.types = append(d.types, t)
+		default:
+			return fmt.Errorf("invalid type %d", n)
+		}
+		c := reflect.New(t).Elem()
+		if err := d.decode(c); err != nil {
+			return err
+		}
+		v.Set(c)
+	}
+	return nil
+}
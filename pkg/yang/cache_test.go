@@ -0,0 +1,197 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var cacheTestModules = map[string]string{
+	"ids": `module ids {
+  namespace "urn:ids";
+  prefix ids;
+  identity proto;
+  identity tcp { base proto; }
+}`,
+	"sys": `module sys {
+  namespace "urn:sys";
+  prefix s;
+  import ids { prefix i; }
+  container system {
+    leaf host-name { type string { length "1..64"; pattern "[a-z]+"; } }
+    leaf mtu { type uint16 { range "68..9000"; } default 1500; units bytes; }
+    leaf proto { type identityref { base i:proto; } }
+    list server {
+      key name;
+      max-elements 10;
+      ordered-by user;
+      leaf name { type string; }
+    }
+  }
+}`,
+}
+
+// cacheTestDir writes cacheTestModules into a new directory.
+func cacheTestDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range cacheTestModules {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".yang"), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCache(t *testing.T) {
+	dir := cacheTestDir(t)
+	defer os.RemoveAll(dir)
+	cacheDir := filepath.Join(dir, "cache")
+
+	ms := NewModules()
+	ms.AddPath(dir)
+	if err := ms.Read("sys"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.SaveCache(cacheDir, "sys"); err == nil {
+		t.Errorf("SaveCache before Process succeeded")
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	if err := ms.SaveCache(cacheDir, "sys"); err != nil {
+		t.Fatalf("SaveCache: %v", err)
+	}
+
+	load := func() (*Modules, bool) {
+		t.Helper()
+		cms := NewModules()
+		cms.AddPath(dir)
+		ok, err := cms.LoadCache(cacheDir, "sys")
+		if err != nil {
+			t.Fatalf("LoadCache: %v", err)
+		}
+		return cms, ok
+	}
+	cms, ok := load()
+	if !ok {
+		t.Fatalf("LoadCache did not find the cache")
+	}
+	if errs := cms.Process(); errs != nil {
+		t.Fatalf("Process of cached modules: %v", errs)
+	}
+
+	for _, name := range []string{"sys", "ids"} {
+		var want, got bytes.Buffer
+		ToEntry(ms.Modules[name]).Print(&want)
+		ToEntry(cms.Modules[name]).Print(&got)
+		if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+			t.Errorf("cached module %s (-want, +got):\n%s", name, diff)
+		}
+		if cms.Modules[name].Modules != cms {
+			t.Errorf("cached module %s does not refer to its Modules", name)
+		}
+	}
+
+	e, errs := cms.GetModule("sys")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	if got, want := e.Namespace().Name, "urn:sys"; got != want {
+		t.Errorf("got namespace %s, want %s", got, want)
+	}
+	host := e.Find("system/host-name")
+	if got, want := host.Type.Length.String(), "1..64"; got != want {
+		t.Errorf("got length %s, want %s", got, want)
+	}
+	if len(host.Type.CompiledPatterns) != 1 || !host.Type.CompiledPatterns[0].Regexp.MatchString("abc") {
+		t.Errorf("got compiled patterns %v, want one matching abc", host.Type.CompiledPatterns)
+	}
+	if got, want := host.Node.Statement().Location(), filepath.Join(dir, "sys.yang")+":6:5"; got != want {
+		t.Errorf("got location %s, want %s", got, want)
+	}
+	mtu := e.Find("system/mtu")
+	if got, want := mtu.Type.Range.String(), "68..9000"; got != want {
+		t.Errorf("got range %s, want %s", got, want)
+	}
+	if got, want := mtu.Node.(*Leaf).Units.asString(), "bytes"; got != want {
+		t.Errorf("got units %s, want %s", got, want)
+	}
+	proto := e.Find("system/proto")
+	if v, err := proto.Type.ParseValue("tcp"); err != nil || v.String() != "ids:tcp" {
+		t.Errorf("ParseValue(tcp) got %v, %v, want ids:tcp", v, err)
+	}
+	server := e.Find("system/server")
+	if server.ListAttr == nil || server.ListAttr.MaxElements != 10 || server.ListAttr.OrderedBy == nil || server.ListAttr.OrderedBy.Name != "user" {
+		t.Errorf("got list attributes %+v, want max-elements 10 ordered-by user", server.ListAttr)
+	}
+
+	// A cache is not found with different options.
+	cms = NewModules()
+	cms.AddPath(dir)
+	cms.ParseOptions.IgnoreModuleResolveErrors = true
+	if ok, err := cms.LoadCache(cacheDir, "sys"); ok || err != nil {
+		t.Errorf("LoadCache with different options got %v, %v, want false, nil", ok, err)
+	}
+
+	// Changing an imported module invalidates the cache.
+	if err := ioutil.WriteFile(filepath.Join(dir, "ids.yang"), []byte(cacheTestModules["ids"]+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := load(); ok {
+		t.Errorf("LoadCache used the cache after ids.yang changed")
+	}
+}
+
+func TestCacheErrors(t *testing.T) {
+	dir := cacheTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ms := NewModules()
+	if err := ms.Parse(cacheTestModules["ids"], "ids.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	if diff := errdiff.Substring(ms.SaveCache(dir, "ids"), "modules added by Parse cannot be cached"); diff != "" {
+		t.Errorf("SaveCache: %s", diff)
+	}
+	if _, err := ms.LoadCache(dir, "ids"); err == nil {
+		t.Errorf("LoadCache into Modules with modules succeeded")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.cache"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ms = NewModules()
+	name := ms.cacheFile(dir, []string{"bad"})
+	if err := os.Rename(filepath.Join(dir, "bad.cache"), name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.LoadCache(dir, "bad"); err == nil {
+		t.Errorf("LoadCache of a bad cache file succeeded")
+	}
+}
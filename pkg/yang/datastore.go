@@ -0,0 +1,256 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements views of the Entry tree per datastore of the Network
+// Management Datastore Architecture (NMDA), RFC 8342.  The conventional
+// configuration datastores only hold configuration, the config true nodes,
+// while the operational datastore holds both configuration and state.  The
+// schema of each datastore may also have deviations of its own, see
+// Options.DatastoreDeviations.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Datastore is an NMDA datastore, an identity of ietf-datastores.
+type Datastore int
+
+// The datastores of RFC 8342.  The zero Datastore is none, the Entry tree
+// itself rather than a view of it.
+const (
+	Running = Datastore(iota + 1)
+	Candidate
+	Startup
+	Intended
+	Operational
+)
+
+// DatastoresModule is the name of the module that defines the datastore
+// identities.
+const DatastoresModule = "ietf-datastores"
+
+var datastoreNames = map[Datastore]string{
+	Running:     "running",
+	Candidate:   "candidate",
+	Startup:     "startup",
+	Intended:    "intended",
+	Operational: "operational",
+}
+
+func (d Datastore) String() string {
+	if s := datastoreNames[d]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("datastore-%d", int(d))
+}
+
+// ParseDatastore returns the datastore named s, the name of an identity of
+// ietf-datastores, which may be qualified by the module name, as in
+// ietf-datastores:running.
+func ParseDatastore(s string) (Datastore, error) {
+	name := strings.TrimPrefix(s, DatastoresModule+":")
+	for d, n := range datastoreNames {
+		if n == name {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown datastore %q", s)
+}
+
+// IsConventional returns true if d is a conventional configuration
+// datastore, one that only holds configuration.
+func (d Datastore) IsConventional() bool {
+	switch d {
+	case Running, Candidate, Startup, Intended:
+		return true
+	}
+	return false
+}
+
+// OriginModule is the name of the module that defines the origin metadata
+// annotation and its identities.
+const OriginModule = "ietf-origin"
+
+// View returns a copy of the Entry tree e as it is in the datastore d.  Only
+// data nodes are in a datastore, RPCs, actions and notifications are left
+// out.  The deviations of the modules listed for d in
+// Options.DatastoreDeviations are applied to the copy, then the views of the
+// conventional datastores leave out the nodes that are not configuration.
+// The copy has the same Parent as e.  View returns nil if e is not in the
+// datastore, along with the errors applying the deviations, if any.
+func (e *Entry) View(d Datastore) (*Entry, []error) {
+	if _, ok := datastoreNames[d]; !ok {
+		return nil, []error{fmt.Errorf("unknown datastore %d", int(d))}
+	}
+	v := e.viewOf(d, e.Parent)
+	if v == nil {
+		return nil, nil
+	}
+
+	// The view is placed in a scratch parent while deviating it, so
+	// that not-supported can remove it without touching e.Parent.
+	scratch := &Entry{Parent: e.Parent, Kind: DirectoryEntry, Dir: map[string]*Entry{v.Name: v}}
+	v.Parent = scratch
+	errs := e.deviateView(d, v)
+	v.Parent = e.Parent
+	if scratch.Dir[v.Name] == nil {
+		return nil, errs
+	}
+
+	if d.IsConventional() {
+		if v.ReadOnly() {
+			return nil, errs
+		}
+		v.dropState()
+	}
+	return v, errs
+}
+
+// viewOf returns a copy of e, with the parent parent, for the view of the
+// datastore d, or nil if e is not a data node.
+func (e *Entry) viewOf(d Datastore, parent *Entry) *Entry {
+	switch e.Node.(type) {
+	case *RPC, *Action, *Notification:
+		return nil
+	}
+	ne := *e
+	ne.Parent = parent
+	ne.datastore = d
+	ne.structures = nil
+	// Deviations change the list attributes and defaults in place.
+	if e.ListAttr != nil {
+		la := *e.ListAttr
+		ne.ListAttr = &la
+	}
+	ne.Default = append([]string(nil), e.Default...)
+	if e.Dir != nil {
+		ne.Dir = make(map[string]*Entry, len(e.Dir))
+		for k, c := range e.Dir {
+			if nc := c.viewOf(d, &ne); nc != nil {
+				ne.Dir[k] = nc
+			}
+		}
+	}
+	return &ne
+}
+
+// deviateView applies the deviations that only apply to the datastore d to
+// v, the view of e.  Deviations of nodes that are not within e are
+// ignored.
+func (e *Entry) deviateView(d Datastore, v *Entry) []error {
+	ms := e.Modules()
+	names := ms.ParseOptions.DatastoreDeviations[d]
+	if len(names) == 0 {
+		return nil
+	}
+	deviating := map[string]bool{}
+	for _, n := range names {
+		deviating[n] = true
+	}
+
+	// find returns the node of the view at the node found by de.Find,
+	// or nil if it is not within e.
+	find := func(de *Entry) func(string) *Entry {
+		return func(path string) *Entry {
+			var names []string
+			for n := de.Find(path); n != e; n = n.Parent {
+				if n == nil {
+					return nil
+				}
+				names = append(names, n.Name)
+			}
+			n := v
+			for i := len(names) - 1; i >= 0 && n != nil; i-- {
+				n = n.Dir[names[i]]
+			}
+			return n
+		}
+	}
+
+	var errs []error
+	seen := map[*Module]bool{}
+	for _, mods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		var keys []string
+		for k := range mods {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			m := mods[k]
+			if seen[m] || !deviating[moduleName(m)] {
+				continue
+			}
+			seen[m] = true
+			de := ToEntry(m)
+			f := find(de)
+			// Deviations of nodes outside of e are left out, those
+			// of missing nodes are reported.
+			var devs []*DeviatedEntry
+			for _, dev := range de.Deviations {
+				if de.Find(dev.DeviatedPath) == nil || f(dev.DeviatedPath) != nil {
+					devs = append(devs, dev)
+				}
+			}
+			errs = append(errs, de.applyDeviate(devs, f)...)
+		}
+	}
+	return errorSort(errs)
+}
+
+// dropState removes the nodes that are not configuration from the tree e.
+func (e *Entry) dropState() {
+	for k, c := range e.Dir {
+		if c.ReadOnly() {
+			delete(e.Dir, k)
+			continue
+		}
+		c.dropState()
+	}
+}
+
+// Datastore returns the datastore of the view e is in, or 0 if e is not in
+// a view.
+func (e *Entry) Datastore() Datastore {
+	return e.datastore
+}
+
+// Origins returns the identities of ietf-origin, qualified by the module
+// name, that the origin metadata annotation of the data node e may have in
+// the operational datastore, as described in RFC 8342 section 5.3.4.  Only
+// configuration can come from the intended or a dynamic configuration
+// datastore, and only a node with a default value can be in use because of
+// it.  Origins returns nil if e is not in a view of the operational
+// datastore or is not a data node.
+func (e *Entry) Origins() []string {
+	if e.datastore != Operational || e.IsChoice() || e.IsCase() {
+		return nil
+	}
+	var origins []string
+	if !e.ReadOnly() {
+		origins = append(origins, "intended", "dynamic")
+	}
+	origins = append(origins, "system", "learned")
+	if len(e.Default) > 0 || (e.Type != nil && e.Type.HasDefault) {
+		origins = append(origins, "default")
+	}
+	origins = append(origins, "unknown")
+	for i, o := range origins {
+		origins[i] = OriginModule + ":" + o
+	}
+	return origins
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const datastoreModule = `module sys {
+  namespace "urn:sys";
+  prefix s;
+  container system {
+    leaf host-name { type string; }
+    leaf mtu { type uint16; default 1500; }
+    list server {
+      key name;
+      min-elements 1;
+      leaf name { type string; }
+      leaf uptime { type uint32; config false; }
+    }
+    container state {
+      config false;
+      leaf boot-time { type string; }
+    }
+    action restart;
+    notification changed;
+  }
+  rpc reboot;
+}
+`
+
+const operationalDeviations = `module sys-oper-dev {
+  namespace "urn:sys-oper-dev";
+  prefix sod;
+  import sys { prefix s; }
+  deviation /s:system/s:host-name { deviate not-supported; }
+  deviation /s:system/s:server { deviate replace { min-elements 0; } }
+}
+`
+
+const runningDeviations = `module sys-run-dev {
+  namespace "urn:sys-run-dev";
+  prefix srd;
+  import sys { prefix s; }
+  deviation /s:system/s:mtu { deviate replace { config false; } }
+}
+`
+
+// datastoreTest returns the module sys of the processed modules of sources
+// with the datastore deviations devs.
+func datastoreTest(t *testing.T, devs map[Datastore][]string, sources ...string) *Entry {
+	t.Helper()
+	ms := NewModules()
+	ms.ParseOptions.DatastoreDeviations = devs
+	for i, src := range sources {
+		if err := ms.Parse(src, fmt.Sprintf("datastore%d.yang", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	e, errs := ms.GetModule("sys")
+	if len(errs) > 0 {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	return e
+}
+
+// treePaths returns the sorted paths of the nodes below e.
+func treePaths(e *Entry) []string {
+	var paths []string
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		for _, c := range e.Dir {
+			paths = append(paths, c.Path())
+			walk(c)
+		}
+	}
+	walk(e)
+	sort.Strings(paths)
+	return paths
+}
+
+func TestParseDatastore(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Datastore
+		wantErr string
+	}{
+		{in: "running", want: Running},
+		{in: "ietf-datastores:operational", want: Operational},
+		{in: "intended", want: Intended},
+		{in: "other:running", wantErr: `unknown datastore "other:running"`},
+		{in: "", wantErr: "unknown datastore"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDatastore(tt.in)
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("ParseDatastore(%q): %s", tt.in, diff)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDatastore(%q): got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	devs := map[Datastore][]string{
+		Operational: {"sys-oper-dev"},
+		Running:     {"sys-run-dev"},
+	}
+	sys := datastoreTest(t, devs, datastoreModule, operationalDeviations, runningDeviations)
+
+	tests := []struct {
+		desc string
+		in   Datastore
+		want []string
+	}{{
+		desc: "running",
+		in:   Running,
+		want: []string{
+			"/sys/system",
+			"/sys/system/host-name",
+			"/sys/system/server",
+			"/sys/system/server/name",
+		},
+	}, {
+		desc: "intended",
+		in:   Intended,
+		want: []string{
+			"/sys/system",
+			"/sys/system/host-name",
+			"/sys/system/mtu",
+			"/sys/system/server",
+			"/sys/system/server/name",
+		},
+	}, {
+		desc: "operational",
+		in:   Operational,
+		want: []string{
+			"/sys/system",
+			"/sys/system/mtu",
+			"/sys/system/server",
+			"/sys/system/server/name",
+			"/sys/system/server/uptime",
+			"/sys/system/state",
+			"/sys/system/state/boot-time",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			v, errs := sys.View(tt.in)
+			if len(errs) > 0 {
+				t.Fatalf("View: %v", errs)
+			}
+			if diff := cmp.Diff(tt.want, treePaths(v)); diff != "" {
+				t.Errorf("View (-want, +got):\n%s", diff)
+			}
+			if got := v.Find("system").Datastore(); got != tt.in {
+				t.Errorf("Datastore: got %v, want %v", got, tt.in)
+			}
+		})
+	}
+
+	// The deviations of a datastore are only applied to its view.
+	if sys.Find("system/host-name") == nil || sys.Find("system/mtu").ReadOnly() {
+		t.Errorf("datastore deviations are applied to the Entry tree")
+	}
+	if got := sys.Find("system/server").ListAttr.MinElements; got != 1 {
+		t.Errorf("min-elements of the Entry tree: got %d, want 1", got)
+	}
+	op, _ := sys.View(Operational)
+	if got := op.Find("system/server").ListAttr.MinElements; got != 0 {
+		t.Errorf("min-elements of the operational view: got %d, want 0", got)
+	}
+	if sys.Find("system/restart") == nil || sys.Find("reboot") == nil {
+		t.Errorf("View removed the RPCs of the Entry tree")
+	}
+
+	// A view of a node that is not in the datastore is nil.
+	if v, _ := sys.Find("system/state").View(Running); v != nil {
+		t.Errorf("running view of state data: got %v, want nil", v)
+	}
+	if v, _ := sys.Find("system/state").View(Operational); v == nil || v.Parent != sys.Dir["system"] {
+		t.Errorf("operational view of state data: got %v, want a copy with the same parent", v)
+	}
+}
+
+func TestOrigins(t *testing.T) {
+	sys := datastoreTest(t, nil, datastoreModule)
+	op, _ := sys.View(Operational)
+	tests := []struct {
+		path string
+		want []string
+	}{{
+		path: "system/host-name",
+		want: []string{"ietf-origin:intended", "ietf-origin:dynamic", "ietf-origin:system", "ietf-origin:learned", "ietf-origin:unknown"},
+	}, {
+		path: "system/mtu",
+		want: []string{"ietf-origin:intended", "ietf-origin:dynamic", "ietf-origin:system", "ietf-origin:learned", "ietf-origin:default", "ietf-origin:unknown"},
+	}, {
+		path: "system/state/boot-time",
+		want: []string{"ietf-origin:system", "ietf-origin:learned", "ietf-origin:unknown"},
+	}}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, op.Find(tt.path).Origins()); diff != "" {
+			t.Errorf("Origins of %s (-want, +got):\n%s", tt.path, diff)
+		}
+	}
+
+	running, _ := sys.View(Running)
+	if got := running.Find("system/host-name").Origins(); got != nil {
+		t.Errorf("Origins in the running view: got %v, want nil", got)
+	}
+	if got := sys.Find("system/host-name").Origins(); got != nil {
+		t.Errorf("Origins in the Entry tree: got %v, want nil", got)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements DataNode, a minimal instance data tree that is built
+// from decoded RFC 7951 JSON and its Entry schema.  It is the data model that
+// XPath expressions are evaluated against.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A DataNode is a node in an instance data tree.  The root of a tree is a
+// document node which has no Schema and no Name.  Choice and case nodes do
+// not appear in instance data, so the Schema of a child may be a descendant
+// of the Schema of its parent.
+type DataNode struct {
+	Schema   *Entry      // schema node this instance was decoded with
+	Module   string      // name of the module defining the node's namespace
+	Name     string      // name of the node, without a prefix
+	Value    string      // string value of leaf and leaf-list instances
+	Parent   *DataNode   // parent node, nil for the document node
+	Children []*DataNode // children in document order
+}
+
+// IsRoot returns true if n is the document node of a data tree.
+func (n *DataNode) IsRoot() bool {
+	return n.Parent == nil && n.Schema == nil
+}
+
+// Root returns the document node of the data tree n is in.
+func (n *DataNode) Root() *DataNode {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// Child returns the first child of n with the given module and name.  An
+// empty module matches any module.
+func (n *DataNode) Child(module, name string) *DataNode {
+	for _, c := range n.Children {
+		if c.Name == name && (module == "" || c.Module == module) {
+			return c
+		}
+	}
+	return nil
+}
+
+// StringValue returns the XPath string-value of n, which is the
+// concatenation of the values of all leaf descendants in document order.
+func (n *DataNode) StringValue() string {
+	if len(n.Children) == 0 {
+		return n.Value
+	}
+	var b strings.Builder
+	for _, c := range n.Children {
+		b.WriteString(c.StringValue())
+	}
+	return b.String()
+}
+
+// Path returns the RFC 7951 style data path of n.  Module names are only
+// included where the namespace changes, and list entries include a predicate
+// for each key.
+func (n *DataNode) Path() string {
+	if n == nil || n.IsRoot() {
+		return "/"
+	}
+	var parts []string
+	for ; n != nil && !n.IsRoot(); n = n.Parent {
+		name := n.Name
+		if n.Parent == nil || n.Parent.IsRoot() || n.Parent.Module != n.Module {
+			name = n.Module + ":" + name
+		}
+		if n.Schema != nil && n.Schema.IsList() && n.Schema.Key != "" {
+			for _, k := range strings.Fields(n.Schema.Key) {
+				_, k = getPrefix(k)
+				if c := n.Child("", k); c != nil {
+					name += fmt.Sprintf("[%s=%s]", k, strconv.Quote(c.Value))
+				}
+			}
+		}
+		parts = append(parts, name)
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// DataChild returns the schema entry of the data node named name that is a
+// child of e in instance data.  Choice and case entries do not appear in
+// instance data and are searched through.  The input and output of an RPC
+// or action are returned for the names "input" and "output".  DataChild
+// returns nil if there is no such child.
+func (e *Entry) DataChild(name string) *Entry {
+	if e == nil {
+		return nil
+	}
+	if e.RPC != nil {
+		switch name {
+		case "input":
+			return e.RPC.Input
+		case "output":
+			return e.RPC.Output
+		}
+	}
+	if c := e.Dir[name]; c != nil && !c.IsChoice() && !c.IsCase() {
+		return c
+	}
+	var names []string
+	for k, c := range e.Dir {
+		if c.IsChoice() || c.IsCase() {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if c := e.Dir[k].DataChild(name); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// NewDataTree returns the document node of a data tree built from data, the
+// decoded form of an RFC 7951 JSON document.  Top level member names must be
+// qualified with the name of their module, e.g., "ietf-interfaces:interfaces",
+// unless the name is unique across modules.  The schema for data is found in
+// modules, a list of module Entry trees.
+func NewDataTree(modules []*Entry, data map[string]interface{}) (*DataNode, error) {
+	root := &DataNode{}
+	var errs []string
+	for _, k := range sortedKeys(data) {
+		qual, name := getPrefix(k)
+		var mod string
+		var schema *Entry
+		for _, m := range modules {
+			if qual != "" && m.Name != qual {
+				continue
+			}
+			if c := m.DataChild(name); c != nil {
+				if schema != nil {
+					errs = append(errs, fmt.Sprintf("/%s: ambiguous top level node, a module name is required", k))
+					schema = nil
+					break
+				}
+				schema, mod = c, m.Name
+			}
+		}
+		if schema == nil {
+			if len(errs) == 0 || !strings.HasPrefix(errs[len(errs)-1], "/"+k+":") {
+				errs = append(errs, fmt.Sprintf("/%s: unknown top level node", k))
+			}
+			continue
+		}
+		errs = append(errs, root.addData(schema, mod, name, data[k])...)
+	}
+	if len(errs) > 0 {
+		return root, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return root, nil
+}
+
+// addData adds the instance(s) of schema found in v as children of n.
+func (n *DataNode) addData(schema *Entry, module, name string, v interface{}) []string {
+	newChild := func() *DataNode {
+		c := &DataNode{Schema: schema, Module: module, Name: name, Parent: n}
+		n.Children = append(n.Children, c)
+		return c
+	}
+	path := func() string {
+		p := n.Path()
+		if !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		if n.IsRoot() || n.Module != module {
+			return p + module + ":" + name
+		}
+		return p + name
+	}
+
+	switch {
+	case schema.IsLeafList():
+		items, ok := v.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: leaf-list value must be an array", path())}
+		}
+		for _, item := range items {
+			newChild().Value = jsonValueString(item)
+		}
+		return nil
+	case schema.IsLeaf():
+		if _, ok := v.(map[string]interface{}); ok {
+			return []string{fmt.Sprintf("%s: leaf value must not be an object", path())}
+		}
+		newChild().Value = jsonValueString(v)
+		return nil
+	case schema.IsList():
+		items, ok := v.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: list value must be an array", path())}
+		}
+		var errs []string
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: list entry must be an object", path()))
+				continue
+			}
+			errs = append(errs, newChild().addMembers(m)...)
+		}
+		return errs
+	case schema.Kind == AnyDataEntry || schema.Kind == AnyXMLEntry:
+		c := newChild()
+		if b, err := json.Marshal(v); err == nil {
+			c.Value = string(b)
+		}
+		return nil
+	default:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %s value must be an object", path(), schema.Node.Kind())}
+		}
+		return newChild().addMembers(m)
+	}
+}
+
+// addMembers adds the members of the JSON object m as children of n.
+func (n *DataNode) addMembers(m map[string]interface{}) []string {
+	var errs []string
+	for _, k := range sortedKeys(m) {
+		mod, name := getPrefix(k)
+		if mod == "" {
+			mod = n.Module
+		}
+		c := n.Schema.DataChild(name)
+		if c == nil {
+			errs = append(errs, fmt.Sprintf("%s/%s: unknown element", n.Path(), k))
+			continue
+		}
+		errs = append(errs, n.addData(c, mod, name, m[k])...)
+	}
+	return errs
+}
+
+// jsonValueString returns the string value of the decoded JSON leaf value v.
+// The RFC 7951 encoding of the empty type, [null], has the value "".
+func jsonValueString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case []interface{}:
+		if len(v) == 1 && v[0] == nil {
+			return ""
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestDataNodePath(t *testing.T) {
+	_, root := xpathTestTree(t)
+	top := root.Child("xp", "top")
+	var got []string
+	for _, n := range []*DataNode{root, top, top.Child("", "mtu"), top.Children[5], top.Children[6].Child("", "speed")} {
+		got = append(got, n.Path())
+	}
+	want := []string{
+		"/",
+		"/xp:top",
+		"/xp:top/mtu",
+		`/xp:top/intf[name="eth0"]`,
+		`/xp:top/intf[name="eth1"]/speed`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestNewDataTreeErrors(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(xpathTestModule, "xp.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	modules := []*Entry{ToEntry(ms.Modules["xp"])}
+
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc: "unqualified top level name",
+		in:   `{"top": {"mtu": 1}}`,
+	}, {
+		desc:    "unknown top level node",
+		in:      `{"xp:bottom": {}}`,
+		wantErr: "/xp:bottom: unknown top level node",
+	}, {
+		desc:    "unknown element",
+		in:      `{"xp:top": {"bogus": 1}}`,
+		wantErr: "/xp:top/bogus: unknown element",
+	}, {
+		desc:    "container not an object",
+		in:      `{"xp:top": 1}`,
+		wantErr: "/xp:top: container value must be an object",
+	}, {
+		desc:    "list not an array",
+		in:      `{"xp:top": {"intf": {"name": "eth0"}}}`,
+		wantErr: "/xp:top/intf: list value must be an array",
+	}, {
+		desc:    "leaf is an object",
+		in:      `{"xp:top": {"mtu": {}}}`,
+		wantErr: "/xp:top/mtu: leaf value must not be an object",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.in), &data); err != nil {
+				t.Fatalf("could not unmarshal data: %v", err)
+			}
+			_, err := NewDataTree(modules, data)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements Diagnostic, the structured form of the errors
+// returned by Parse, Modules.Process and Entry.GetErrors.
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Severity is the severity of a Diagnostic.
+type Severity string
+
+// The severities of diagnostics.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// A Code identifies the kind of problem reported by a Diagnostic.  Codes are
+// stable, unlike the text of messages, and may be used to filter diagnostics.
+type Code string
+
+// The codes of diagnostics.
+const (
+	CodeSyntax           Code = "syntax"              // the input is not valid YANG
+	CodeUnknownStatement Code = "unknown-statement"   // a statement is not allowed where it is
+	CodeMissingStatement Code = "missing-statement"   // a required substatement is missing
+	CodeInvalidValue     Code = "invalid-value"       // a statement has an invalid argument
+	CodeDuplicate        Code = "duplicate"           // something is defined twice
+	CodeUnresolved       Code = "unresolved"          // a module, prefix, type, grouping, feature, identity or node is not found
+	CodeCircular         Code = "circular-dependency" // something depends on itself
+	CodeDeviation        Code = "invalid-deviation"   // a deviation cannot be applied
+	CodeStatus           Code = "invalid-status"      // a definition references a less current one
+	CodeOther            Code = "other"               // any other problem
+)
+
+// A Location is a position in a source file.  Line and Col start at 1 and
+// are 0 when not known.
+type Location struct {
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	Col  int    `json:"column,omitempty"`
+}
+
+// String returns l in the form file:line:col, as used in error messages.
+func (l Location) String() string {
+	switch {
+	case l.File == "" && l.Line == 0:
+		return "unknown"
+	case l.File == "":
+		return fmt.Sprintf("line %d:%d", l.Line, l.Col)
+	case l.Line == 0:
+		return l.File
+	default:
+		return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Col)
+	}
+}
+
+// known returns true if anything is known about l.
+func (l Location) known() bool {
+	return l.File != "" || l.Line != 0
+}
+
+// NodeLocation returns the location of the statement n was parsed from.
+func NodeLocation(n Node) Location {
+	if n == nil || n.Statement() == nil {
+		return Location{}
+	}
+	s := n.Statement()
+	return Location{File: s.file, Line: s.line, Col: s.col}
+}
+
+// A RelatedLocation is another location relevant to a Diagnostic, such as
+// the first definition of something defined twice.
+type RelatedLocation struct {
+	Location
+	Message string `json:"message"`
+}
+
+// A Diagnostic is a problem found in a YANG source.  It is an error whose
+// text is the location followed by the message, which is preceded by
+// "warning: " for warnings.
+type Diagnostic struct {
+	Location
+	Severity Severity          `json:"severity"`
+	Code     Code              `json:"code"`
+	Keyword  string            `json:"keyword,omitempty"` // keyword of the offending statement
+	Message  string            `json:"message"`
+	Related  []RelatedLocation `json:"related,omitempty"`
+
+	// located is set when the diagnostic is about a node, whose location
+	// is written as "unknown" when it is not known, as done by Source.
+	located bool
+}
+
+func (d *Diagnostic) Error() string {
+	msg := d.Message
+	if d.Severity == SeverityWarning {
+		msg = "warning: " + msg
+	}
+	if !d.known() && !d.located {
+		return msg
+	}
+	return d.Location.String() + ": " + msg
+}
+
+// diagnosticf returns an error Diagnostic with code for the statement of n
+// and the message formatted from format and v.
+func diagnosticf(n Node, code Code, format string, v ...interface{}) *Diagnostic {
+	d := &Diagnostic{
+		Location: NodeLocation(n),
+		Severity: SeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, v...),
+		located:  true,
+	}
+	if n != nil && n.Statement() != nil {
+		d.Keyword = n.Statement().Keyword
+	}
+	return d
+}
+
+// statementDiagnosticf is diagnosticf for a statement that is not yet a
+// Node.
+func statementDiagnosticf(s *Statement, code Code, format string, v ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Location: Location{File: s.file, Line: s.line, Col: s.col},
+		Severity: SeverityError,
+		Code:     code,
+		Keyword:  s.Keyword,
+		Message:  fmt.Sprintf(format, v...),
+		located:  true,
+	}
+}
+
+// related returns d after adding the location of n, described by message,
+// to its related locations.
+func (d *Diagnostic) related(n Node, message string) *Diagnostic {
+	if l := NodeLocation(n); l.known() {
+		d.Related = append(d.Related, RelatedLocation{Location: l, Message: message})
+	}
+	return d
+}
+
+// Diagnostics is a list of diagnostics that is also an error, such as the
+// syntax errors found by Parse.  Its text has one diagnostic per line.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	lines := make([]string, len(ds))
+	for i, d := range ds {
+		lines[i] = d.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// locationPrefix matches the location at the start of an error that is
+// not a Diagnostic.
+var locationPrefix = regexp.MustCompile(`(?s)^(?:line |(.+?):)(\d+):(\d+): (.*)$`)
+
+// DiagnosticOf returns err as a Diagnostic.  An error that neither is nor
+// wraps a Diagnostic becomes one with code CodeOther and the location, if
+// any, its text starts with.
+func DiagnosticOf(err error) *Diagnostic {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		return d
+	}
+	d = &Diagnostic{Severity: SeverityError, Code: CodeOther, Message: err.Error()}
+	if m := locationPrefix.FindStringSubmatch(d.Message); m != nil {
+		d.File = m[1]
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Col, _ = strconv.Atoi(m[3])
+		d.Message = m[4]
+	}
+	return d
+}
+
+// ToDiagnostics returns errs as diagnostics.  Diagnostics in errs are
+// flattened.
+func ToDiagnostics(errs ...error) Diagnostics {
+	var ds Diagnostics
+	for _, err := range errs {
+		var list Diagnostics
+		if errors.As(err, &list) {
+			ds = append(ds, list...)
+			continue
+		}
+		ds = append(ds, DiagnosticOf(err))
+	}
+	return ds
+}
+
+// lessDiagnostic returns true if a sorts before b: by file, then line and
+// column, then message.
+func lessDiagnostic(a, b *Diagnostic) bool {
+	switch {
+	case a.File != b.File:
+		return a.File < b.File
+	case a.Line != b.Line:
+		return a.Line < b.Line
+	case a.Col != b.Col:
+		return a.Col < b.Col
+	default:
+		return a.Message < b.Message
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	_, err := Parse(`module m {
+  namespace "urn:m"
+  prefix m;
+  leaf "l" { type string; }
+}
+}`, "m.yang")
+	if err == nil {
+		t.Fatalf("Parse succeeded")
+	}
+	want := Diagnostics{{
+		Location: Location{File: "m.yang", Line: 3, Col: 3},
+		Severity: SeverityError,
+		Code:     CodeSyntax,
+		Message:  "prefix: syntax error, expected ';' or '{'",
+	}, {
+		Location: Location{File: "m.yang", Line: 6, Col: 1},
+		Severity: SeverityError,
+		Code:     CodeSyntax,
+		Message:  "unexpected }",
+	}}
+	if diff := cmp.Diff(want, ToDiagnostics(err), cmpopts.IgnoreUnexported(Diagnostic{})); diff != "" {
+		t.Errorf("Parse diagnostics (-want, +got):\n%s", diff)
+	}
+	if got, want := err.Error(), "m.yang:3:3: prefix: syntax error, expected ';' or '{'\nm.yang:6:1: unexpected }"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+}
+
+func TestProcessDiagnostics(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  namespace "urn:m";
+  prefix m;
+  import missing { prefix x; }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Parse(`module n {
+  namespace "urn:n";
+  prefix n;
+  container c {
+    leaf a { type no-such-type; }
+    leaf a { type string; }
+  }
+}`, "n.yang"); err != nil {
+		t.Fatal(err)
+	}
+	errs := ms.Process()
+	want := Diagnostics{{
+		Location: Location{File: "m.yang", Line: 4, Col: 3},
+		Severity: SeverityError,
+		Code:     CodeUnresolved,
+		Keyword:  "import",
+		Message:  "no such module: missing",
+	}}
+	if diff := cmp.Diff(want, ToDiagnostics(errs...), cmpopts.IgnoreUnexported(Diagnostic{})); diff != "" {
+		t.Errorf("Process diagnostics (-want, +got):\n%s", diff)
+	}
+
+	errs = ToEntry(ms.Modules["n"]).GetErrors()
+	want = Diagnostics{{
+		Location: Location{File: "n.yang", Line: 4, Col: 3},
+		Severity: SeverityError,
+		Code:     CodeDuplicate,
+		Keyword:  "container",
+		Message:  "duplicate key from n.yang:6:5: a",
+		Related: []RelatedLocation{{
+			Location: Location{File: "n.yang", Line: 6, Col: 5},
+			Message:  "duplicate a",
+		}},
+	}, {
+		Location: Location{File: "n.yang", Line: 5, Col: 14},
+		Severity: SeverityError,
+		Code:     CodeUnresolved,
+		Keyword:  "type",
+		Message:  "unknown type: n:no-such-type",
+	}}
+	if diff := cmp.Diff(want, ToDiagnostics(errs...), cmpopts.IgnoreUnexported(Diagnostic{})); diff != "" {
+		t.Errorf("GetErrors diagnostics (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCompileDiagnostics(t *testing.T) {
+	tests := []struct {
+		desc     string
+		body     string
+		wantCode Code
+	}{{
+		desc:     "bad pattern",
+		body:     `leaf l { type string { pattern "[a-"; } }`,
+		wantCode: CodeInvalidValue,
+	}, {
+		desc:     "invalid pattern modifier",
+		body:     `leaf l { type string { pattern "a" { modifier "invert"; } } }`,
+		wantCode: CodeInvalidValue,
+	}, {
+		desc: "typedef with an unknown identity base",
+		body: `typedef t { type identityref { base nosuch; } }
+  leaf l { type t; }`,
+		wantCode: CodeUnresolved,
+	}, {
+		desc:     "bad must",
+		body:     `leaf l { type string; must "(. = 'a'"; }`,
+		wantCode: CodeInvalidValue,
+	}, {
+		desc:     "bad when",
+		body:     `leaf l { type string; when "foo("; }`,
+		wantCode: CodeInvalidValue,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(fmt.Sprintf(`module m {
+  yang-version 1.1;
+  namespace "urn:m";
+  prefix m;
+  %s
+}`, tt.body), "m.yang"); err != nil {
+				t.Fatal(err)
+			}
+			errs := ms.Process()
+			if len(errs) == 0 {
+				t.Fatalf("Process succeeded")
+			}
+			for _, d := range ToDiagnostics(errs...) {
+				if d.Code == CodeOther {
+					t.Errorf("got a diagnostic without a code: %v", d)
+				}
+			}
+			if got := ToDiagnostics(errs...)[0].Code; got != tt.wantCode {
+				t.Errorf("got code %s, want %s: %v", got, tt.wantCode, errs)
+			}
+		})
+	}
+}
+
+func TestDiagnosticOf(t *testing.T) {
+	d := &Diagnostic{Location: Location{File: "f.yang", Line: 1, Col: 2}, Code: CodeDuplicate, Message: "m"}
+	tests := []struct {
+		desc string
+		in   error
+		want *Diagnostic
+	}{{
+		desc: "diagnostic",
+		in:   d,
+		want: d,
+	}, {
+		desc: "wrapped diagnostic",
+		in:   fmt.Errorf("reading: %w", d),
+		want: d,
+	}, {
+		desc: "located error",
+		in:   errors.New("f.yang:10:3: bad thing: here"),
+		want: &Diagnostic{Location: Location{File: "f.yang", Line: 10, Col: 3}, Severity: SeverityError, Code: CodeOther, Message: "bad thing: here"},
+	}, {
+		desc: "line only",
+		in:   errors.New("line 4:5: bad"),
+		want: &Diagnostic{Location: Location{Line: 4, Col: 5}, Severity: SeverityError, Code: CodeOther, Message: "bad"},
+	}, {
+		desc: "no location",
+		in:   errors.New("no such file: x.yang"),
+		want: &Diagnostic{Severity: SeverityError, Code: CodeOther, Message: "no such file: x.yang"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, DiagnosticOf(tt.in), cmpopts.IgnoreUnexported(Diagnostic{})); diff != "" {
+				t.Errorf("DiagnosticOf (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiagnosticError(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *Diagnostic
+		want string
+	}{{
+		desc: "located",
+		in:   diagnosticf(&Value{Source: &Statement{file: "f", line: 1, col: 2}}, CodeOther, "bad %s", "x"),
+		want: "f:1:2: bad x",
+	}, {
+		desc: "node without a statement",
+		in:   diagnosticf(&Value{}, CodeOther, "bad"),
+		want: "unknown: bad",
+	}, {
+		desc: "without location",
+		in:   &Diagnostic{Message: "bad"},
+		want: "bad",
+	}, {
+		desc: "warning",
+		in:   &Diagnostic{Location: Location{File: "f", Line: 3, Col: 1}, Severity: SeverityWarning, Message: "odd"},
+		want: "f:3:1: warning: odd",
+	}}
+	for _, tt := range tests {
+		if got := tt.in.Error(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
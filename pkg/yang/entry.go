@@ -93,6 +93,7 @@ type Entry struct {
 	Config    TriState  // config state of this entry, if known
 	Prefix    *Value    `json:",omitempty"` // prefix to use from this point down
 	Mandatory TriState  `json:",omitempty"` // whether this entry is mandatory in the tree
+	Status    Status    `json:",omitempty"` // status of this entry, raised to that of its ancestors
 
 	// Fields associated with directory nodes
 	Dir map[string]*Entry `json:",omitempty"`
@@ -104,6 +105,10 @@ type Entry struct {
 	// Extensions found
 	Exts []*Statement `json:",omitempty"`
 
+	// ExtensionValues are the values of the extensions in Exts that have
+	// a registered ExtensionHandler, keyed by module:extension.
+	ExtensionValues map[string][]interface{} `json:",omitempty"`
+
 	// Fields associated with list nodes (both lists and leaf-lists)
 	ListAttr *ListAttr `json:",omitempty"`
 
@@ -136,6 +141,17 @@ type Entry struct {
 	// the augmenting entity per RFC6020 Section 7.15.2. The namespace
 	// of the Entry should be accessed using the Namespace function.
 	namespace *Value
+
+	// schema is set on the top-level nodes of a schema mounted at a mount
+	// point, the Entry of which is their Parent, to the mounted modules.
+	schema *Modules
+
+	// structures are the structures defined in the module e, see
+	// Structures.
+	structures map[string]*Entry
+
+	// datastore is the datastore of the view e is in, see View.
+	datastore Datastore
 }
 
 // An RPCEntry contains information related to an RPC Node.
@@ -170,6 +186,9 @@ type UsesStmt struct {
 // when looking for rooted nodes not part of this Entry tree.
 func (e *Entry) Modules() *Modules {
 	for e.Parent != nil {
+		if e.schema != nil {
+			return e.schema
+		}
 		e = e.Parent
 	}
 	return e.Node.(*Module).Modules
@@ -266,6 +285,7 @@ const (
 	NotificationEntry
 	OutputEntry
 	DeviateEntry
+	StructureEntry
 )
 
 // EntryKindToName maps EntryKind to their names
@@ -280,6 +300,7 @@ var EntryKindToName = map[EntryKind]string{
 	NotificationEntry: "Notification",
 	OutputEntry:       "Output",
 	DeviateEntry:      "Deviate",
+	StructureEntry:    "Structure",
 }
 
 func (k EntryKind) String() string {
@@ -310,20 +331,14 @@ func newLeaf(n Node) *Entry {
 	}
 }
 
-// newError returns an error Entry using format and v to create the error
-// contained in the node.  The location of the error is prepended.
-func newError(n Node, format string, v ...interface{}) *Entry {
+// newError returns an error Entry using code, format and v to create the
+// diagnostic contained in the node.  The location of the error is that of n.
+func newError(n Node, code Code, format string, v ...interface{}) *Entry {
 	e := &Entry{Node: n}
-	e.errorf("%s: "+format, append([]interface{}{Source(n)}, v...)...)
+	e.addError(diagnosticf(n, code, format, v...))
 	return e
 }
 
-// errorf appends the error constructed from string and v to the list of errors
-// on e.
-func (e *Entry) errorf(format string, v ...interface{}) {
-	e.Errors = append(e.Errors, fmt.Errorf(format, v...))
-}
-
 // addError appends err to the list of errors on e if err is not nil.
 func (e *Entry) addError(err error) {
 	if err != nil {
@@ -386,7 +401,7 @@ func (e *Entry) GetErrors() []error {
 func (e *Entry) add(key string, value *Entry) *Entry {
 	value.Parent = e
 	if e.Dir[key] != nil {
-		e.errorf("%s: duplicate key from %s: %s", Source(e.Node), Source(value.Node), key)
+		e.addError(diagnosticf(e.Node, CodeDuplicate, "duplicate key from %s: %s", Source(value.Node), key).related(value.Node, "duplicate "+key))
 		return e
 	}
 	e.Dir[key] = value
@@ -396,7 +411,7 @@ func (e *Entry) add(key string, value *Entry) *Entry {
 // delete removes the directory entry key from the entry.
 func (e *Entry) delete(key string) {
 	if _, ok := e.Dir[key]; !ok {
-		e.errorf("%s: unknown child key %s", Source(e.Node), key)
+		e.addError(diagnosticf(e.Node, CodeUnresolved, "unknown child key %s", key))
 	}
 	delete(e.Dir, key)
 }
@@ -500,10 +515,10 @@ func semCheckMaxElements(v *Value) (uint64, error) {
 	}
 	val, err := strconv.ParseUint(v.Name, 10, 64)
 	if err != nil {
-		return val, fmt.Errorf(`%s: invalid max-elements value %q (expect "unbounded" or a positive integer): %v`, Source(v), v.Name, err)
+		return val, diagnosticf(v, CodeInvalidValue, `invalid max-elements value %q (expect "unbounded" or a positive integer): %v`, v.Name, err)
 	}
 	if val == 0 {
-		return val, fmt.Errorf(`%s: invalid max-elements value 0 (expect "unbounded" or a positive integer)`, Source(v))
+		return val, diagnosticf(v, CodeInvalidValue, `invalid max-elements value 0 (expect "unbounded" or a positive integer)`)
 	}
 	return val, nil
 }
@@ -515,7 +530,7 @@ func semCheckMinElements(v *Value) (uint64, error) {
 	}
 	val, err := strconv.ParseUint(v.Name, 10, 64)
 	if err != nil {
-		return val, fmt.Errorf(`%s: invalid min-elements value %q (expect a non-negative integer): %v`, Source(v), v.Name, err)
+		return val, diagnosticf(v, CodeInvalidValue, `invalid min-elements value %q (expect a non-negative integer): %v`, v.Name, err)
 	}
 	return val, nil
 }
@@ -535,17 +550,22 @@ func ToEntry(n Node) (e *Entry) {
 		}
 	}
 	ms := RootNode(n).Modules
-	if e := ms.entryCache[n]; e != nil {
+	if e := ms.cachedEntry(n); e != nil {
 		return e
 	}
 	defer func() {
-		ms.entryCache[n] = e
+		ms.cacheEntry(n, e)
 	}()
 
 	// Copy in the extensions from our Node, if any.
 	defer func(n Node) {
 		if e != nil {
 			e.Exts = append(e.Exts, n.Exts()...)
+			// The extensions of a leaf-list were handled by the
+			// leaf it was converted to.
+			if _, ok := n.(*LeafList); !ok {
+				e.applyExtensions(n)
+			}
 		}
 	}(n)
 
@@ -561,7 +581,7 @@ func ToEntry(n Node) (e *Entry) {
 			case "false":
 				return TSFalse, nil
 			default:
-				return TSUnset, fmt.Errorf("%s: invalid config value: %s", Source(n), v.Name)
+				return TSUnset, diagnosticf(n, CodeInvalidValue, "invalid config value: %s", v.Name)
 			}
 		}
 		return TSUnset, nil
@@ -588,6 +608,18 @@ func ToEntry(n Node) (e *Entry) {
 		addExtraKeywordsToLeafEntry(n, e)
 		e.Mandatory, err = tristateValue(s.Mandatory)
 		e.addError(err)
+		e.Status, err = parseStatus(s.Status)
+		e.addError(err)
+		if y := s.Type.YangType; y != nil {
+			if y.Base != nil {
+				if td, ok := y.Base.ParentNode().(*Typedef); ok {
+					e.addError(checkStatusReference(n, td))
+				}
+			}
+			for _, b := range y.IdentityBases {
+				e.addError(checkStatusReference(n, b))
+			}
+		}
 		return e
 	case *LeafList:
 		// Create the equivalent leaf element that we are a list of.
@@ -632,18 +664,32 @@ func ToEntry(n Node) (e *Entry) {
 				return nil
 			}
 
-			return newError(n, "unknown group: %s", s.Name)
+			return newError(n, CodeUnresolved, "unknown group: %s", s.Name)
 		}
 		// We need to return a duplicate so we resolve properly
 		// when the group is used in multiple locations and the
 		// grouping has a leafref that references outside the group.
 		e = ToEntry(g).dup()
 		addExtraKeywordsToLeafEntry(n, e)
+		e.addError(checkStatusReference(n, g))
+		// The nodes of the grouping take on the status of the uses.
+		st, err := parseStatus(s.Status)
+		e.addError(err)
+		e.raiseStatus(st)
 		return e
 	}
 
 	e = newDirectory(n)
 
+	// enabled reports whether the child node c is supported by the feature
+	// set in the parse options.  Errors evaluating the if-feature statements
+	// of c are recorded on e and c is treated as not supported.
+	enabled := func(c Node) bool {
+		ok, err := ms.IsEnabled(c)
+		e.addError(err)
+		return ok
+	}
+
 	// Special handling for individual Node types.  Lists are like any other
 	// node except a List has a ListAttr.
 	//
@@ -678,6 +724,8 @@ func ToEntry(n Node) (e *Entry) {
 		e.Kind = NotificationEntry
 	case *Deviate:
 		e.Kind = DeviateEntry
+	case *Structure:
+		e.Kind = StructureEntry
 	}
 
 	// Use Elem to get the Value of structure that n is pointing to.
@@ -695,7 +743,7 @@ func ToEntry(n Node) (e *Entry) {
 		name := strings.Split(yang, ",")[0]
 		switch name {
 		case "":
-			e.addError(fmt.Errorf("%s: nil statement", Source(n)))
+			e.addError(diagnosticf(n, CodeOther, "nil statement"))
 		case "config":
 			e.Config, err = tristateValue(fv.Interface())
 			e.addError(err)
@@ -709,32 +757,53 @@ func ToEntry(n Node) (e *Entry) {
 			}
 		case "action":
 			for _, r := range fv.Interface().([]*Action) {
+				if !enabled(r) {
+					continue
+				}
 				e.add(r.Name, ToEntry(r))
 			}
 		case "augment":
 			for _, a := range fv.Interface().([]*Augment) {
+				if !enabled(a) {
+					continue
+				}
 				ne := ToEntry(a)
 				ne.Parent = e
 				e.Augments = append(e.Augments, ne)
 			}
 		case "anydata":
 			for _, a := range fv.Interface().([]*AnyData) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "anyxml":
 			for _, a := range fv.Interface().([]*AnyXML) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "case":
 			for _, a := range fv.Interface().([]*Case) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "choice":
 			for _, a := range fv.Interface().([]*Choice) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "container":
 			for _, a := range fv.Interface().([]*Container) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "grouping":
@@ -764,14 +833,14 @@ func ToEntry(n Node) (e *Entry) {
 				includedToSrc := n.NName() + ":" + a.Module.Name
 
 				switch {
-				case ms.mergedSubmodule[srcToIncluded]:
+				case ms.merged(srcToIncluded):
 					// We have already merged this module, so don't try and do it
 					// again.
 					continue
-				case !ms.mergedSubmodule[includedToSrc] && a.Module.NName() != n.NName():
+				case !ms.merged(includedToSrc) && a.Module.NName() != n.NName():
 					// We have not merged A->B, and B != B hence go ahead and merge.
 					includedToParent := a.Module.Name + ":" + a.Module.BelongsTo.Name
-					if ms.mergedSubmodule[includedToParent] {
+					if ms.merged(includedToParent) {
 						// Don't try and re-import submodules that have already been imported
 						// into the top-level module. Note that this ensures that we get to the
 						// top the tree (whichever the actual module for the chain of
@@ -780,25 +849,37 @@ func ToEntry(n Node) (e *Entry) {
 						// walking through a sub-cycle of the include graph.
 						continue
 					}
-					ms.mergedSubmodule[srcToIncluded] = true
-					ms.mergedSubmodule[includedToParent] = true
-					e.merge(a.Module.Prefix, nil, ToEntry(a.Module))
+					ms.setMerged(srcToIncluded, includedToParent)
+					sub := ToEntry(a.Module)
+					e.merge(a.Module.Prefix, nil, sub)
+					for _, se := range sortedEntries(sub.structures) {
+						e.addStructure(se.dup())
+					}
 				case ms.ParseOptions.IgnoreSubmoduleCircularDependencies:
 					continue
 				default:
-					e.addError(fmt.Errorf("%s: has a circular dependency, importing %s", n.NName(), a.Module.NName()))
+					e.addError(diagnosticf(a, CodeCircular, "%s: has a circular dependency, importing %s", n.NName(), a.Module.NName()))
 				}
 			}
 		case "leaf":
 			for _, a := range fv.Interface().([]*Leaf) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "leaf-list":
 			for _, a := range fv.Interface().([]*LeafList) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "list":
 			for _, a := range fv.Interface().([]*List) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "key":
@@ -807,6 +888,9 @@ func ToEntry(n Node) (e *Entry) {
 			}
 		case "notification":
 			for _, a := range fv.Interface().([]*Notification) {
+				if !enabled(a) {
+					continue
+				}
 				e.add(a.Name, ToEntry(a))
 			}
 		case "rpc":
@@ -814,6 +898,9 @@ func ToEntry(n Node) (e *Entry) {
 			// seems fine to ignore them for now, we are
 			// just interested in the tree structure.
 			for _, r := range fv.Interface().([]*RPC) {
+				if !enabled(r) {
+					continue
+				}
 				switch rpc := ToEntry(r); {
 				case rpc.RPC == nil:
 					// When "rpc" has no "input" or "output" children
@@ -847,11 +934,16 @@ func ToEntry(n Node) (e *Entry) {
 				e.RPC.Output.Kind = OutputEntry
 			}
 		case "identity":
-			if i := fv.Interface().([]*Identity); i != nil {
-				e.Identities = i
+			for _, i := range fv.Interface().([]*Identity) {
+				if enabled(i) {
+					e.Identities = append(e.Identities, i)
+				}
 			}
 		case "uses":
 			for _, a := range fv.Interface().([]*Uses) {
+				if !enabled(a) {
+					continue
+				}
 				grouping := ToEntry(a)
 				if grouping != nil {
 					e.merge(nil, nil, grouping)
@@ -888,7 +980,7 @@ func ToEntry(n Node) (e *Entry) {
 				// TODO(wenovus): support refine statement's default substatement.
 				d, ok := fv.Interface().(*Value)
 				if !ok {
-					e.addError(fmt.Errorf("%s: unexpected default type in %s:%s", Source(n), n.Kind(), n.NName()))
+					e.addError(diagnosticf(n, CodeInvalidValue, "unexpected default type in %s:%s", n.Kind(), n.NName()))
 				}
 				// TODO(wenovus): deviate statement and refine statement should
 				// allow multiple default substatements for leaf-list types (YANG1.1).
@@ -922,7 +1014,7 @@ func ToEntry(n Node) (e *Entry) {
 
 					dt, ok := toDeviation[d.Statement().Argument]
 					if !ok {
-						e.addError(fmt.Errorf("%s: unknown deviation type in %s:%s", Source(n), n.Kind(), n.NName()))
+						e.addError(diagnosticf(n, CodeDeviation, "unknown deviation type in %s:%s", n.Kind(), n.NName()))
 						continue
 					}
 
@@ -936,7 +1028,7 @@ func ToEntry(n Node) (e *Entry) {
 		case "mandatory":
 			v, ok := fv.Interface().(*Value)
 			if !ok {
-				e.addError(fmt.Errorf("%s: did not get expected value type", Source(n)))
+				e.addError(diagnosticf(n, CodeInvalidValue, "did not get expected value type"))
 			}
 			e.Mandatory, err = tristateValue(v)
 			e.addError(err)
@@ -948,7 +1040,7 @@ func ToEntry(n Node) (e *Entry) {
 			// corresponding logic.
 			v, ok := fv.Interface().(*Value)
 			if !ok {
-				e.addError(fmt.Errorf("%s: max or min elements had wrong type, %s:%s", Source(n), n.Kind(), n.NName()))
+				e.addError(diagnosticf(n, CodeInvalidValue, "max or min elements had wrong type, %s:%s", n.Kind(), n.NName()))
 				continue
 			}
 
@@ -974,7 +1066,7 @@ func ToEntry(n Node) (e *Entry) {
 		case "units":
 			v, ok := fv.Interface().(*Value)
 			if !ok {
-				e.addError(fmt.Errorf("%s: units had wrong type, %s:%s", Source(n), n.Kind(), n.NName()))
+				e.addError(diagnosticf(n, CodeInvalidValue, "units had wrong type, %s:%s", n.Kind(), n.NName()))
 			}
 			if v != nil {
 				e.Units = v.asString()
@@ -992,7 +1084,6 @@ func ToEntry(n Node) (e *Entry) {
 			"presence",
 			"reference",
 			"revision",
-			"status",
 			"unique",
 			"when",
 			"yang-version":
@@ -1001,11 +1092,18 @@ func ToEntry(n Node) (e *Entry) {
 			}
 			continue
 
+		case "status":
+			if v := fv.Interface().(*Value); v != nil {
+				e.Status, err = parseStatus(v)
+				e.addError(err)
+				addToExtrasSlice(fv, name, e)
+			}
+			continue
 		case "Ext", "Name", "Parent", "Statement":
 			// These are meta-keywords used internally
 			continue
 		default:
-			e.addError(fmt.Errorf("%s: unexpected statement: %s", Source(n), name))
+			e.addError(diagnosticf(n, CodeUnknownStatement, "unexpected statement: %s", name))
 			continue
 
 		}
@@ -1013,12 +1111,32 @@ func ToEntry(n Node) (e *Entry) {
 		found = true
 	}
 	if !found {
-		return newError(n, "%T: cannot be converted to a *Entry", n)
+		return newError(n, CodeOther, "%T: cannot be converted to a *Entry", n)
+	}
+	if m, ok := n.(*Module); ok {
+		for _, s := range m.Structure {
+			se := ToEntry(s)
+			e.importErrors(se)
+			e.addStructure(se)
+		}
+		for _, a := range m.AugmentStructure {
+			ne := ToEntry(a)
+			ne.Parent = e
+			e.Augments = append(e.Augments, ne)
+		}
 	}
 	// If prefix isn't set, provide it based on our root node (module)
 	if e.Prefix == nil {
 		e.Prefix = getRootPrefix(e)
 	}
+	// Nodes are at least as deprecated as the nodes they are within.
+	for _, c := range e.Dir {
+		c.raiseStatus(e.Status)
+	}
+	if e.RPC != nil {
+		e.RPC.Input.raiseStatus(e.Status)
+		e.RPC.Output.raiseStatus(e.Status)
+	}
 
 	return e
 }
@@ -1081,10 +1199,15 @@ func (e *Entry) Augment(addErrors bool) (processed, skipped int) {
 	// progress)
 	var unapplied []*Entry
 	for _, a := range e.Augments {
-		target := a.Find(a.Name)
+		var target *Entry
+		if _, ok := a.Node.(*AugmentStructure); ok {
+			target = a.findStructure(a.Name)
+		} else {
+			target = a.Find(a.Name)
+		}
 		if target == nil {
 			if !RootNode(e.Node).Modules.ParseOptions.IgnoreModuleResolveErrors && addErrors {
-				e.errorf("%s: augment %s not found", Source(a.Node), a.Name)
+				e.addError(diagnosticf(a.Node, CodeUnresolved, "%s %s not found", a.Node.Kind(), a.Name))
 			}
 			skipped++
 			unapplied = append(unapplied, a)
@@ -1105,14 +1228,23 @@ func (e *Entry) Augment(addErrors bool) (processed, skipped int) {
 // ApplyDeviate walks the deviations within the supplied entry, and applies them to the
 // schema.
 func (e *Entry) ApplyDeviate() []error {
+	return e.applyDeviate(e.Deviations, e.Find)
+}
+
+// applyDeviate applies the deviations devs of the module e to the nodes
+// found by find, which is given the path of each deviation.
+func (e *Entry) applyDeviate(devs []*DeviatedEntry, find func(string) *Entry) []error {
 	var errs []error
 	appendErr := func(err error) { errs = append(errs, err) }
-	for _, d := range e.Deviations {
-		deviatedNode := e.Find(d.DeviatedPath)
+	for _, d := range devs {
+		deviatedNode := find(d.DeviatedPath)
 		if deviatedNode == nil {
-			appendErr(fmt.Errorf("cannot find target node to deviate, %s", d.DeviatedPath))
+			appendErr(diagnosticf(d.Node, CodeDeviation, "cannot find target node to deviate, %s", d.DeviatedPath))
 			continue
 		}
+		if err := checkStatusReference(d.Node, deviatedNode.Node); err != nil {
+			appendErr(err)
+		}
 
 		for dt, dv := range d.Deviate {
 			for _, devSpec := range dv {
@@ -1129,9 +1261,9 @@ func (e *Entry) ApplyDeviate() []error {
 							case deviatedNode.IsLeafList():
 								deviatedNode.Default = append(deviatedNode.Default, devSpec.Default...)
 							case len(devSpec.Default) > 1:
-								appendErr(fmt.Errorf("%s: tried to add more than one default to a non-leaflist entry at deviation", Source(e.Node)))
+								appendErr(diagnosticf(d.Node, CodeDeviation, "tried to add more than one default to a non-leaflist entry at deviation"))
 							case len(deviatedNode.Default) != 0:
-								appendErr(fmt.Errorf("%s: tried to add a default value to an entry that already has a default value", Source(e.Node)))
+								appendErr(diagnosticf(d.Node, CodeDeviation, "tried to add a default value to an entry that already has a default value"))
 							case len(devSpec.Default) == 1 && len(deviatedNode.Default) == 0:
 								deviatedNode.Default = append([]string{}, devSpec.Default[0])
 							}
@@ -1146,7 +1278,7 @@ func (e *Entry) ApplyDeviate() []error {
 
 					if devSpec.deviatePresence.hasMinElements {
 						if !deviatedNode.IsList() && !deviatedNode.IsLeafList() {
-							appendErr(fmt.Errorf("tried to deviate min-elements on a non-list type %s", deviatedNode.Kind))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "tried to deviate min-elements on a non-list type %s", deviatedNode.Kind))
 							continue
 						}
 						deviatedNode.ListAttr.MinElements = devSpec.ListAttr.MinElements
@@ -1154,7 +1286,7 @@ func (e *Entry) ApplyDeviate() []error {
 
 					if devSpec.deviatePresence.hasMaxElements {
 						if !deviatedNode.IsList() && !deviatedNode.IsLeafList() {
-							appendErr(fmt.Errorf("tried to deviate max-elements on a non-list type %s", deviatedNode.Kind))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "tried to deviate max-elements on a non-list type %s", deviatedNode.Kind))
 							continue
 						}
 						deviatedNode.ListAttr.MaxElements = devSpec.ListAttr.MaxElements
@@ -1171,7 +1303,7 @@ func (e *Entry) ApplyDeviate() []error {
 				case DeviationNotSupported:
 					dp := deviatedNode.Parent
 					if dp == nil {
-						appendErr(fmt.Errorf("%s: node %s does not have a valid parent, but deviate not-supported references one", Source(e.Node), e.Name))
+						appendErr(diagnosticf(d.Node, CodeDeviation, "node %s does not have a valid parent, but deviate not-supported references one", e.Name))
 						continue
 					}
 					dp.delete(deviatedNode.Name)
@@ -1186,11 +1318,11 @@ func (e *Entry) ApplyDeviate() []error {
 							// It is unclear from RFC7950 on how deviate delete works
 							// when there are duplicate leaf-list values in config-false leafs.
 							// TODO(wenbli): Add support for deleting default values when the leaf-list is a config leaf (duplicates are not allowed).
-							appendErr(fmt.Errorf("%s: deviate delete on default statements unsupported for leaf-lists, please use replace instead", Source(e.Node)))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "deviate delete on default statements unsupported for leaf-lists, please use replace instead"))
 						case len(deviatedNode.Default) == 0:
-							appendErr(fmt.Errorf("%s: tried to deviate delete a default statement that doesn't exist", Source(e.Node)))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "tried to deviate delete a default statement that doesn't exist"))
 						case devSpec.Default[0] != deviatedNode.Default[0]:
-							appendErr(fmt.Errorf("%s: tried to deviate delete a default statement with a non-matching keyword", Source(e.Node)))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "tried to deviate delete a default statement with a non-matching keyword"))
 						default:
 							deviatedNode.Default = nil
 						}
@@ -1202,30 +1334,30 @@ func (e *Entry) ApplyDeviate() []error {
 
 					if devSpec.deviatePresence.hasMinElements {
 						if !deviatedNode.IsList() && !deviatedNode.IsLeafList() {
-							appendErr(fmt.Errorf("tried to deviate min-elements on a non-list type %s", deviatedNode.Kind))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "tried to deviate min-elements on a non-list type %s", deviatedNode.Kind))
 							continue
 						}
 						if deviatedNode.ListAttr.MinElements != devSpec.ListAttr.MinElements {
 							// Argument value must match:
 							// https://tools.ietf.org/html/rfc7950#section-7.20.3.2
-							appendErr(fmt.Errorf("min-element value %d differs from deviation's min-element value %d for entry %v", devSpec.ListAttr.MinElements, deviatedNode.ListAttr.MinElements, d.DeviatedPath))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "min-element value %d differs from deviation's min-element value %d for entry %v", devSpec.ListAttr.MinElements, deviatedNode.ListAttr.MinElements, d.DeviatedPath))
 						}
 						deviatedNode.ListAttr.MinElements = 0
 					}
 
 					if devSpec.deviatePresence.hasMaxElements {
 						if !deviatedNode.IsList() && !deviatedNode.IsLeafList() {
-							appendErr(fmt.Errorf("tried to deviate max-elements on a non-list type %s", deviatedNode.Kind))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "tried to deviate max-elements on a non-list type %s", deviatedNode.Kind))
 							continue
 						}
 						if deviatedNode.ListAttr.MaxElements != devSpec.ListAttr.MaxElements {
-							appendErr(fmt.Errorf("max-element value %d differs from deviation's max-element value %d for entry %v", devSpec.ListAttr.MaxElements, deviatedNode.ListAttr.MaxElements, d.DeviatedPath))
+							appendErr(diagnosticf(d.Node, CodeDeviation, "max-element value %d differs from deviation's max-element value %d for entry %v", devSpec.ListAttr.MaxElements, deviatedNode.ListAttr.MaxElements, d.DeviatedPath))
 						}
 						deviatedNode.ListAttr.MaxElements = math.MaxUint64
 					}
 
 				default:
-					appendErr(fmt.Errorf("invalid deviation type %s", dt))
+					appendErr(diagnosticf(d.Node, CodeDeviation, "invalid deviation type %s", dt))
 				}
 			}
 		}
@@ -1294,10 +1426,14 @@ func (e *Entry) Find(name string) *Entry {
 	if parts[0] == "" {
 		parts = parts[1:]
 		contextNode := e.Node
-		for e.Parent != nil {
+		// The root of a mounted schema is its mount point, the root of
+		// a structure is the structure.
+		for e.Parent != nil && e.schema == nil && e.Kind != StructureEntry {
 			e = e.Parent
 		}
-		if prefix, _ := getPrefix(parts[0]); prefix != "" {
+		if e.schema != nil {
+			e = e.Parent
+		} else if prefix, _ := getPrefix(parts[0]); prefix != "" && e.Kind != StructureEntry {
 			mod := FindModuleByPrefix(contextNode, prefix)
 			if mod == nil {
 				if RootNode(e.Node).Modules.ParseOptions.IgnoreModuleResolveErrors {
@@ -1448,6 +1584,23 @@ func (e *Entry) dup() *Entry {
 			ne.Dir[k] = de
 		}
 	}
+	if e.ExtensionValues != nil {
+		ne.ExtensionValues = make(map[string][]interface{}, len(e.ExtensionValues))
+		for k, v := range e.ExtensionValues {
+			ne.ExtensionValues[k] = append([]interface{}(nil), v...)
+		}
+	}
+	if e.RPC != nil {
+		ne.RPC = &RPCEntry{}
+		if e.RPC.Input != nil {
+			ne.RPC.Input = e.RPC.Input.dup()
+			ne.RPC.Input.Parent = &ne
+		}
+		if e.RPC.Output != nil {
+			ne.RPC.Output = e.RPC.Output.dup()
+			ne.RPC.Output.Parent = &ne
+		}
+	}
 	return &ne
 }
 
@@ -1465,10 +1618,10 @@ func (e *Entry) merge(prefix *Value, namespace *Value, oe *Entry) {
 			v.namespace = namespace
 		}
 		if se := e.Dir[k]; se != nil {
-			er := newError(oe.Node, `Duplicate node %q in %q from:
+			d := diagnosticf(oe.Node, CodeDuplicate, `Duplicate node %q in %q from:
    %s: %s
    %s: %s`, k, e.Name, Source(v.Node), v.Name, Source(se.Node), se.Name)
-			e.addError(er.Errors[0])
+			e.addError(d.related(v.Node, "definition of "+v.Name).related(se.Node, "definition of "+se.Name))
 		} else {
 			v.Parent = e
 			v.Exts = append(v.Exts, oe.Exts...)
@@ -1477,78 +1630,8 @@ func (e *Entry) merge(prefix *Value, namespace *Value, oe *Entry) {
 	}
 }
 
-// nless returns -1 if a is less than b, 0 if a == b, and 1 if a > b.
-// If a and b are both numeric, then nless compares them as numbers,
-// otherwise they are compared lexicographically.
-func nless(a, b string) int {
-	an, ae := strconv.Atoi(a)
-	bn, be := strconv.Atoi(b)
-	switch {
-	case ae == nil && be == nil:
-		switch {
-		case an < bn:
-			return -1
-		case an > bn:
-			return 1
-		default:
-			return 0
-		}
-	case a < b:
-		return -1
-	case a > b:
-		return 1
-	default:
-		return 0
-	}
-}
-
-type sError struct {
-	s   string
-	err error
-}
-
-type sortedErrors []sError
-
-func (s sortedErrors) Len() int      { return len(s) }
-func (s sortedErrors) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
-func (s sortedErrors) Less(i, j int) bool {
-	// We expect the error strings to be composed of error messages,
-	// line numbers, etc. delimited by ":".
-	const errorSplitCount = 4
-	fi := strings.SplitN(s[i].s, ":", errorSplitCount)
-	fj := strings.SplitN(s[j].s, ":", errorSplitCount)
-	// First, order the errors by the file name.
-	if fi[0] < fj[0] {
-		return true
-	}
-	if fi[0] > fj[0] {
-		return false
-	}
-
-	// compare remaining indices of the error string slices
-	// in order to create a total ordering.
-	for i := 1; i < errorSplitCount; i++ {
-		switch {
-		// Handle when an expected index doesn't exist.
-		case len(fj) == i:
-			return false
-		case len(fi) == i:
-			return true
-		}
-
-		switch nless(fi[i], fj[i]) {
-		case -1:
-			return true
-		case 1:
-			return false
-		}
-	}
-	return false
-}
-
-// errorSort sorts the strings in the errors slice assuming each line starts
-// with file:line:col.  Line and column number are sorted numerically.
-// Duplicate errors are stripped.
+// errorSort sorts errors by their location, as found by DiagnosticOf, and
+// then by their message.  Duplicate errors are stripped.
 func errorSort(errors []error) []error {
 	switch len(errors) {
 	case 0:
@@ -1556,23 +1639,37 @@ func errorSort(errors []error) []error {
 	case 1:
 		return errors
 	}
-	elist := make(sortedErrors, len(errors))
+	ds := make([]*Diagnostic, len(errors))
 	for x, err := range errors {
-		elist[x] = sError{err.Error(), err}
+		ds[x] = DiagnosticOf(err)
 	}
-	sort.Sort(elist)
+	sorted := append([]error(nil), errors...)
+	sort.Sort(byDiagnostic{sorted, ds})
 	errors = make([]error, len(errors))
 	i := 0
-	for _, err := range elist {
-		if i > 0 && reflect.DeepEqual(err.err, errors[i-1]) {
+	for _, err := range sorted {
+		if i > 0 && reflect.DeepEqual(err, errors[i-1]) {
 			continue
 		}
-		errors[i] = err.err
+		errors[i] = err
 		i++
 	}
 	return errors[:i]
 }
 
+// byDiagnostic sorts errs by their diagnostics, ds.
+type byDiagnostic struct {
+	errs []error
+	ds   []*Diagnostic
+}
+
+func (s byDiagnostic) Len() int { return len(s.errs) }
+func (s byDiagnostic) Swap(i, j int) {
+	s.errs[i], s.errs[j] = s.errs[j], s.errs[i]
+	s.ds[i], s.ds[j] = s.ds[j], s.ds[i]
+}
+func (s byDiagnostic) Less(i, j int) bool { return lessDiagnostic(s.ds[i], s.ds[j]) }
+
 // SingleDefaultValue returns the single schema default value for e and a bool
 // indicating whether the entry contains one and only one default value. The
 // empty string is returned when the entry has zero or multiple default values.
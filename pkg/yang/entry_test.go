@@ -3825,16 +3825,22 @@ func TestLeafEntry(t *testing.T) {
 }
 
 func TestLess(t *testing.T) {
-	sErrors := sortedErrors{
-		{"testfile0", errors.New("test error0")},
-		{"testfile1", errors.New("test error1")},
-		{"testfile1:1", errors.New("test error2")},
-		{"testfile2:1", errors.New("test error3")},
-		{"testfile2:1:1", errors.New("test error4")},
-		{"testfile3:1:1:error5", errors.New("test error5")},
-		{"testfile3:1:2:error6", errors.New("test error6")},
-		{"testfile3:1:1:error7", errors.New("test error7")},
-	}
+	errs := []error{
+		errors.New("test error0"),
+		errors.New("test error1"),
+		errors.New("line 1:1: test error2"),
+		errors.New("testfile1:1:1: test error3"),
+		errors.New("testfile1:1:2: test error4"),
+		errors.New("testfile1:2:1: test error5"),
+		errors.New("testfile1:10:1: test error6"),
+		errors.New("testfile2:1:1: test error7"),
+		&Diagnostic{Location: Location{File: "testfile1", Line: 1, Col: 1}, Code: CodeSyntax, Message: "test error8"},
+	}
+	ds := make([]*Diagnostic, len(errs))
+	for i, err := range errs {
+		ds[i] = DiagnosticOf(err)
+	}
+	sErrors := byDiagnostic{errs, ds}
 
 	tests := []struct {
 		desc string
@@ -3842,80 +3848,85 @@ func TestLess(t *testing.T) {
 		j    int
 		want bool
 	}{{
-		desc: "compare two different strings without seperator ':'",
+		desc: "compare two different errors without a location",
 		i:    0,
 		j:    1,
 		want: true,
 	}, {
-		desc: "compare two different strings without seperator ':'",
+		desc: "compare two different errors without a location",
 		i:    1,
 		j:    0,
 		want: false,
 	}, {
-		desc: "compare one slice in a string with two slices in another string",
+		desc: "compare an error without a location with one with a line",
 		i:    1,
 		j:    2,
 		want: true,
 	}, {
-		desc: "compare two different strings with two slices each",
+		desc: "compare an error without a file with one with a file",
 		i:    2,
 		j:    3,
 		want: true,
 	}, {
-		desc: "compare two different strings with two slices each",
+		desc: "compare an error with a file with one without a file",
 		i:    3,
 		j:    2,
 		want: false,
 	}, {
-		desc: "compare two slices in a string with three slices in another string",
+		desc: "compare two errors on the same line",
 		i:    3,
 		j:    4,
 		want: true,
 	}, {
-		desc: "compare three slices in a string with two slices in another string",
+		desc: "compare two errors on the same line",
 		i:    4,
 		j:    3,
 		want: false,
 	}, {
-		desc: "compare two different strings with four slices each",
+		desc: "compare two errors on different lines",
+		i:    4,
+		j:    5,
+		want: true,
+	}, {
+		desc: "compare line numbers numerically",
 		i:    5,
 		j:    6,
 		want: true,
 	}, {
-		desc: "compare two different strings with four slices each",
+		desc: "compare line numbers numerically",
 		i:    6,
 		j:    5,
 		want: false,
 	}, {
-		desc: "compare two identical strings without separator ':'",
-		i:    1,
-		j:    1,
-		want: false,
+		desc: "compare two errors in different files",
+		i:    6,
+		j:    7,
+		want: true,
 	}, {
-		desc: "compare two identical strings with two slices",
-		i:    2,
-		j:    2,
+		desc: "compare two errors in different files",
+		i:    7,
+		j:    6,
 		want: false,
 	}, {
-		desc: "compare two identical strings with three slices",
-		i:    4,
-		j:    4,
-		want: false,
+		desc: "compare two errors at the same location",
+		i:    3,
+		j:    8,
+		want: true,
 	}, {
-		desc: "compare two identical strings with four slices",
-		i:    5,
-		j:    5,
+		desc: "compare two errors at the same location",
+		i:    8,
+		j:    3,
 		want: false,
 	}, {
-		desc: "compare different strings with four slices",
-		i:    7,
-		j:    5,
+		desc: "compare two identical errors without a location",
+		i:    1,
+		j:    1,
 		want: false,
 	}, {
-		desc: "compare different strings with four slices",
+		desc: "compare two identical errors with a location",
 		i:    5,
-		j:    7,
-		want: true,
+		j:    5,
+		want: false,
 	}}
 	var cmpSymbol byte
 	for _, tt := range tests {
@@ -3926,7 +3937,34 @@ func TestLess(t *testing.T) {
 			} else {
 				cmpSymbol = '>'
 			}
-			t.Errorf("%s: incorrect less comparison: \"%s\" %c \"%s\"", tt.desc, sErrors[tt.i].s, cmpSymbol, sErrors[tt.j].s)
+			t.Errorf("%s: incorrect less comparison: \"%s\" %c \"%s\"", tt.desc, errs[tt.i], cmpSymbol, errs[tt.j])
 		}
 	}
 }
+
+func TestErrorSort(t *testing.T) {
+	errs := []error{
+		errors.New("b.yang:10:2: error1"),
+		errors.New("b.yang:2:5: error2"),
+		errors.New("a.yang:3:1: error3"),
+		&Diagnostic{Location: Location{File: "a.yang", Line: 3, Col: 1}, Code: CodeSyntax, Message: "error4"},
+		errors.New("line 1:2: error5"),
+		errors.New("error6"),
+		errors.New("b.yang:2:5: error2"),
+	}
+	var got []string
+	for _, err := range errorSort(errs) {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"error6",
+		"line 1:2: error5",
+		"a.yang:3:1: error3",
+		"a.yang:3:1: error4",
+		"b.yang:2:5: error2",
+		"b.yang:10:2: error1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("errorSort (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the registry of extension handlers.  Uses of an
+// extension are kept as Statements in the Exts of a node and its Entry.  A
+// handler registered for the extension additionally turns each use into a
+// Go value stored in the ExtensionValues of the Entry, so that generators
+// can be driven by annotations in the model without parsing them again.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// An ExtensionHandler handles the uses of the extension Name defined in the
+// module Module.
+type ExtensionHandler struct {
+	Module string // name of the module that defines the extension
+	Name   string // name of the extension
+
+	// Parse returns the value of s, a use of the extension defined by
+	// ext.  Before Parse is called s has been checked to have an argument
+	// if, and only if, ext has an argument statement.  A nil Parse stores
+	// the argument of s as a string.
+	Parse func(ext *Extension, s *Statement) (interface{}, error)
+
+	// Inherit is set if the extension used in a grouping, uses or
+	// augment statement applies to the nodes that statement adds to the
+	// tree.  The value is then also stored on each of those nodes.
+	Inherit bool
+}
+
+// key returns the key of the values of h in Entry.ExtensionValues.
+func (h *ExtensionHandler) key() string {
+	return h.Module + ":" + h.Name
+}
+
+var extensionHandlers struct {
+	mu sync.RWMutex
+	m  map[string]*ExtensionHandler
+}
+
+// RegisterExtension registers h as the handler of its extension.  It
+// panics if a handler for the same extension is already registered.
+// Handlers must be registered before the modules using the extension are
+// processed.
+func RegisterExtension(h *ExtensionHandler) {
+	extensionHandlers.mu.Lock()
+	defer extensionHandlers.mu.Unlock()
+	if extensionHandlers.m == nil {
+		extensionHandlers.m = map[string]*ExtensionHandler{}
+	}
+	if extensionHandlers.m[h.key()] != nil {
+		panic(fmt.Sprintf("yang: extension %s registered twice", h.key()))
+	}
+	extensionHandlers.m[h.key()] = h
+}
+
+// ExtensionHandlers returns the registered extension handlers sorted by
+// module and extension name.
+func ExtensionHandlers() []*ExtensionHandler {
+	extensionHandlers.mu.RLock()
+	defer extensionHandlers.mu.RUnlock()
+	hs := make([]*ExtensionHandler, 0, len(extensionHandlers.m))
+	for _, h := range extensionHandlers.m {
+		hs = append(hs, h)
+	}
+	sort.Slice(hs, func(i, j int) bool { return hs[i].key() < hs[j].key() })
+	return hs
+}
+
+// extensionHandler returns the handler of extension module:name, or nil.
+func extensionHandler(module, name string) *ExtensionHandler {
+	extensionHandlers.mu.RLock()
+	defer extensionHandlers.mu.RUnlock()
+	return extensionHandlers.m[module+":"+name]
+}
+
+// findExtension returns the definition of the extension name in m or the
+// submodules it includes, or nil.
+func findExtension(m *Module, name string) *Extension {
+	for _, e := range m.Extension {
+		if e.Name == name {
+			return e
+		}
+	}
+	for _, i := range m.Include {
+		if i.Module == nil {
+			continue
+		}
+		if e := findExtension(i.Module, name); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// parseExtension returns the handler of s, a use of an extension in n, and
+// the value it parsed s into.  A nil handler is returned if the extension
+// has no handler.
+func parseExtension(n Node, s *Statement) (*ExtensionHandler, interface{}, error) {
+	names := strings.SplitN(s.Keyword, ":", 2)
+	if len(names) != 2 {
+		return nil, nil, nil
+	}
+	mod := FindModuleByPrefix(n, names[0])
+	if mod == nil {
+		return nil, nil, nil
+	}
+	if mod.Kind() == "submodule" && mod.BelongsTo != nil {
+		if m := mod.Modules.Modules[mod.BelongsTo.Name]; m != nil {
+			mod = m
+		}
+	}
+	h := extensionHandler(mod.Name, names[1])
+	if h == nil {
+		return nil, nil, nil
+	}
+	ext := findExtension(mod, names[1])
+	switch {
+	case ext == nil:
+		return h, nil, diagnosticf(s, CodeUnresolved, "extension %s is not defined in module %s", names[1], mod.Name)
+	case ext.Argument != nil && !s.HasArgument:
+		return h, nil, diagnosticf(s, CodeMissingStatement, "extension %s requires argument %s", s.Keyword, ext.Argument.Name)
+	case ext.Argument == nil && s.HasArgument:
+		return h, nil, diagnosticf(s, CodeInvalidValue, "extension %s takes no argument", s.Keyword)
+	}
+	if h.Parse == nil {
+		return h, s.Argument, nil
+	}
+	v, err := h.Parse(ext, s)
+	if err != nil {
+		if _, ok := err.(*Diagnostic); !ok {
+			err = diagnosticf(s, CodeInvalidValue, "%s: %v", s.Keyword, err)
+		}
+		return h, nil, err
+	}
+	return h, v, nil
+}
+
+// addExtensionValue adds v as a value of the extension key to e.
+func (e *Entry) addExtensionValue(key string, v interface{}) {
+	if e.ExtensionValues == nil {
+		e.ExtensionValues = map[string][]interface{}{}
+	}
+	e.ExtensionValues[key] = append(e.ExtensionValues[key], v)
+}
+
+// applyExtensions stores the values of the uses of extensions in n that
+// have a handler on e, which was derived from n.  Errors are added to e.
+func (e *Entry) applyExtensions(n Node) {
+	for _, s := range n.Exts() {
+		h, v, err := parseExtension(n, s)
+		if h == nil {
+			continue
+		}
+		if err != nil {
+			e.addError(err)
+			continue
+		}
+		e.addExtensionValue(h.key(), v)
+		if !h.Inherit {
+			continue
+		}
+		switch n.(type) {
+		case *Grouping, *Uses, *Augment:
+			for _, c := range e.Dir {
+				c.addExtensionValue(h.key(), v)
+			}
+		}
+	}
+}
+
+// ExtensionValue returns the values of the uses of the extension name,
+// defined in module, on e, as parsed by its registered handler.
+func (e *Entry) ExtensionValue(module, name string) []interface{} {
+	return e.ExtensionValues[module+":"+name]
+}
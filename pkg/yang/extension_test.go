@@ -0,0 +1,185 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func init() {
+	RegisterExtension(&ExtensionHandler{
+		Module: "ext-test",
+		Name:   "weight",
+		Parse: func(_ *Extension, s *Statement) (interface{}, error) {
+			return strconv.Atoi(s.Argument)
+		},
+	})
+	RegisterExtension(&ExtensionHandler{
+		Module:  "ext-test",
+		Name:    "telemetry",
+		Inherit: true,
+	})
+	RegisterExtension(&ExtensionHandler{
+		Module: "ext-test",
+		Name:   "flag",
+		Parse: func(_ *Extension, s *Statement) (interface{}, error) {
+			return true, nil
+		},
+	})
+	RegisterExtension(&ExtensionHandler{
+		Module: "ext-test",
+		Name:   "undefined",
+	})
+}
+
+const extTestModule = `module ext-test {
+  namespace "urn:ext-test";
+  prefix x;
+
+  extension weight { argument value; }
+  extension telemetry { argument mode; }
+  extension flag;
+  extension unhandled;
+`
+
+func TestRegisterExtension(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterExtension of a registered extension did not panic")
+		}
+	}()
+	RegisterExtension(&ExtensionHandler{Module: "ext-test", Name: "weight"})
+}
+
+func TestExtensionValues(t *testing.T) {
+	ms := NewModules()
+	in := extTestModule + `
+  grouping g {
+    x:telemetry on-change;
+    leaf a { type string; x:weight 3; }
+  }
+  container c {
+    x:flag;
+    x:unhandled;
+    uses g;
+    uses g2 { x:telemetry sampled; }
+  }
+  grouping g2 {
+    leaf b { type string; }
+  }
+  augment /x:c {
+    x:telemetry periodic;
+    leaf d { type string; }
+  }
+}
+`
+	if err := ms.Parse(in, "ext-test.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	m, _ := ms.GetModule("ext-test")
+	c := m.Dir["c"]
+
+	for _, tt := range []struct {
+		desc string
+		e    *Entry
+		want map[string][]interface{}
+	}{{
+		desc: "no argument",
+		e:    c,
+		want: map[string][]interface{}{"ext-test:flag": {true}},
+	}, {
+		desc: "parsed value and inherited from grouping",
+		e:    c.Dir["a"],
+		want: map[string][]interface{}{
+			"ext-test:weight":    {3},
+			"ext-test:telemetry": {"on-change"},
+		},
+	}, {
+		desc: "inherited from uses",
+		e:    c.Dir["b"],
+		want: map[string][]interface{}{"ext-test:telemetry": {"sampled"}},
+	}, {
+		desc: "inherited from augment",
+		e:    c.Dir["d"],
+		want: map[string][]interface{}{"ext-test:telemetry": {"periodic"}},
+	}} {
+		if tt.e == nil {
+			t.Errorf("%s: entry not found", tt.desc)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, tt.e.ExtensionValues); diff != "" {
+			t.Errorf("%s: ExtensionValues (-want, +got):\n%s", tt.desc, diff)
+		}
+	}
+	if got := c.Dir["a"].ExtensionValue("ext-test", "weight"); len(got) != 1 || got[0] != 3 {
+		t.Errorf("ExtensionValue(ext-test, weight): got %v, want [3]", got)
+	}
+	if len(c.Exts) != 2 {
+		t.Errorf("got %d extension statements on c, want 2", len(c.Exts))
+	}
+}
+
+func TestExtensionErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc:    "missing argument",
+		in:      `leaf l { type string; x:weight; }`,
+		wantErr: "extension x:weight requires argument value",
+	}, {
+		desc:    "unexpected argument",
+		in:      `leaf l { type string; x:flag yes; }`,
+		wantErr: "extension x:flag takes no argument",
+	}, {
+		desc:    "parse error",
+		in:      `leaf l { type string; x:weight heavy; }`,
+		wantErr: `x:weight: strconv.Atoi: parsing "heavy"`,
+	}, {
+		desc:    "handler without definition",
+		in:      `leaf l { type string; x:undefined; }`,
+		wantErr: "extension undefined is not defined in module ext-test",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(extTestModule+tt.in+"\n}\n", "ext-test.yang"); err != nil {
+				t.Fatal(err)
+			}
+			var msgs []string
+			for _, err := range ms.Process() {
+				msgs = append(msgs, err.Error())
+			}
+			var err error
+			if len(msgs) > 0 {
+				err = errors.New(strings.Join(msgs, "\n"))
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
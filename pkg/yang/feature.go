@@ -0,0 +1,388 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the evaluation of if-feature statements as described
+// in https://tools.ietf.org/html/rfc7950#section-7.20.2.  Evaluation is only
+// done when Options.Features is set, otherwise every feature is considered
+// to be supported.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FeatureWildcard may be used either as a module name or as a feature name
+// within a FeatureSet.  As a module name it provides the features for all
+// modules not explicitly listed.  As a feature name it enables all features
+// of the module.
+const FeatureWildcard = "*"
+
+// A FeatureSet maps a module name to the names of the features that are
+// enabled for that module.  Modules that are not present in the set, and are
+// not covered by a FeatureWildcard entry, have all of their features enabled.
+// An empty, non-nil, list of features disables all features of the module.
+type FeatureSet map[string][]string
+
+// ParseFeatureSet parses specs of the form "module:feature[,feature...]" into
+// a FeatureSet.  An element without a ":" names an additional feature of the
+// module named by the preceding element, which allows the output of a comma
+// separated command line flag to be passed in directly.  The spec "module:"
+// disables all features of module.
+func ParseFeatureSet(specs ...string) (FeatureSet, error) {
+	fs := FeatureSet{}
+	mod := ""
+	for _, spec := range specs {
+		for _, s := range strings.Split(spec, ",") {
+			s = strings.TrimSpace(s)
+			if i := strings.Index(s, ":"); i >= 0 {
+				mod = s[:i]
+				s = s[i+1:]
+				if mod == "" {
+					return nil, fmt.Errorf("missing module name in feature spec %q", spec)
+				}
+				if fs[mod] == nil {
+					fs[mod] = []string{}
+				}
+			}
+			if mod == "" {
+				return nil, fmt.Errorf("feature %q has no module, expected module:feature", s)
+			}
+			if s != "" {
+				fs[mod] = append(fs[mod], s)
+			}
+		}
+	}
+	return fs, nil
+}
+
+// Enabled returns true if the feature named feature of the module named
+// module is enabled in fs.  A nil FeatureSet enables all features.
+func (fs FeatureSet) Enabled(module, feature string) bool {
+	if fs == nil {
+		return true
+	}
+	features, ok := fs[module]
+	if !ok {
+		if features, ok = fs[FeatureWildcard]; !ok {
+			return true
+		}
+	}
+	for _, f := range features {
+		if f == feature || f == FeatureWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns fs in the form accepted by ParseFeatureSet, sorted by
+// module name.
+func (fs FeatureSet) String() string {
+	var mods []string
+	for m := range fs {
+		mods = append(mods, m)
+	}
+	sort.Strings(mods)
+	var parts []string
+	for _, m := range mods {
+		parts = append(parts, m+":"+strings.Join(fs[m], ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// An ifFeatureExpr is a compiled if-feature expression.  Leaf expressions
+// have a name and no operands.
+type ifFeatureExpr struct {
+	op   string // "", "not", "and" or "or"
+	name string // prefixed feature name when op is ""
+	args []*ifFeatureExpr
+}
+
+// parseIfFeature compiles the YANG 1.1 if-feature expression s:
+//
+//	if-feature-expr   = if-feature-term [sep or-keyword sep if-feature-expr]
+//	if-feature-term   = if-feature-factor [sep and-keyword sep if-feature-term]
+//	if-feature-factor = not-keyword sep if-feature-factor /
+//	                    "(" optsep if-feature-expr optsep ")" /
+//	                    identifier-ref-arg
+//
+// A YANG 1.0 if-feature argument is a single identifier-ref-arg, which is a
+// valid YANG 1.1 expression.
+func parseIfFeature(s string) (*ifFeatureExpr, error) {
+	p := &ifFeatureParser{tokens: tokenizeIfFeature(s)}
+	x, err := p.expr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid if-feature expression %q: %v", s, err)
+	}
+	if t := p.peek(); t != "" {
+		return nil, fmt.Errorf("invalid if-feature expression %q: unexpected %q", s, t)
+	}
+	return x, nil
+}
+
+// tokenizeIfFeature splits s into parentheses and words.
+func tokenizeIfFeature(s string) []string {
+	var tokens []string
+	start := -1
+	flush := func(i int) {
+		if start >= 0 {
+			tokens = append(tokens, s[start:i])
+			start = -1
+		}
+	}
+	for i, c := range s {
+		switch c {
+		case '(', ')':
+			flush(i)
+			tokens = append(tokens, string(c))
+		case ' ', '\t', '\n', '\r':
+			flush(i)
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(s))
+	return tokens
+}
+
+type ifFeatureParser struct {
+	tokens []string
+}
+
+func (p *ifFeatureParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *ifFeatureParser) next() string {
+	t := p.peek()
+	if t != "" {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+func (p *ifFeatureParser) expr() (*ifFeatureExpr, error) {
+	x, err := p.term()
+	if err != nil || p.peek() != "or" {
+		return x, err
+	}
+	p.next()
+	y, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &ifFeatureExpr{op: "or", args: []*ifFeatureExpr{x, y}}, nil
+}
+
+func (p *ifFeatureParser) term() (*ifFeatureExpr, error) {
+	x, err := p.factor()
+	if err != nil || p.peek() != "and" {
+		return x, err
+	}
+	p.next()
+	y, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	return &ifFeatureExpr{op: "and", args: []*ifFeatureExpr{x, y}}, nil
+}
+
+func (p *ifFeatureParser) factor() (*ifFeatureExpr, error) {
+	switch t := p.next(); t {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "not":
+		x, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return &ifFeatureExpr{op: "not", args: []*ifFeatureExpr{x}}, nil
+	case "(":
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return x, nil
+	case ")", "and", "or":
+		return nil, fmt.Errorf("unexpected %q", t)
+	default:
+		return &ifFeatureExpr{name: t}, nil
+	}
+}
+
+// eval evaluates x, calling lookup to determine if each named feature is
+// enabled.
+func (x *ifFeatureExpr) eval(lookup func(string) (bool, error)) (bool, error) {
+	switch x.op {
+	case "not":
+		v, err := x.args[0].eval(lookup)
+		return !v, err
+	case "and", "or":
+		v, err := x.args[0].eval(lookup)
+		if err != nil {
+			return false, err
+		}
+		// Both operands are evaluated so unknown features are always
+		// reported.
+		w, err := x.args[1].eval(lookup)
+		if err != nil {
+			return false, err
+		}
+		if x.op == "and" {
+			return v && w, nil
+		}
+		return v || w, nil
+	default:
+		return lookup(x.name)
+	}
+}
+
+// ifFeatures returns the if-feature statements of n, if n has any.
+func ifFeatures(n Node) []*Value {
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	f := v.Elem().FieldByName("IfFeature")
+	if !f.IsValid() {
+		return nil
+	}
+	ifs, _ := f.Interface().([]*Value)
+	return ifs
+}
+
+// findFeature returns the feature named name defined in module m or any of
+// the submodules m includes.
+func findFeature(m *Module, name string) *Feature {
+	for _, f := range m.Feature {
+		if f.Name == name {
+			return f
+		}
+	}
+	for _, i := range m.Include {
+		if i.Module == nil {
+			continue
+		}
+		if f := findFeature(i.Module, name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// featureEnabled reports whether the feature referenced by the possibly
+// prefixed name in the context of n is enabled.  A feature is only enabled if
+// it is listed in the FeatureSet and its own if-feature statements are
+// satisfied.  seen is used to detect circular feature references.
+func (ms *Modules) featureEnabled(n Node, name string, seen map[*Feature]bool) (bool, error) {
+	prefix, fname := getPrefix(name)
+	mod := FindModuleByPrefix(n, prefix)
+	if mod == nil {
+		return false, diagnosticf(n, CodeUnresolved, "unknown prefix %q in if-feature %s", prefix, name)
+	}
+	top := mod
+	if mod.Kind() == "submodule" {
+		if top = module(mod); top == nil {
+			top = mod
+		}
+	}
+	f := findFeature(top, fname)
+	if f == nil && top != mod {
+		f = findFeature(mod, fname)
+	}
+	if f == nil {
+		return false, diagnosticf(n, CodeUnresolved, "unknown feature %s in module %s", name, top.Name)
+	}
+	if seen[f] {
+		return false, diagnosticf(f, CodeCircular, "feature %s is circularly dependent on itself", f.Name)
+	}
+	if !ms.ParseOptions.Features.Enabled(top.Name, fname) {
+		return false, nil
+	}
+	seen[f] = true
+	defer delete(seen, f)
+	return ms.ifFeaturesEnabled(f, seen)
+}
+
+// ifFeaturesEnabled evaluates the if-feature statements of n.  It returns
+// true if n has no if-feature statements or all of them evaluate to true.
+func (ms *Modules) ifFeaturesEnabled(n Node, seen map[*Feature]bool) (bool, error) {
+	for _, v := range ifFeatures(n) {
+		x, err := parseIfFeature(v.Name)
+		if err != nil {
+			return false, diagnosticf(v, CodeInvalidValue, "%v", err)
+		}
+		ok, err := x.eval(func(name string) (bool, error) {
+			return ms.featureEnabled(n, name, seen)
+		})
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// IsEnabled reports whether node n is supported given the feature set in
+// ms.ParseOptions.Features, i.e., if all of its if-feature statements
+// evaluate to true.  When no feature set has been provided all nodes are
+// enabled.
+func (ms *Modules) IsEnabled(n Node) (bool, error) {
+	if ms == nil || ms.ParseOptions.Features == nil || n == nil {
+		return true, nil
+	}
+	return ms.ifFeaturesEnabled(n, map[*Feature]bool{})
+}
+
+// EnabledFeatures returns the names of the features defined by module m
+// (and its submodules) that are enabled given the feature set in
+// ms.ParseOptions.Features.
+func (ms *Modules) EnabledFeatures(m *Module) ([]string, error) {
+	var names []string
+	var errs []string
+	var add func(*Module)
+	add = func(m *Module) {
+		for _, f := range m.Feature {
+			ok, err := ms.featureEnabled(f, f.Name, map[*Feature]bool{})
+			switch {
+			case err != nil:
+				errs = append(errs, err.Error())
+			case ok:
+				names = append(names, f.Name)
+			}
+		}
+		for _, i := range m.Include {
+			if i.Module != nil {
+				add(i.Module)
+			}
+		}
+	}
+	add(m)
+	sort.Strings(names)
+	if len(errs) > 0 {
+		return names, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return names, nil
+}
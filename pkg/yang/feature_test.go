@@ -0,0 +1,401 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestParseIfFeature(t *testing.T) {
+	features := map[string]bool{
+		"a":   true,
+		"b":   false,
+		"p:c": true,
+	}
+	lookup := func(name string) (bool, error) {
+		v, ok := features[name]
+		if !ok {
+			return false, fmt.Errorf("unknown feature %s", name)
+		}
+		return v, nil
+	}
+
+	tests := []struct {
+		in      string
+		want    bool
+		wantErr string
+	}{
+		{in: "a", want: true},
+		{in: "b", want: false},
+		{in: "p:c", want: true},
+		{in: "not b", want: true},
+		{in: "not not b", want: false},
+		{in: "a and b", want: false},
+		{in: "a or b", want: true},
+		{in: "b or b or p:c", want: true},
+		{in: "a and b or p:c", want: true},
+		{in: "a and (b or p:c)", want: true},
+		{in: "not (a and p:c)", want: false},
+		{in: "(a)and(not b)", want: true},
+		{in: "a and unknown", wantErr: "unknown feature unknown"},
+		{in: "", wantErr: "unexpected end of expression"},
+		{in: "a and", wantErr: "unexpected end of expression"},
+		{in: "a b", wantErr: `unexpected "b"`},
+		{in: "(a or b", wantErr: "missing ')'"},
+		{in: "or a", wantErr: `unexpected "or"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			x, err := parseIfFeature(tt.in)
+			var got bool
+			if err == nil {
+				got, err = x.eval(lookup)
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeatureSet(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      []string
+		want    FeatureSet
+		wantErr string
+	}{{
+		desc: "single module",
+		in:   []string{"foo:a,b"},
+		want: FeatureSet{"foo": {"a", "b"}},
+	}, {
+		desc: "multiple modules in one spec",
+		in:   []string{"foo:a", "b", "bar:c"},
+		want: FeatureSet{"foo": {"a", "b"}, "bar": {"c"}},
+	}, {
+		desc: "no features",
+		in:   []string{"foo:"},
+		want: FeatureSet{"foo": {}},
+	}, {
+		desc: "wildcards",
+		in:   []string{"*:x", "foo:*"},
+		want: FeatureSet{"*": {"x"}, "foo": {"*"}},
+	}, {
+		desc:    "missing module",
+		in:      []string{"a"},
+		wantErr: "has no module",
+	}, {
+		desc:    "empty module",
+		in:      []string{":a"},
+		wantErr: "missing module name",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseFeatureSet(tt.in...)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); err == nil && diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFeatureSetEnabled(t *testing.T) {
+	fs := FeatureSet{
+		"foo": {"a"},
+		"bar": {},
+		"baz": {"*"},
+	}
+	for _, tt := range []struct {
+		fs      FeatureSet
+		module  string
+		feature string
+		want    bool
+	}{
+		{fs: nil, module: "foo", feature: "b", want: true},
+		{fs: fs, module: "foo", feature: "a", want: true},
+		{fs: fs, module: "foo", feature: "b", want: false},
+		{fs: fs, module: "bar", feature: "a", want: false},
+		{fs: fs, module: "baz", feature: "a", want: true},
+		{fs: fs, module: "other", feature: "a", want: true},
+		{fs: FeatureSet{"*": {"a"}}, module: "other", feature: "a", want: true},
+		{fs: FeatureSet{"*": {"a"}}, module: "other", feature: "b", want: false},
+	} {
+		if got := tt.fs.Enabled(tt.module, tt.feature); got != tt.want {
+			t.Errorf("%v.Enabled(%q, %q): got %v, want %v", tt.fs, tt.module, tt.feature, got, tt.want)
+		}
+	}
+}
+
+func TestFeaturePruning(t *testing.T) {
+	modules := map[string]string{
+		"feat": `
+module feat {
+  prefix f;
+  namespace "urn:feat";
+  yang-version 1.1;
+
+  feature alpha;
+  feature beta;
+  feature gamma {
+    if-feature alpha;
+  }
+
+  identity base-id;
+  identity alpha-id {
+    base base-id;
+    if-feature alpha;
+  }
+  identity beta-id {
+    base base-id;
+    if-feature beta;
+  }
+
+  container top {
+    leaf always { type string; }
+    leaf a { if-feature alpha; type string; }
+    leaf b { if-feature beta; type string; }
+    leaf not-b { if-feature "not beta"; type string; }
+    leaf g { if-feature gamma; type string; }
+    leaf a-or-b { if-feature "alpha or beta"; type string; }
+    leaf a-and-b { if-feature "alpha and beta"; type string; }
+    container c-b {
+      if-feature beta;
+      leaf x { type string; }
+    }
+    uses grp {
+      if-feature beta;
+    }
+    leaf e {
+      type enumeration {
+        enum one;
+        enum two { if-feature beta; }
+        enum three { if-feature alpha; }
+      }
+    }
+    leaf bits {
+      type bits {
+        bit b0 { position 0; }
+        bit b1 { position 1; if-feature beta; }
+      }
+    }
+    leaf id {
+      type identityref { base base-id; }
+    }
+  }
+
+  grouping grp {
+    leaf from-grp { type string; }
+  }
+
+  augment "/f:top" {
+    if-feature beta;
+    leaf aug-b { type string; }
+  }
+  augment "/f:top" {
+    if-feature alpha;
+    leaf aug-a { type string; }
+  }
+}
+`,
+	}
+
+	tests := []struct {
+		desc      string
+		features  FeatureSet
+		wantLeafs []string
+		wantEnums []string
+		wantBits  []string
+		wantIDs   []string
+		wantErr   string
+	}{{
+		desc:      "no feature set enables everything",
+		wantLeafs: []string{"a", "a-and-b", "a-or-b", "always", "aug-a", "aug-b", "b", "bits", "c-b", "e", "from-grp", "g", "id", "not-b"},
+		wantEnums: []string{"one", "three", "two"},
+		wantBits:  []string{"b0", "b1"},
+		wantIDs:   []string{"alpha-id", "beta-id"},
+	}, {
+		desc:      "only alpha",
+		features:  FeatureSet{"feat": {"alpha"}},
+		wantLeafs: []string{"a", "a-or-b", "always", "aug-a", "bits", "e", "id", "not-b"},
+		wantEnums: []string{"one", "three"},
+		wantBits:  []string{"b0"},
+		wantIDs:   []string{"alpha-id"},
+	}, {
+		desc:      "gamma requires alpha",
+		features:  FeatureSet{"feat": {"gamma"}},
+		wantLeafs: []string{"always", "bits", "e", "id", "not-b"},
+		wantEnums: []string{"one"},
+		wantBits:  []string{"b0"},
+	}, {
+		desc:      "wildcard",
+		features:  FeatureSet{"feat": {"*"}},
+		wantLeafs: []string{"a", "a-and-b", "a-or-b", "always", "aug-a", "aug-b", "b", "bits", "c-b", "e", "from-grp", "g", "id"},
+		wantEnums: []string{"one", "three", "two"},
+		wantBits:  []string{"b0", "b1"},
+		wantIDs:   []string{"alpha-id", "beta-id"},
+	}, {
+		desc:      "other module listed only",
+		features:  FeatureSet{"other": {}},
+		wantLeafs: []string{"a", "a-and-b", "a-or-b", "always", "aug-a", "aug-b", "b", "bits", "c-b", "e", "from-grp", "g", "id"},
+		wantEnums: []string{"one", "three", "two"},
+		wantBits:  []string{"b0", "b1"},
+		wantIDs:   []string{"alpha-id", "beta-id"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			ms.ParseOptions.Features = tt.features
+			for name, src := range modules {
+				if err := ms.Parse(src, name+".yang"); err != nil {
+					t.Fatalf("could not parse %s: %v", name, err)
+				}
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			top := ToEntry(ms.Modules["feat"]).Dir["top"]
+			var leafs []string
+			for k := range top.Dir {
+				leafs = append(leafs, k)
+			}
+			sort.Strings(leafs)
+			if diff := cmp.Diff(tt.wantLeafs, leafs); diff != "" {
+				t.Errorf("leafs (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEnums, top.Dir["e"].Type.Enum.Names()); diff != "" {
+				t.Errorf("enums (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantBits, top.Dir["bits"].Type.Bit.Names()); diff != "" {
+				t.Errorf("bits (-want, +got):\n%s", diff)
+			}
+			var ids []string
+			for _, v := range top.Dir["id"].Type.IdentityBase.Values {
+				ids = append(ids, v.Name)
+			}
+			sort.Strings(ids)
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("identities (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFeatureErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc: "unknown feature",
+		in: `module err {
+  prefix e;
+  namespace "urn:err";
+  leaf l { if-feature missing; type string; }
+}`,
+		wantErr: "unknown feature missing in module err",
+	}, {
+		desc: "unknown prefix",
+		in: `module err {
+  prefix e;
+  namespace "urn:err";
+  leaf l { if-feature x:missing; type string; }
+}`,
+		wantErr: `unknown prefix "x"`,
+	}, {
+		desc: "bad expression",
+		in: `module err {
+  prefix e;
+  namespace "urn:err";
+  feature f;
+  leaf l { if-feature "f and"; type string; }
+}`,
+		wantErr: "invalid if-feature expression",
+	}, {
+		desc: "circular features",
+		in: `module err {
+  prefix e;
+  namespace "urn:err";
+  feature f { if-feature g; }
+  feature g { if-feature f; }
+  leaf l { if-feature f; type string; }
+}`,
+		wantErr: "circularly dependent",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			ms.ParseOptions.Features = FeatureSet{"err": {"*"}}
+			if err := ms.Parse(tt.in, "err.yang"); err != nil {
+				t.Fatalf("could not parse module: %v", err)
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnabledFeatures(t *testing.T) {
+	ms := NewModules()
+	ms.ParseOptions.Features = FeatureSet{"feat": {"a", "c"}}
+	if err := ms.Parse(`module feat {
+  prefix f;
+  namespace "urn:feat";
+  feature a;
+  feature b;
+  feature c { if-feature b; }
+}`, "feat.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	got, err := ms.EnabledFeatures(ms.Modules["feat"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"a"}, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
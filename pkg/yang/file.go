@@ -26,12 +26,12 @@ import (
 
 var (
 	// revisionDateSuffixRegex matches on the revision-date portion of a YANG
-	// file's name.
-	revisionDateSuffixRegex = regexp.MustCompile(`^@\d{4}-\d{2}-\d{2}\.yang$`)
+	// or YIN file's name.
+	revisionDateSuffixRegex = regexp.MustCompile(`^@\d{4}-\d{2}-\d{2}\.(yang|yin)$`)
 )
 
 // PathsWithModules returns all paths under and including the
-// root containing files with a ".yang" extension, as well as
+// root containing files with a ".yang" or ".yin" extension, as well as
 // any error encountered
 func PathsWithModules(root string) (paths []string, err error) {
 	pm := map[string]bool{}
@@ -41,7 +41,7 @@ func PathsWithModules(root string) (paths []string, err error) {
 			if info == nil {
 				return nil
 			}
-			if !info.IsDir() && strings.HasSuffix(p, ".yang") {
+			if !info.IsDir() && isModuleFile(p) {
 				dir := filepath.Dir(p)
 				if !pm[dir] {
 					pm[dir] = true
@@ -59,6 +59,8 @@ func PathsWithModules(root string) (paths []string, err error) {
 // of directory names, to Path, if they are not already in Path. Using
 // multiple arguments is also supported.
 func (ms *Modules) AddPath(paths ...string) {
+	ms.pathMu.Lock()
+	defer ms.pathMu.Unlock()
 	for _, path := range paths {
 		for _, p := range strings.Split(path, ":") {
 			if !ms.pathMap[p] {
@@ -69,19 +71,33 @@ func (ms *Modules) AddPath(paths ...string) {
 	}
 }
 
+// isModuleFile returns true if name has the extension of a YANG or YIN file.
+func isModuleFile(name string) bool {
+	return strings.HasSuffix(name, ".yang") || strings.HasSuffix(name, ".yin")
+}
+
 // readFile makes testing of findFile easier.
 var readFile = ioutil.ReadFile
 
 // scanDir makes testing of findFile easier.
 var scanDir = findInDir
 
+// FindFile returns the name of the file that Read parses for name, which is
+// a file name or a module or submodule name looked up as described for
+// findFile.  The file is not parsed.
+func (ms *Modules) FindFile(name string) (string, error) {
+	file, _, err := ms.findFile(name)
+	return file, err
+}
+
 // findFile returns the name and contents of the .yang file associated with
 // name, or an error.  If name is a module name rather than a file name (it does
-// not have a .yang extension and there is no / in name), .yang is appended to
-// the the name.  The directory that the .yang file is found in is added to Path
-// if not already in Path. If a file is not found by exact match, directories
-// are scanned for "name@revision-date.yang" files, the latest (sorted by
-// YYYY-MM-DD revision-date) of these will be selected.
+// not have a .yang or .yin extension and there is no / in name), .yang is
+// appended to the the name.  The directory that the .yang file is found in is
+// added to Path if not already in Path. If a file is not found by exact match,
+// directories are scanned for "name.yin" and "name@revision-date.yang" or
+// "name@revision-date.yin" files, the latest (sorted by YYYY-MM-DD
+// revision-date) of these will be selected.
 //
 // If a path has the form dir/... then dir and all direct or indirect
 // subdirectories of dir are searched.
@@ -89,8 +105,9 @@ var scanDir = findInDir
 // The current directory (.) is always checked first, no matter the value of
 // Path.
 func (ms *Modules) findFile(name string) (string, string, error) {
+	lookup := name
 	slash := strings.Index(name, "/")
-	if slash < 0 && !strings.HasSuffix(name, ".yang") {
+	if slash < 0 && !isModuleFile(name) {
 		name += ".yang"
 		if best := scanDir(".", name, false); best != "" {
 			// we found a matching candidate in the local directory
@@ -101,13 +118,17 @@ func (ms *Modules) findFile(name string) (string, string, error) {
 	switch data, err := readFile(name); true {
 	case err == nil:
 		ms.AddPath(filepath.Dir(name))
+		ms.addSource(lookup, name, string(data))
 		return name, string(data), nil
 	case slash >= 0:
 		// If there are any /'s in the name then don't search Path.
 		return "", "", fmt.Errorf("no such file: %s", name)
 	}
 
-	for _, dir := range ms.Path {
+	ms.pathMu.Lock()
+	path := append([]string(nil), ms.Path...)
+	ms.pathMu.Unlock()
+	for _, dir := range path {
 		var n string
 		if filepath.Base(dir) == "..." {
 			n = scanDir(filepath.Dir(dir), name, true)
@@ -118,6 +139,7 @@ func (ms *Modules) findFile(name string) (string, string, error) {
 			continue
 		}
 		if data, err := readFile(n); err == nil {
+			ms.addSource(lookup, n, string(data))
 			return n, string(data), nil
 		}
 	}
@@ -132,24 +154,31 @@ func (ms *Modules) findFile(name string) (string, string, error) {
 // https://tools.ietf.org/html/rfc7950#section-5.2:
 // module-or-submodule-name ['@' revision-date] '.yang'
 // where revision-date = 4DIGIT "-" 2DIGIT "-" 2DIGIT
+// A module in YIN syntax has the same name with a '.yin' extension, per
+// https://tools.ietf.org/html/rfc7950#section-13.
 //
 // If a perfect name match is found, then that file's path is returned.
-// Else if file(s) with otherwise matching names but which contain a
+// Else if a file with the other of the two extensions is found, its path is
+// returned.  Else if file(s) with otherwise matching names but which contain a
 // revision-date pattern exactly matching the above are found, then path of the
-// one with the latest date is returned.
+// one with the latest date is returned, preferring .yang to .yin for the same
+// date.
 func findInDir(dir, name string, recurse bool) string {
 	fis, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return ""
 	}
 
+	var alternate string
 	var revisions []string
-	mname := strings.TrimSuffix(name, ".yang")
+	mname := strings.TrimSuffix(strings.TrimSuffix(name, ".yang"), ".yin")
 	for _, fi := range fis {
 		switch {
 		case !fi.IsDir():
 			if fn := fi.Name(); fn == name {
 				return filepath.Join(dir, name)
+			} else if fn == mname+".yang" || fn == mname+".yin" {
+				alternate = fn
 			} else if strings.HasPrefix(fn, mname) && revisionDateSuffixRegex.MatchString(strings.TrimPrefix(fn, mname)) {
 				revisions = append(revisions, fn)
 			}
@@ -159,9 +188,16 @@ func findInDir(dir, name string, recurse bool) string {
 			}
 		}
 	}
+	if alternate != "" {
+		return filepath.Join(dir, alternate)
+	}
 	if len(revisions) == 0 {
 		return ""
 	}
-	sort.Strings(revisions)
+	// Sort on the name without its extension so that the date decides,
+	// with .yang sorting after .yin for the same date.
+	sort.Slice(revisions, func(i, j int) bool {
+		return strings.TrimSuffix(revisions[i], ".yin") < strings.TrimSuffix(revisions[j], ".yin")
+	})
 	return filepath.Join(dir, revisions[len(revisions)-1])
 }
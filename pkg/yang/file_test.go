@@ -69,6 +69,30 @@ func TestFindFile(t *testing.T) {
 	}
 }
 
+func TestModulesFindFile(t *testing.T) {
+	readFile = ioutil.ReadFile
+	scanDir = findInDir
+	ms := NewModules()
+	ms.AddPath("testdata/find-file-test")
+
+	tests := []struct {
+		desc    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"module name", "blue", filepath.Join("testdata", "find-file-test", "blue.yang"), false},
+		{"file name", "testdata/find-file-test/blue.yang", "testdata/find-file-test/blue.yang", false},
+		{"not found", "green", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ms.FindFile(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: FindFile(%q) got %q, %v, want %q, error %t", tt.desc, tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
 func TestScanForPathsAndAddModules(t *testing.T) {
 	// disable any readFile mock setup by other tests
 	readFile = ioutil.ReadFile
@@ -154,6 +178,30 @@ func TestFindInDir(t *testing.T) {
 		inName:    "red.yang",
 		inRecurse: true,
 		want:      filepath.Join(testDir, "dir", "dirdir", "red@2022-02-22.yang"),
+	}, {
+		desc:      "yin file for yang name",
+		inDir:     testDir,
+		inName:    "purple.yang",
+		inRecurse: false,
+		want:      filepath.Join(testDir, "purple.yin"),
+	}, {
+		desc:      "exact yin match",
+		inDir:     testDir,
+		inName:    "purple.yin",
+		inRecurse: false,
+		want:      filepath.Join(testDir, "purple.yin"),
+	}, {
+		desc:      "revision match prefers yang to yin",
+		inDir:     testDir,
+		inName:    "orange.yang",
+		inRecurse: false,
+		want:      filepath.Join(testDir, "orange@2020-01-01.yang"),
+	}, {
+		desc:      "revision match of yin name",
+		inDir:     testDir,
+		inName:    "orange.yin",
+		inRecurse: false,
+		want:      filepath.Join(testDir, "orange@2020-01-01.yang"),
 	}}
 
 	for _, tt := range tests {
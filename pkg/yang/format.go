@@ -0,0 +1,371 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements Format, which rewrites YANG source in a canonical
+// layout following the guidelines of RFC 8407 section 4, see
+// https://tools.ietf.org/html/rfc8407#section-4.
+//
+// Substatements are put in the order of the RFC 7950 section 14 grammar, the
+// data definition and other body statements keep their relative order.
+// Arguments are only quoted when needed or when they are text, XPath or
+// schema node identifiers.  Text arguments are wrapped to fit the line
+// width.  Comments are kept with the statement they precede, follow on the
+// same line or close.
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatOptions control the layout of the output of Format.
+type FormatOptions struct {
+	Indent int // number of spaces for each level of nesting, 2 if 0
+	Width  int // line width to wrap text arguments at, 72 if 0
+}
+
+// Format parses the YANG source input, read from path, and returns it in
+// canonical form.  Formatting the output of Format again does not change it.
+func Format(input, path string, opts *FormatOptions) ([]byte, error) {
+	root, err := parse(input, path, true)
+	if err != nil {
+		return nil, err
+	}
+	f := &yangFormatter{indent: 2, width: 72}
+	if opts != nil && opts.Indent > 0 {
+		f.indent = opts.Indent
+	}
+	if opts != nil && opts.Width > 0 {
+		f.width = opts.Width
+	}
+	f.statements(root, 0)
+	f.comments(root.endComments, "")
+	return f.buf.Bytes(), nil
+}
+
+// canonicalOrder lists, for each statement, the groups of substatements in
+// the order they are written.  Substatements not listed, such as data
+// definitions, are written after the listed ones.
+var canonicalOrder = map[string][][]string{
+	"module": {
+		{"yang-version", "namespace", "prefix"},
+		{"import", "include"},
+		{"organization", "contact", "description", "reference"},
+		{"revision"},
+	},
+	"submodule": {
+		{"yang-version", "belongs-to"},
+		{"import", "include"},
+		{"organization", "contact", "description", "reference"},
+		{"revision"},
+	},
+	"import":       {{"prefix", "revision-date", "description", "reference"}},
+	"include":      {{"revision-date", "description", "reference"}},
+	"belongs-to":   {{"prefix"}},
+	"revision":     {{"description", "reference"}},
+	"extension":    {{"argument", "status", "description", "reference"}},
+	"argument":     {{"yin-element"}},
+	"feature":      {{"if-feature", "status", "description", "reference"}},
+	"identity":     {{"if-feature", "base", "status", "description", "reference"}},
+	"typedef":      {{"type", "units", "default", "status", "description", "reference"}},
+	"type":         {{"fraction-digits", "range", "length", "pattern", "enum", "bit", "path", "require-instance", "base", "type"}},
+	"range":        {{"error-message", "error-app-tag", "description", "reference"}},
+	"length":       {{"error-message", "error-app-tag", "description", "reference"}},
+	"pattern":      {{"modifier", "error-message", "error-app-tag", "description", "reference"}},
+	"must":         {{"error-message", "error-app-tag", "description", "reference"}},
+	"enum":         {{"if-feature", "value", "status", "description", "reference"}},
+	"bit":          {{"if-feature", "position", "status", "description", "reference"}},
+	"container":    {{"when", "if-feature", "must", "presence", "config", "status", "description", "reference"}},
+	"leaf":         {{"when", "if-feature", "type", "units", "must", "default", "config", "mandatory", "status", "description", "reference"}},
+	"leaf-list":    {{"when", "if-feature", "type", "units", "must", "default", "config", "min-elements", "max-elements", "ordered-by", "status", "description", "reference"}},
+	"list":         {{"when", "if-feature", "must", "key", "unique", "config", "min-elements", "max-elements", "ordered-by", "status", "description", "reference"}},
+	"choice":       {{"when", "if-feature", "default", "config", "mandatory", "status", "description", "reference"}},
+	"case":         {{"when", "if-feature", "status", "description", "reference"}},
+	"anydata":      {{"when", "if-feature", "must", "config", "mandatory", "status", "description", "reference"}},
+	"anyxml":       {{"when", "if-feature", "must", "config", "mandatory", "status", "description", "reference"}},
+	"grouping":     {{"status", "description", "reference"}},
+	"uses":         {{"when", "if-feature", "status", "description", "reference", "refine", "augment"}},
+	"refine":       {{"if-feature", "must", "presence", "default", "config", "mandatory", "min-elements", "max-elements", "description", "reference"}},
+	"augment":      {{"when", "if-feature", "status", "description", "reference"}},
+	"rpc":          {{"if-feature", "status", "description", "reference", "typedef", "grouping", "input", "output"}},
+	"action":       {{"if-feature", "status", "description", "reference", "typedef", "grouping", "input", "output"}},
+	"input":        {{"must"}},
+	"output":       {{"must"}},
+	"notification": {{"if-feature", "must", "status", "description", "reference"}},
+	"deviation":    {{"description", "reference"}},
+}
+
+// quotedArguments are the statements whose argument is always quoted.
+var quotedArguments = map[string]bool{
+	"augment":       true,
+	"contact":       true,
+	"description":   true,
+	"deviation":     true,
+	"error-app-tag": true,
+	"error-message": true,
+	"key":           true,
+	"length":        true,
+	"must":          true,
+	"namespace":     true,
+	"organization":  true,
+	"path":          true,
+	"pattern":       true,
+	"presence":      true,
+	"range":         true,
+	"reference":     true,
+	"refine":        true,
+	"unique":        true,
+	"when":          true,
+}
+
+// textArguments are the statements whose argument is human readable text
+// that may be wrapped.
+var textArguments = map[string]bool{
+	"contact":       true,
+	"description":   true,
+	"error-message": true,
+	"organization":  true,
+	"reference":     true,
+}
+
+// A yangFormatter holds the state of formatting a file.
+type yangFormatter struct {
+	buf    bytes.Buffer
+	indent int
+	width  int
+}
+
+// rank returns the group and position of each substatement of s in the
+// canonical order.  An extension statement stays with the statement before
+// it.
+func rank(s *Statement) (groups, positions []int) {
+	order := canonicalOrder[s.Keyword]
+	var group, pos int
+	for _, c := range s.statements {
+		if !strings.Contains(c.Keyword, ":") {
+			group, pos = len(order), 0
+		Find:
+			for g, kws := range order {
+				for p, kw := range kws {
+					if kw == c.Keyword {
+						group, pos = g, p
+						break Find
+					}
+				}
+			}
+		}
+		groups = append(groups, group)
+		positions = append(positions, pos)
+	}
+	return groups, positions
+}
+
+// statements writes the substatements of s, at nesting level depth, in
+// canonical order.
+func (f *yangFormatter) statements(s *Statement, depth int) {
+	groups, positions := rank(s)
+	index := make([]int, len(s.statements))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		a, b := index[i], index[j]
+		if groups[a] != groups[b] {
+			return groups[a] < groups[b]
+		}
+		return positions[a] < positions[b]
+	})
+	body := len(canonicalOrder[s.Keyword])
+	for n, i := range index {
+		if n > 0 {
+			p := index[n-1]
+			switch {
+			case s.Keyword == "" || s.Keyword == "module" || s.Keyword == "submodule":
+				// Separate the groups of module statements, the
+				// revisions and the body statements.
+				if groups[i] != groups[p] || s.statements[i].Keyword == "revision" || groups[i] == body {
+					f.buf.WriteString("\n")
+				}
+			case groups[i] == body && groups[p] == body:
+				if len(s.statements[i].statements) > 0 || len(s.statements[p].statements) > 0 {
+					f.buf.WriteString("\n")
+				}
+			}
+		}
+		f.statement(s.statements[i], depth)
+	}
+}
+
+// statement writes s at nesting level depth.
+func (f *yangFormatter) statement(s *Statement, depth int) {
+	indent := strings.Repeat(" ", depth*f.indent)
+	f.comments(s.comments, indent)
+	f.buf.WriteString(indent + s.Keyword)
+	block := len(s.statements) > 0 || len(s.endComments) > 0
+	end := ";"
+	if block {
+		end = " {"
+	}
+	if s.HasArgument {
+		f.argument(s, indent, end)
+	}
+	f.buf.WriteString(end)
+	if !block {
+		f.lineComments(s, indent)
+		return
+	}
+	f.buf.WriteString("\n")
+	f.statements(s, depth+1)
+	f.comments(s.endComments, indent+strings.Repeat(" ", f.indent))
+	f.buf.WriteString(indent + "}")
+	f.lineComments(s, indent)
+}
+
+// lineComments ends the line of s with its line comments.
+func (f *yangFormatter) lineComments(s *Statement, indent string) {
+	for _, c := range s.lineComments {
+		f.buf.WriteString(" " + strings.Replace(c, "\n", "\n"+indent, -1))
+	}
+	f.buf.WriteString("\n")
+}
+
+// comments writes each comment in cs on lines of its own.
+func (f *yangFormatter) comments(cs []string, indent string) {
+	for _, c := range cs {
+		for _, line := range strings.Split(c, "\n") {
+			if line != "" {
+				line = indent + line
+			}
+			f.buf.WriteString(line + "\n")
+		}
+	}
+}
+
+// argument writes the argument of s, which is written after the keyword at
+// indent and is followed by end.  An argument that does not fit on the line
+// of the keyword is written on the next line.
+func (f *yangFormatter) argument(s *Statement, indent, end string) {
+	arg := s.Argument
+	quote := quoteFor(s.Keyword, arg)
+	if quote == "" {
+		f.buf.WriteString(" " + arg)
+		return
+	}
+	multiline := strings.Contains(arg, "\n")
+	if multiline {
+		// Only double quoted strings strip the indentation of their
+		// continuation lines.
+		quote = `"`
+	}
+	if !multiline {
+		text := quote + escape(arg, quote) + quote
+		if width(indent)+len(s.Keyword)+1+width(text)+len(end) <= f.width {
+			f.buf.WriteString(" " + text)
+			return
+		}
+	}
+	argIndent := indent + strings.Repeat(" ", f.indent)
+	var lines []string
+	for _, line := range strings.Split(arg, "\n") {
+		if textArguments[s.Keyword] {
+			lines = append(lines, wrap(line, f.width-width(argIndent)-1)...)
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	f.buf.WriteString("\n" + argIndent + quote)
+	for i, line := range lines {
+		if i > 0 {
+			f.buf.WriteString("\n")
+			if line != "" {
+				f.buf.WriteString(argIndent + " ")
+			}
+		}
+		f.buf.WriteString(escape(line, quote))
+	}
+	f.buf.WriteString(quote)
+}
+
+// quoteFor returns the quote character to use for arg, the argument of the
+// statement keyword, or "" if arg is written unquoted.  Single quotes are
+// used for patterns and arguments containing double quotes or backslashes
+// so they need no escapes.
+func quoteFor(keyword, arg string) string {
+	if !quotedArguments[keyword] && isPlainArgument(arg) {
+		return ""
+	}
+	if !strings.Contains(arg, "'") && (keyword == "pattern" || strings.ContainsAny(arg, `"\`)) {
+		return "'"
+	}
+	return `"`
+}
+
+// isPlainArgument reports whether arg, such as an identifier, number, date
+// or range, can be written without quotes.
+func isPlainArgument(arg string) bool {
+	if arg == "" || strings.Contains(arg, "//") || strings.Contains(arg, "/*") || strings.Contains(arg, "*/") {
+		return false
+	}
+	for _, c := range arg {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("_-.:/", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// escape escapes s for writing between quote characters.  Single quoted
+// strings have no escapes.
+func escape(s, quote string) string {
+	if quote != `"` {
+		return s
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`).Replace(s)
+}
+
+// width returns the number of characters in s.
+func width(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// wrap splits line into lines of at most n characters, breaking it at
+// spaces.  Words longer than n are not broken.
+func wrap(line string, n int) []string {
+	var out []string
+	for width(line) > n {
+		// Do not break the indentation of the line.
+		start := len(line) - len(strings.TrimLeft(line, " "))
+		i := -1
+		for j, c := range line {
+			if c == ' ' && j > start {
+				if utf8.RuneCountInString(line[:j]) > n && i >= 0 {
+					break
+				}
+				i = j
+			}
+		}
+		if i < 0 {
+			break
+		}
+		out = append(out, strings.TrimRight(line[:i], " "))
+		line = line[i+1:]
+	}
+	return append(out, line)
+}
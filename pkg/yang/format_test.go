@@ -0,0 +1,231 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		opts    *FormatOptions
+		want    string
+		wantErr string
+	}{{
+		desc: "canonical order and comments",
+		in: `// Leading file comment
+module ex { prefix ex;   namespace urn:ex;
+  yang-version 1.1;
+  description "A very long description that goes on and on well beyond the seventy-two character limit of the line.";
+  revision 2021-02-01 { description "Second."; }
+  revision 2021-01-01 { description "First."; }
+  import other { prefix o; }
+  /* block
+     comment */
+  container top { // on the open brace
+    leaf b { description "B"; type string { pattern '\d+'; length "1..10"; } } // trailing b
+    leaf a { mandatory true; type int32; }
+    // before close
+  } // end top
+  leaf x { type string; default "has space"; o:ext "arg"; must "../a = \"x\""; }
+}
+// end of file
+`,
+		want: `// Leading file comment
+module ex {
+  yang-version 1.1;
+  namespace "urn:ex";
+  prefix ex;
+
+  import other {
+    prefix o;
+  }
+
+  description
+    "A very long description that goes on and on well beyond the
+     seventy-two character limit of the line.";
+
+  revision 2021-02-01 {
+    description "Second.";
+  }
+
+  revision 2021-01-01 {
+    description "First.";
+  }
+
+  /* block
+     comment */
+  container top {
+    // on the open brace
+    leaf b {
+      type string {
+        length "1..10";
+        pattern '\d+';
+      }
+      description "B";
+    } // trailing b
+
+    leaf a {
+      type int32;
+      mandatory true;
+    }
+    // before close
+  } // end top
+
+  leaf x {
+    type string;
+    must '../a = "x"';
+    default "has space";
+    o:ext arg;
+  }
+}
+// end of file
+`,
+	}, {
+		desc: "indent and multi-line text",
+		in: `module m {
+	namespace "urn:m";
+	prefix m;
+	description
+		"First line.
+
+		 Indented	tab and \"quote\" and \\.";
+	leaf-list l { type string; ordered-by user; max-elements 3; }
+}`,
+		opts: &FormatOptions{Indent: 4, Width: 40},
+		want: `module m {
+    namespace "urn:m";
+    prefix m;
+
+    description
+        "First line.
+
+         Indented\ttab and \"quote\" and \\.";
+
+    leaf-list l {
+        type string;
+        max-elements 3;
+        ordered-by user;
+    }
+}
+`,
+	}, {
+		desc: "blank lines between comments",
+		in: `// License.
+
+
+// About m.
+module m {
+  namespace "urn:m";
+  prefix m;
+  /* one */
+  // two
+
+  // three
+  leaf l { type string; }
+}
+`,
+		want: `// License.
+
+// About m.
+module m {
+  namespace "urn:m";
+  prefix m;
+
+  /* one */
+  // two
+
+  // three
+  leaf l {
+    type string;
+  }
+}
+`,
+	}, {
+		desc:    "syntax error",
+		in:      "module m {",
+		wantErr: "missing 1 closing brace",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Format(tt.in, "test.yang", tt.opts)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Format: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("Format (-want, +got):\n%s", diff)
+			}
+			again, err := Format(string(got), "test.yang", tt.opts)
+			if err != nil {
+				t.Fatalf("Format of formatted source: %v", err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Errorf("Format is not idempotent, got:\n%s", again)
+			}
+		})
+	}
+}
+
+// TestFormatPreservesStatements checks that formatting does not change the
+// keywords or arguments of any statement.
+func TestFormatPreservesStatements(t *testing.T) {
+	in := `module m {
+  namespace "urn:m";
+  prefix m;
+  typedef t {
+    type string {
+      pattern "[a-z]+\\d*" + '\s';
+      pattern "it's";
+    }
+  }
+  leaf l {
+    type t;
+    description "one" + " two";
+  }
+}`
+	got, err := Format(in, "m.yang", nil)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	var want, have bytes.Buffer
+	for _, s := range mustParse(t, in) {
+		s.Write(&want, "")
+	}
+	for _, s := range mustParse(t, string(got)) {
+		s.Write(&have, "")
+	}
+	if diff := cmp.Diff(want.String(), have.String()); diff != "" {
+		t.Errorf("statements changed (-want, +got):\n%s\nformatted source:\n%s", diff, got)
+	}
+}
+
+func mustParse(t *testing.T, in string) []*Statement {
+	t.Helper()
+	ss, err := Parse(in, "m.yang")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return ss
+}
@@ -16,6 +16,7 @@ package yang
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -52,7 +53,7 @@ func newResolvedIdentity(m *Module, i *Identity) (string, *resolvedIdentity) {
 		Module:   m,
 		Identity: i,
 	}
-	return i.modulePrefixedName(), r
+	return i.QualifiedName(), r
 }
 
 func appendIfNotIn(ids []*Identity, chk *Identity) []*Identity {
@@ -85,7 +86,6 @@ func (mod *Module) findIdentityBase(baseStr string) (*resolvedIdentity, []error)
 
 	basePrefix, baseName := getPrefix(baseStr)
 	rootPrefix := mod.GetPrefix()
-	source := Source(mod)
 	typeDict := mod.Modules.typeDict
 
 	switch basePrefix {
@@ -95,14 +95,14 @@ func (mod *Module) findIdentityBase(baseStr string) (*resolvedIdentity, []error)
 		keyName := fmt.Sprintf("%s:%s", module(mod).Name, baseName)
 		base, ok = typeDict.identities.dict[keyName]
 		if !ok {
-			errs = append(errs, fmt.Errorf("%s: can't resolve the local base %s as %s", source, baseStr, keyName))
+			errs = append(errs, diagnosticf(mod, CodeUnresolved, "can't resolve the local base %s as %s", baseStr, keyName))
 		}
 	default:
 		// This is an identity which is defined within another module
 		extmod := FindModuleByPrefix(mod, basePrefix)
 		if extmod == nil {
 			errs = append(errs,
-				fmt.Errorf("%s: can't find external module with prefix %s", source, basePrefix))
+				diagnosticf(mod, CodeUnresolved, "can't find external module with prefix %s", basePrefix))
 			break
 		}
 		// The identity we are looking for is modulename:basename.
@@ -114,7 +114,7 @@ func (mod *Module) findIdentityBase(baseStr string) (*resolvedIdentity, []error)
 		// Error if we did not find the identity that had the name specified in
 		// the module it was expected to be in.
 		if base.isEmpty() {
-			errs = append(errs, fmt.Errorf("%s: can't resolve remote base %s", source, baseStr))
+			errs = append(errs, diagnosticf(mod, CodeUnresolved, "can't resolve remote base %s", baseStr))
 		}
 	}
 	return &base, errs
@@ -130,8 +130,21 @@ func (ms *Modules) resolveIdentities() []error {
 	// from them, and compile them into a "fully resolved" map that means that
 	// we can look them up based on the 'real' prefix of the module and the
 	// name of the identity.
+	// enabled reports whether identity i is supported by the feature set in
+	// the parse options.
+	enabled := func(i *Identity) bool {
+		ok, err := ms.IsEnabled(i)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return ok
+	}
+
 	for _, mod := range ms.Modules {
 		for _, i := range mod.Identities() {
+			if !enabled(i) {
+				continue
+			}
 			keyName, r := newResolvedIdentity(mod, i)
 			ms.typeDict.identities.dict[keyName] = *r
 		}
@@ -144,6 +157,9 @@ func (ms *Modules) resolveIdentities() []error {
 				continue
 			}
 			for _, i := range in.Module.Identities() {
+				if !enabled(i) {
+					continue
+				}
 				keyName, r := newResolvedIdentity(in.Module, i)
 				ms.typeDict.identities.dict[keyName] = *r
 			}
@@ -166,9 +182,13 @@ func (ms *Modules) resolveIdentities() []error {
 					errs = append(errs, baseErr...)
 					continue
 				}
+				if err := checkStatusReference(i.Identity, base.Identity); err != nil {
+					errs = append(errs, err)
+				}
 
 				// Build up a list of direct children of this identity.
 				base.Identity.Values = append(base.Identity.Values, i.Identity)
+				i.Identity.bases = appendIfNotIn(i.Identity.bases, base.Identity)
 			}
 		}
 	}
@@ -184,3 +204,126 @@ func (ms *Modules) resolveIdentities() []error {
 
 	return errs
 }
+
+// Bases returns the identities that s is directly derived from, the
+// identities named by its base statements.  Bases returns nil until the
+// identities of the modules have been resolved by Process.
+func (s *Identity) Bases() []*Identity {
+	return s.bases
+}
+
+// IsDerivedFrom returns true if the identity s is derived from base, either
+// directly or through other identities, which may be defined in other
+// modules.  An identity is not derived from itself.
+func (s *Identity) IsDerivedFrom(base *Identity) bool {
+	seen := map[*Identity]bool{}
+	var derived func(i *Identity) bool
+	derived = func(i *Identity) bool {
+		for _, b := range i.bases {
+			if b == base {
+				return true
+			}
+			if !seen[b] {
+				seen[b] = true
+				if derived(b) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return base != nil && derived(s)
+}
+
+// FindIdentity returns the identity with the module-qualified name name, as
+// in module:identity, or nil if there is none.  The identities of
+// submodules are qualified by the name of the module they belong to.
+func (ms *Modules) FindIdentity(name string) *Identity {
+	d := &ms.typeDict.identities
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dict[name].Identity
+}
+
+// DerivedIdentities returns the module-qualified names of the identities
+// that are derived from the identity with the module-qualified name base,
+// in sorted order.  Only the identities directly derived from base are
+// returned unless transitive is true.
+func (ms *Modules) DerivedIdentities(base string, transitive bool) ([]string, error) {
+	b := ms.FindIdentity(base)
+	if b == nil {
+		return nil, fmt.Errorf("unknown identity %s", base)
+	}
+	d := &ms.typeDict.identities
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var names []string
+	for name, r := range d.dict {
+		if transitive && r.Identity.IsDerivedFrom(b) || !transitive && hasBase(r.Identity, b) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// hasBase returns true if base is one of the bases of the identity i.
+func hasBase(i, base *Identity) bool {
+	for _, b := range i.bases {
+		if b == base {
+			return true
+		}
+	}
+	return false
+}
+
+// bases returns the bases of the identityref y, or nil if y is not an
+// identityref.
+func (y *YangType) bases() []*Identity {
+	if len(y.IdentityBases) > 0 {
+		return y.IdentityBases
+	}
+	if y.IdentityBase != nil {
+		return []*Identity{y.IdentityBase}
+	}
+	return nil
+}
+
+// AllowsIdentity returns true if the identity id is a valid value of the
+// identityref y, that is if id is derived from all the bases of y.
+func (y *YangType) AllowsIdentity(id *Identity) bool {
+	bases := y.bases()
+	for _, b := range bases {
+		if !id.IsDerivedFrom(b) {
+			return false
+		}
+	}
+	return len(bases) > 0
+}
+
+// AllowedIdentities returns the module-qualified names of the identities
+// that are valid values of the identityref y, in sorted order.  The
+// identities allowed by the identityref members of a union are included.
+// AllowedIdentities returns nil if y allows no identities.
+func (y *YangType) AllowedIdentities() []string {
+	seen := map[string]bool{}
+	var names []string
+	var add func(y *YangType)
+	add = func(y *YangType) {
+		for _, t := range y.Type {
+			add(t)
+		}
+		if y.Kind != Yidentityref || y.IdentityBase == nil {
+			return
+		}
+		for _, id := range y.IdentityBase.Values {
+			if name := id.QualifiedName(); !seen[name] && y.AllowsIdentity(id) {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	add(y)
+	sort.Strings(names)
+	return names
+}
@@ -15,6 +15,7 @@
 package yang
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -793,3 +794,114 @@ func TestIdentityTree(t *testing.T) {
 		})
 	}
 }
+
+const identityQueryBase = `module crypto-base {
+  namespace "urn:crypto-base";
+  prefix cb;
+  identity crypto-alg;
+  identity symmetric { base crypto-alg; }
+  identity hash { base crypto-alg; }
+}
+`
+
+const identityQueryModule = `module crypto {
+  yang-version 1.1;
+  namespace "urn:crypto";
+  prefix c;
+  import crypto-base { prefix cb; }
+  identity aes { base cb:symmetric; }
+  identity keyed-hash { base cb:symmetric; base cb:hash; }
+  identity hmac-sha256 { base keyed-hash; }
+  identity sha256 { base cb:hash; }
+
+  leaf symmetric-hash {
+    type identityref { base cb:symmetric; base cb:hash; }
+  }
+  leaf alg {
+    type union {
+      type identityref { base keyed-hash; }
+      type identityref { base cb:symmetric; }
+    }
+  }
+}
+`
+
+func TestIdentityQueries(t *testing.T) {
+	ms := NewModules()
+	for i, src := range []string{identityQueryBase, identityQueryModule} {
+		if err := ms.Parse(src, fmt.Sprintf("identity%d.yang", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+
+	derived := []struct {
+		desc       string
+		base       string
+		transitive bool
+		want       []string
+		wantErr    string
+	}{{
+		desc: "direct",
+		base: "crypto-base:crypto-alg",
+		want: []string{"crypto-base:hash", "crypto-base:symmetric"},
+	}, {
+		desc:       "transitive",
+		base:       "crypto-base:crypto-alg",
+		transitive: true,
+		want: []string{
+			"crypto-base:hash",
+			"crypto-base:symmetric",
+			"crypto:aes",
+			"crypto:hmac-sha256",
+			"crypto:keyed-hash",
+			"crypto:sha256",
+		},
+	}, {
+		desc: "several bases",
+		base: "crypto-base:hash",
+		want: []string{"crypto:keyed-hash", "crypto:sha256"},
+	}, {
+		desc:    "unknown",
+		base:    "crypto:md5",
+		wantErr: "unknown identity crypto:md5",
+	}}
+	for _, tt := range derived {
+		got, err := ms.DerivedIdentities(tt.base, tt.transitive)
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("%s: %s", tt.desc, diff)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: DerivedIdentities (-want, +got):\n%s", tt.desc, diff)
+		}
+	}
+
+	hmac, alg := ms.FindIdentity("crypto:hmac-sha256"), ms.FindIdentity("crypto-base:crypto-alg")
+	if !hmac.IsDerivedFrom(alg) || alg.IsDerivedFrom(hmac) || alg.IsDerivedFrom(alg) {
+		t.Errorf("IsDerivedFrom: hmac-sha256 must be derived from crypto-alg only")
+	}
+
+	m, _ := ms.GetModule("crypto")
+	allowed := []struct {
+		leaf string
+		want []string
+	}{{
+		leaf: "symmetric-hash",
+		want: []string{"crypto:hmac-sha256", "crypto:keyed-hash"},
+	}, {
+		leaf: "alg",
+		want: []string{"crypto:aes", "crypto:hmac-sha256", "crypto:keyed-hash"},
+	}}
+	for _, tt := range allowed {
+		if diff := cmp.Diff(tt.want, m.Dir[tt.leaf].Type.AllowedIdentities()); diff != "" {
+			t.Errorf("AllowedIdentities of %s (-want, +got):\n%s", tt.leaf, diff)
+		}
+	}
+
+	if _, err := m.Dir["symmetric-hash"].Type.ParseValue("c:aes"); err == nil {
+		t.Errorf("ParseValue: aes is allowed, it is not derived from hash")
+	}
+}
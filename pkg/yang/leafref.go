@@ -0,0 +1,352 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the resolution of leafref paths, see
+// https://tools.ietf.org/html/rfc7950#section-9.9.2, against the Entry tree.
+
+import (
+	"fmt"
+)
+
+// LeafrefTarget returns the leaf or leaf-list entry referenced by the path of
+// the leafref e.  An error is returned if e is not a leafref or its path
+// does not resolve to a leaf or leaf-list.
+func (e *Entry) LeafrefTarget() (*Entry, error) {
+	if e == nil || e.Type == nil || e.Type.Kind != Yleafref {
+		return nil, fmt.Errorf("%s: not a leafref", e.Path())
+	}
+	return e.resolveLeafref(leafrefContext(e, e.Type), e.Type.Path, map[*Entry]bool{})
+}
+
+// LeafrefType returns the type of the entry ultimately referenced by the
+// leafref e, following chains of leafrefs.  The type of e is returned if it
+// is not a leafref.
+func (e *Entry) LeafrefType() (*YangType, error) {
+	seen := map[*Entry]bool{}
+	for e.Type != nil && e.Type.Kind == Yleafref {
+		if seen[e] {
+			return nil, &leafrefLoopError{e}
+		}
+		seen[e] = true
+		t, err := e.resolveLeafref(leafrefContext(e, e.Type), e.Type.Path, seen)
+		if err != nil {
+			return nil, err
+		}
+		e = t
+	}
+	return e.Type, nil
+}
+
+// A leafrefLoopError is returned by LeafrefType when a chain of leafrefs
+// returns to the leafref e.
+type leafrefLoopError struct {
+	e *Entry
+}
+
+func (err *leafrefLoopError) Error() string {
+	return err.e.Path() + ": leafref loop"
+}
+
+// leafrefContext returns the node whose module prefixes are used to resolve
+// the path of the leafref type y of e.  This is the type statement that
+// provided the path, which may be in a typedef in a different module.
+func leafrefContext(e *Entry, y *YangType) Node {
+	var t *Type
+	switch n := e.Node.(type) {
+	case *Leaf:
+		t = n.Type
+	case *LeafList:
+		t = n.Type
+	}
+	for t != nil && t.YangType != nil {
+		if t.YangType == y || t.YangType.Path == y.Path {
+			for ; t != nil; t = t.YangType.Base {
+				if t.Path != nil {
+					return t
+				}
+				if t.YangType == nil {
+					break
+				}
+			}
+			break
+		}
+		// Search the members of a union for y.
+		var next *Type
+		for _, m := range t.Type {
+			if m.YangType == y {
+				next = m
+			}
+		}
+		t = next
+	}
+	return e.Node
+}
+
+// resolveLeafref resolves the leafref path, defined in the context of node
+// n, relative to e.  seen holds the leafrefs being resolved, to detect loops
+// through deref().
+func (e *Entry) resolveLeafref(n Node, path string, seen map[*Entry]bool) (*Entry, error) {
+	x, err := CompileXPath(n, path)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := x.root.(*xpPath)
+	if !ok {
+		return nil, fmt.Errorf("leafref path %q is not a location path", path)
+	}
+	r := &leafrefResolver{x: x, leaf: e, seen: seen}
+	t, err := r.path(e, p)
+	if err != nil {
+		return nil, fmt.Errorf("leafref path %q: %v", path, err)
+	}
+	if !t.IsLeaf() && !t.IsLeafList() {
+		return nil, fmt.Errorf("leafref path %q: %s is not a leaf or leaf-list", path, t.Path())
+	}
+	return t, nil
+}
+
+// A leafrefResolver resolves the location paths of a compiled leafref path
+// against the Entry tree.
+type leafrefResolver struct {
+	x    *XPath
+	leaf *Entry // the leafref, i.e., current()
+	seen map[*Entry]bool
+}
+
+// path returns the entry p selects when evaluated with e as the context
+// entry.  A nil entry stands for the root of the data tree.
+func (r *leafrefResolver) path(e *Entry, p *xpPath) (*Entry, error) {
+	switch {
+	case p.filter != nil:
+		var err error
+		if e, err = r.filter(e, p.filter); err != nil {
+			return nil, err
+		}
+	case p.absolute:
+		e = nil
+	}
+	for _, s := range p.steps {
+		var err error
+		if e, err = r.step(e, s); err != nil {
+			return nil, err
+		}
+	}
+	if e == nil {
+		return nil, fmt.Errorf("path selects the root")
+	}
+	return e, nil
+}
+
+// filter resolves the current() and deref() function calls that may start
+// a leafref path.
+func (r *leafrefResolver) filter(e *Entry, x xpExpr) (*Entry, error) {
+	f, ok := x.(*xpFunc)
+	if !ok {
+		return nil, fmt.Errorf("only current() and deref() may start a path")
+	}
+	switch f.name {
+	case "current":
+		return r.leaf, nil
+	case "deref":
+		p, ok := f.args[0].(*xpPath)
+		if !ok {
+			return nil, fmt.Errorf("deref() argument is not a path")
+		}
+		t, err := r.path(e, p)
+		if err != nil {
+			return nil, err
+		}
+		if t.Type == nil || t.Type.Kind != Yleafref {
+			return nil, fmt.Errorf("deref() argument %s is not a leafref", t.Path())
+		}
+		if r.seen[t] {
+			return nil, fmt.Errorf("deref() of %s loops", t.Path())
+		}
+		r.seen[t] = true
+		defer delete(r.seen, t)
+		return t.resolveLeafref(leafrefContext(t, t.Type), t.Type.Path, r.seen)
+	}
+	return nil, fmt.Errorf("function %s() is not allowed in a leafref path", f.name)
+}
+
+// step returns the entry selected by s from e and checks the predicates of
+// s, which must be of the form key = current()/path.
+func (r *leafrefResolver) step(e *Entry, s *xpStep) (*Entry, error) {
+	var t *Entry
+	switch {
+	case s.axis == "parent" && s.test.kind == "node":
+		if e == nil {
+			return nil, fmt.Errorf("path goes above the root")
+		}
+		t = e.Parent
+		for t != nil && (t.IsChoice() || t.IsCase()) {
+			t = t.Parent
+		}
+		if t != nil && t.Parent == nil {
+			// The module entry is the root of the data tree.
+			t = nil
+		}
+	case s.axis == "self" && s.test.kind == "node":
+		t = e
+	case s.axis == "child" && s.test.kind == "name" && s.test.local != "":
+		var err error
+		if t, err = r.child(e, s.test); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported location step in leafref path")
+	}
+
+	for _, pred := range s.preds {
+		if err := r.predicate(t, pred); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// child returns the child of e named by test, where a nil e is the root of
+// the data tree.
+func (r *leafrefResolver) child(e *Entry, test xpNodeTest) (*Entry, error) {
+	module := test.module
+	if !test.prefixed {
+		// Unprefixed names are in the namespace of the leafref.
+		if m, err := r.leaf.InstantiatingModule(); err == nil {
+			module = m
+		}
+	}
+	name := test.local
+	if module != "" {
+		name = module + ":" + test.local
+	}
+	if e == nil {
+		ms := r.leaf.Modules()
+		m := ms.Modules[module]
+		if m == nil {
+			return nil, fmt.Errorf("unknown module %s", module)
+		}
+		e = ToEntry(m)
+	}
+	t := e.DataChild(test.local)
+	if t == nil {
+		return nil, fmt.Errorf("%s not found", r.describe(e, name))
+	}
+	if test.prefixed {
+		if m, err := t.InstantiatingModule(); err == nil && m != module {
+			return nil, fmt.Errorf("%s not found", r.describe(e, name))
+		}
+	}
+	return t, nil
+}
+
+// describe returns a description of the node named name as a child of e.
+func (r *leafrefResolver) describe(e *Entry, name string) string {
+	if e.Parent == nil {
+		return "/" + name
+	}
+	return e.Path() + "/" + name
+}
+
+// predicate checks that pred, a predicate of a step selecting e, is an
+// equality between a key of e and a path starting with current().
+func (r *leafrefResolver) predicate(e *Entry, pred xpExpr) error {
+	b, ok := pred.(*xpBinary)
+	if !ok || b.op != "=" {
+		return fmt.Errorf("predicate must be of the form key = current()/path")
+	}
+	key, ok := b.l.(*xpPath)
+	if !ok || key.filter != nil || key.absolute {
+		return fmt.Errorf("predicate must start with a key name")
+	}
+	if _, err := r.path(e, key); err != nil {
+		return fmt.Errorf("predicate: %v", err)
+	}
+	p, ok := b.r.(*xpPath)
+	if !ok {
+		// current() on its own is an *xpFunc.
+		if f, ok := b.r.(*xpFunc); ok && f.name == "current" {
+			return nil
+		}
+		return fmt.Errorf("predicate must be compared with current()/path")
+	}
+	if f, ok := p.filter.(*xpFunc); !ok || f.name != "current" {
+		return fmt.Errorf("predicate must be compared with current()/path")
+	}
+	if _, err := r.path(e, p); err != nil {
+		return fmt.Errorf("predicate: %v", err)
+	}
+	return nil
+}
+
+// checkLeafrefs resolves the leafref paths of e and all of its descendants,
+// returning an error for each path that does not resolve unless the
+// IgnoreModuleResolveErrors option is set.
+func (e *Entry) checkLeafrefs() []error {
+	var errs []error
+	if e.Type != nil {
+		for _, y := range leafrefTypes(e.Type) {
+			target, err := e.resolveLeafref(leafrefContext(e, y), y.Path, map[*Entry]bool{})
+			if err != nil {
+				if ms := e.Modules(); ms == nil || !ms.ParseOptions.IgnoreModuleResolveErrors {
+					errs = append(errs, diagnosticf(e.Node, CodeUnresolved, "%v", err))
+				}
+				continue
+			}
+			if err := checkStatusReference(e.Node, target.Node); err != nil {
+				errs = append(errs, err)
+			}
+			if y != e.Type {
+				continue
+			}
+			// Only a loop is reported here, the other errors of
+			// the chain are reported at the leafrefs they are in.
+			if _, err := e.LeafrefType(); err != nil {
+				if _, ok := err.(*leafrefLoopError); ok {
+					if ms := e.Modules(); ms == nil || !ms.ParseOptions.IgnoreModuleResolveErrors {
+						errs = append(errs, diagnosticf(e.Node, CodeUnresolved, "leafref path %q: %v", y.Path, err))
+					}
+				}
+			}
+		}
+	}
+	for _, c := range e.Dir {
+		errs = append(errs, c.checkLeafrefs()...)
+	}
+	if e.RPC != nil {
+		if e.RPC.Input != nil {
+			errs = append(errs, e.RPC.Input.checkLeafrefs()...)
+		}
+		if e.RPC.Output != nil {
+			errs = append(errs, e.RPC.Output.checkLeafrefs()...)
+		}
+	}
+	return errs
+}
+
+// leafrefTypes returns y, or the members of the union y, that are leafrefs.
+func leafrefTypes(y *YangType) []*YangType {
+	switch y.Kind {
+	case Yleafref:
+		return []*YangType{y}
+	case Yunion:
+		var ys []*YangType
+		for _, t := range y.Type {
+			ys = append(ys, leafrefTypes(t)...)
+		}
+		return ys
+	}
+	return nil
+}
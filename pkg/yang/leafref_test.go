@@ -0,0 +1,278 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var leafrefTestModules = map[string]string{
+	"base": `
+module base {
+  prefix b;
+  namespace "urn:base";
+
+  typedef intf-ref {
+    type leafref { path "/b:interfaces/b:interface/b:name"; }
+  }
+
+  container interfaces {
+    list interface {
+      key name;
+      leaf name { type string; }
+      leaf mtu { type uint16; }
+      list address {
+        key ip;
+        leaf ip { type string; }
+      }
+    }
+  }
+}`,
+	"user": `
+module user {
+  prefix u;
+  namespace "urn:user";
+  yang-version 1.1;
+  import base { prefix bs; }
+
+  grouping refs {
+    leaf grouped { type leafref { path "../local"; } }
+  }
+
+  container top {
+    leaf local { type int32; }
+    leaf abs { type leafref { path "/u:top/u:local"; } }
+    leaf rel { type leafref { path "../local"; } }
+    leaf unprefixed { type leafref { path "/top/local"; } }
+    leaf other { type leafref { path "/bs:interfaces/bs:interface/bs:name"; } }
+    leaf typedef { type bs:intf-ref; }
+    leaf chained { type leafref { path "../typedef"; } }
+    leaf ip {
+      type leafref {
+        path "/bs:interfaces/bs:interface[bs:name = current()/../typedef]/bs:address/bs:ip";
+      }
+    }
+    leaf mtu {
+      type leafref { path "deref(../typedef)/../bs:mtu"; }
+    }
+    leaf in-union {
+      type union {
+        type string;
+        type leafref { path "../local"; }
+      }
+    }
+    choice ch {
+      case a {
+        leaf in-case { type leafref { path "../local"; } }
+      }
+    }
+    uses refs;
+  }
+}`,
+}
+
+func TestLeafrefTarget(t *testing.T) {
+	ms := NewModules()
+	for name, src := range leafrefTestModules {
+		if err := ms.Parse(src, name+".yang"); err != nil {
+			t.Fatalf("could not parse %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process modules: %v", errs)
+	}
+	top := ToEntry(ms.Modules["user"]).Dir["top"]
+
+	tests := []struct {
+		leaf     string
+		want     string
+		wantKind TypeKind
+		wantErr  string
+	}{
+		{leaf: "abs", want: "/user/top/local", wantKind: Yint32},
+		{leaf: "rel", want: "/user/top/local", wantKind: Yint32},
+		{leaf: "unprefixed", want: "/user/top/local", wantKind: Yint32},
+		{leaf: "grouped", want: "/user/top/local", wantKind: Yint32},
+		{leaf: "in-case", want: "/user/top/local", wantKind: Yint32},
+		{leaf: "other", want: "/base/interfaces/interface/name", wantKind: Ystring},
+		{leaf: "typedef", want: "/base/interfaces/interface/name", wantKind: Ystring},
+		{leaf: "chained", want: "/user/top/typedef", wantKind: Ystring},
+		{leaf: "ip", want: "/base/interfaces/interface/address/ip", wantKind: Ystring},
+		{leaf: "mtu", want: "/base/interfaces/interface/mtu", wantKind: Yuint16},
+		{leaf: "local", wantErr: "not a leafref"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.leaf, func(t *testing.T) {
+			e := top.Find(tt.leaf)
+			if e == nil {
+				e = top.Dir["ch"].Dir["a"].Dir[tt.leaf]
+			}
+			got, err := e.LeafrefTarget()
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if got.Path() != tt.want {
+				t.Errorf("got target %s, want %s", got.Path(), tt.want)
+			}
+			y, err := e.LeafrefType()
+			if err != nil {
+				t.Fatalf("LeafrefType: %v", err)
+			}
+			if y.Kind != tt.wantKind {
+				t.Errorf("got type %v, want %v", y.Kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestLeafrefErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		ignore  bool // IgnoreModuleResolveErrors
+		wantErr string
+	}{{
+		desc: "missing node",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf a { type string; }
+  leaf b { type leafref { path "../c"; } }
+}`,
+		wantErr: `lr.yang:5:3: leafref path "../c": /lr:c not found`,
+	}, {
+		desc: "missing node ignored",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf a { type string; }
+  leaf b { type leafref { path "../c"; } }
+}`,
+		ignore: true,
+	}, {
+		desc: "missing absolute node",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  container c { leaf a { type string; } }
+  leaf b { type leafref { path "/l:c/l:x"; } }
+}`,
+		wantErr: `leafref path "/l:c/l:x": /lr/c/lr:x not found`,
+	}, {
+		desc: "target is not a leaf",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  container c { leaf a { type string; } }
+  leaf b { type leafref { path "/l:c"; } }
+}`,
+		wantErr: "/lr/c is not a leaf or leaf-list",
+	}, {
+		desc: "above the root",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf b { type leafref { path "../../a"; } }
+}`,
+		wantErr: "path goes above the root",
+	}, {
+		desc: "unknown prefix",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf b { type leafref { path "/x:a"; } }
+}`,
+		wantErr: `unknown prefix "x"`,
+	}, {
+		desc: "bad predicate",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  list x { key k; leaf k { type string; } }
+  leaf b { type leafref { path "/l:x[l:k = 'a']/l:k"; } }
+}`,
+		wantErr: "predicate must be compared with current()/path",
+	}, {
+		desc: "dangling predicate",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  list x { key k; leaf k { type string; } }
+  leaf b { type leafref { path "/l:x[l:k = current()/../l:nope]/l:k"; } }
+}`,
+		wantErr: "predicate: /lr:nope not found",
+	}, {
+		desc: "leafref in union",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf b { type union { type string; type leafref { path "../nope"; } } }
+}`,
+		wantErr: `leafref path "../nope"`,
+	}, {
+		desc: "deref loop",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  yang-version 1.1;
+  leaf a { type leafref { path "deref(../b)/../a"; } }
+  leaf b { type leafref { path "deref(../a)/../b"; } }
+}`,
+		wantErr: "loops",
+	}, {
+		desc: "leafref loop",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf a { type leafref { path "../b"; } }
+  leaf b { type leafref { path "../c"; } }
+  leaf c { type leafref { path "../a"; } }
+}`,
+		wantErr: "leafref loop",
+	}, {
+		desc: "leafref loop ignored",
+		in: `module lr {
+  prefix l;
+  namespace "urn:lr";
+  leaf a { type leafref { path "../b"; } }
+  leaf b { type leafref { path "../a"; } }
+}`,
+		ignore: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			ms.ParseOptions.IgnoreModuleResolveErrors = tt.ignore
+			if err := ms.Parse(tt.in, "lr.yang"); err != nil {
+				t.Fatalf("could not parse module: %v", err)
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
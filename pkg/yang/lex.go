@@ -21,6 +21,7 @@ package yang
 //    tEOF         // end-of-file
 //    tString      // A de-quoted string (e.g., "\"bob\"" becomes "bob")
 //    tUnquoted    // An un-quoted string
+//    tComment     // A comment, only when comments are kept
 //    '{'
 //    ';'
 //    '}'
@@ -48,8 +49,9 @@ type stateFn func(*lexer) stateFn
 
 // A lexer holds the internal state of the lexer.
 type lexer struct {
-	errout io.Writer // destination for errors, defaults to os.Stderr
-	errcnt int       // number of errors encountered
+	errout io.Writer     // destination for errors, defaults to os.Stderr
+	errcnt int           // number of errors encountered
+	diags  []*Diagnostic // the errors written to errout
 
 	file  string // name of file we are processing
 	input string // contents of the file
@@ -59,6 +61,7 @@ type lexer struct {
 	col   int    // the current column number (0 based, add 1 before displaying)
 
 	debug     bool        // set to true to include internal debugging
+	comments  bool        // set to emit comments as tComment tokens
 	inPattern bool        // set when parsing the argument to a pattern
 	items     chan *token // channel of scanned items.
 	tcol      int         // column with tabs expanded (for multi-line strings)
@@ -77,6 +80,7 @@ const (
 	tError                      // An error
 	tString                     // A dequoted string
 	tUnquoted                   // A non-quoted string
+	tComment                    // A comment, including its delimiters
 )
 
 // String returns c as a string.
@@ -88,6 +92,8 @@ func (c code) String() string {
 		return "String"
 	case tUnquoted:
 		return "Unquoted"
+	case tComment:
+		return "Comment"
 	}
 	if c < 0 || c > '~' {
 		return fmt.Sprintf("%d", c)
@@ -310,6 +316,12 @@ func (l *lexer) Errorf(f string, v ...interface{}) {
 
 		fmt.Fprintf(buf, "%s:%d: ", name, line)
 	}
+	d := &Diagnostic{
+		Location: Location{File: l.file, Line: l.line, Col: l.col + 1},
+		Severity: SeverityError,
+		Code:     CodeSyntax,
+		Message:  strings.TrimSuffix(fmt.Sprintf(f, v...), "\n"),
+	}
 	fmt.Fprintf(buf, "%s:%d:%d: ", l.file, l.line, l.col+1)
 	fmt.Fprintf(buf, f, v...)
 	b := buf.Bytes()
@@ -317,7 +329,7 @@ func (l *lexer) Errorf(f string, v ...interface{}) {
 		buf.Write([]byte{'\n'})
 	}
 	l.emit(tError)
-	l.adderror(buf.Bytes())
+	l.adderror(buf.Bytes(), d)
 }
 
 func (l *lexer) ErrorfAt(line, col int, f string, v ...interface{}) {
@@ -329,21 +341,28 @@ func (l *lexer) ErrorfAt(line, col int, f string, v ...interface{}) {
 	l.Errorf(f, v...)
 }
 
-// adderror writes out the error string err and increases the error count.
+// adderror writes out the error string err, records its diagnostic d and
+// increases the error count.
 // If more than maxErrors are encountered, a "too many errors" message is
 // displayed and processing stops (by clearing the input).
-func (l *lexer) adderror(err []byte) {
+func (l *lexer) adderror(err []byte, d *Diagnostic) {
 	if l.errcnt == maxErrors {
 		l.pos = 0
 		l.start = 0
 		l.input = ""
 		l.errout.Write([]byte(tooMany))
+		l.diags = append(l.diags, &Diagnostic{
+			Severity: SeverityError,
+			Code:     CodeSyntax,
+			Message:  strings.TrimSuffix(tooMany, "\n"),
+		})
 		l.errcnt++
 		return
 	} else if l.errcnt == maxErrors+1 {
 		return
 	}
 	l.errout.Write(err)
+	l.diags = append(l.diags, d)
 	l.errcnt++
 }
 
@@ -389,6 +408,9 @@ func lexGround(l *lexer) stateFn {
 				l.ErrorfAt(l.line, l.col-1, `lexer internal error: all lines should be newline-terminated.`)
 				return nil
 			}
+			if l.comments {
+				l.emitText(tComment, strings.TrimRight(l.input[l.start:l.pos], " \t\r"))
+			}
 			return lexGround
 		case '*':
 			// Start of a /* comment
@@ -399,6 +421,9 @@ func lexGround(l *lexer) stateFn {
 			// Now actually skip the */
 			l.next()
 			l.next()
+			if l.comments {
+				l.emit(tComment)
+			}
 			return lexGround
 		default:
 			return lexUnquoted
@@ -20,11 +20,19 @@ package yang
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
 )
 
 // Modules contains information about all the top level modules and
 // submodules that are read into it via its Read method.
+//
+// Read, ReadAll, Parse, Process and GetModule may be called from multiple
+// goroutines, they are serialized with each other.  Once Process has returned
+// without errors, and as long as no more modules are read, the Modules and
+// SubModules maps, the Module nodes and the Entry trees returned by ToEntry
+// and GetModule may be read from any number of goroutines.  The Entry trees
+// must not be changed while they are shared.
 type Modules struct {
 	Modules    map[string]*Module // All "module" nodes
 	SubModules map[string]*Module // All "submodule" nodes
@@ -32,6 +40,18 @@ type Modules struct {
 	nsMu       sync.Mutex         // nsMu protects the byNS map.
 	byNS       map[string]*Module // Cache of namespace lookup
 	typeDict   *typeDictionary    // Cache for type definitions.
+	// mu serializes the methods that read modules into ms or process them.
+	mu sync.Mutex
+	// added counts the modules and submodules added to ms, processed is the
+	// value of added when Process last ran, if hasProcessed, and processErrs
+	// are the errors it returned.  They let Process skip the work when
+	// nothing has been read since.
+	added        int
+	processed    int
+	hasProcessed bool
+	processErrs  []error
+	// entryMu protects entryCache and mergedSubmodule.
+	entryMu sync.Mutex
 	// entryCache is used to prevent unnecessary recursion into previously
 	// converted nodes.
 	entryCache map[Node]*Entry
@@ -46,8 +66,15 @@ type Modules struct {
 	ParseOptions Options
 	// Path is the list of directories to look for .yang files in.
 	Path []string
+	// pathMu protects Path, pathMap and sources.
+	pathMu sync.Mutex
 	// pathMap is used to prevent adding dups in Path.
 	pathMap map[string]bool
+	// sources are the files that have been read, parsed is set if modules
+	// have been added by Parse rather than read from files.  A cache is
+	// only valid while the sources have not changed.
+	sources []cacheSource
+	parsed  bool
 }
 
 // NewModules returns a newly created and initialized Modules.
@@ -69,12 +96,78 @@ func NewModules() *Modules {
 // actual .yang file or a module/submodule name (the base name of a .yang file,
 // e.g., foo.yang is named foo).  An error is returned if the file is not
 // found or there was an error parsing the file.
+// Note: As with Parse, if an error is returned, valid modules might still have
+// been added to the Modules cache.
 func (ms *Modules) Read(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.read(name)
+}
+
+// read implements Read with ms.mu held.
+func (ms *Modules) read(name string) error {
+	// The nodes built before a parse error are still added.
+	nodes, readErr := ms.readNodes(name)
+	if err := ms.addNodes(nodes); err != nil {
+		return err
+	}
+	return readErr
+}
+
+// ReadAll reads each of the named yang modules into ms, as Read does.  The
+// files are found, parsed and built into Nodes concurrently and then added
+// to ms in the order of names, so the result is the same as calling Read for
+// each name in turn.  ReadAll returns a slice with the error of reading each
+// name, in the order of names, which is nil if the name was read.
+func (ms *Modules) ReadAll(names ...string) []error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	type result struct {
+		nodes []Node
+		err   error
+	}
+	results := make([]result, len(names))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(names) {
+		workers = len(names)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				nodes, err := ms.readNodes(names[i])
+				results[i] = result{nodes, err}
+			}
+		}()
+	}
+	for i := range names {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	errs := make([]error, len(names))
+	for i, r := range results {
+		if errs[i] = ms.addNodes(r.nodes); errs[i] == nil {
+			errs[i] = r.err
+		}
+	}
+	return errs
+}
+
+// readNodes finds the file named by name and returns the Nodes it holds,
+// which are the Nodes built before any error when there is one.  It does not
+// change ms other than adding to Path so it may be called concurrently.
+func (ms *Modules) readNodes(name string) ([]Node, error) {
 	name, data, err := ms.findFile(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return ms.Parse(data, name)
+	return ms.parseNodes(data, name)
 }
 
 // Parse parses data as YANG source and adds it to ms.  The name should reflect
@@ -82,15 +175,42 @@ func (ms *Modules) Read(name string) error {
 // Note: If an error is returned, valid modules might still have been added to
 // the Modules cache.
 func (ms *Modules) Parse(data, name string) error {
-	ss, err := Parse(data, name)
-	if err != nil {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.parsed = true
+	// The nodes built before a parse error are still added.
+	nodes, parseErr := ms.parseNodes(data, name)
+	if err := ms.addNodes(nodes); err != nil {
 		return err
 	}
+	return parseErr
+}
+
+// parseNodes parses data, read from name, and builds the Nodes of the
+// modules it holds.  The Nodes built before any error are returned with it.
+func (ms *Modules) parseNodes(data, name string) ([]Node, error) {
+	parse := Parse
+	if isYIN(data, name) {
+		parse = ParseYIN
+	}
+	ss, err := parse(data, name)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []Node
 	for _, s := range ss {
 		n, err := buildASTWithTypeDict(s, ms.typeDict)
 		if err != nil {
-			return err
+			return nodes, err
 		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// addNodes adds the modules in nodes to ms, stopping at the first error.
+func (ms *Modules) addNodes(nodes []Node) error {
+	for _, n := range nodes {
 		if err := ms.add(n); err != nil {
 			return err
 		}
@@ -106,8 +226,10 @@ func (ms *Modules) Parse(data, name string) error {
 // then looking up the module name.  It is safe to call Read and Process prior
 // to calling GetModule.
 func (ms *Modules) GetModule(name string) (*Entry, []error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 	if ms.Modules[name] == nil {
-		if err := ms.Read(name); err != nil {
+		if err := ms.read(name); err != nil {
 			return nil, []error{err}
 		}
 		if ms.Modules[name] == nil {
@@ -116,7 +238,7 @@ func (ms *Modules) GetModule(name string) (*Entry, []error) {
 	}
 	// Make sure that the modules have all been processed and have no
 	// errors.
-	if errs := ms.Process(); len(errs) != 0 {
+	if errs := ms.process(); len(errs) != 0 {
 		return nil, errs
 	}
 	return ToEntry(ms.Modules[name]), nil
@@ -165,8 +287,10 @@ func (ms *Modules) add(n Node) error {
 	fullName := mod.FullName()
 	mod.Modules = ms
 
+	ms.added++
 	if o := m[fullName]; o != nil {
-		return fmt.Errorf("duplicate %s %s at %s and %s", kind, fullName, Source(o), Source(n))
+		d := diagnosticf(n, CodeDuplicate, "duplicate %s %s at %s and %s", kind, fullName, Source(o), Source(n))
+		return d.related(o, "first definition of "+fullName)
 	}
 	m[fullName] = mod
 	if fullName == name {
@@ -184,7 +308,8 @@ func (ms *Modules) add(n Node) error {
 
 // FindModule returns the Module/Submodule specified by n, which must be a
 // *Include or *Import.  If n is a *Include then a submodule is returned.  If n
-// is a *Import then a module is returned.
+// is a *Import then a module is returned.  FindModule reads the module if it
+// has not been read yet, which is only safe while processing ms.
 func (ms *Modules) FindModule(n Node) *Module {
 	name := n.NName()
 	rev := name
@@ -213,9 +338,9 @@ func (ms *Modules) FindModule(n Node) *Module {
 	}
 
 	// Try to read first a module by revision
-	if err := ms.Read(rev); err != nil {
+	if err := ms.read(rev); err != nil {
 		// if failed, try to read a module by its bare name
-		if err := ms.Read(name); err != nil {
+		if err := ms.read(name); err != nil {
 			return nil
 		}
 	}
@@ -225,6 +350,36 @@ func (ms *Modules) FindModule(n Node) *Module {
 	return m[name]
 }
 
+// cachedEntry returns the Entry already built for n, or nil.
+func (ms *Modules) cachedEntry(n Node) *Entry {
+	ms.entryMu.Lock()
+	defer ms.entryMu.Unlock()
+	return ms.entryCache[n]
+}
+
+// cacheEntry records e as the Entry built for n.
+func (ms *Modules) cacheEntry(n Node, e *Entry) {
+	ms.entryMu.Lock()
+	defer ms.entryMu.Unlock()
+	ms.entryCache[n] = e
+}
+
+// merged returns true if the submodule merge named by key has been done.
+func (ms *Modules) merged(key string) bool {
+	ms.entryMu.Lock()
+	defer ms.entryMu.Unlock()
+	return ms.mergedSubmodule[key]
+}
+
+// setMerged records that the submodule merges named by keys have been done.
+func (ms *Modules) setMerged(keys ...string) {
+	ms.entryMu.Lock()
+	defer ms.entryMu.Unlock()
+	for _, k := range keys {
+		ms.mergedSubmodule[k] = true
+	}
+}
+
 // FindModuleByNamespace either returns the Module specified by the namespace
 // or returns an error.
 func (ms *Modules) FindModuleByNamespace(ns string) (*Module, error) {
@@ -256,7 +411,7 @@ func (ms *Modules) FindModuleByNamespace(ns string) (*Module, error) {
 	return found, nil
 }
 
-// process satisfies all include and import statements and verifies that all
+// resolve satisfies all include and import statements and verifies that all
 // link ref paths reference a known node.  If an import or include references
 // a [sub]module that is not already known, Process will search for a .yang
 // file that contains it, returning an error if not found.  An error is also
@@ -264,7 +419,7 @@ func (ms *Modules) FindModuleByNamespace(ns string) (*Module, error) {
 //
 // Process must be called once all the source modules have been read in and
 // prior to converting Node tree into an Entry tree.
-func (ms *Modules) process() []error {
+func (ms *Modules) resolve() []error {
 	var mods []*Module
 	var errs []error
 
@@ -319,13 +474,38 @@ func (ms *Modules) process() []error {
 // while processing.  Even though multiple errors may be returned, this does
 // not mean these are all the errors.  Process will terminate processing early
 // based on the type and location of the error.
+//
+// Process only does the work once, calling it again without reading any more
+// modules returns the same errors as the previous call.  ParseOptions must be
+// set before the first call to Process.
 func (ms *Modules) Process() []error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.process()
+}
+
+// process implements Process with ms.mu held.
+func (ms *Modules) process() []error {
+	if ms.hasProcessed && ms.processed == ms.added {
+		return ms.processErrs
+	}
+	ms.processErrs = ms.processModules()
+	// Modules read while processing have been processed too.
+	ms.processed = ms.added
+	ms.hasProcessed = true
+	return ms.processErrs
+}
+
+// processModules does the work of Process.
+func (ms *Modules) processModules() []error {
 	// Reset globals that may remain stale if multiple Process() calls are
 	// made by the same caller.
+	ms.entryMu.Lock()
 	ms.mergedSubmodule = map[string]bool{}
 	ms.entryCache = map[Node]*Entry{}
+	ms.entryMu.Unlock()
 
-	errs := ms.process()
+	errs := ms.resolve()
 	if len(errs) > 0 {
 		return errorSort(errs)
 	}
@@ -394,13 +574,31 @@ func (ms *Modules) Process() []error {
 	for _, devmods := range []map[string]*Module{ms.Modules, ms.SubModules} {
 		for _, m := range devmods {
 			e := ToEntry(m)
-			if !dvP[e.Name] {
+			// The deviations of the schema of a single datastore
+			// are applied to its view.
+			if !dvP[e.Name] && !ms.ParseOptions.datastoreDeviations(m) {
 				errs = append(errs, e.ApplyDeviate()...)
 				dvP[e.Name] = true
 			}
 		}
 	}
 
+	// Finally make sure that all when and must statements are valid XPath
+	// expressions and that all leafref paths resolve.
+	seen := map[Node]bool{}
+	for _, devmods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, m := range devmods {
+			e := ToEntry(m)
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			errs = append(errs, e.checkXPaths(seen)...)
+			errs = append(errs, e.checkLeafrefs()...)
+			errs = append(errs, e.checkAugmentStatus()...)
+		}
+	}
+
 	return errorSort(errs)
 }
 
@@ -421,7 +619,7 @@ func (ms *Modules) include(m *Module) error {
 				continue
 			}
 
-			return fmt.Errorf("no such submodule: %s", i.Name)
+			return diagnosticf(i, CodeUnresolved, "no such submodule: %s", i.Name)
 		}
 		// Process the include statements in our included module.
 		if err := ms.include(im); err != nil {
@@ -439,7 +637,7 @@ func (ms *Modules) include(m *Module) error {
 				continue
 			}
 
-			return fmt.Errorf("no such module: %s", i.Name)
+			return diagnosticf(i, CodeUnresolved, "no such module: %s", i.Name)
 		}
 		// Process the include statements in our included module.
 		if err := ms.include(im); err != nil {
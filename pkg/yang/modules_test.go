@@ -15,7 +15,12 @@
 package yang
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/openconfig/gnmi/errdiff"
@@ -412,3 +417,245 @@ func TestModulesTotalProcess(t *testing.T) {
 		})
 	}
 }
+
+// writeTestModules writes n modules, each of which imports a common types
+// module, into a new directory and returns the directory and the names of
+// the modules.  The directory must be removed by the caller.
+func writeTestModules(tb testing.TB, n int) (string, []string) {
+	tb.Helper()
+	dir, err := ioutil.TempDir("", "modules")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	write := func(name, text string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".yang"), []byte(text), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	write("types", `module types {
+  namespace "urn:types";
+  prefix t;
+  typedef name { type string { length "1..64"; pattern "[a-z][a-z0-9-]*"; } }
+  typedef mtu { type uint16 { range "68..9216"; } }
+  grouping counters {
+    leaf in-octets { type uint64; config false; }
+    leaf out-octets { type uint64; config false; }
+  }
+}`)
+	var names []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("mod%d", i)
+		var b strings.Builder
+		fmt.Fprintf(&b, "module %s {\n  namespace \"urn:%s\";\n  prefix m;\n  import types { prefix t; }\n", name, name)
+		for j := 0; j < 20; j++ {
+			fmt.Fprintf(&b, "  container c%d {\n    description \"Container %d of %s.\";\n", j, j, name)
+			fmt.Fprintf(&b, "    list entry {\n      key \"name\";\n      leaf name { type t:name; }\n")
+			fmt.Fprintf(&b, "      leaf mtu { type t:mtu; default 1500; }\n      leaf enabled { type boolean; }\n")
+			fmt.Fprintf(&b, "      uses t:counters;\n    }\n  }\n")
+		}
+		b.WriteString("}\n")
+		write(name, b.String())
+		names = append(names, name)
+	}
+	return dir, names
+}
+
+func TestReadAll(t *testing.T) {
+	dir, names := writeTestModules(t, 8)
+	defer os.RemoveAll(dir)
+
+	ms := NewModules()
+	ms.AddPath(dir)
+	errs := ms.ReadAll(append(names, "missing")...)
+	if got, want := len(errs), len(names)+1; got != want {
+		t.Fatalf("got %d errors, want %d", got, want)
+	}
+	for i, err := range errs[:len(names)] {
+		if err != nil {
+			t.Errorf("reading %s: %v", names[i], err)
+		}
+	}
+	if diff := errdiff.Substring(errs[len(names)], "no such file: missing.yang"); diff != "" {
+		t.Errorf("reading missing: %s", diff)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	for _, name := range names {
+		if ms.Modules[name] == nil {
+			t.Errorf("module %s was not read", name)
+		}
+	}
+	if ms.Modules["types"] == nil {
+		t.Errorf("imported module types was not read")
+	}
+
+	// Reading the same module twice gives a duplicate error for the second.
+	ms = NewModules()
+	ms.AddPath(dir)
+	errs = ms.ReadAll(names[0], names[0])
+	if errs[0] != nil {
+		t.Errorf("reading %s: %v", names[0], errs[0])
+	}
+	if diff := errdiff.Substring(errs[1], "duplicate module"); diff != "" {
+		t.Errorf("reading %s again: %s", names[0], diff)
+	}
+}
+
+func TestProcessOnce(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module a { namespace "urn:a"; prefix a; leaf l { type string; } }`, "a.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	e := ToEntry(ms.Modules["a"])
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("second Process: %v", errs)
+	}
+	if ToEntry(ms.Modules["a"]) != e {
+		t.Errorf("second Process built a new Entry for module a")
+	}
+
+	// Reading another module processes the modules again.
+	if err := ms.Parse(`module b { namespace "urn:b"; prefix b; leaf l { type no-such-type; } }`, "b.yang"); err != nil {
+		t.Fatal(err)
+	}
+	errs := ms.Process()
+	if len(errs) == 0 {
+		t.Fatalf("Process with module b got no errors")
+	}
+	if ToEntry(ms.Modules["a"]) == e {
+		t.Errorf("Process after reading module b did not process module a again")
+	}
+	if again := ms.Process(); fmt.Sprint(again) != fmt.Sprint(errs) {
+		t.Errorf("second Process got errors %v, want %v", again, errs)
+	}
+}
+
+func TestConcurrentGetModule(t *testing.T) {
+	dir, names := writeTestModules(t, 8)
+	defer os.RemoveAll(dir)
+
+	ms := NewModules()
+	ms.AddPath(dir)
+	var wg sync.WaitGroup
+	errc := make(chan error, len(names))
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			e, errs := ms.GetModule(name)
+			if len(errs) > 0 {
+				errc <- fmt.Errorf("GetModule %s: %v", name, errs)
+				return
+			}
+			// Read only access to the Entry tree.
+			l := e.Find("c3/entry/mtu")
+			if l == nil {
+				errc <- fmt.Errorf("%s: c3/entry/mtu not found", name)
+				return
+			}
+			if got, want := l.Path(), "/"+name+"/c3/entry/mtu"; got != want {
+				errc <- fmt.Errorf("got path %s, want %s", got, want)
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Error(err)
+	}
+}
+
+func benchmarkRead(b *testing.B, readAll bool) {
+	dir, names := writeTestModules(b, 64)
+	defer os.RemoveAll(dir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ms := NewModules()
+		ms.AddPath(dir)
+		if readAll {
+			for _, err := range ms.ReadAll(names...) {
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		} else {
+			for _, name := range names {
+				if err := ms.Read(name); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkRead and BenchmarkReadAll compare reading modules one at a time
+// with reading them concurrently, which is faster when GOMAXPROCS is more
+// than 1.
+func BenchmarkRead(b *testing.B)    { benchmarkRead(b, false) }
+func BenchmarkReadAll(b *testing.B) { benchmarkRead(b, true) }
+
+// BenchmarkProcessAgain measures calling Process again without reading any
+// more modules, as the multi mode of the yang command used to do.
+func BenchmarkProcessAgain(b *testing.B) {
+	dir, names := writeTestModules(b, 64)
+	defer os.RemoveAll(dir)
+	ms := NewModules()
+	ms.AddPath(dir)
+	ms.ReadAll(names...)
+	if errs := ms.Process(); len(errs) > 0 {
+		b.Fatal(errs)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ms.Process()
+	}
+}
+
+func TestReadParseError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The first module of the file is built before the error in the
+	// second.
+	src := `
+module a { namespace "urn:a"; prefix a; }
+module b { namespace "urn:b"; prefix b; bogus-statement x; }
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "ab.yang"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "ab.yang")
+
+	for _, tt := range []struct {
+		desc string
+		read func(ms *Modules) error
+	}{{
+		desc: "Read",
+		read: func(ms *Modules) error { return ms.Read(file) },
+	}, {
+		desc: "ReadAll",
+		read: func(ms *Modules) error { return ms.ReadAll(file)[0] },
+	}, {
+		desc: "Parse",
+		read: func(ms *Modules) error { return ms.Parse(src, file) },
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if diff := errdiff.Substring(tt.read(ms), "bogus-statement"); diff != "" {
+				t.Errorf("%s", diff)
+			}
+			if ms.Modules["a"] == nil {
+				t.Errorf("module a was not added")
+			}
+			if ms.Modules["b"] != nil {
+				t.Errorf("module b was added")
+			}
+		})
+	}
+}
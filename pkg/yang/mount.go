@@ -0,0 +1,177 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements YANG Schema Mount, RFC 8528.  A container or list
+// with the mount-point extension of ietf-yang-schema-mount is a mount
+// point: the data nodes below it are defined by a separate set of modules,
+// the mounted schema, which is only known once the modules are processed.
+// Mounting a schema adds the top-level nodes of its modules to the Entry of
+// each mount point, so that Find, Path and the encodings of the Entry tree
+// continue into the mounted schema.
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SchemaMountModule is the name of the module that defines the mount-point
+// extension.
+const SchemaMountModule = "ietf-yang-schema-mount"
+
+func init() {
+	RegisterExtension(&ExtensionHandler{
+		Module: SchemaMountModule,
+		Name:   "mount-point",
+	})
+}
+
+// MountPoint returns the label of the mount point e is, or "" if e is not a
+// mount point.
+func (e *Entry) MountPoint() string {
+	for _, v := range e.ExtensionValue(SchemaMountModule, "mount-point") {
+		if label, ok := v.(string); ok {
+			return label
+		}
+	}
+	return ""
+}
+
+// IsMounted returns true if e is the top-level node of a mounted schema.
+func (e *Entry) IsMounted() bool {
+	return e.schema != nil
+}
+
+// A Mount is a schema mounted at the mount points with a given label.
+type Mount struct {
+	Module string   // name of the module that defines the mount points
+	Label  string   // label of the mount points
+	Schema *Modules // modules of the mounted schema, already processed
+
+	// ReadOnly is set if the mounted data nodes are state data, as when
+	// the config leaf of the mount point in ietf-yang-schema-mount is
+	// false.
+	ReadOnly bool
+}
+
+// Mount mounts m.Schema at every mount point labelled m.Label that is
+// defined in the module m.Module or its submodules.  The top-level nodes of
+// the modules in m.Schema become children of each mount point, keeping the
+// namespace of their module.  Mount points within the mounted schema must
+// be mounted in m.Schema before it is mounted in ms.  An error is returned
+// if there is no such mount point or if a mounted node has the same name as
+// a node already at the mount point.
+func (ms *Modules) Mount(m *Mount) error {
+	if m.Schema == nil {
+		return fmt.Errorf("mount point %s:%s: no schema to mount", m.Module, m.Label)
+	}
+	var top []*Entry
+	for _, mod := range uniqueModules(m.Schema) {
+		e := ToEntry(mod)
+		if errs := e.GetErrors(); len(errs) > 0 {
+			return fmt.Errorf("mount point %s:%s: mounted module %s has errors: %v", m.Module, m.Label, mod.Name, errs[0])
+		}
+		top = append(top, sortedEntries(e.Dir)...)
+	}
+	names := map[string]bool{}
+	for _, c := range top {
+		if names[c.Name] {
+			return fmt.Errorf("mount point %s:%s: mounted schema defines %s twice", m.Module, m.Label, c.Name)
+		}
+		names[c.Name] = true
+	}
+
+	var points []*Entry
+	seen := map[*Entry]bool{}
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		if e == nil || seen[e] || e.IsMounted() {
+			return
+		}
+		seen[e] = true
+		if e.MountPoint() == m.Label && e.Node != nil {
+			if root := RootNode(e.Node); root != nil && moduleName(root) == m.Module {
+				points = append(points, e)
+			}
+		}
+		for _, c := range sortedEntries(e.Dir) {
+			walk(c)
+		}
+		if e.RPC != nil {
+			walk(e.RPC.Input)
+			walk(e.RPC.Output)
+		}
+	}
+	for _, mod := range uniqueModules(ms) {
+		walk(ToEntry(mod))
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("mount point %s:%s not found", m.Module, m.Label)
+	}
+
+	for _, p := range points {
+		if p.Dir == nil {
+			p.Dir = map[string]*Entry{}
+		}
+		for _, c := range top {
+			if p.Dir[c.Name] != nil {
+				return fmt.Errorf("mount point %s: mounted node %s already exists", p.Path(), c.Name)
+			}
+		}
+		for _, c := range top {
+			nc := c.dup()
+			nc.Parent = p
+			nc.namespace = c.Namespace()
+			nc.schema = m.Schema
+			if m.ReadOnly {
+				nc.Config = TSFalse
+			}
+			p.Dir[c.Name] = nc
+		}
+	}
+	return nil
+}
+
+// uniqueModules returns the modules of ms, each once, sorted by name.
+func uniqueModules(ms *Modules) []*Module {
+	var names []string
+	for name := range ms.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var mods []*Module
+	seen := map[*Module]bool{}
+	for _, name := range names {
+		if m := ms.Modules[name]; !seen[m] {
+			seen[m] = true
+			mods = append(mods, m)
+		}
+	}
+	return mods
+}
+
+// sortedEntries returns the entries of dir sorted by name.
+func sortedEntries(dir map[string]*Entry) []*Entry {
+	names := make([]string, 0, len(dir))
+	for name := range dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	es := make([]*Entry, len(names))
+	for i, name := range names {
+		es[i] = dir[name]
+	}
+	return es
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const schemaMountModule = `module ietf-yang-schema-mount {
+  namespace "urn:ietf:params:xml:ns:yang:ietf-yang-schema-mount";
+  prefix yangmnt;
+  extension mount-point { argument label; }
+}
+`
+
+const hostModule = `module host {
+  namespace "urn:host";
+  prefix h;
+  import ietf-yang-schema-mount { prefix yangmnt; }
+  container instances {
+    list instance {
+      key name;
+      leaf name { type string; }
+      container root {
+        yangmnt:mount-point vrf-root;
+      }
+    }
+  }
+}
+`
+
+const routingModule = `module routing {
+  namespace "urn:routing";
+  prefix rt;
+  container routing {
+    leaf router-id { type string; }
+    leaf ref { type leafref { path "/rt:routing/rt:router-id"; } }
+  }
+}
+`
+
+// mountTest returns the processed modules of sources, which are parsed in
+// order.
+func mountTest(t *testing.T, sources ...string) *Modules {
+	t.Helper()
+	ms := NewModules()
+	for i, src := range sources {
+		if err := ms.Parse(src, fmt.Sprintf("mount%d.yang", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	return ms
+}
+
+func TestMount(t *testing.T) {
+	ms := mountTest(t, schemaMountModule, hostModule)
+	mounted := mountTest(t, routingModule)
+
+	host, _ := ms.GetModule("host")
+	root := host.Find("instances/instance/root")
+	if root == nil {
+		t.Fatal("mount point not found")
+	}
+	if got := root.MountPoint(); got != "vrf-root" {
+		t.Errorf("MountPoint: got %q, want vrf-root", got)
+	}
+
+	if err := ms.Mount(&Mount{Module: "host", Label: "vrf-root", Schema: mounted, ReadOnly: true}); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+
+	e := host.Find("instances/instance/root/rt:routing/router-id")
+	if e == nil {
+		t.Fatal("mounted leaf not found")
+	}
+	if got, want := e.Path(), "/host/instances/instance/root/routing/router-id"; got != want {
+		t.Errorf("Path: got %s, want %s", got, want)
+	}
+	if got := e.Namespace().Name; got != "urn:routing" {
+		t.Errorf("Namespace: got %s, want urn:routing", got)
+	}
+	if got, err := e.InstantiatingModule(); err != nil || got != "routing" {
+		t.Errorf("InstantiatingModule: got %s, %v, want routing", got, err)
+	}
+	if got := root.Namespace().Name; got != "urn:host" {
+		t.Errorf("Namespace of the mount point: got %s, want urn:host", got)
+	}
+	if e.Modules() != mounted {
+		t.Errorf("Modules of a mounted node is not the mounted schema")
+	}
+	if !e.ReadOnly() || root.ReadOnly() {
+		t.Errorf("ReadOnly: got %v for the mounted leaf and %v for the mount point, want true and false", e.ReadOnly(), root.ReadOnly())
+	}
+	if !e.Parent.IsMounted() || e.IsMounted() {
+		t.Errorf("IsMounted: only the top-level mounted node is mounted")
+	}
+
+	// Absolute paths within the mounted schema start at the mount point.
+	if got := e.Find("/rt:routing/ref"); got == nil || got.Parent != e.Parent {
+		t.Errorf("absolute Find from a mounted node: got %v, want the mounted ref leaf", got)
+	}
+	if got := e.Find("../../../name"); got == nil || got.Path() != "/host/instances/instance/name" {
+		t.Errorf("relative Find across the mount point: got %v, want the name leaf", got)
+	}
+}
+
+func TestMountErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		mount   func(t *testing.T, ms *Modules) *Mount
+		wantErr string
+	}{{
+		desc: "unknown label",
+		mount: func(t *testing.T, _ *Modules) *Mount {
+			return &Mount{Module: "host", Label: "other", Schema: mountTest(t, routingModule)}
+		},
+		wantErr: "mount point host:other not found",
+	}, {
+		desc: "wrong module",
+		mount: func(t *testing.T, _ *Modules) *Mount {
+			return &Mount{Module: "routing", Label: "vrf-root", Schema: mountTest(t, routingModule)}
+		},
+		wantErr: "mount point routing:vrf-root not found",
+	}, {
+		desc: "no schema",
+		mount: func(*testing.T, *Modules) *Mount {
+			return &Mount{Module: "host", Label: "vrf-root"}
+		},
+		wantErr: "no schema to mount",
+	}, {
+		desc: "mounted twice",
+		mount: func(t *testing.T, ms *Modules) *Mount {
+			m := &Mount{Module: "host", Label: "vrf-root", Schema: mountTest(t, routingModule)}
+			if err := ms.Mount(m); err != nil {
+				t.Fatal(err)
+			}
+			return m
+		},
+		wantErr: "mounted node routing already exists",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := mountTest(t, schemaMountModule, hostModule)
+			err := ms.Mount(tt.mount(t, ms))
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
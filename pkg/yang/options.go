@@ -30,4 +30,31 @@ type Options struct {
 
 	// IgnoreModuleResolveErrors specifies if module resolution errors can be ignored.
 	IgnoreModuleResolveErrors bool
+
+	// Features, when not nil, specifies the features supported for each
+	// module.  Nodes, enums, bits and identities whose if-feature statements
+	// evaluate to false are removed from the Entry tree before augments and
+	// deviations are applied.  When nil, all features are supported.
+	Features FeatureSet
+
+	// DatastoreDeviations maps NMDA datastores to the names of the modules
+	// whose deviations only apply to the schema of that datastore.  The
+	// deviations of these modules, and their submodules, are not applied
+	// to the Entry tree but to the views of the datastore returned by
+	// (*Entry).View.
+	DatastoreDeviations map[Datastore][]string
+}
+
+// datastoreDeviations returns true if the deviations of the module or
+// submodule m only apply to the views of a datastore.
+func (o Options) datastoreDeviations(m *Module) bool {
+	name := moduleName(m)
+	for _, names := range o.DatastoreDeviations {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -20,7 +20,6 @@ package yang
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -42,6 +41,15 @@ type parser struct {
 	// hitBrace is updated with the file, line, and column of the brace's
 	// location.
 	hitBrace *Statement
+
+	// When comments are kept, pending holds the comments not yet attached
+	// to a statement, the last of which ends on line pendingEnd, and last
+	// is the statement whose final token, on line lastLine, was the most
+	// recent token read.
+	pending    []string
+	pendingEnd int
+	last       *Statement
+	lastLine   int
 }
 
 // Statement is a generic YANG statement that may have sub-statements.
@@ -57,6 +65,11 @@ type Statement struct {
 	Argument    string
 	statements  []*Statement
 
+	// Comments are only kept when parsing for Format.
+	comments     []string // comments on the lines before the statement, "" for a blank line
+	lineComments []string // comments after the statement on its last line
+	endComments  []string // comments before the closing brace of the statement
+
 	file string
 	line int // 1's based line number
 	col  int // 1's based column number
@@ -77,16 +90,7 @@ func (s *Statement) SubStatements() []*Statement { return s.statements }
 
 // Location returns the location in the source where s was defined.
 func (s *Statement) Location() string {
-	switch {
-	case s.file == "" && s.line == 0:
-		return "unknown"
-	case s.file == "":
-		return fmt.Sprintf("line %d:%d", s.line, s.col)
-	case s.line == 0:
-		return s.file
-	default:
-		return fmt.Sprintf("%s:%d:%d", s.file, s.line, s.col)
-	}
+	return Location{File: s.file, Line: s.line, Col: s.col}.String()
 }
 
 // Write writes the tree in s to w, each line indented by ident.  Children
@@ -154,31 +158,109 @@ var ignoreMe = &Statement{}
 // encountered, nil and an error are returned.  The error's text includes all
 // errors encountered.
 func Parse(input, path string) ([]*Statement, error) {
-	var statements []*Statement
+	root, err := parse(input, path, false)
+	if err != nil {
+		return nil, err
+	}
+	return root.statements, nil
+}
+
+// parse parses input and returns a statement without a keyword holding the
+// top level statements.  Comments are attached to the statements when
+// comments is set.
+func parse(input, path string, comments bool) (*Statement, error) {
+	root := &Statement{}
 	p := &parser{
 		lex:      newLexer(input, path),
 		errout:   &bytes.Buffer{},
 		hitBrace: &Statement{},
 	}
 	p.lex.errout = p.errout
+	p.lex.comments = comments
 Loop:
 	for {
 		switch ns := p.nextStatement(); ns {
 		case nil:
 			break Loop
 		case p.hitBrace:
-			fmt.Fprintf(p.errout, "%s:%d:%d: unexpected %c\n", ns.file, ns.line, ns.col, '}')
+			p.errorf(Location{File: ns.file, Line: ns.line, Col: ns.col}, "unexpected %c", '}')
 		default:
-			statements = append(statements, ns)
+			root.statements = append(root.statements, ns)
 		}
 	}
+	root.endComments = p.takeComments()
 
 	p.checkStatementDepthIsZero()
 
 	if p.errout.Len() == 0 {
-		return statements, nil
+		return root, nil
 	}
-	return nil, errors.New(strings.TrimSpace(p.errout.String()))
+	return nil, Diagnostics(p.lex.diags)
+}
+
+// errorf writes the syntax error at loc formatted from format and v to
+// p.errout and records its diagnostic.
+func (p *parser) errorf(loc Location, format string, v ...interface{}) {
+	d := &Diagnostic{
+		Location: loc,
+		Severity: SeverityError,
+		Code:     CodeSyntax,
+		Message:  fmt.Sprintf(format, v...),
+	}
+	fmt.Fprintln(p.errout, d.Error())
+	p.lex.diags = append(p.lex.diags, d)
+}
+
+// tokenLocation returns the location of t.
+func tokenLocation(t *token) Location {
+	return Location{File: t.File, Line: t.Line, Col: t.Col}
+}
+
+// tokenText returns the text of t, or its code if it has none.
+func tokenText(t *token) string {
+	if t.Text == "" {
+		return t.code.String()
+	}
+	return t.Text
+}
+
+// comment records the comment t.  A comment on the same line as the end of
+// the previous statement belongs to that statement, any other comment
+// belongs to the statement that follows it.  A blank line between two
+// comments that belong to the same statement is kept as an empty comment.
+func (p *parser) comment(t *token) {
+	text := commentText(t)
+	if p.last != nil && t.Line == p.lastLine {
+		p.last.lineComments = append(p.last.lineComments, text)
+		return
+	}
+	if len(p.pending) > 0 && t.Line > p.pendingEnd+1 {
+		p.pending = append(p.pending, "")
+	}
+	p.pending = append(p.pending, text)
+	p.pendingEnd = t.Line + strings.Count(t.Text, "\n")
+}
+
+// takeComments returns and clears the pending comments.
+func (p *parser) takeComments() []string {
+	c := p.pending
+	p.pending = nil
+	return c
+}
+
+// commentText returns the text of the comment t.  The indentation of the
+// lines of a multi-line comment is made relative to the start of the
+// comment.
+func commentText(t *token) string {
+	lines := strings.Split(t.Text, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		for n := 1; n < t.Col && line != "" && (line[0] == ' ' || line[0] == '\t'); n++ {
+			line = line[1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
 }
 
 // push pushes tokens t back on the input stream so they will be the next
@@ -207,7 +289,11 @@ func (p *parser) next() *token {
 	// next returns the next unprocessed lexer token.
 	next := func() *token {
 		for {
-			if t := p.lex.NextToken(); t.Code() != tError {
+			switch t := p.lex.NextToken(); t.Code() {
+			case tError:
+			case tComment:
+				p.comment(t)
+			default:
 				return t
 			}
 		}
@@ -267,17 +353,19 @@ func (p *parser) nextStatement() *Statement {
 		return p.hitBrace
 	case tUnquoted:
 	default:
-		fmt.Fprintf(p.errout, "%v: keyword token not an unquoted string\n", t)
+		p.errorf(tokenLocation(t), "%s: keyword token not an unquoted string", tokenText(t))
 		return ignoreMe
 	}
 	// Invariant: t represents a keyword token.
 
 	s := &Statement{
-		Keyword: t.Text,
-		file:    t.File,
-		line:    t.Line,
-		col:     t.Col,
+		Keyword:  t.Text,
+		comments: p.takeComments(),
+		file:     t.File,
+		line:     t.Line,
+		col:      t.Col,
 	}
+	p.last = nil
 
 	// The keyword "pattern" must be treated specially. When
 	// parsing the argument for "pattern", escape sequences
@@ -294,9 +382,10 @@ func (p *parser) nextStatement() *Statement {
 
 	switch t.Code() {
 	case tEOF:
-		fmt.Fprintf(p.errout, "%s: unexpected EOF\n", s.file)
+		p.errorf(Location{File: s.file}, "unexpected EOF")
 		return nil
 	case ';':
+		p.last, p.lastLine = s, t.Line
 		return s
 	case '{':
 		p.statementDepth += 1
@@ -306,13 +395,15 @@ func (p *parser) nextStatement() *Statement {
 				// Signal EOF reached.
 				return nil
 			case p.hitBrace:
+				s.endComments = p.takeComments()
+				p.last, p.lastLine = s, ns.line
 				return s
 			default:
 				s.statements = append(s.statements, ns)
 			}
 		}
 	default:
-		fmt.Fprintf(p.errout, "%v: syntax error, expected ';' or '{'\n", t)
+		p.errorf(tokenLocation(t), "%s: syntax error, expected ';' or '{'", tokenText(t))
 		return ignoreMe
 	}
 }
@@ -333,6 +424,5 @@ func (p *parser) checkStatementDepthIsZero() {
 	if p.statementDepth > 1 {
 		plural = "s"
 	}
-	fmt.Fprintf(p.errout, "%s:%d:%d: missing %d closing brace%s\n",
-		p.lex.file, p.lex.line, p.lex.col, p.statementDepth, plural)
+	p.errorf(Location{File: p.lex.file, Line: p.lex.line, Col: p.lex.col}, "missing %d closing brace%s", p.statementDepth, plural)
 }
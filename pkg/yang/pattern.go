@@ -0,0 +1,668 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file translates the XML Schema regular expressions used by the
+// pattern statement (RFC 7950 section 9.4.5, defined in appendix F of XML
+// Schema Part 2) into the RE2 syntax accepted by Go's regexp package.
+//
+// Character classes are evaluated into explicit sets of code points, which
+// allows character class subtraction, the XSD multi-character escapes, and
+// the Unicode block escapes (\p{IsBasicLatin}), none of which RE2 has.
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A CompiledPattern is a pattern statement compiled into a Go regular
+// expression.
+type CompiledPattern struct {
+	Pattern string         // the XSD pattern as written in the module
+	Invert  bool           // set by "modifier invert-match"
+	Regexp  *regexp.Regexp // the anchored translation of Pattern
+}
+
+// CompilePattern compiles the XSD regular expression pattern.  If invert is
+// set the pattern is an invert-match pattern.
+func CompilePattern(pattern string, invert bool) (*CompiledPattern, error) {
+	s, err := TranslatePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledPattern{Pattern: pattern, Invert: invert, Regexp: re}, nil
+}
+
+// MatchString reports whether s satisfies p, that is, s matches p or, if p is
+// an invert-match pattern, s does not match p.
+func (p *CompiledPattern) MatchString(s string) bool {
+	return p.Regexp.MatchString(s) != p.Invert
+}
+
+// PatternMismatch returns the first compiled pattern of y that s does not
+// satisfy, or nil if s satisfies all of them.
+func (y *YangType) PatternMismatch(s string) *CompiledPattern {
+	for _, p := range y.CompiledPatterns {
+		if !p.MatchString(s) {
+			return p
+		}
+	}
+	return nil
+}
+
+// TranslatePattern returns the RE2 equivalent of the XSD regular expression
+// pattern.  The result is anchored at both ends as an XSD regular expression
+// always matches the entire string.
+func TranslatePattern(pattern string) (string, error) {
+	p := &xsdParser{s: pattern}
+	p.b.WriteString("^(?:")
+	if err := p.regExp(); err != nil {
+		return "", err
+	}
+	if p.pos < len(p.s) {
+		return "", p.errorf("unmatched )")
+	}
+	p.b.WriteString(")$")
+	return p.b.String(), nil
+}
+
+// An xsdParser translates an XSD regular expression using recursive descent
+// over the grammar in appendix F of XML Schema Part 2.
+type xsdParser struct {
+	s   string
+	pos int // byte offset of the next rune in s
+	b   strings.Builder
+}
+
+func (p *xsdParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+// peek returns the next rune without consuming it, or -1 at the end.
+func (p *xsdParser) peek() rune {
+	if p.pos >= len(p.s) {
+		return -1
+	}
+	r, _ := utf8.DecodeRuneInString(p.s[p.pos:])
+	return r
+}
+
+// peekByte returns the byte at offset off from the next rune, or 0.
+func (p *xsdParser) peekByte(off int) byte {
+	if p.pos+off >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos+off]
+}
+
+// next consumes and returns the next rune, or -1 at the end.
+func (p *xsdParser) next() rune {
+	if p.pos >= len(p.s) {
+		return -1
+	}
+	r, n := utf8.DecodeRuneInString(p.s[p.pos:])
+	p.pos += n
+	return r
+}
+
+// regExp parses branches separated by | up to the end of the pattern or an
+// unmatched ).
+func (p *xsdParser) regExp() error {
+	for {
+		if err := p.branch(); err != nil {
+			return err
+		}
+		if p.peek() != '|' {
+			return nil
+		}
+		p.next()
+		p.b.WriteByte('|')
+	}
+}
+
+func (p *xsdParser) branch() error {
+	for {
+		switch p.peek() {
+		case -1, '|', ')':
+			return nil
+		}
+		if err := p.piece(); err != nil {
+			return err
+		}
+	}
+}
+
+// piece parses an atom with an optional quantifier.
+func (p *xsdParser) piece() error {
+	if err := p.atom(); err != nil {
+		return err
+	}
+	switch r := p.peek(); r {
+	case '?', '*', '+':
+		p.next()
+		p.b.WriteRune(r)
+	case '{':
+		if err := p.quantity(); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	// XSD has neither lazy quantifiers nor repeated quantifiers.
+	switch p.peek() {
+	case '?', '*', '+', '{':
+		return p.errorf("invalid nested repetition operator")
+	}
+	return nil
+}
+
+// quantity parses a {n}, {n,} or {n,m} quantifier.
+func (p *xsdParser) quantity() error {
+	p.next()
+	min := p.digits()
+	if min == "" {
+		return p.errorf("missing repetition count")
+	}
+	q := min
+	if p.peek() == ',' {
+		p.next()
+		max := p.digits()
+		q += "," + max
+		if max != "" {
+			n, _ := strconv.Atoi(min)
+			m, err := strconv.Atoi(max)
+			if err != nil || m < n {
+				return p.errorf("invalid repetition count {%s}", q)
+			}
+		}
+	}
+	if p.next() != '}' {
+		return p.errorf("missing } in quantifier")
+	}
+	p.b.WriteString("{" + q + "}")
+	return nil
+}
+
+func (p *xsdParser) digits() string {
+	start := p.pos
+	for p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *xsdParser) atom() error {
+	switch r := p.next(); r {
+	case '(':
+		p.b.WriteString("(?:")
+		if err := p.regExp(); err != nil {
+			return err
+		}
+		if p.next() != ')' {
+			return p.errorf("missing )")
+		}
+		p.b.WriteByte(')')
+	case '[':
+		set, err := p.charClassExpr()
+		if err != nil {
+			return err
+		}
+		p.b.WriteString(set.String())
+	case '.':
+		p.b.WriteString(`[^\n\r]`)
+	case '\\':
+		c, set, err := p.escape()
+		if err != nil {
+			return err
+		}
+		if set != nil {
+			p.b.WriteString(set.String())
+		} else {
+			p.b.WriteString(quoteRune(c))
+		}
+	case '?', '*', '+', '{':
+		return p.errorf("missing argument to repetition operator %q", r)
+	case ']', '}':
+		return p.errorf("unescaped %q", r)
+	default:
+		// Everything else, including ^ and $, is an ordinary character.
+		p.b.WriteString(quoteRune(r))
+	}
+	return nil
+}
+
+// escape parses the escape sequence following a \.  It returns either the
+// single character denoted by the escape or, for a multi-character or
+// category escape, the set of characters it matches.
+func (p *xsdParser) escape() (rune, runeSet, error) {
+	switch r := p.next(); r {
+	case 'n':
+		return '\n', nil, nil
+	case 'r':
+		return '\r', nil, nil
+	case 't':
+		return '\t', nil, nil
+	case '\\', '|', '.', '?', '*', '+', '(', ')', '{', '}', '-', '[', ']', '^':
+		return r, nil, nil
+	case 's', 'S', 'i', 'I', 'c', 'C', 'd', 'D', 'w', 'W':
+		return 0, multiCharEscape(r), nil
+	case 'p', 'P':
+		set, err := p.property()
+		if err != nil {
+			return 0, nil, err
+		}
+		if r == 'P' {
+			set = set.negate()
+		}
+		return 0, set, nil
+	case -1:
+		return 0, nil, p.errorf("trailing \\")
+	default:
+		return 0, nil, p.errorf("invalid escape sequence \\%c", r)
+	}
+}
+
+// property parses the {name} following \p or \P and returns the characters
+// of the named category or block.
+func (p *xsdParser) property() (runeSet, error) {
+	if p.next() != '{' {
+		return nil, p.errorf("missing { after \\p")
+	}
+	end := strings.IndexByte(p.s[p.pos:], '}')
+	if end < 0 {
+		return nil, p.errorf("missing } after \\p{")
+	}
+	name := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	if strings.HasPrefix(name, "Is") {
+		if set, ok := unicodeBlocks[name[2:]]; ok {
+			return set, nil
+		}
+		return nil, p.errorf("unknown Unicode block %q", name)
+	}
+	set, ok := categorySet(name)
+	if !ok {
+		return nil, p.errorf("unknown Unicode category %q", name)
+	}
+	return set, nil
+}
+
+// charClassExpr parses a character class expression following its [, up to
+// and including its ].
+func (p *xsdParser) charClassExpr() (runeSet, error) {
+	negate := false
+	if p.peek() == '^' {
+		p.next()
+		negate = true
+	}
+	var set runeSet
+	empty := true
+	for {
+		r := p.peek()
+		switch {
+		case r == -1:
+			return nil, p.errorf("missing ]")
+		case r == ']':
+			if empty {
+				return nil, p.errorf("empty character class")
+			}
+			p.next()
+			if negate {
+				set = set.negate()
+			}
+			return set, nil
+		case r == '-' && p.peekByte(1) == '[' && !empty:
+			// A subtraction must be the last part of the group.
+			p.pos += 2
+			sub, err := p.charClassExpr()
+			if err != nil {
+				return nil, err
+			}
+			if p.next() != ']' {
+				return nil, p.errorf("character class subtraction must be last")
+			}
+			if negate {
+				set = set.negate()
+			}
+			return set.subtract(sub), nil
+		case r == '[':
+			return nil, p.errorf("unescaped [ in character class")
+		}
+		empty = false
+
+		lo, s, err := p.classChar()
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			set = set.union(s)
+			continue
+		}
+		hi := lo
+		if p.peek() == '-' && p.peekByte(1) != ']' && p.peekByte(1) != '[' {
+			p.next()
+			if hi, s, err = p.classChar(); err != nil {
+				return nil, err
+			}
+			if s != nil {
+				return nil, p.errorf("invalid character class range")
+			}
+			if hi < lo {
+				return nil, p.errorf("invalid character class range %c-%c", lo, hi)
+			}
+		}
+		set = set.union(runeSet{{lo, hi}})
+	}
+}
+
+// classChar parses a single character, or a character class escape, in a
+// character class.
+func (p *xsdParser) classChar() (rune, runeSet, error) {
+	switch r := p.next(); r {
+	case '\\':
+		return p.escape()
+	case '[', ']', -1:
+		return 0, nil, p.errorf("invalid character class range")
+	default:
+		return r, nil, nil
+	}
+}
+
+// A runeSet is a sorted list of non-overlapping, non-adjacent ranges of code
+// points.  The result of a set operation is never nil, a nil runeSet is used
+// to mean no set at all.
+type runeSet []runeRange
+
+type runeRange struct {
+	lo, hi rune
+}
+
+// union returns the code points in either s or t.
+func (s runeSet) union(t runeSet) runeSet {
+	all := append(append(runeSet{}, s...), t...)
+	sort.Slice(all, func(i, j int) bool { return all[i].lo < all[j].lo })
+	out := runeSet{}
+	for _, r := range all {
+		if n := len(out); n > 0 && r.lo <= out[n-1].hi+1 {
+			if r.hi > out[n-1].hi {
+				out[n-1].hi = r.hi
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// negate returns the code points not in s.
+func (s runeSet) negate() runeSet {
+	out := runeSet{}
+	next := rune(0)
+	for _, r := range s {
+		if r.lo > next {
+			out = append(out, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, runeRange{next, unicode.MaxRune})
+	}
+	return out
+}
+
+// subtract returns the code points in s that are not in t.
+func (s runeSet) subtract(t runeSet) runeSet {
+	return s.negate().union(t).negate()
+}
+
+// String returns s as an RE2 character class.
+func (s runeSet) String() string {
+	if len(s) == 1 && s[0].lo == s[0].hi {
+		return quoteRune(s[0].lo)
+	}
+	if len(s) == 0 {
+		return `[^\x00-\x{10FFFF}]`
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, r := range s {
+		writeClassRune(&b, r.lo)
+		if r.hi > r.lo {
+			b.WriteByte('-')
+			writeClassRune(&b, r.hi)
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// quoteRune returns r as an RE2 literal.
+func quoteRune(r rune) string {
+	if unicode.IsPrint(r) {
+		return regexp.QuoteMeta(string(r))
+	}
+	return fmt.Sprintf(`\x{%X}`, r)
+}
+
+func writeClassRune(b *strings.Builder, r rune) {
+	if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+		b.WriteRune(r)
+		return
+	}
+	fmt.Fprintf(b, `\x{%X}`, r)
+}
+
+// tableSet returns the code points in t.
+func tableSet(t *unicode.RangeTable) runeSet {
+	var s runeSet
+	add := func(lo, hi, stride rune) {
+		if stride == 1 {
+			s = append(s, runeRange{lo, hi})
+			return
+		}
+		for c := lo; c <= hi; c += stride {
+			s = append(s, runeRange{c, c})
+		}
+	}
+	for _, r := range t.R16 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range t.R32 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return runeSet{}.union(s)
+}
+
+// xsdCategories are the Unicode general categories that may be named by \p.
+var xsdCategories = map[string]bool{
+	"L": true, "Lu": true, "Ll": true, "Lt": true, "Lm": true, "Lo": true,
+	"M": true, "Mn": true, "Mc": true, "Me": true,
+	"N": true, "Nd": true, "Nl": true, "No": true,
+	"P": true, "Pc": true, "Pd": true, "Ps": true, "Pe": true, "Pi": true, "Pf": true, "Po": true,
+	"Z": true, "Zs": true, "Zl": true, "Zp": true,
+	"S": true, "Sm": true, "Sc": true, "Sk": true, "So": true,
+	"C": true, "Cc": true, "Cf": true, "Co": true, "Cn": true,
+}
+
+// categorySet returns the code points in the Unicode general category name.
+func categorySet(name string) (runeSet, bool) {
+	if !xsdCategories[name] {
+		return nil, false
+	}
+	// Go has no table for Cn, the unassigned code points.
+	var assigned runeSet
+	if name == "Cn" || name == "C" {
+		for _, t := range unicode.Categories {
+			assigned = assigned.union(tableSet(t))
+		}
+	}
+	switch name {
+	case "Cn":
+		return assigned.negate(), true
+	case "C":
+		return tableSet(unicode.C).union(assigned.negate()), true
+	}
+	return tableSet(unicode.Categories[name]), true
+}
+
+func mustCategory(names ...string) runeSet {
+	var set runeSet
+	for _, name := range names {
+		s, _ := categorySet(name)
+		set = set.union(s)
+	}
+	return set
+}
+
+func runes(rs ...rune) runeSet {
+	var s runeSet
+	for _, r := range rs {
+		s = append(s, runeRange{r, r})
+	}
+	return runeSet{}.union(s)
+}
+
+var (
+	multiCharOnce    sync.Once
+	multiCharEscapes map[rune]runeSet
+)
+
+// multiCharEscape returns the set of the XSD multi-character escape \r.
+func multiCharEscape(r rune) runeSet {
+	multiCharOnce.Do(func() {
+		multiCharEscapes = map[rune]runeSet{
+			's': runes(' ', '\t', '\n', '\r'),
+			'i': mustCategory("L").union(runes('_', ':')),
+			'c': mustCategory("L", "Nd", "Nl", "Mn", "Mc").union(runes('.', '-', '_', ':', 0xB7)),
+			'd': mustCategory("Nd"),
+			'w': mustCategory("P", "Z", "C").negate(),
+		}
+	})
+	if unicode.IsUpper(r) {
+		return multiCharEscapes[unicode.ToLower(r)].negate()
+	}
+	return multiCharEscapes[r]
+}
+
+// unicodeBlocks are the Unicode blocks that may be named by \p{IsBlock},
+// with the names used by XML Schema.
+var unicodeBlocks = map[string]runeSet{
+	"BasicLatin":                           {{0x0000, 0x007F}},
+	"Latin-1Supplement":                    {{0x0080, 0x00FF}},
+	"LatinExtended-A":                      {{0x0100, 0x017F}},
+	"LatinExtended-B":                      {{0x0180, 0x024F}},
+	"IPAExtensions":                        {{0x0250, 0x02AF}},
+	"SpacingModifierLetters":               {{0x02B0, 0x02FF}},
+	"CombiningDiacriticalMarks":            {{0x0300, 0x036F}},
+	"Greek":                                {{0x0370, 0x03FF}},
+	"Cyrillic":                             {{0x0400, 0x04FF}},
+	"Armenian":                             {{0x0530, 0x058F}},
+	"Hebrew":                               {{0x0590, 0x05FF}},
+	"Arabic":                               {{0x0600, 0x06FF}},
+	"Syriac":                               {{0x0700, 0x074F}},
+	"Thaana":                               {{0x0780, 0x07BF}},
+	"Devanagari":                           {{0x0900, 0x097F}},
+	"Bengali":                              {{0x0980, 0x09FF}},
+	"Gurmukhi":                             {{0x0A00, 0x0A7F}},
+	"Gujarati":                             {{0x0A80, 0x0AFF}},
+	"Oriya":                                {{0x0B00, 0x0B7F}},
+	"Tamil":                                {{0x0B80, 0x0BFF}},
+	"Telugu":                               {{0x0C00, 0x0C7F}},
+	"Kannada":                              {{0x0C80, 0x0CFF}},
+	"Malayalam":                            {{0x0D00, 0x0D7F}},
+	"Sinhala":                              {{0x0D80, 0x0DFF}},
+	"Thai":                                 {{0x0E00, 0x0E7F}},
+	"Lao":                                  {{0x0E80, 0x0EFF}},
+	"Tibetan":                              {{0x0F00, 0x0FFF}},
+	"Myanmar":                              {{0x1000, 0x109F}},
+	"Georgian":                             {{0x10A0, 0x10FF}},
+	"HangulJamo":                           {{0x1100, 0x11FF}},
+	"Ethiopic":                             {{0x1200, 0x137F}},
+	"Cherokee":                             {{0x13A0, 0x13FF}},
+	"UnifiedCanadianAboriginalSyllabics":   {{0x1400, 0x167F}},
+	"Ogham":                                {{0x1680, 0x169F}},
+	"Runic":                                {{0x16A0, 0x16FF}},
+	"Khmer":                                {{0x1780, 0x17FF}},
+	"Mongolian":                            {{0x1800, 0x18AF}},
+	"LatinExtendedAdditional":              {{0x1E00, 0x1EFF}},
+	"GreekExtended":                        {{0x1F00, 0x1FFF}},
+	"GeneralPunctuation":                   {{0x2000, 0x206F}},
+	"SuperscriptsandSubscripts":            {{0x2070, 0x209F}},
+	"CurrencySymbols":                      {{0x20A0, 0x20CF}},
+	"CombiningMarksforSymbols":             {{0x20D0, 0x20FF}},
+	"LetterlikeSymbols":                    {{0x2100, 0x214F}},
+	"NumberForms":                          {{0x2150, 0x218F}},
+	"Arrows":                               {{0x2190, 0x21FF}},
+	"MathematicalOperators":                {{0x2200, 0x22FF}},
+	"MiscellaneousTechnical":               {{0x2300, 0x23FF}},
+	"ControlPictures":                      {{0x2400, 0x243F}},
+	"OpticalCharacterRecognition":          {{0x2440, 0x245F}},
+	"EnclosedAlphanumerics":                {{0x2460, 0x24FF}},
+	"BoxDrawing":                           {{0x2500, 0x257F}},
+	"BlockElements":                        {{0x2580, 0x259F}},
+	"GeometricShapes":                      {{0x25A0, 0x25FF}},
+	"MiscellaneousSymbols":                 {{0x2600, 0x26FF}},
+	"Dingbats":                             {{0x2700, 0x27BF}},
+	"BraillePatterns":                      {{0x2800, 0x28FF}},
+	"CJKRadicalsSupplement":                {{0x2E80, 0x2EFF}},
+	"KangxiRadicals":                       {{0x2F00, 0x2FDF}},
+	"IdeographicDescriptionCharacters":     {{0x2FF0, 0x2FFF}},
+	"CJKSymbolsandPunctuation":             {{0x3000, 0x303F}},
+	"Hiragana":                             {{0x3040, 0x309F}},
+	"Katakana":                             {{0x30A0, 0x30FF}},
+	"Bopomofo":                             {{0x3100, 0x312F}},
+	"HangulCompatibilityJamo":              {{0x3130, 0x318F}},
+	"Kanbun":                               {{0x3190, 0x319F}},
+	"BopomofoExtended":                     {{0x31A0, 0x31BF}},
+	"EnclosedCJKLettersandMonths":          {{0x3200, 0x32FF}},
+	"CJKCompatibility":                     {{0x3300, 0x33FF}},
+	"CJKUnifiedIdeographsExtensionA":       {{0x3400, 0x4DBF}},
+	"CJKUnifiedIdeographs":                 {{0x4E00, 0x9FFF}},
+	"YiSyllables":                          {{0xA000, 0xA48F}},
+	"YiRadicals":                           {{0xA490, 0xA4CF}},
+	"HangulSyllables":                      {{0xAC00, 0xD7AF}},
+	"HighSurrogates":                       {}, // surrogates never appear in Go strings
+	"HighPrivateUseSurrogates":             {},
+	"LowSurrogates":                        {},
+	"PrivateUse":                           {{0xE000, 0xF8FF}, {0xF0000, 0xFFFFD}, {0x100000, 0x10FFFD}},
+	"CJKCompatibilityIdeographs":           {{0xF900, 0xFAFF}},
+	"AlphabeticPresentationForms":          {{0xFB00, 0xFB4F}},
+	"ArabicPresentationForms-A":            {{0xFB50, 0xFDFF}},
+	"CombiningHalfMarks":                   {{0xFE20, 0xFE2F}},
+	"CJKCompatibilityForms":                {{0xFE30, 0xFE4F}},
+	"SmallFormVariants":                    {{0xFE50, 0xFE6F}},
+	"ArabicPresentationForms-B":            {{0xFE70, 0xFEFE}},
+	"Specials":                             {{0xFEFF, 0xFEFF}, {0xFFF0, 0xFFFF}},
+	"HalfwidthandFullwidthForms":           {{0xFF00, 0xFFEF}},
+	"OldItalic":                            {{0x10300, 0x1032F}},
+	"Gothic":                               {{0x10330, 0x1034F}},
+	"Deseret":                              {{0x10400, 0x1044F}},
+	"ByzantineMusicalSymbols":              {{0x1D000, 0x1D0FF}},
+	"MusicalSymbols":                       {{0x1D100, 0x1D1FF}},
+	"MathematicalAlphanumericSymbols":      {{0x1D400, 0x1D7FF}},
+	"CJKUnifiedIdeographsExtensionB":       {{0x20000, 0x2A6DF}},
+	"CJKCompatibilityIdeographsSupplement": {{0x2F800, 0x2FA1F}},
+	"Tags":                                 {{0xE0000, 0xE007F}},
+}
@@ -0,0 +1,270 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestTranslatePattern(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pattern string
+		want    string
+	}{{
+		desc:    "literal",
+		pattern: "abc",
+		want:    "^(?:abc)$",
+	}, {
+		desc:    "anchors are ordinary characters",
+		pattern: "^a.b$",
+		want:    `^(?:\^a[^\n\r]b\$)$`,
+	}, {
+		desc:    "groups and quantifiers",
+		pattern: "(ab|c){2,3}d?e*f+",
+		want:    "^(?:(?:ab|c){2,3}d?e*f+)$",
+	}, {
+		desc:    "character class",
+		pattern: "[a-c0-9_]",
+		want:    `^(?:[0-9\x{5F}a-c])$`,
+	}, {
+		desc:    "negated class",
+		pattern: "[^a]",
+		want:    `^(?:[\x{0}-\x{60}b-\x{10FFFF}])$`,
+	}, {
+		desc:    "subtraction",
+		pattern: "[a-z-[aeiou]]",
+		want:    `^(?:[b-df-hj-np-tv-z])$`,
+	}, {
+		desc:    "single character escapes",
+		pattern: `\n\.\-\[\^`,
+		want:    `^(?:\x{A}\.-\[\^)$`,
+	}, {
+		desc:    "whitespace escape",
+		pattern: `\s`,
+		want:    `^(?:[\x{9}-\x{A}\x{D}\x{20}])$`,
+	}, {
+		desc:    "dash at the ends of a class",
+		pattern: "[-a-]",
+		want:    `^(?:[\x{2D}a])$`,
+	}, {
+		desc:    "basic latin block",
+		pattern: `\p{IsBasicLatin}`,
+		want:    `^(?:[\x{0}-\x{7F}])$`,
+	}, {
+		desc:    "surrogate block is empty",
+		pattern: `a\p{IsLowSurrogates}?`,
+		want:    `^(?:a[^\x00-\x{10FFFF}]?)$`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := TranslatePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("TranslatePattern(%q): %v", tt.pattern, err)
+			}
+			if got != tt.want {
+				t.Errorf("TranslatePattern(%q) got %s, want %s", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	const (
+		ipv4     = `(([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])\.){3}([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])(%[\p{N}\p{L}]+)?`
+		domain   = `((([a-zA-Z0-9_]([a-zA-Z0-9\-_]){0,61})?[a-zA-Z0-9]\.)*([a-zA-Z0-9_]([a-zA-Z0-9\-_]){0,61})?[a-zA-Z0-9]\.?)|\.`
+		dateTime = `\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[\+\-]\d{2}:\d{2})`
+		ncName   = `[\i-[:]][\c-[:]]*`
+	)
+	tests := []struct {
+		pattern string
+		invert  bool
+		in      string
+		want    bool
+	}{
+		{ipv4, false, "192.0.2.1", true},
+		{ipv4, false, "192.0.2.1%eth0", true},
+		{ipv4, false, "192.0.2.256", false},
+		{ipv4, false, "192.0.2.1\n", false},
+		{domain, false, "www.example.com.", true},
+		{domain, false, ".", true},
+		{domain, false, "-bad.example", false},
+		{dateTime, false, "2021-01-02T03:04:05.6Z", true},
+		{dateTime, false, "2021-01-02T03:04:05+01:00", true},
+		{dateTime, false, "2021-01-02 03:04:05Z", false},
+		{ncName, false, "my-name_1", true},
+		{ncName, false, "ns:name", false},
+		{ncName, false, "1name", false},
+		{`\p{IsBasicLatin}*`, false, "plain ascii", true},
+		{`\p{IsBasicLatin}*`, false, "café", false},
+		{`\P{L}+`, false, "123 !", true},
+		{`\w+`, false, "abc123", true},
+		{`\w+`, false, "a-b", false},
+		{`\S+`, false, "a\tb", false},
+		{`.`, false, "\n", false},
+		{`[xX][mM][lL].*`, true, "xml-name", false},
+		{`[xX][mM][lL].*`, true, "name", true},
+		{`a|b`, false, "ab", false},
+	}
+
+	for _, tt := range tests {
+		p, err := CompilePattern(tt.pattern, tt.invert)
+		if err != nil {
+			t.Errorf("CompilePattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := p.MatchString(tt.in); got != tt.want {
+			t.Errorf("pattern %q (invert %t) matching %q got %t, want %t", tt.pattern, tt.invert, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr string
+	}{
+		{"a??", "invalid nested repetition operator at offset 2"},
+		{"*a", `missing argument to repetition operator '*' at offset 1`},
+		{"[a", "missing ] at offset 2"},
+		{"[]", "empty character class at offset 1"},
+		{"(a", "missing ) at offset 2"},
+		{"a)", "unmatched ) at offset 1"},
+		{"a]", `unescaped ']' at offset 2`},
+		{`\q`, `invalid escape sequence \q at offset 2`},
+		{`a\`, `trailing \ at offset 2`},
+		{`\p{IsKlingon}`, `unknown Unicode block "IsKlingon"`},
+		{`\p{Greek}`, `unknown Unicode category "Greek"`},
+		{"x{2,1}", "invalid repetition count {2,1}"},
+		{"x{,1}", "missing repetition count"},
+		{"[z-a]", "invalid character class range z-a"},
+		{"[a-[b]x]", "character class subtraction must be last"},
+		{"a{1001}", "invalid repeat count"},
+	}
+	for _, tt := range tests {
+		_, err := CompilePattern(tt.pattern, false)
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("CompilePattern(%q): %s", tt.pattern, diff)
+		}
+	}
+}
+
+func TestPatternStatements(t *testing.T) {
+	tests := []struct {
+		desc        string
+		in          string
+		wantErr     string
+		wantPattern []string
+		wantInvert  []string
+		match       map[string]bool
+	}{{
+		desc: "patterns and inverted patterns",
+		in: `
+module p {
+  yang-version 1.1;
+  namespace "urn:p";
+  prefix p;
+
+  typedef id {
+    type string {
+      pattern '[a-zA-Z_][a-zA-Z0-9\-_.]*';
+    }
+  }
+  leaf l {
+    type id {
+      pattern '[xX][mM][lL].*' {
+        modifier invert-match;
+      }
+    }
+  }
+}
+`,
+		wantPattern: []string{`[a-zA-Z_][a-zA-Z0-9\-_.]*`},
+		wantInvert:  []string{`[xX][mM][lL].*`},
+		match: map[string]bool{
+			"name":    true,
+			"XmlName": false,
+			"1name":   false,
+		},
+	}, {
+		desc: "untranslatable pattern",
+		in: `
+module p {
+  namespace "urn:p";
+  prefix p;
+
+  leaf l {
+    type string {
+      pattern '[a-';
+    }
+  }
+}
+`,
+		wantErr: "p.yang:8:7: bad pattern: invalid character class range at offset 3: [a-",
+	}, {
+		desc: "bad modifier",
+		in: `
+module p {
+  yang-version 1.1;
+  namespace "urn:p";
+  prefix p;
+
+  leaf l {
+    type string {
+      pattern 'a' {
+        modifier reverse;
+      }
+    }
+  }
+}
+`,
+		wantErr: `p.yang:10:9: invalid pattern modifier "reverse"`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(tt.in, "p.yang"); err != nil {
+				t.Fatalf("could not parse module: %v", err)
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Process: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			typ := ToEntry(ms.Modules["p"]).Dir["l"].Type
+			if !ssEqual(typ.Pattern, tt.wantPattern) {
+				t.Errorf("got patterns %q, want %q", typ.Pattern, tt.wantPattern)
+			}
+			if !ssEqual(typ.InvertPattern, tt.wantInvert) {
+				t.Errorf("got inverted patterns %q, want %q", typ.InvertPattern, tt.wantInvert)
+			}
+			for s, want := range tt.match {
+				if got := typ.PatternMismatch(s) == nil; got != want {
+					t.Errorf("%q matches patterns got %t, want %t", s, got, want)
+				}
+			}
+		})
+	}
+}
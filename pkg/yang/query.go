@@ -0,0 +1,387 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements queries of Entry trees.  A query is a schema node
+// identifier, as in /if:interfaces/if:interface, whose steps may be the
+// wildcard *, may be separated by // to match nodes at any depth below the
+// previous step, and may be followed by filters in square brackets.  For
+// example, the config true leaves of type leafref anywhere below /bgp are
+//
+//	/bgp//*[kind=leaf][config=true][type=leafref]
+//
+// A step matches the child nodes of the nodes matched by the previous step,
+// including choice and case nodes, which are part of schema node
+// identifiers.  The prefix of a step is either the prefix or the name of the
+// module that defines the node, a step without a prefix matches the nodes of
+// any module.  A filter is a KEY=VALUE or KEY!=VALUE pair, where VALUE may
+// list alternatives separated by |, as in [kind=leaf|leaf-list].  The keys
+// are:
+//
+//	kind    the keyword of the node, such as leaf, list or choice
+//	config  true or false, the config of a data node, nodes of RPCs,
+//	        actions and notifications have none
+//	type    the name of the type of the node or its built-in type
+//	status  current, deprecated or obsolete
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Query is a parsed query of Entry trees.
+type Query struct {
+	query    string
+	absolute bool
+	steps    []*queryStep
+}
+
+// A queryStep is a step of a query.
+type queryStep struct {
+	descendant bool   // the step follows //
+	prefix     string // prefix or module name, "" for any module
+	name       string // node name or "*"
+	filters    []*queryFilter
+}
+
+// A queryFilter is a filter of the nodes matched by a step.
+type queryFilter struct {
+	key    string
+	not    bool
+	values []string
+}
+
+// queryFilters are the functions that return the values of nodes that the
+// keys of filters select.  A node without a value never matches a filter.
+var queryFilters = map[string]func(e *Entry) []string{
+	"kind": func(e *Entry) []string {
+		if e.Node == nil {
+			return nil
+		}
+		return []string{e.Node.Kind()}
+	},
+	"config": func(e *Entry) []string {
+		if !isDataNode(e) {
+			return nil
+		}
+		return []string{fmt.Sprint(!e.ReadOnly())}
+	},
+	"type": func(e *Entry) []string {
+		if e.Type == nil {
+			return nil
+		}
+		return []string{e.Type.Name, e.Type.Kind.String()}
+	},
+	"status": func(e *Entry) []string {
+		return []string{e.Status.String()}
+	},
+}
+
+// A QueryResult is a node matched by a query.
+type QueryResult struct {
+	// Path is the path of the node from its module, where the name of a
+	// node is qualified by the name of its module if it is a top-level
+	// node or its module is not the module of its parent, as in
+	// /openconfig-bgp:bgp/global/ext:stats.
+	Path  string
+	Entry *Entry
+}
+
+// ParseQuery parses the query s.
+func ParseQuery(s string) (*Query, error) {
+	q := &Query{query: s}
+	rest := s
+	switch {
+	case strings.HasPrefix(rest, "//"):
+		q.absolute = true
+	case strings.HasPrefix(rest, "/"):
+		q.absolute = true
+		rest = rest[1:]
+	}
+	for rest != "" {
+		step := &queryStep{}
+		if strings.HasPrefix(rest, "//") {
+			step.descendant = true
+			rest = rest[2:]
+		}
+		var err error
+		if rest, err = step.parse(rest); err != nil {
+			return nil, fmt.Errorf("invalid query %q: %v", s, err)
+		}
+		q.steps = append(q.steps, step)
+		if rest == "" {
+			break
+		}
+		if rest[0] != '/' || rest == "/" {
+			return nil, fmt.Errorf("invalid query %q: unexpected %q", s, rest)
+		}
+		if !strings.HasPrefix(rest, "//") {
+			rest = rest[1:]
+		}
+	}
+	if len(q.steps) == 0 {
+		return nil, fmt.Errorf("invalid query %q: no steps", s)
+	}
+	return q, nil
+}
+
+// parse parses the name and filters of the step at the start of s and
+// returns the rest of s.
+func (st *queryStep) parse(s string) (string, error) {
+	i := strings.IndexAny(s, "/[")
+	if i < 0 {
+		i = len(s)
+	}
+	st.prefix, st.name = getPrefix(s[:i])
+	if st.name == "" || strings.Contains(st.name, ":") {
+		return "", fmt.Errorf("invalid step %q", s[:i])
+	}
+	s = s[i:]
+	for strings.HasPrefix(s, "[") {
+		i := strings.Index(s, "]")
+		if i < 0 {
+			return "", fmt.Errorf("missing ] in %q", s)
+		}
+		f, err := parseQueryFilter(s[1:i])
+		if err != nil {
+			return "", err
+		}
+		st.filters = append(st.filters, f)
+		s = s[i+1:]
+	}
+	return s, nil
+}
+
+// parseQueryFilter parses the filter s, the text within square brackets.
+func parseQueryFilter(s string) (*queryFilter, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid filter [%s], want [KEY=VALUE]", s)
+	}
+	f := &queryFilter{key: strings.TrimSpace(s[:i])}
+	if strings.HasSuffix(f.key, "!") {
+		f.not = true
+		f.key = strings.TrimSpace(strings.TrimSuffix(f.key, "!"))
+	}
+	if queryFilters[f.key] == nil {
+		return nil, fmt.Errorf("unknown filter %s", f.key)
+	}
+	for _, v := range strings.Split(s[i+1:], "|") {
+		f.values = append(f.values, strings.TrimSpace(v))
+	}
+	return f, nil
+}
+
+// String returns the query as it was parsed.
+func (q *Query) String() string {
+	return q.query
+}
+
+// Query returns the nodes matched by the query s relative to e, or to the
+// root of the tree of e if s is absolute.
+func (e *Entry) Query(s string) ([]*QueryResult, error) {
+	q, err := ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	return q.Eval(e), nil
+}
+
+// Eval returns the nodes matched by q in the trees of the entries, each of
+// which is the context of a relative query, or the root of whose tree is
+// the context of an absolute query.  The results are in tree order,
+// sorted by name within each node, and each node is only returned once.
+func (q *Query) Eval(entries ...*Entry) []*QueryResult {
+	var nodes []*Entry
+	for _, e := range entries {
+		if q.absolute {
+			for e.Parent != nil {
+				e = e.Parent
+			}
+		}
+		nodes = append(nodes, e)
+	}
+	for _, st := range q.steps {
+		seen := map[*Entry]bool{}
+		var next []*Entry
+		for _, n := range nodes {
+			for _, c := range st.candidates(n) {
+				if !seen[c] && st.matches(c) {
+					seen[c] = true
+					next = append(next, c)
+				}
+			}
+		}
+		nodes = next
+	}
+
+	results := make([]*QueryResult, len(nodes))
+	keys := make(map[*Entry][]string, len(nodes))
+	for i, n := range nodes {
+		results[i] = &QueryResult{Path: queryPath(n), Entry: n}
+		keys[n] = pathNames(n)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := keys[results[i].Entry], keys[results[j].Entry]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return results
+}
+
+// candidates returns the nodes the step may match in the context of n, its
+// children, or all of its descendants if the step follows //, in tree
+// order.
+func (st *queryStep) candidates(n *Entry) []*Entry {
+	var out []*Entry
+	var walk func(n *Entry)
+	walk = func(n *Entry) {
+		for _, c := range queryChildren(n) {
+			out = append(out, c)
+			if st.descendant {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return out
+}
+
+// matches returns true if the step matches the node e.
+func (st *queryStep) matches(e *Entry) bool {
+	if st.name != "*" && st.name != e.Name {
+		return false
+	}
+	if st.prefix != "" {
+		m := entryModule(e)
+		if m == nil || (st.prefix != m.Name && st.prefix != m.GetPrefix()) {
+			return false
+		}
+	}
+	for _, f := range st.filters {
+		if !f.matches(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns true if the node e passes the filter f.
+func (f *queryFilter) matches(e *Entry) bool {
+	got := queryFilters[f.key](e)
+	if got == nil {
+		return false
+	}
+	for _, v := range f.values {
+		for _, g := range got {
+			if v == g {
+				return !f.not
+			}
+		}
+	}
+	return f.not
+}
+
+// queryChildren returns the children of e sorted by name, including the
+// input and output of an RPC or action.
+func queryChildren(e *Entry) []*Entry {
+	children := make([]*Entry, 0, len(e.Dir)+2)
+	for _, c := range e.Dir {
+		children = append(children, c)
+	}
+	if e.RPC != nil {
+		for _, c := range []*Entry{e.RPC.Input, e.RPC.Output} {
+			if c != nil {
+				children = append(children, c)
+			}
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// isDataNode returns true if e is not an RPC, action or notification, or
+// within one.
+func isDataNode(e *Entry) bool {
+	for ; e != nil; e = e.Parent {
+		switch {
+		case e.RPC != nil, e.Kind == InputEntry, e.Kind == OutputEntry, e.Kind == NotificationEntry:
+			return false
+		}
+	}
+	return true
+}
+
+// entryModule returns the module that defines the namespace of e, or nil
+// if it is not known.
+func entryModule(e *Entry) *Module {
+	root := e
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	if _, ok := root.Node.(*Module); !ok {
+		return nil
+	}
+	ms := e.Modules()
+	ns := e.Namespace()
+	if ms == nil || ns == nil || ns.Name == "" {
+		return nil
+	}
+	m, err := ms.FindModuleByNamespace(ns.Name)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// pathNames returns the names of the nodes from the root of the tree of e
+// to e, leaving out the root.
+func pathNames(e *Entry) []string {
+	var names []string
+	for ; e.Parent != nil; e = e.Parent {
+		names = append(names, e.Name)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names
+}
+
+// queryPath returns the path of e for a QueryResult.
+func queryPath(e *Entry) string {
+	var parts []string
+	for ; e.Parent != nil; e = e.Parent {
+		name := e.Name
+		if m := entryModule(e); m != nil {
+			if pm := entryModule(e.Parent); e.Parent.Parent == nil || pm != m {
+				name = m.Name + ":" + name
+			}
+		}
+		parts = append(parts, name)
+	}
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString("/")
+		b.WriteString(parts[i])
+	}
+	return b.String()
+}
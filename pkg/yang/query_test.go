@@ -0,0 +1,190 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const queryModule = `module bgp {
+  namespace "urn:bgp";
+  prefix b;
+  container bgp {
+    container global {
+      leaf as { type uint32; }
+      leaf router-id { type leafref { path "../as"; } }
+      container state {
+        config false;
+        leaf peer { type leafref { path "../../as"; } }
+      }
+    }
+    list neighbor {
+      key address;
+      leaf address { type string; }
+      leaf peer-as { type leafref { path "../../global/as"; } }
+      leaf description { type string; status deprecated; }
+      choice transport {
+        case tcp { leaf port { type uint16; } }
+        leaf interface { type string; }
+      }
+    }
+  }
+  rpc clear {
+    input { leaf neighbor { type leafref { path "/bgp/neighbor/address"; } } }
+  }
+}
+`
+
+const queryAugmentModule = `module bgp-ext {
+  namespace "urn:bgp-ext";
+  prefix bx;
+  import bgp { prefix b; }
+  augment /b:bgp/b:global {
+    leaf mtu { type uint16; }
+  }
+}
+`
+
+func TestQuery(t *testing.T) {
+	ms := structureTest(t, queryModule, queryAugmentModule)
+	bgp, _ := ms.GetModule("bgp")
+
+	tests := []struct {
+		desc    string
+		in      string
+		want    []string
+		wantErr string
+	}{{
+		desc: "prefixed path",
+		in:   "/b:bgp/b:global/b:as",
+		want: []string{"/bgp:bgp/global/as"},
+	}, {
+		desc: "module name prefix",
+		in:   "/bgp:bgp/bgp-ext:global",
+	}, {
+		desc: "wildcard",
+		in:   "/bgp/global/*",
+		want: []string{
+			"/bgp:bgp/global/as",
+			"/bgp:bgp/global/bgp-ext:mtu",
+			"/bgp:bgp/global/router-id",
+			"/bgp:bgp/global/state",
+		},
+	}, {
+		desc: "prefixed wildcard",
+		in:   "/bgp/global/bx:*",
+		want: []string{"/bgp:bgp/global/bgp-ext:mtu"},
+	}, {
+		desc: "config leafrefs",
+		in:   "/bgp//*[kind=leaf][config=true][type=leafref]",
+		want: []string{"/bgp:bgp/global/router-id", "/bgp:bgp/neighbor/peer-as"},
+	}, {
+		desc: "all leafrefs",
+		in:   "//*[type=leafref]",
+		want: []string{
+			"/bgp:bgp/global/router-id",
+			"/bgp:bgp/global/state/peer",
+			"/bgp:bgp/neighbor/peer-as",
+			"/bgp:clear/input/neighbor",
+		},
+	}, {
+		desc: "state",
+		in:   "//*[config=false]",
+		want: []string{"/bgp:bgp/global/state", "/bgp:bgp/global/state/peer"},
+	}, {
+		desc: "choice and case",
+		in:   "//transport/*/*",
+		want: []string{"/bgp:bgp/neighbor/transport/interface/interface", "/bgp:bgp/neighbor/transport/tcp/port"},
+	}, {
+		desc: "kinds",
+		in:   "/bgp/*[kind=list|container]",
+		want: []string{"/bgp:bgp/global", "/bgp:bgp/neighbor"},
+	}, {
+		desc: "negated filter",
+		in:   "/bgp/neighbor/*[status!=current]",
+		want: []string{"/bgp:bgp/neighbor/description"},
+	}, {
+		desc: "descendant after step",
+		in:   "/bgp/global//peer",
+		want: []string{"/bgp:bgp/global/state/peer"},
+	}, {
+		desc: "relative",
+		in:   "neighbor/address",
+		want: []string{"/bgp:bgp/neighbor/address"},
+	}, {
+		desc:    "unknown filter",
+		in:      "//*[color=red]",
+		wantErr: "unknown filter color",
+	}, {
+		desc:    "bad filter",
+		in:      "//*[kind]",
+		wantErr: "want [KEY=VALUE]",
+	}, {
+		desc:    "missing bracket",
+		in:      "//*[kind=leaf",
+		wantErr: "missing ]",
+	}, {
+		desc:    "trailing slash",
+		in:      "/bgp/",
+		wantErr: "unexpected",
+	}, {
+		desc:    "empty",
+		in:      "/",
+		wantErr: "no steps",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := bgp
+			if tt.desc == "relative" {
+				ctx = bgp.Dir["bgp"]
+			}
+			results, err := ctx.Query(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Path)
+				if r.Entry == nil {
+					t.Errorf("%s: no entry", r.Path)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Query(%q) (-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestQueryEval(t *testing.T) {
+	ms := structureTest(t, queryModule, queryAugmentModule)
+	bgp, _ := ms.GetModule("bgp")
+	ext, _ := ms.GetModule("bgp-ext")
+	q, err := ParseQuery("/*[kind=container|rpc]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range q.Eval(ext, bgp, bgp) {
+		got = append(got, r.Path)
+	}
+	if diff := cmp.Diff([]string{"/bgp:bgp", "/bgp:clear"}, got); diff != "" {
+		t.Errorf("Eval (-want, +got):\n%s", diff)
+	}
+}
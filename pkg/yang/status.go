@@ -0,0 +1,184 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Status is the value of a status statement (RFC 7950 Section 7.21.2).
+// The zero value is StatusCurrent, the status of a definition without a
+// status statement.  Statuses are ordered, a greater Status is less
+// current.
+type Status int
+
+// The statuses of definitions.
+const (
+	StatusCurrent Status = iota
+	StatusDeprecated
+	StatusObsolete
+)
+
+var statusNames = map[Status]string{
+	StatusCurrent:    "current",
+	StatusDeprecated: "deprecated",
+	StatusObsolete:   "obsolete",
+}
+
+// String returns s as it is written in YANG.
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Status) UnmarshalText(b []byte) error {
+	for st, name := range statusNames {
+		if name == string(b) {
+			*s = st
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid status %q", b)
+}
+
+// parseStatus returns the Status given by the argument of the status
+// statement v.  StatusCurrent is returned if v is nil.
+func parseStatus(v *Value) (Status, error) {
+	if v == nil {
+		return StatusCurrent, nil
+	}
+	var s Status
+	if err := s.UnmarshalText([]byte(v.Name)); err != nil {
+		return StatusCurrent, diagnosticf(v, CodeInvalidValue, "%v", err)
+	}
+	return s, nil
+}
+
+// nodeStatus returns the status given by the status statement of n, if n
+// has one.  Invalid statuses are treated as current.
+func nodeStatus(n Node) Status {
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return StatusCurrent
+	}
+	f := v.Elem().FieldByName("Status")
+	if !f.IsValid() {
+		return StatusCurrent
+	}
+	sv, ok := f.Interface().(*Value)
+	if !ok {
+		return StatusCurrent
+	}
+	s, _ := parseStatus(sv)
+	return s
+}
+
+// effectiveStatus returns the status of n taking into account the
+// definitions n is nested in: a node within a deprecated container is
+// itself deprecated.
+func effectiveStatus(n Node) Status {
+	s := StatusCurrent
+	for ; n != nil; n = n.ParentNode() {
+		if ns := nodeStatus(n); ns > s {
+			s = ns
+		}
+	}
+	return s
+}
+
+// checkStatusReference returns an error if n, which references the
+// definition def, is more current than def and both are in the same module.
+// A current definition may not reference a deprecated or obsolete one, a
+// deprecated definition may not reference an obsolete one.  References to
+// other modules are not checked as their status may change independently.
+// The references checked are the types of leaves and typedefs, the
+// groupings of uses, the bases of identities and identityrefs, the targets
+// of augments and deviations and the targets of leafref paths.
+func checkStatusReference(n, def Node) error {
+	if n == nil || def == nil || !sameModule(n, def) {
+		return nil
+	}
+	ns, ds := effectiveStatus(n), effectiveStatus(def)
+	if ns >= ds {
+		return nil
+	}
+	return diagnosticf(n, CodeStatus, "%s %s %s references %s %s %s", ns, n.Kind(), n.NName(), ds, def.Kind(), def.NName()).related(def, "definition of "+def.NName())
+}
+
+// sameModule returns true if a and b are defined in the same module or its
+// submodules.
+func sameModule(a, b Node) bool {
+	ma, mb := RootNode(a), RootNode(b)
+	if ma == nil || mb == nil {
+		return false
+	}
+	return moduleName(ma) == moduleName(mb)
+}
+
+// moduleName returns the name of m, or of the module m belongs to if m is a
+// submodule.
+func moduleName(m *Module) string {
+	if m.Kind() == "submodule" && m.BelongsTo != nil {
+		return m.BelongsTo.Name
+	}
+	return m.Name
+}
+
+// checkAugmentStatus returns the errors of the augments of the nodes of the
+// tree e that are more current than the node they augment.
+func (e *Entry) checkAugmentStatus() []error {
+	if e == nil {
+		return nil
+	}
+	var errs []error
+	for _, a := range e.Augmented {
+		if err := checkStatusReference(a.Node, e.Node); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, c := range e.Dir {
+		errs = append(errs, c.checkAugmentStatus()...)
+	}
+	if e.RPC != nil {
+		errs = append(errs, e.RPC.Input.checkAugmentStatus()...)
+		errs = append(errs, e.RPC.Output.checkAugmentStatus()...)
+	}
+	return errs
+}
+
+// raiseStatus sets the status of e and the entries below it to s if they
+// are more current than s.
+func (e *Entry) raiseStatus(s Status) {
+	if e == nil || e.Status >= s {
+		return
+	}
+	e.Status = s
+	for _, c := range e.Dir {
+		c.raiseStatus(s)
+	}
+	if e.RPC != nil {
+		e.RPC.Input.raiseStatus(s)
+		e.RPC.Output.raiseStatus(s)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestStatusText(t *testing.T) {
+	for _, s := range []Status{StatusCurrent, StatusDeprecated, StatusObsolete} {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		var got Status
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if got != s {
+			t.Errorf("%s: got %s after round trip through %s", s, got, b)
+		}
+	}
+	var s Status
+	if err := s.UnmarshalText([]byte("retired")); err == nil {
+		t.Errorf("UnmarshalText(retired): got no error")
+	}
+}
+
+const statusModule = `module status {
+  namespace "urn:status";
+  prefix s;
+
+  typedef old-name {
+    type string;
+    status deprecated;
+  }
+  typedef color {
+    type enumeration {
+      enum red;
+      enum green { status deprecated; }
+      enum blue { status obsolete; }
+    }
+  }
+  typedef flags {
+    type bits {
+      bit up;
+      bit down { status obsolete; }
+    }
+  }
+  grouping g {
+    leaf in-group { type string; }
+  }
+
+  container c {
+    status deprecated;
+    leaf a { type old-name; }
+    container inner {
+      leaf b { type string; status obsolete; }
+    }
+    uses g { status obsolete; }
+  }
+  leaf current { type color; }
+  leaf bits { type flags; }
+  rpc r {
+    status obsolete;
+    input { leaf i { type string; } }
+  }
+}
+`
+
+func TestEntryStatus(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(statusModule, "status.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	m, _ := ms.GetModule("status")
+
+	for _, tt := range []struct {
+		path string
+		want Status
+	}{
+		{"current", StatusCurrent},
+		{"c", StatusDeprecated},
+		{"c/a", StatusDeprecated},
+		{"c/inner", StatusDeprecated},
+		{"c/inner/b", StatusObsolete},
+		{"c/in-group", StatusObsolete},
+		{"r", StatusObsolete},
+	} {
+		e := m.Find(tt.path)
+		if e == nil {
+			t.Errorf("%s: not found", tt.path)
+			continue
+		}
+		if e.Status != tt.want {
+			t.Errorf("%s: got status %s, want %s", tt.path, e.Status, tt.want)
+		}
+	}
+	if got := m.Dir["r"].RPC.Input.Dir["i"].Status; got != StatusObsolete {
+		t.Errorf("rpc input leaf: got status %s, want obsolete", got)
+	}
+
+	enum := m.Dir["current"].Type.Enum
+	gotEnum := map[string]Status{}
+	for _, name := range enum.Names() {
+		gotEnum[name] = enum.Status(name)
+	}
+	if diff := cmp.Diff(map[string]Status{"red": StatusCurrent, "green": StatusDeprecated, "blue": StatusObsolete}, gotEnum); diff != "" {
+		t.Errorf("enum status (-want, +got):\n%s", diff)
+	}
+	if got := m.Dir["bits"].Type.Bit.Status("down"); got != StatusObsolete {
+		t.Errorf("bit down: got status %s, want obsolete", got)
+	}
+}
+
+func TestStatusReferences(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc: "current leaf uses deprecated typedef",
+		in: `
+  typedef t { type string; status deprecated; }
+  leaf l { type t; }`,
+		wantErr: "current leaf l references deprecated typedef t",
+	}, {
+		desc: "deprecated leaf uses obsolete typedef",
+		in: `
+  typedef t { type string; status obsolete; }
+  leaf l { type t; status deprecated; }`,
+		wantErr: "deprecated leaf l references obsolete typedef t",
+	}, {
+		desc: "deprecated container allows deprecated typedef",
+		in: `
+  typedef t { type string; status deprecated; }
+  container c { status deprecated; leaf l { type t; } }`,
+	}, {
+		desc: "current uses of obsolete grouping",
+		in: `
+  grouping g { status obsolete; leaf x { type string; } }
+  container c { uses g; }`,
+		wantErr: "current uses g references obsolete grouping g",
+	}, {
+		desc: "identityref to deprecated identity",
+		in: `
+  identity base-id { status deprecated; }
+  leaf l { type identityref { base base-id; } }`,
+		wantErr: "current leaf l references deprecated identity base-id",
+	}, {
+		desc: "identityref typedef to deprecated identity",
+		in: `
+  identity base-id { status deprecated; }
+  typedef t { type identityref { base base-id; } }
+  leaf l { type t; }`,
+		wantErr: "current leaf l references deprecated identity base-id",
+	}, {
+		desc: "identity derived from obsolete identity",
+		in: `
+  identity base-id { status obsolete; }
+  identity derived { base base-id; }`,
+		wantErr: "current identity derived references obsolete identity base-id",
+	}, {
+		desc: "current typedef derived from deprecated typedef",
+		in: `
+  typedef t { type string; status deprecated; }
+  typedef u { type t; }`,
+		wantErr: "current typedef u references deprecated typedef t",
+	}, {
+		desc: "current augment of obsolete container",
+		in: `
+  container c { status obsolete; }
+  augment /m:c { leaf l { type string; } }`,
+		wantErr: "current augment /m:c references obsolete container c",
+	}, {
+		desc: "current deviation of deprecated leaf",
+		in: `
+  leaf l { type string; status deprecated; }
+  deviation /m:l { deviate add { default "x"; } }`,
+		wantErr: "references deprecated leaf l",
+	}, {
+		desc: "current leafref to deprecated leaf",
+		in: `
+  leaf target { type string; status deprecated; }
+  leaf ref { type leafref { path "../target"; } }`,
+		wantErr: "current leaf ref references deprecated leaf target",
+	}, {
+		desc: "deprecated leafref to deprecated leaf",
+		in: `
+  leaf target { type string; status deprecated; }
+  leaf ref { type leafref { path "../target"; } status deprecated; }`,
+	}, {
+		desc: "invalid status",
+		in: `
+  leaf l { type string; status retired; }`,
+		wantErr: `invalid status "retired"`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			in := "module m {\n  namespace \"urn:m\";\n  prefix m;\n" + tt.in + "\n}\n"
+			if err := ms.Parse(in, "m.yang"); err != nil {
+				t.Fatal(err)
+			}
+			var msgs []string
+			for _, err := range ms.Process() {
+				msgs = append(msgs, err.Error())
+			}
+			var err error
+			if len(msgs) > 0 {
+				err = errors.New(strings.Join(msgs, "\n"))
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
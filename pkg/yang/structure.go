@@ -0,0 +1,155 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements YANG data structures, the structure and
+// augment-structure extensions of ietf-yang-structure-ext, RFC 8791, and
+// the yang-data extension of ietf-restconf, RFC 8040, which it replaces.
+// A structure defines a tree of data nodes that is not part of the data
+// tree of the module, such as the contents of an error message.  The
+// extension statements are built into Structure and AugmentStructure nodes
+// of the module when it is parsed, and each structure into an Entry tree of
+// its own, found with Structures.
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	// StructureModule is the name of the module that defines the
+	// structure and augment-structure extensions.
+	StructureModule = "ietf-yang-structure-ext"
+	// RestconfModule is the name of the module that defines the
+	// yang-data extension.
+	RestconfModule = "ietf-restconf"
+)
+
+// structureKeywords maps the extensions that are built into structures, as
+// MODULE:NAME, to the keyword of the node they are built into.
+var structureKeywords = map[string]string{
+	StructureModule + ":structure":         "structure",
+	StructureModule + ":augment-structure": "augment-structure",
+	RestconfModule + ":yang-data":          "structure",
+}
+
+func init() {
+	// There are no structure and augment-structure statements, so the
+	// types are not found from meta.
+	for keyword, t := range map[string]reflect.Type{
+		"structure":         reflect.TypeOf(&Structure{}),
+		"augment-structure": reflect.TypeOf(&AugmentStructure{}),
+	} {
+		nameMap[keyword] = t
+		initTypes(t)
+	}
+}
+
+// buildStructures builds the structure, yang-data and augment-structure
+// extensions of the module m into its Structure and AugmentStructure
+// fields.  The extensions are also left in m.Extensions.
+func buildStructures(m *Module, types *typeDictionary) error {
+	for _, s := range m.Extensions {
+		names := strings.SplitN(s.Keyword, ":", 2)
+		if len(names) != 2 {
+			continue
+		}
+		keyword := structureKeywords[prefixModuleName(m, names[0])+":"+names[1]]
+		if keyword == "" {
+			continue
+		}
+		// Build a copy of the statement under the keyword of its
+		// node, the node keeps the extension statement as its source.
+		ks := *s
+		ks.Keyword = keyword
+		v, err := build(&ks, reflect.ValueOf(m), types)
+		if err != nil {
+			return err
+		}
+		switch n := v.Interface().(type) {
+		case *Structure:
+			n.Source = s
+			m.Structure = append(m.Structure, n)
+		case *AugmentStructure:
+			n.Source = s
+			m.AugmentStructure = append(m.AugmentStructure, n)
+		}
+	}
+	return nil
+}
+
+// prefixModuleName returns the name of the module that prefix refers to in
+// the module or submodule m, or "" if there is none.  Only the prefixes of m
+// are used, the imported modules need not have been read.
+func prefixModuleName(m *Module, prefix string) string {
+	if p := m.getPrefix(); p != nil && p.Name == prefix {
+		if m.BelongsTo != nil {
+			return m.BelongsTo.Name
+		}
+		return m.Name
+	}
+	for _, i := range m.Import {
+		if i.Prefix != nil && i.Prefix.Name == prefix {
+			return i.Name
+		}
+	}
+	return ""
+}
+
+// Structures returns the structures defined in the module e and its
+// submodules, by name.  A structure is an Entry of kind StructureEntry that
+// holds the top-level nodes of the structure in its Dir.  It is not a data
+// node, so it is not in e.Dir, but its Parent is e.  Absolute paths within a
+// structure start at the structure.  Structures returns nil if e is not a
+// module or defines no structures.
+func (e *Entry) Structures() map[string]*Entry {
+	return e.structures
+}
+
+// addStructure adds the structure s to the module e.
+func (e *Entry) addStructure(s *Entry) {
+	if o := e.structures[s.Name]; o != nil {
+		d := diagnosticf(s.Node, CodeDuplicate, "duplicate structure %s", s.Name)
+		e.addError(d.related(o.Node, "first definition of "+s.Name))
+		return
+	}
+	if e.structures == nil {
+		e.structures = map[string]*Entry{}
+	}
+	s.Parent = e
+	e.structures[s.Name] = s
+}
+
+// findStructure returns the node at path, the argument of an
+// augment-structure statement, which starts with the name of a structure,
+// or nil if it is not found.  Prefixes are relative to the module of e.
+func (e *Entry) findStructure(path string) *Entry {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	prefix, name := getPrefix(parts[0])
+	var mod *Module
+	if prefix == "" {
+		mod = module(e.Node)
+	} else if m := FindModuleByPrefix(e.Node, prefix); m != nil {
+		mod = module(m)
+	}
+	if mod == nil {
+		return nil
+	}
+	s := ToEntry(mod).structures[name]
+	if s == nil || len(parts) == 1 {
+		return s
+	}
+	return s.Find(parts[1])
+}
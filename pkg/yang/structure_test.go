@@ -0,0 +1,233 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const structureExtModule = `module ietf-yang-structure-ext {
+  namespace "urn:ietf:params:xml:ns:yang:ietf-yang-structure-ext";
+  prefix sx;
+  extension structure { argument name; }
+  extension augment-structure { argument path; }
+}
+`
+
+const restconfModule = `module ietf-restconf {
+  namespace "urn:ietf:params:xml:ns:yang:ietf-restconf";
+  prefix rc;
+  extension yang-data { argument name; }
+  rc:yang-data yang-errors {
+    container errors {
+      leaf error-tag { type string; }
+    }
+  }
+}
+`
+
+const structureModule = `module msg {
+  namespace "urn:msg";
+  prefix m;
+  import ietf-yang-structure-ext { prefix sx; }
+  include msg-sub;
+
+  container config { leaf name { type string; } }
+
+  sx:structure message {
+    typedef id { type uint32; }
+    grouping body { leaf text { type string; } }
+    container header {
+      leaf id { type id; }
+      leaf reply-to { type leafref { path "/m:header/m:id"; } }
+    }
+    uses body;
+  }
+}
+`
+
+const structureSubmodule = `submodule msg-sub {
+  belongs-to msg { prefix m; }
+  import ietf-yang-structure-ext { prefix sx; }
+
+  sx:structure address {
+    leaf host { type string; }
+  }
+}
+`
+
+const structureAugmentModule = `module msg-ext {
+  namespace "urn:msg-ext";
+  prefix mx;
+  import ietf-yang-structure-ext { prefix sx; }
+  import msg { prefix m; }
+
+  sx:augment-structure "/m:message/m:header" {
+    leaf priority { type uint8; }
+  }
+}
+`
+
+// structureTest returns the processed modules of sources, which are parsed
+// in order.
+func structureTest(t *testing.T, sources ...string) *Modules {
+	t.Helper()
+	ms := NewModules()
+	for i, src := range sources {
+		if err := ms.Parse(src, fmt.Sprintf("structure%d.yang", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	return ms
+}
+
+// structureNames returns the sorted names of the structures of e.
+func structureNames(e *Entry) []string {
+	var names []string
+	for name := range e.Structures() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestStructures(t *testing.T) {
+	ms := structureTest(t, structureExtModule, structureModule, structureSubmodule, structureAugmentModule)
+	msg, _ := ms.GetModule("msg")
+
+	if diff := cmp.Diff([]string{"address", "message"}, structureNames(msg)); diff != "" {
+		t.Errorf("Structures (-want, +got):\n%s", diff)
+	}
+	if msg.Dir["message"] != nil || msg.Dir["header"] != nil {
+		t.Errorf("structure nodes are data nodes of the module")
+	}
+
+	s := msg.Structures()["message"]
+	if s.Kind != StructureEntry || s.Parent != msg {
+		t.Errorf("structure message: got kind %v, parent %v, want Structure in msg", s.Kind, s.Parent)
+	}
+	if got, want := s.Node.Statement().Keyword, "sx:structure"; got != want {
+		t.Errorf("structure source keyword: got %s, want %s", got, want)
+	}
+	if s.Dir["text"] == nil {
+		t.Errorf("leaf text of the used grouping not in the structure")
+	}
+
+	id := s.Find("header/id")
+	if id == nil {
+		t.Fatal("leaf header/id not found")
+	}
+	if got := id.Type.Root.Name; got != "uint32" {
+		t.Errorf("leaf id with a typedef of the structure: got type %s, want uint32", got)
+	}
+	if got, want := id.Path(), "/msg/message/header/id"; got != want {
+		t.Errorf("Path: got %s, want %s", got, want)
+	}
+	// Absolute paths within a structure start at the structure.
+	if got := s.Find("header/reply-to").Find("/m:header/m:id"); got != id {
+		t.Errorf("absolute Find within a structure: got %v, want the id leaf", got)
+	}
+
+	if a := msg.Structures()["address"]; a == nil || a.Dir["host"] == nil || a.Parent != msg {
+		t.Errorf("structure address of the submodule not found in msg: %v", a)
+	}
+
+	p := s.Find("header/priority")
+	if p == nil {
+		t.Fatal("augmented leaf header/priority not found")
+	}
+	if got := p.Namespace().Name; got != "urn:msg-ext" {
+		t.Errorf("Namespace of the augmented leaf: got %s, want urn:msg-ext", got)
+	}
+	if len(s.Dir["header"].Augmented) != 1 {
+		t.Errorf("header: got %d augments, want 1", len(s.Dir["header"].Augmented))
+	}
+}
+
+func TestYangData(t *testing.T) {
+	ms := structureTest(t, restconfModule)
+	rc, _ := ms.GetModule("ietf-restconf")
+	if diff := cmp.Diff([]string{"yang-errors"}, structureNames(rc)); diff != "" {
+		t.Errorf("Structures (-want, +got):\n%s", diff)
+	}
+	if rc.Structures()["yang-errors"].Find("errors/error-tag") == nil {
+		t.Errorf("leaf errors/error-tag not found in yang-errors")
+	}
+	if len(rc.Dir) != 0 {
+		t.Errorf("yang-data nodes are data nodes of the module: %v", rc.Dir)
+	}
+}
+
+func TestStructureErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc: "unknown structure",
+		in: `module bad {
+  namespace "urn:bad";
+  prefix b;
+  import ietf-yang-structure-ext { prefix sx; }
+  sx:augment-structure "/b:none" { leaf l { type string; } }
+}`,
+		wantErr: "augment-structure /b:none not found",
+	}, {
+		desc: "duplicate structure",
+		in: `module bad {
+  namespace "urn:bad";
+  prefix b;
+  import ietf-yang-structure-ext { prefix sx; }
+  sx:structure s { leaf a { type string; } }
+  sx:structure s { leaf b { type string; } }
+}`,
+		wantErr: "duplicate structure s",
+	}, {
+		desc: "invalid statement",
+		in: `module bad {
+  namespace "urn:bad";
+  prefix b;
+  import ietf-yang-structure-ext { prefix sx; }
+  sx:structure s { config false; }
+}`,
+		wantErr: "unknown structure field: config",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			err := ms.Parse(structureExtModule, "ietf-yang-structure-ext.yang")
+			if err == nil {
+				err = ms.Parse(tt.in, "bad.yang")
+			}
+			if err == nil {
+				if errs := ms.Process(); len(errs) > 0 {
+					err = errs[0]
+				}
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
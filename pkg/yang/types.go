@@ -65,7 +65,7 @@ func (d *typeDictionary) find(n Node, name string) *Typedef {
 func (d *typeDictionary) findExternal(n Node, prefix, name string) (*Typedef, error) {
 	root := FindModuleByPrefix(n, prefix)
 	if root == nil {
-		return nil, fmt.Errorf("%s: unknown prefix: %s for type %s", Source(n), prefix, name)
+		return nil, diagnosticf(n, CodeUnresolved, "unknown prefix: %s for type %s", prefix, name)
 	}
 	if td := d.find(root, name); td != nil {
 		return td, nil
@@ -73,7 +73,7 @@ func (d *typeDictionary) findExternal(n Node, prefix, name string) (*Typedef, er
 	if prefix != "" {
 		name = prefix + ":" + name
 	}
-	return nil, fmt.Errorf("%s: unknown type %s", Source(n), name)
+	return nil, diagnosticf(n, CodeUnresolved, "unknown type %s", name)
 }
 
 // typedefs returns a slice of all typedefs in d.
@@ -107,9 +107,21 @@ func (d *typeDictionary) resolveTypedefs() []error {
 	// When resolve typedefs, we may need to look up other typedefs.
 	// We gather all typedefs into a slice so we don't deadlock on
 	// typeDict.
-	for _, td := range d.typedefs() {
+	tds := d.typedefs()
+	for _, td := range tds {
 		errs = append(errs, td.resolve(d)...)
 	}
+	// A typedef may not be more current than the typedef its type is
+	// derived from.
+	for _, td := range tds {
+		if y := td.Type.YangType; y != nil && y.Base != nil {
+			if base, ok := y.Base.ParentNode().(*Typedef); ok {
+				if err := checkStatusReference(td, base); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
 	return errs
 }
 
@@ -144,13 +156,17 @@ func (t *Typedef) resolve(d *typeDictionary) []error {
 		y.Default = t.Default.Name
 	}
 
-	if t.Type.IdentityBase != nil {
-		// We need to copy over the IdentityBase statement if the type has one
-		if idBase, err := RootNode(t).findIdentityBase(t.Type.IdentityBase.Name); err == nil {
-			y.IdentityBase = idBase.Identity
-		} else {
-			return []error{fmt.Errorf("could not resolve identity base for typedef: %s", t.Type.IdentityBase.Name)}
+	if len(t.Type.IdentityBases) > 0 {
+		// We need to copy over the base statements if the type has them
+		y.IdentityBases = nil
+		for _, b := range t.Type.IdentityBases {
+			idBase, err := RootNode(t).findIdentityBase(b.Name)
+			if err != nil {
+				return []error{diagnosticf(b, CodeUnresolved, "could not resolve identity base for typedef: %s", b.Name)}
+			}
+			y.IdentityBases = append(y.IdentityBases, idBase.Identity)
 		}
+		y.IdentityBase = y.IdentityBases[0]
 	}
 
 	// If we changed something, we are the new root.
@@ -174,7 +190,10 @@ func (t *Type) resolve(d *typeDictionary) (errs []error) {
 
 	prefix, name := getPrefix(t.Name)
 	root := RootNode(t)
-	ms := root.Modules
+	var ms *Modules
+	if root != nil {
+		ms = root.Modules
+	}
 	rootPrefix := root.GetPrefix()
 
 	source := "unknown"
@@ -206,7 +225,7 @@ check:
 			pname = fmt.Sprintf("%s[%s]:%s", prefix, root.Prefix.Name, t.Name)
 		}
 
-		return []error{fmt.Errorf("%s: unknown type: %s", Source(t), pname)}
+		return []error{diagnosticf(t, CodeUnresolved, "unknown type: %s", pname)}
 
 	default:
 		source = "imported"
@@ -216,7 +235,7 @@ check:
 		var err error
 		td, err = d.findExternal(t, prefix, name)
 		if err != nil {
-			if ms.ParseOptions.IgnoreModuleResolveErrors {
+			if ms != nil && ms.ParseOptions.IgnoreModuleResolveErrors {
 				return nil
 			}
 
@@ -230,11 +249,11 @@ check:
 	// Make a copy of the typedef we are based on so we can
 	// augment it.
 	if td.YangType == nil {
-		if ms.ParseOptions.IgnoreModuleResolveErrors {
+		if ms != nil && ms.ParseOptions.IgnoreModuleResolveErrors {
 			return nil
 		}
 
-		return []error{fmt.Errorf("%s: no YangType defined for %s %s", Source(td), source, td.Name)}
+		return []error{diagnosticf(td, CodeInvalidValue, "no YangType defined for %s %s", source, td.Name)}
 	}
 	y := *td.YangType
 
@@ -255,7 +274,7 @@ check:
 	switch {
 	case isDecimal64 && y.FractionDigits != 0:
 		if t.FractionDigits != nil {
-			return append(errs, fmt.Errorf("%s: overriding of fraction-digits not allowed", Source(t)))
+			return append(errs, diagnosticf(t, CodeInvalidValue, "overriding of fraction-digits not allowed"))
 		}
 		// FractionDigits already set via type inheritance.
 	case isDecimal64:
@@ -263,7 +282,7 @@ check:
 		// fraction-digits in the range from 1-18.
 		i, err := t.FractionDigits.asRangeInt(1, 18)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("%s: %v", Source(t), err))
+			errs = append(errs, diagnosticf(t, CodeInvalidValue, "%v", err))
 		}
 		y.FractionDigits = int(i)
 		// We only know to how to populate Range after knowing the
@@ -273,7 +292,7 @@ check:
 			Number{Value: MaxInt64, FractionDigits: uint8(i)},
 		}}
 	case t.FractionDigits != nil:
-		errs = append(errs, fmt.Errorf("%s: fraction-digits only allowed for decimal64 values", Source(t)))
+		errs = append(errs, diagnosticf(t, CodeInvalidValue, "fraction-digits only allowed for decimal64 values"))
 	case y.Kind == Yidentityref:
 		if source != "builtin" {
 			// This is a typedef that refers to an identityref, so we want to simply
@@ -281,30 +300,39 @@ check:
 			break
 		}
 
-		if t.IdentityBase == nil {
-			errs = append(errs, fmt.Errorf("%s: an identityref must specify a base", Source(t)))
+		if len(t.IdentityBases) == 0 {
+			errs = append(errs, diagnosticf(t, CodeInvalidValue, "an identityref must specify a base"))
 			break
 		}
 
+		// A YANG 1.1 identityref may have several bases, its values
+		// are derived from all of them.
 		root := RootNode(t.Parent)
-		resolvedBase, baseErr := root.findIdentityBase(t.IdentityBase.Name)
-		if baseErr != nil {
-			errs = append(errs, baseErr...)
-			break
+		var bases []*Identity
+		for _, b := range t.IdentityBases {
+			resolvedBase, baseErr := root.findIdentityBase(b.Name)
+			if baseErr != nil {
+				errs = append(errs, baseErr...)
+				continue
+			}
+			if resolvedBase.Identity == nil {
+				errs = append(errs, diagnosticf(b, CodeUnresolved, "%s: identity has a null base", b.Name))
+				continue
+			}
+			bases = append(bases, resolvedBase.Identity)
 		}
-
-		if resolvedBase.Identity == nil {
-			errs = append(errs, fmt.Errorf("%s: identity has a null base", t.IdentityBase.Name))
+		if len(bases) != len(t.IdentityBases) {
 			break
 		}
-		y.IdentityBase = resolvedBase.Identity
+		y.IdentityBase = bases[0]
+		y.IdentityBases = bases
 	}
 
 	if t.Range != nil {
 		yr, err := y.Range.parseChildRanges(t.Range.Name, isDecimal64, uint8(y.FractionDigits))
 		switch {
 		case err != nil:
-			errs = append(errs, fmt.Errorf("%s: bad range: %v", Source(t.Range), err))
+			errs = append(errs, diagnosticf(t.Range, CodeInvalidValue, "bad range: %v", err))
 		case yr.Equal(y.Range):
 		default:
 			y.Range = yr
@@ -319,12 +347,12 @@ check:
 		yr, err := parentRange.parseChildRanges(t.Length.Name, false, 0)
 		switch {
 		case err != nil:
-			errs = append(errs, fmt.Errorf("%s: bad length: %v", Source(t.Length), err))
+			errs = append(errs, diagnosticf(t.Length, CodeInvalidValue, "bad length: %v", err))
 		case yr.Equal(y.Length):
 		default:
 			for _, r := range yr {
 				if r.Min.Negative {
-					errs = append(errs, fmt.Errorf("%s: negative length: %v", Source(t.Length), yr))
+					errs = append(errs, diagnosticf(t.Length, CodeInvalidValue, "negative length: %v", yr))
 					break
 				}
 			}
@@ -347,12 +375,30 @@ check:
 		return e.Set(name, i)
 	}
 
+	// enabled reports whether an enum or bit is supported by the feature
+	// set in the parse options.
+	enabled := func(n Node) bool {
+		ok, err := ms.IsEnabled(n)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return ok
+	}
+
 	if len(t.Enum) > 0 {
 		enum := NewEnumType()
 		for _, e := range t.Enum {
+			if !enabled(e) {
+				continue
+			}
 			if err := set(enum, e.Name, e.Value); err != nil {
-				errs = append(errs, fmt.Errorf("%s: %v", Source(e), err))
+				errs = append(errs, diagnosticf(e, CodeInvalidValue, "%v", err))
 			}
+			st, err := parseStatus(e.Status)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			enum.setStatus(e.Name, st)
 		}
 		y.Enum = enum
 	}
@@ -360,9 +406,17 @@ check:
 	if len(t.Bit) > 0 {
 		bit := NewBitfield()
 		for _, e := range t.Bit {
+			if !enabled(e) {
+				continue
+			}
 			if err := set(bit, e.Name, e.Position); err != nil {
-				errs = append(errs, fmt.Errorf("%s: %v", Source(e), err))
+				errs = append(errs, diagnosticf(e, CodeInvalidValue, "%v", err))
+			}
+			st, err := parseStatus(e.Status)
+			if err != nil {
+				errs = append(errs, err)
 			}
+			bit.setStatus(e.Name, st)
 		}
 		y.Bit = bit
 	}
@@ -375,18 +429,42 @@ check:
 	for _, p := range y.Pattern {
 		seenPatterns[p] = true
 	}
+	seenInvertPatterns := map[string]bool{}
+	for _, p := range y.InvertPattern {
+		seenInvertPatterns[p] = true
+	}
 	seenPOSIXPatterns := map[string]bool{}
 	for _, p := range y.POSIXPattern {
 		seenPOSIXPatterns[p] = true
 	}
 
-	// First parse out the pattern statements.
-	// These patterns are not checked because there is no support for W3C regexes by Go.
+	// First parse out the pattern statements, translating each into a Go
+	// regular expression.  The compiled patterns are copied before being
+	// appended to so they are not shared with the base type.
 	for _, pv := range t.Pattern {
-		if !seenPatterns[pv.Name] {
-			seenPatterns[pv.Name] = true
-			y.Pattern = append(y.Pattern, pv.Name)
+		invert := false
+		if pv.Modifier != nil {
+			if pv.Modifier.Name != "invert-match" {
+				errs = append(errs, diagnosticf(pv.Modifier, CodeInvalidValue, "invalid pattern modifier %q", pv.Modifier.Name))
+				continue
+			}
+			invert = true
+		}
+		seen, patterns := seenPatterns, &y.Pattern
+		if invert {
+			seen, patterns = seenInvertPatterns, &y.InvertPattern
+		}
+		if seen[pv.Name] {
+			continue
+		}
+		seen[pv.Name] = true
+		*patterns = append(*patterns, pv.Name)
+		cp, err := CompilePattern(pv.Name, invert)
+		if err != nil {
+			errs = append(errs, diagnosticf(pv, CodeInvalidValue, "bad pattern: %v: %s", err, pv.Name))
+			continue
 		}
+		y.CompiledPatterns = append(y.CompiledPatterns[:len(y.CompiledPatterns):len(y.CompiledPatterns)], cp)
 	}
 
 	// Then, parse out the posix-pattern statements, if they exist.
@@ -403,7 +481,7 @@ check:
 				// the error, re.Code is the real error.
 				err = errors.New(re.Code.String())
 			}
-			errs = append(errs, fmt.Errorf("%s: bad pattern: %v: %s", Source(n), err, p))
+			errs = append(errs, diagnosticf(n, CodeInvalidValue, "bad pattern: %v: %s", err, p))
 		}
 	}
 	for _, ext := range posixPatterns {
@@ -574,6 +574,7 @@ type EnumType struct {
 	unique   bool  // numeric values must be unique (enums)
 	toString map[int64]string
 	toInt    map[string]int64
+	status   map[string]Status // status of names that are not current
 }
 
 // NewEnumType returns an initialized EnumType.
@@ -645,6 +646,22 @@ func (e *EnumType) Name(value int64) string { return e.toString[value] }
 // IsDefined to definitively confirm name is in e.
 func (e *EnumType) Value(name string) int64 { return e.toInt[name] }
 
+// Status returns the status of name in e.  StatusCurrent is returned if
+// name has no status statement or is not in e.
+func (e *EnumType) Status(name string) Status { return e.status[name] }
+
+// setStatus sets the status of name in e.
+func (e *EnumType) setStatus(name string, s Status) {
+	if s == StatusCurrent {
+		delete(e.status, name)
+		return
+	}
+	if e.status == nil {
+		e.status = map[string]Status{}
+	}
+	e.status[name] = s
+}
+
 // IsDefined returns true if name is defined in e, else false.
 func (e *EnumType) IsDefined(name string) bool {
 	_, defined := e.toInt[name]
@@ -0,0 +1,482 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file parses values of the built-in types from their lexical
+// representation and formats them in their canonical form, as described in
+// https://tools.ietf.org/html/rfc7950#section-9.
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A TypedValue is a value of a YANG type.
+type TypedValue struct {
+	// Type is the type the value was parsed with.  For a union it is the
+	// member type that accepted the value.
+	Type *YangType
+	// Value is the value itself:
+	//
+	//	integer types, decimal64  Number
+	//	binary                    []byte
+	//	bits                      []string, the bit names in position order
+	//	boolean                   bool
+	//	empty                     nil
+	//	enumeration               string, the enum name
+	//	identityref               *Identity
+	//	instance-identifier       string, in canonical form
+	//	string                    string
+	Value interface{}
+}
+
+// String returns v in the canonical form of its type.
+func (v TypedValue) String() string {
+	switch x := v.Value.(type) {
+	case Number:
+		if x.IsDecimal() {
+			return canonicalDecimal(x)
+		}
+		return x.String()
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x)
+	case []string:
+		return strings.Join(x, " ")
+	case bool:
+		return strconv.FormatBool(x)
+	case nil:
+		return ""
+	case *Identity:
+		return x.PrefixedName()
+	case string:
+		return x
+	}
+	return fmt.Sprint(v.Value)
+}
+
+// canonicalDecimal returns n without trailing zeros in its fraction, keeping
+// at least one fractional digit.
+func canonicalDecimal(n Number) string {
+	s := n.String()
+	i := len(s)
+	for s[i-1] == '0' && s[i-2] != '.' {
+		i--
+	}
+	return s[:i]
+}
+
+// ParseValue parses s, the lexical representation of a value of type y, and
+// returns its value.  An error is returned if s is not a valid value of y,
+// including when it violates a range, length or pattern restriction of y.
+// The members of a union are tried in order and the first one accepting s is
+// used.
+//
+// The value of a leafref depends on its target, use the type returned by
+// Entry.LeafrefType to parse it.  An identityref may be qualified by the
+// prefix or name of the module defining the identity.
+func (y *YangType) ParseValue(s string) (TypedValue, error) {
+	v := TypedValue{Type: y}
+	var err error
+	switch y.Kind {
+	case Yint8, Yint16, Yint32, Yint64, Yuint8, Yuint16, Yuint32, Yuint64:
+		v.Value, err = y.parseInt(s)
+	case Ydecimal64:
+		v.Value, err = y.parseDecimal(s)
+	case Ystring:
+		err = y.checkString(s)
+		v.Value = s
+	case Ybinary:
+		v.Value, err = y.parseBinary(s)
+	case Ybits:
+		v.Value, err = y.parseBits(s)
+	case Ybool:
+		switch s {
+		case "true":
+			v.Value = true
+		case "false":
+			v.Value = false
+		default:
+			err = fmt.Errorf("invalid boolean value %q", s)
+		}
+	case Yempty:
+		if s != "" {
+			err = fmt.Errorf("invalid empty value %q", s)
+		}
+	case Yenum:
+		if y.Enum == nil || !y.Enum.IsDefined(s) {
+			var names []string
+			if y.Enum != nil {
+				names = y.Enum.Names()
+			}
+			err = fmt.Errorf("%q is not a valid enum, allowed values are %s", s, strings.Join(names, ", "))
+		}
+		v.Value = s
+	case Yidentityref:
+		v.Value, err = y.parseIdentity(s)
+	case YinstanceIdentifier:
+		v.Value, err = canonicalInstanceIdentifier(s)
+		if err != nil {
+			err = fmt.Errorf("invalid instance-identifier %q: %v", s, err)
+		}
+	case Yleafref:
+		err = fmt.Errorf("cannot parse %q without the type of the leafref target %s", s, y.Path)
+	case Yunion:
+		return y.parseUnion(s)
+	default:
+		err = fmt.Errorf("cannot parse a value of type %s", y.Kind)
+	}
+	if err != nil {
+		return TypedValue{}, err
+	}
+	return v, nil
+}
+
+// FormatCanonical returns s, the lexical representation of a value of type y,
+// in its canonical form.  An error is returned if s is not a valid value of
+// y.
+func (y *YangType) FormatCanonical(s string) (string, error) {
+	v, err := y.ParseValue(s)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// builtinRanges holds the ranges of the integer types.
+var builtinRanges = map[TypeKind]YangRange{
+	Yint8:   Int8Range,
+	Yint16:  Int16Range,
+	Yint32:  Int32Range,
+	Yint64:  Int64Range,
+	Yuint8:  Uint8Range,
+	Yuint16: Uint16Range,
+	Yuint32: Uint32Range,
+	Yuint64: Uint64Range,
+}
+
+// parseInt parses the integer s, an optional sign followed by decimal
+// digits.
+func (y *YangType) parseInt(s string) (Number, error) {
+	var n Number
+	digits := s
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		n.Negative = s[0] == '-'
+		digits = s[1:]
+	}
+	if !isDigits(digits) {
+		return n, fmt.Errorf("invalid %s value %q", y.Kind, s)
+	}
+	var err error
+	if n.Value, err = strconv.ParseUint(digits, 10, 64); err != nil {
+		return n, fmt.Errorf("invalid %s value %q", y.Kind, s)
+	}
+	if n.Value == 0 {
+		n.Negative = false
+	}
+	r := y.Range
+	if len(r) == 0 {
+		r = builtinRanges[y.Kind]
+	}
+	return n, checkRange(r, n, s)
+}
+
+// parseDecimal parses the decimal64 value s, an optional sign followed by
+// decimal digits and an optional fraction.
+func (y *YangType) parseDecimal(s string) (Number, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 {
+		return Number{}, fmt.Errorf("invalid decimal64 value %q", s)
+	}
+	whole, frac := digits, "0"
+	if i := strings.Index(digits, "."); i >= 0 {
+		whole, frac = digits[:i], digits[i+1:]
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Number{}, fmt.Errorf("invalid decimal64 value %q", s)
+	}
+	n, err := ParseDecimal(s, uint8(y.FractionDigits))
+	if err != nil {
+		return n, fmt.Errorf("invalid decimal64 value %q: %v", s, err)
+	}
+	return n, checkRange(y.Range, n, s)
+}
+
+// isDigits reports whether s is a non-empty string of decimal digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRange returns an error if n, the value of s, is not within r.  An
+// empty range allows all values.
+func checkRange(r YangRange, n Number, s string) error {
+	if r.Contains(YangRange{{Min: n, Max: n}}) {
+		return nil
+	}
+	return fmt.Errorf("value %s is outside of range %s", s, r)
+}
+
+// checkLength returns an error if the length n of the value s is not within
+// r.
+func checkLength(r YangRange, n int, s string) error {
+	if r.Contains(YangRange{{Min: FromInt(int64(n)), Max: FromInt(int64(n))}}) {
+		return nil
+	}
+	return fmt.Errorf("length %d of %q is outside of length %s", n, s, r)
+}
+
+// checkString checks the string s against the length and patterns of y.
+func (y *YangType) checkString(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("%q is not valid UTF-8", s)
+	}
+	if err := checkLength(y.Length, utf8.RuneCountInString(s), s); err != nil {
+		return err
+	}
+	switch p := y.PatternMismatch(s); {
+	case p == nil:
+		return nil
+	case p.Invert:
+		return fmt.Errorf("%q matches inverted pattern %q", s, p.Pattern)
+	default:
+		return fmt.Errorf("%q does not match pattern %q", s, p.Pattern)
+	}
+}
+
+// parseBinary parses the base64 encoded value s and checks its decoded
+// length against the length of y.
+func (y *YangType) parseBinary(s string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 binary value %q", s)
+	}
+	return b, checkLength(y.Length, len(b), s)
+}
+
+// parseBits parses the space separated bit names in s and returns them in
+// position order.
+func (y *YangType) parseBits(s string) ([]string, error) {
+	if y.Bit == nil {
+		return nil, fmt.Errorf("bits type %s has no bits", y.Name)
+	}
+	names := strings.Fields(s)
+	seen := map[string]bool{}
+	for _, b := range names {
+		if !y.Bit.IsDefined(b) {
+			return nil, fmt.Errorf("%q is not a valid bit, allowed bits are %s", b, strings.Join(y.Bit.Names(), ", "))
+		}
+		if seen[b] {
+			return nil, fmt.Errorf("bit %q is repeated", b)
+		}
+		seen[b] = true
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return y.Bit.Value(names[i]) < y.Bit.Value(names[j])
+	})
+	return names, nil
+}
+
+// parseIdentity returns the identity named by s, which must be derived from
+// all the bases of y.  The identity name may be qualified by the prefix or name
+// of its module.  An unqualified name must be unambiguous.
+func (y *YangType) parseIdentity(s string) (*Identity, error) {
+	if y.IdentityBase == nil {
+		return nil, fmt.Errorf("identityref type %s has no base", y.Name)
+	}
+	qual, name := "", s
+	if i := strings.Index(s, ":"); i >= 0 {
+		qual, name = s[:i], s[i+1:]
+	}
+	var found *Identity
+	for _, id := range y.IdentityBase.Values {
+		if id.Name != name || !y.AllowsIdentity(id) {
+			continue
+		}
+		if qual != "" {
+			if m := module(id); qual != RootNode(id).GetPrefix() && (m == nil || qual != m.Name) {
+				continue
+			}
+		}
+		if found != nil {
+			return nil, fmt.Errorf("identity %q is ambiguous, qualify it with its module", s)
+		}
+		found = id
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%q is not derived from identity %s", s, y.IdentityBase.Name)
+	}
+	return found, nil
+}
+
+// parseUnion returns the value of s for the first member of the union y
+// that accepts it.
+func (y *YangType) parseUnion(s string) (TypedValue, error) {
+	var errs []string
+	for _, m := range y.Type {
+		v, err := m.ParseValue(s)
+		if err == nil {
+			return v, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return TypedValue{}, fmt.Errorf("%q does not match any member of the union: %s", s, strings.Join(errs, "; "))
+}
+
+// canonicalInstanceIdentifier checks the syntax of the instance-identifier
+// s and returns it without optional whitespace.  Predicate values are
+// enclosed in single quotes unless they contain one.
+func canonicalInstanceIdentifier(s string) (string, error) {
+	if s == "" {
+		return "", errors.New("empty path")
+	}
+	p := &instanceIDParser{s: s}
+	var b strings.Builder
+	for p.i < len(s) {
+		if s[p.i] != '/' {
+			return "", p.errorf("expected /")
+		}
+		p.i++
+		id, err := p.nodeIdentifier()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("/" + id)
+		var keys, others int
+		for p.i < len(s) && s[p.i] == '[' {
+			p.i++
+			p.skipSpace()
+			if p.i < len(s) && s[p.i] >= '0' && s[p.i] <= '9' {
+				start := p.i
+				for p.i < len(s) && s[p.i] >= '0' && s[p.i] <= '9' {
+					p.i++
+				}
+				if s[start] == '0' {
+					return "", p.errorf("position must be a positive integer")
+				}
+				b.WriteString("[" + s[start:p.i] + "]")
+				others++
+			} else {
+				name := "."
+				if p.i < len(s) && s[p.i] == '.' {
+					p.i++
+					others++
+				} else {
+					if name, err = p.nodeIdentifier(); err != nil {
+						return "", err
+					}
+					keys++
+				}
+				p.skipSpace()
+				if p.i >= len(s) || s[p.i] != '=' {
+					return "", p.errorf("expected =")
+				}
+				p.i++
+				p.skipSpace()
+				value, err := p.quoted()
+				if err != nil {
+					return "", err
+				}
+				q := "'"
+				if strings.Contains(value, "'") {
+					q = `"`
+				}
+				b.WriteString("[" + name + "=" + q + value + q + "]")
+			}
+			p.skipSpace()
+			if p.i >= len(s) || s[p.i] != ']' {
+				return "", p.errorf("expected ]")
+			}
+			p.i++
+			if others > 1 || (others > 0 && keys > 0) {
+				return "", p.errorf("position and leaf-list predicates cannot be combined with other predicates")
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// An instanceIDParser holds the state of parsing an instance-identifier.
+type instanceIDParser struct {
+	s string
+	i int
+}
+
+func (p *instanceIDParser) errorf(format string, v ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, v...), p.i)
+}
+
+func (p *instanceIDParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// nodeIdentifier parses an identifier, optionally qualified by a prefix.
+func (p *instanceIDParser) nodeIdentifier() (string, error) {
+	start := p.i
+	if err := p.identifier(); err != nil {
+		return "", err
+	}
+	if p.i < len(p.s) && p.s[p.i] == ':' {
+		p.i++
+		if err := p.identifier(); err != nil {
+			return "", err
+		}
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *instanceIDParser) identifier() error {
+	if p.i >= len(p.s) || !isIdentStart(p.s[p.i]) {
+		return p.errorf("expected identifier")
+	}
+	for p.i++; p.i < len(p.s); p.i++ {
+		if c := p.s[p.i]; !isIdentStart(c) && !(c >= '0' && c <= '9') && c != '-' && c != '.' {
+			break
+		}
+	}
+	return nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// quoted parses a single or double quoted string and returns its contents.
+func (p *instanceIDParser) quoted() (string, error) {
+	if p.i >= len(p.s) || (p.s[p.i] != '\'' && p.s[p.i] != '"') {
+		return "", p.errorf("expected quoted string")
+	}
+	q := p.s[p.i]
+	end := strings.IndexByte(p.s[p.i+1:], q)
+	if end < 0 {
+		return "", p.errorf("unterminated string")
+	}
+	value := p.s[p.i+1 : p.i+1+end]
+	p.i += end + 2
+	return value, nil
+}
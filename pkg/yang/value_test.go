@@ -0,0 +1,201 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const valueModule = `
+module val {
+  yang-version 1.1;
+  namespace "urn:val";
+  prefix v;
+
+  identity animal;
+  identity dog { base animal; }
+  identity poodle { base dog; }
+  identity rock;
+
+  container c {
+    leaf i8 { type int8; }
+    leaf u64 { type uint64; }
+    leaf count { type int32 { range "1..10 | 20"; } }
+    leaf dec { type decimal64 { fraction-digits 3; range "-10 .. 10"; } }
+    leaf str { type string { length "1..4"; pattern "[a-z]*"; } }
+    leaf bin { type binary { length "1..3"; } }
+    leaf flags { type bits { bit a { position 3; } bit b { position 1; } bit c; } }
+    leaf flag { type boolean; }
+    leaf none { type empty; }
+    leaf color { type enumeration { enum red; enum green; } }
+    leaf pet { type identityref { base animal; } }
+    leaf target { type instance-identifier; }
+    leaf mixed { type union { type int8; type enumeration { enum unbounded; } type string; } }
+    leaf ref { type leafref { path "../count"; } }
+  }
+}
+`
+
+func valueTypes(t *testing.T) map[string]*YangType {
+	t.Helper()
+	ms := NewModules()
+	if err := ms.Parse(valueModule, "val.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	types := map[string]*YangType{}
+	for name, e := range ToEntry(ms.Modules["val"]).Dir["c"].Dir {
+		types[name] = e.Type
+	}
+	return types
+}
+
+func TestParseValue(t *testing.T) {
+	types := valueTypes(t)
+
+	tests := []struct {
+		desc          string
+		leaf          string
+		in            string
+		wantCanonical string
+		wantKind      TypeKind
+		wantErr       string
+	}{
+		{desc: "int8", leaf: "i8", in: "-128", wantCanonical: "-128"},
+		{desc: "int8 sign and leading zeros", leaf: "i8", in: "+007", wantCanonical: "7"},
+		{desc: "negative zero", leaf: "i8", in: "-0", wantCanonical: "0"},
+		{desc: "int8 overflow", leaf: "i8", in: "128", wantErr: "value 128 is outside of range -128..127"},
+		{desc: "hexadecimal", leaf: "i8", in: "0x10", wantErr: `invalid int8 value "0x10"`},
+		{desc: "whitespace", leaf: "i8", in: " 1", wantErr: `invalid int8 value " 1"`},
+		{desc: "uint64 max", leaf: "u64", in: "18446744073709551615", wantCanonical: "18446744073709551615"},
+		{desc: "uint64 negative", leaf: "u64", in: "-1", wantErr: "outside of range 0..18446744073709551615"},
+		{desc: "restricted range", leaf: "count", in: "20", wantCanonical: "20"},
+		{desc: "outside restricted range", leaf: "count", in: "11", wantErr: "value 11 is outside of range 1..10|20"},
+		{desc: "decimal trailing zeros", leaf: "dec", in: "1.500", wantCanonical: "1.5"},
+		{desc: "decimal integer", leaf: "dec", in: "+2", wantCanonical: "2.0"},
+		{desc: "decimal zero", leaf: "dec", in: "-0.000", wantCanonical: "0.0"},
+		{desc: "decimal small", leaf: "dec", in: "-0.05", wantCanonical: "-0.05"},
+		{desc: "decimal precision", leaf: "dec", in: "1.2345", wantErr: "too much precision"},
+		{desc: "decimal range", leaf: "dec", in: "10.001", wantErr: "value 10.001 is outside of range -10.000..10.000"},
+		{desc: "decimal missing digits", leaf: "dec", in: ".5", wantErr: `invalid decimal64 value ".5"`},
+		{desc: "string", leaf: "str", in: "abc", wantCanonical: "abc"},
+		{desc: "string length", leaf: "str", in: "abcde", wantErr: `length 5 of "abcde" is outside of length 1..4`},
+		{desc: "string pattern", leaf: "str", in: "ABC", wantErr: `"ABC" does not match pattern "[a-z]*"`},
+		{desc: "binary", leaf: "bin", in: "AAE=", wantCanonical: "AAE="},
+		{desc: "binary length", leaf: "bin", in: "AAAAAA==", wantErr: "length 4 of \"AAAAAA==\" is outside of length 1..3"},
+		{desc: "binary encoding", leaf: "bin", in: "A", wantErr: `invalid base64 binary value "A"`},
+		{desc: "bits in position order", leaf: "flags", in: " c  a b", wantCanonical: "b a c"},
+		{desc: "no bits", leaf: "flags", in: "", wantCanonical: ""},
+		{desc: "unknown bit", leaf: "flags", in: "d", wantErr: `"d" is not a valid bit, allowed bits are a, b, c`},
+		{desc: "repeated bit", leaf: "flags", in: "a a", wantErr: `bit "a" is repeated`},
+		{desc: "boolean", leaf: "flag", in: "false", wantCanonical: "false"},
+		{desc: "bad boolean", leaf: "flag", in: "True", wantErr: `invalid boolean value "True"`},
+		{desc: "empty", leaf: "none", in: "", wantCanonical: ""},
+		{desc: "bad empty", leaf: "none", in: "x", wantErr: `invalid empty value "x"`},
+		{desc: "enumeration", leaf: "color", in: "green", wantCanonical: "green"},
+		{desc: "bad enumeration", leaf: "color", in: "blue", wantErr: `"blue" is not a valid enum, allowed values are green, red`},
+		{desc: "identity by prefix", leaf: "pet", in: "v:poodle", wantCanonical: "v:poodle"},
+		{desc: "identity by module", leaf: "pet", in: "val:dog", wantCanonical: "v:dog"},
+		{desc: "unqualified identity", leaf: "pet", in: "dog", wantCanonical: "v:dog"},
+		{desc: "underived identity", leaf: "pet", in: "v:rock", wantErr: `"v:rock" is not derived from identity animal`},
+		{desc: "base identity", leaf: "pet", in: "v:animal", wantErr: "not derived"},
+		{desc: "wrong module", leaf: "pet", in: "x:dog", wantErr: "not derived"},
+		{
+			desc:          "instance-identifier",
+			leaf:          "target",
+			in:            `/v:c/v:list[ v:k1 = "a'b" ][v:k2='x']/v:leaf`,
+			wantCanonical: `/v:c/v:list[v:k1="a'b"][v:k2='x']/v:leaf`,
+		},
+		{desc: "leaf-list instance", leaf: "target", in: `/v:c/v:ll[.="x"]`, wantCanonical: `/v:c/v:ll[.='x']`},
+		{desc: "positional instance", leaf: "target", in: "/v:c/v:ll[ 2 ]", wantCanonical: "/v:c/v:ll[2]"},
+		{desc: "relative instance", leaf: "target", in: "v:c", wantErr: "expected / at offset 0"},
+		{desc: "zero position", leaf: "target", in: "/c[0]", wantErr: "position must be a positive integer"},
+		{desc: "unterminated predicate", leaf: "target", in: "/c[k='a'", wantErr: "expected ] at offset 8"},
+		{desc: "mixed predicates", leaf: "target", in: "/c[k='a'][1]", wantErr: "cannot be combined"},
+		{desc: "trailing slash", leaf: "target", in: "/c/", wantErr: "expected identifier at offset 3"},
+		{desc: "union first member", leaf: "mixed", in: "007", wantCanonical: "7", wantKind: Yint8},
+		{desc: "union later member", leaf: "mixed", in: "unbounded", wantCanonical: "unbounded", wantKind: Yenum},
+		{desc: "union fallback", leaf: "mixed", in: "200", wantCanonical: "200", wantKind: Ystring},
+		{desc: "leafref", leaf: "ref", in: "1", wantErr: "without the type of the leafref target ../count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			typ := types[tt.leaf]
+			v, err := typ.ParseValue(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("ParseValue(%q): %s", tt.in, diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := v.String(); got != tt.wantCanonical {
+				t.Errorf("ParseValue(%q) got canonical form %q, want %q", tt.in, got, tt.wantCanonical)
+			}
+			if tt.wantKind != Ynone && v.Type.Kind != tt.wantKind {
+				t.Errorf("ParseValue(%q) got member type %s, want %s", tt.in, v.Type.Kind, tt.wantKind)
+			}
+			got, err := typ.FormatCanonical(v.String())
+			if err != nil {
+				t.Fatalf("FormatCanonical(%q): %v", v.String(), err)
+			}
+			if got != tt.wantCanonical {
+				t.Errorf("FormatCanonical(%q) got %q, want %q", v.String(), got, tt.wantCanonical)
+			}
+		})
+	}
+}
+
+func TestParseValueTypes(t *testing.T) {
+	types := valueTypes(t)
+
+	tests := []struct {
+		leaf string
+		in   string
+		want interface{}
+	}{
+		{"i8", "-5", Number{Value: 5, Negative: true}},
+		{"dec", "1.5", Number{Value: 1500, FractionDigits: 3}},
+		{"bin", "AAE=", []byte{0, 1}},
+		{"flags", "a b", []string{"b", "a"}},
+		{"flag", "true", true},
+		{"none", "", nil},
+		{"color", "red", "red"},
+		{"str", "ab", "ab"},
+	}
+	for _, tt := range tests {
+		v, err := types[tt.leaf].ParseValue(tt.in)
+		if err != nil {
+			t.Errorf("ParseValue(%q): %v", tt.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, v.Value); diff != "" {
+			t.Errorf("ParseValue(%q) (-want, +got):\n%s", tt.in, diff)
+		}
+	}
+
+	v, err := types["pet"].ParseValue("poodle")
+	if err != nil {
+		t.Fatalf("ParseValue(poodle): %v", err)
+	}
+	if id, ok := v.Value.(*Identity); !ok || id.Name != "poodle" {
+		t.Errorf("ParseValue(poodle) got %v, want identity poodle", v.Value)
+	}
+}
@@ -0,0 +1,830 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the lexer, parser and compiler for XPath 1.0
+// expressions as used by YANG must and when statements, see
+// https://tools.ietf.org/html/rfc7950#section-6.4.  The evaluator is found in
+// xpath_eval.go.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// An XPath is a compiled XPath 1.0 expression.  Prefixes used in the
+// expression have been resolved to module names in the context of the node
+// the expression was defined in.
+type XPath struct {
+	expr    string
+	root    xpExpr
+	context Node    // node the expression was defined in
+	module  *Module // module of context, used for unprefixed names
+
+	// moduleNames is set when prefixes are module names, as they are
+	// in RFC 7951 encoded instance-identifiers.
+	moduleNames bool
+}
+
+// String returns the source text of x.
+func (x *XPath) String() string { return x.expr }
+
+// The kinds of XPath tokens.
+const (
+	xtEOF      = iota
+	xtNumber   // 1, 1.5, .5
+	xtLiteral  // "string" or 'string'
+	xtName     // NameTest: name, prefix:name, prefix:* and *
+	xtOp       // / // ( ) [ ] . .. @ , | + - = != < <= > >= and or mod div *
+	xtFunction // a function name, which is followed by (
+	xtNodeType // node, text, comment or processing-instruction followed by (
+	xtAxis     // an axis name, which is followed by ::
+	xtVariable // $name
+)
+
+type xpToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// xpLex splits s into XPath tokens, disambiguating names and operators as
+// described in https://www.w3.org/TR/1999/REC-xpath-19991116/#exprlex.
+func xpLex(s string) ([]xpToken, error) {
+	var tokens []xpToken
+	// operatorContext returns true if the next token must be read as an
+	// operator (i.e., * is multiply and a name is an operator name).
+	operatorContext := func() bool {
+		if len(tokens) == 0 {
+			return false
+		}
+		t := tokens[len(tokens)-1]
+		switch t.kind {
+		case xtOp:
+			switch t.text {
+			case "@", "::", "(", "[", ",":
+				return false
+			case ")", "]", ".", "..":
+				return true
+			}
+			return false
+		case xtAxis:
+			return false
+		}
+		return true
+	}
+	// peekNonSpace returns the first non-space character at or after i.
+	peekNonSpace := func(i int) (byte, int) {
+		for i < len(s) && isXPSpace(s[i]) {
+			i++
+		}
+		if i < len(s) {
+			return s[i], i
+		}
+		return 0, i
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+		switch {
+		case isXPSpace(c):
+			i++
+			continue
+		case c == '"' || c == '\'':
+			j := strings.IndexByte(s[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string literal at offset %d", i)
+			}
+			tokens = append(tokens, xpToken{xtLiteral, s[i+1 : i+1+j], start})
+			i += j + 2
+			continue
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, xpToken{xtNumber, s[start:i], start})
+			continue
+		case c == '$':
+			i++
+			name, n := xpQName(s[i:])
+			if name == "" {
+				return nil, fmt.Errorf("missing variable name at offset %d", start)
+			}
+			i += n
+			tokens = append(tokens, xpToken{xtVariable, name, start})
+			continue
+		case c == '*':
+			i++
+			if operatorContext() {
+				tokens = append(tokens, xpToken{xtOp, "*", start})
+			} else {
+				tokens = append(tokens, xpToken{xtName, "*", start})
+			}
+			continue
+		}
+
+		// Multi-character operators come first.
+		var op string
+		for _, o := range []string{"//", "..", "::", "!=", "<=", ">=", "/", "(", ")", "[", "]", ".", "@", ",", "|", "+", "-", "=", "<", ">"} {
+			if strings.HasPrefix(s[i:], o) {
+				op = o
+				break
+			}
+		}
+		if op != "" {
+			tokens = append(tokens, xpToken{xtOp, op, start})
+			i += len(op)
+			continue
+		}
+
+		name, n := xpQName(s[i:])
+		if name == "" {
+			r, _ := utf8.DecodeRuneInString(s[i:])
+			return nil, fmt.Errorf("unexpected character %q at offset %d", r, i)
+		}
+		i += n
+		if operatorContext() {
+			switch name {
+			case "and", "or", "mod", "div":
+				tokens = append(tokens, xpToken{xtOp, name, start})
+				continue
+			}
+			return nil, fmt.Errorf("unexpected name %q at offset %d", name, start)
+		}
+		switch next, j := peekNonSpace(i); {
+		case next == '(':
+			switch name {
+			case "node", "text", "comment", "processing-instruction":
+				tokens = append(tokens, xpToken{xtNodeType, name, start})
+			default:
+				tokens = append(tokens, xpToken{xtFunction, name, start})
+			}
+		case next == ':' && j+1 < len(s) && s[j+1] == ':':
+			tokens = append(tokens, xpToken{xtAxis, name, start})
+			tokens = append(tokens, xpToken{xtOp, "::", j})
+			i = j + 2
+		default:
+			tokens = append(tokens, xpToken{xtName, name, start})
+		}
+	}
+	tokens = append(tokens, xpToken{xtEOF, "", len(s)})
+	return tokens, nil
+}
+
+func isXPSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// xpQName returns the QName (or prefix:*) at the start of s and its length.
+// An empty string is returned if s does not start with a name.
+func xpQName(s string) (string, int) {
+	n := xpNCName(s)
+	if n == 0 {
+		return "", 0
+	}
+	if n+1 < len(s) && s[n] == ':' && s[n+1] != ':' {
+		if s[n+1] == '*' {
+			return s[:n+2], n + 2
+		}
+		if m := xpNCName(s[n+1:]); m > 0 {
+			return s[:n+1+m], n + 1 + m
+		}
+	}
+	return s[:n], n
+}
+
+// xpNCName returns the length of the NCName at the start of s.
+func xpNCName(s string) int {
+	for i, r := range s {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// The XPath expression tree.  Each node of the tree implements xpExpr.
+type xpExpr interface{}
+
+type (
+	xpNumber  float64
+	xpLiteral string
+	// xpBinary is a binary operator, op is one of or, and, =, !=, <, <=, >,
+	// >=, +, -, *, div, mod and |.
+	xpBinary struct {
+		op   string
+		l, r xpExpr
+	}
+	xpNegate struct {
+		x xpExpr
+	}
+	xpFunc struct {
+		name string
+		args []xpExpr
+		fn   *xpFunction
+	}
+	// xpFilter is a FilterExpr: a primary expression with predicates.
+	xpFilter struct {
+		primary xpExpr
+		preds   []xpExpr
+	}
+	// xpPath is a location path.  If filter is non-nil the path is a
+	// FilterExpr followed by a relative location path, otherwise the path
+	// starts at the context node, or the root if absolute is set.
+	xpPath struct {
+		filter   xpExpr
+		absolute bool
+		steps    []*xpStep
+	}
+	xpStep struct {
+		axis  string
+		test  xpNodeTest
+		preds []xpExpr
+	}
+	// xpNodeTest tests nodes found along an axis.  kind is "name" for a
+	// name test, "node" for node() and "text" for text() and other node
+	// type tests that never match instance data.  An empty local name
+	// matches any name, an empty module any module.  Unprefixed names are
+	// in the namespace of the current node, which for a node defined in a
+	// grouping is the module the grouping is used in.
+	xpNodeTest struct {
+		kind     string
+		module   string
+		local    string
+		prefixed bool
+	}
+)
+
+// xpParser is a recursive descent parser for XPath 1.0 expressions.
+type xpParser struct {
+	tokens []xpToken
+	x      *XPath
+}
+
+func (p *xpParser) peek() xpToken { return p.tokens[0] }
+
+func (p *xpParser) next() xpToken {
+	t := p.tokens[0]
+	if t.kind != xtEOF {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+func (p *xpParser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != xtOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *xpParser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		if t.kind == xtEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at offset %d, found %q", op, t.pos, t.text)
+	}
+	p.next()
+	return nil
+}
+
+// binary parses a left associative sequence of operands separated by any
+// of ops.
+func (p *xpParser) binary(operand func() (xpExpr, error), ops ...string) (xpExpr, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(ops...) {
+		op := p.next().text
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l = &xpBinary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *xpParser) orExpr() (xpExpr, error) { return p.binary(p.andExpr, "or") }
+func (p *xpParser) andExpr() (xpExpr, error) {
+	return p.binary(p.equalityExpr, "and")
+}
+func (p *xpParser) equalityExpr() (xpExpr, error) {
+	return p.binary(p.relationalExpr, "=", "!=")
+}
+func (p *xpParser) relationalExpr() (xpExpr, error) {
+	return p.binary(p.additiveExpr, "<", "<=", ">", ">=")
+}
+func (p *xpParser) additiveExpr() (xpExpr, error) {
+	return p.binary(p.multiplicativeExpr, "+", "-")
+}
+func (p *xpParser) multiplicativeExpr() (xpExpr, error) {
+	return p.binary(p.unaryExpr, "*", "div", "mod")
+}
+
+func (p *xpParser) unaryExpr() (xpExpr, error) {
+	if p.isOp("-") {
+		p.next()
+		x, err := p.unaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &xpNegate{x}, nil
+	}
+	return p.binary(p.pathExpr, "|")
+}
+
+// pathExpr parses a PathExpr, which is either a LocationPath or a
+// FilterExpr optionally followed by a relative location path.
+func (p *xpParser) pathExpr() (xpExpr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == xtNumber, t.kind == xtLiteral, t.kind == xtFunction, t.kind == xtVariable, t.kind == xtOp && t.text == "(":
+		primary, err := p.primaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		var preds []xpExpr
+		for p.isOp("[") {
+			pred, err := p.predicate()
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, pred)
+		}
+		var filter xpExpr = primary
+		if len(preds) > 0 {
+			filter = &xpFilter{primary: primary, preds: preds}
+		}
+		if !p.isOp("/", "//") {
+			return filter, nil
+		}
+		path := &xpPath{filter: filter}
+		return path, p.relativePath(path, true)
+	}
+	return p.locationPath()
+}
+
+func (p *xpParser) primaryExpr() (xpExpr, error) {
+	t := p.next()
+	switch t.kind {
+	case xtNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return xpNumber(f), nil
+	case xtLiteral:
+		return xpLiteral(t.text), nil
+	case xtVariable:
+		return nil, fmt.Errorf("variable $%s is not supported in YANG", t.text)
+	case xtFunction:
+		return p.functionCall(t)
+	}
+	// Must be "("
+	x, err := p.orExpr()
+	if err != nil {
+		return nil, err
+	}
+	return x, p.expect(")")
+}
+
+func (p *xpParser) functionCall(t xpToken) (xpExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	f := &xpFunc{name: t.text}
+	if !p.isOp(")") {
+		for {
+			arg, err := p.orExpr()
+			if err != nil {
+				return nil, err
+			}
+			f.args = append(f.args, arg)
+			if !p.isOp(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	prefix, name := getPrefix(f.name)
+	if prefix != "" {
+		return nil, fmt.Errorf("unknown function %s", f.name)
+	}
+	f.fn = xpFunctions[name]
+	switch {
+	case f.fn == nil:
+		return nil, fmt.Errorf("unknown function %s", f.name)
+	case len(f.args) < f.fn.minArgs, f.fn.maxArgs >= 0 && len(f.args) > f.fn.maxArgs:
+		return nil, fmt.Errorf("wrong number of arguments to %s(): %d", f.name, len(f.args))
+	}
+	return f, nil
+}
+
+func (p *xpParser) predicate() (xpExpr, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	x, err := p.orExpr()
+	if err != nil {
+		return nil, err
+	}
+	return x, p.expect("]")
+}
+
+func (p *xpParser) locationPath() (xpExpr, error) {
+	path := &xpPath{}
+	if p.isOp("/") {
+		p.next()
+		path.absolute = true
+		// A lone "/" selects the root.
+		if !p.startsStep() {
+			return path, nil
+		}
+		return path, p.relativePath(path, false)
+	}
+	if p.isOp("//") {
+		path.absolute = true
+		return path, p.relativePath(path, true)
+	}
+	return path, p.relativePath(path, false)
+}
+
+// startsStep returns true if the next token can start a location step.
+func (p *xpParser) startsStep() bool {
+	t := p.peek()
+	switch t.kind {
+	case xtName, xtNodeType, xtAxis:
+		return true
+	case xtOp:
+		return t.text == "." || t.text == ".." || t.text == "@"
+	}
+	return false
+}
+
+// relativePath parses a RelativeLocationPath into path.  If separated is set
+// then the path begins with a "/" or "//" separator.
+func (p *xpParser) relativePath(path *xpPath, separated bool) error {
+	for first := !separated; ; first = false {
+		if !first {
+			switch {
+			case p.isOp("/"):
+				p.next()
+			case p.isOp("//"):
+				p.next()
+				path.steps = append(path.steps, &xpStep{axis: "descendant-or-self", test: xpNodeTest{kind: "node"}})
+			default:
+				return nil
+			}
+		}
+		step, err := p.step()
+		if err != nil {
+			return err
+		}
+		path.steps = append(path.steps, step)
+	}
+}
+
+func (p *xpParser) step() (*xpStep, error) {
+	switch {
+	case p.isOp("."):
+		p.next()
+		return &xpStep{axis: "self", test: xpNodeTest{kind: "node"}}, nil
+	case p.isOp(".."):
+		p.next()
+		return &xpStep{axis: "parent", test: xpNodeTest{kind: "node"}}, nil
+	}
+	step := &xpStep{axis: "child"}
+	switch t := p.peek(); {
+	case p.isOp("@"):
+		p.next()
+		step.axis = "attribute"
+	case t.kind == xtAxis:
+		p.next()
+		p.next() // ::
+		if !xpAxes[t.text] {
+			return nil, fmt.Errorf("unknown axis %s", t.text)
+		}
+		step.axis = t.text
+	}
+
+	t := p.next()
+	switch t.kind {
+	case xtName:
+		test, err := p.nameTest(t.text)
+		if err != nil {
+			return nil, err
+		}
+		step.test = test
+	case xtNodeType:
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if t.text == "processing-instruction" && p.peek().kind == xtLiteral {
+			p.next()
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		step.test = xpNodeTest{kind: t.text}
+	case xtEOF:
+		return nil, fmt.Errorf("unexpected end of expression, expected a location step")
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+
+	for p.isOp("[") {
+		pred, err := p.predicate()
+		if err != nil {
+			return nil, err
+		}
+		step.preds = append(step.preds, pred)
+	}
+	return step, nil
+}
+
+// nameTest resolves the prefix of the name test s.  The module of an
+// unprefixed name defaults to the module the expression was defined in, see
+// RFC 7950 section 6.4.1.
+func (p *xpParser) nameTest(s string) (xpNodeTest, error) {
+	test := xpNodeTest{kind: "name"}
+	prefix, local := getPrefix(s)
+	test.prefixed = prefix != ""
+	if local != "*" {
+		test.local = local
+	}
+	if s == "*" {
+		return test, nil
+	}
+	mod, err := p.x.resolvePrefix(prefix)
+	if err != nil {
+		return test, err
+	}
+	if mod != nil {
+		test.module = mod.Name
+	}
+	return test, nil
+}
+
+// resolvePrefix returns the module that prefix refers to in the context of
+// x.  The empty prefix refers to the module x was defined in.  A nil module
+// and no error is returned if x has no context.
+func (x *XPath) resolvePrefix(prefix string) (*Module, error) {
+	if x.moduleNames {
+		if prefix == "" {
+			return nil, nil
+		}
+		return &Module{Name: prefix}, nil
+	}
+	if x.context == nil {
+		if prefix != "" {
+			return nil, fmt.Errorf("unknown prefix %q", prefix)
+		}
+		return nil, nil
+	}
+	if prefix == "" {
+		return x.module, nil
+	}
+	m := FindModuleByPrefix(x.context, prefix)
+	if m == nil {
+		return nil, fmt.Errorf("unknown prefix %q", prefix)
+	}
+	if mm := module(m); mm != nil {
+		m = mm
+	}
+	return m, nil
+}
+
+var xpAxes = map[string]bool{
+	"ancestor":           true,
+	"ancestor-or-self":   true,
+	"attribute":          true,
+	"child":              true,
+	"descendant":         true,
+	"descendant-or-self": true,
+	"following":          true,
+	"following-sibling":  true,
+	"namespace":          true,
+	"parent":             true,
+	"preceding":          true,
+	"preceding-sibling":  true,
+	"self":               true,
+}
+
+// CompileXPath compiles the XPath 1.0 expression expr.  Prefixes in expr are
+// resolved in the context of n, the node the expression is defined in, and
+// unprefixed names refer to the module of n.  n may be nil, in which case
+// only unprefixed names may be used and they match nodes of any module.
+func CompileXPath(n Node, expr string) (*XPath, error) {
+	x := &XPath{expr: expr, context: n}
+	if n != nil {
+		x.module = module(n)
+		if x.module == nil {
+			x.module = RootNode(n)
+		}
+	}
+	tokens, err := xpLex(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %v", expr, err)
+	}
+	p := &xpParser{tokens: tokens, x: x}
+	x.root, err = p.orExpr()
+	if err == nil && p.peek().kind != xtEOF {
+		t := p.peek()
+		err = fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %v", expr, err)
+	}
+	return x, nil
+}
+
+// compileStatement compiles the XPath argument of v, which is a must or when
+// statement, returning errors as Diagnostics for the statement.
+func compileStatement(v Node) (*XPath, error) {
+	x, err := CompileXPath(v.ParentNode(), v.NName())
+	if err != nil {
+		return nil, diagnosticf(v, CodeInvalidValue, "%s %v", v.Kind(), err)
+	}
+	return x, nil
+}
+
+// WhenXPath returns the compiled when statement of e, or nil if e has none.
+// An error is returned if the expression does not compile.
+func (e *Entry) WhenXPath() (*XPath, error) {
+	v := whenValue(e.Node)
+	if v == nil {
+		return nil, nil
+	}
+	x, err := CompileXPath(e.Node, v.Name)
+	if err != nil {
+		return nil, diagnosticf(v, CodeInvalidValue, "when %v", err)
+	}
+	return x, nil
+}
+
+// MustXPaths returns the compiled must statements of e.  An error is
+// returned for the first expression that does not compile.
+func (e *Entry) MustXPaths() ([]*XPath, error) {
+	var xs []*XPath
+	for _, m := range Musts(e.Node) {
+		x, err := compileStatement(m)
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+	return xs, nil
+}
+
+// Musts returns the must statements of n, if it has any.
+func Musts(n Node) []*Must {
+	switch n := n.(type) {
+	case *Container:
+		return n.Must
+	case *Leaf:
+		return n.Must
+	case *LeafList:
+		return n.Must
+	case *List:
+		return n.Must
+	case *AnyXML:
+		return n.Must
+	case *AnyData:
+		return n.Must
+	}
+	return nil
+}
+
+// whenValue returns the when statement of n, if it has one.
+func whenValue(n Node) *Value {
+	switch n := n.(type) {
+	case *Container:
+		return n.When
+	case *Leaf:
+		return n.When
+	case *LeafList:
+		return n.When
+	case *List:
+		return n.When
+	case *Choice:
+		return n.When
+	case *Case:
+		return n.When
+	case *AnyXML:
+		return n.When
+	case *AnyData:
+		return n.When
+	case *Augment:
+		return n.When
+	case *Uses:
+		return n.When
+	}
+	return nil
+}
+
+// checkXPaths compiles the when and must statements of e and all of its
+// descendants and resolves their location paths against the Entry tree,
+// returning any errors.  seen prevents checking the same statement more than
+// once when groupings are used multiple times.
+func (e *Entry) checkXPaths(seen map[Node]bool) []error {
+	var errs []error
+	if e.Node != nil && !seen[e.Node] {
+		seen[e.Node] = true
+		if x, err := e.WhenXPath(); err != nil {
+			errs = append(errs, err)
+		} else if x != nil {
+			errs = append(errs, e.checkXPathSchema(e, whenValue(e.Node), "when", x)...)
+		}
+		for _, m := range Musts(e.Node) {
+			x, err := compileStatement(m)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			errs = append(errs, e.checkXPathSchema(e, m, "must", x)...)
+		}
+	}
+	for _, c := range e.Dir {
+		errs = append(errs, c.checkXPaths(seen)...)
+	}
+	if e.RPC != nil {
+		if e.RPC.Input != nil {
+			errs = append(errs, e.RPC.Input.checkXPaths(seen)...)
+		}
+		if e.RPC.Output != nil {
+			errs = append(errs, e.RPC.Output.checkXPaths(seen)...)
+		}
+	}
+	// The context of the when of an applied augment is its target, the
+	// augments that could not be applied are only compiled.
+	for _, a := range e.Augmented {
+		if a.Node == nil || seen[a.Node] {
+			continue
+		}
+		seen[a.Node] = true
+		if x, err := a.WhenXPath(); err != nil {
+			errs = append(errs, err)
+		} else if x != nil {
+			errs = append(errs, a.checkXPathSchema(e, whenValue(a.Node), "when", x)...)
+		}
+	}
+	for _, a := range e.Augments {
+		errs = append(errs, a.checkXPaths(seen)...)
+	}
+	return errs
+}
+
+// checkXPathSchema resolves the location paths of x, the compiled argument
+// of the statement n of e, which is a must or when statement as given by
+// kind, against the Entry tree with ctx, or its closest data ancestor, as
+// the context entry.
+func (e *Entry) checkXPathSchema(ctx *Entry, n Node, kind string, x *XPath) []error {
+	for ctx != nil && (ctx.IsChoice() || ctx.IsCase()) {
+		ctx = ctx.Parent
+	}
+	if ctx == nil || ctx.Parent == nil {
+		return nil
+	}
+	if isAugment(ctx.Node) {
+		// The target of an augment that was not applied is unknown.
+		return nil
+	}
+	if err := checkXPathSchema(x, ctx); err != nil {
+		if ms := e.Modules(); ms == nil || !ms.ParseOptions.IgnoreModuleResolveErrors {
+			return []error{diagnosticf(n, CodeUnresolved, "%s %q: %v", kind, x, err)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,901 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the evaluation of compiled XPath expressions against a
+// DataNode tree, including the core XPath 1.0 function library and the YANG
+// functions defined in https://tools.ietf.org/html/rfc7950#section-10.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An XPath expression evaluates to one of the four XPath 1.0 types:
+//
+//	node-set  []*DataNode, in document order
+//	boolean   bool
+//	number    float64
+//	string    string
+
+// xpContext is the dynamic context of an evaluation.
+type xpContext struct {
+	x       *XPath
+	node    *DataNode // the context node
+	pos     int       // the context position, starting at 1
+	size    int       // the context size
+	current *DataNode // the initial context node, returned by current()
+}
+
+func (c *xpContext) with(n *DataNode, pos, size int) *xpContext {
+	nc := *c
+	nc.node, nc.pos, nc.size = n, pos, size
+	return &nc
+}
+
+// Evaluate evaluates x with n as the context node, which is also the node
+// returned by current().  The result is a []*DataNode, string, float64 or
+// bool.
+func (x *XPath) Evaluate(n *DataNode) (interface{}, error) {
+	if n == nil {
+		return nil, fmt.Errorf("%s: no context node", x.expr)
+	}
+	c := &xpContext{x: x, node: n, pos: 1, size: 1, current: n}
+	v, err := c.eval(x.root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", x.expr, err)
+	}
+	return v, nil
+}
+
+// EvaluateBool evaluates x with n as the context node and converts the
+// result to a boolean, as is done for must and when statements.
+func (x *XPath) EvaluateBool(n *DataNode) (bool, error) {
+	v, err := x.Evaluate(n)
+	if err != nil {
+		return false, err
+	}
+	return xpBoolean(v), nil
+}
+
+// EvaluateNodes evaluates x with n as the context node and returns the
+// resulting node-set.  An error is returned if x does not evaluate to a
+// node-set.
+func (x *XPath) EvaluateNodes(n *DataNode) ([]*DataNode, error) {
+	v, err := x.Evaluate(n)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.([]*DataNode)
+	if !ok {
+		return nil, fmt.Errorf("%s: expression does not evaluate to a node-set", x.expr)
+	}
+	return ns, nil
+}
+
+func (c *xpContext) eval(e xpExpr) (interface{}, error) {
+	switch e := e.(type) {
+	case xpNumber:
+		return float64(e), nil
+	case xpLiteral:
+		return string(e), nil
+	case *xpNegate:
+		v, err := c.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		return -xpNumberOf(v), nil
+	case *xpBinary:
+		return c.binary(e)
+	case *xpFunc:
+		return e.fn.call(c, e)
+	case *xpFilter:
+		v, err := c.eval(e.primary)
+		if err != nil {
+			return nil, err
+		}
+		ns, ok := v.([]*DataNode)
+		if !ok {
+			return nil, fmt.Errorf("predicate applied to a non node-set")
+		}
+		return c.filter(ns, e.preds)
+	case *xpPath:
+		return c.path(e)
+	}
+	return nil, fmt.Errorf("unknown expression type %T", e)
+}
+
+func (c *xpContext) binary(e *xpBinary) (interface{}, error) {
+	l, err := c.eval(e.l)
+	if err != nil {
+		return nil, err
+	}
+	// and and or short circuit.
+	switch e.op {
+	case "and":
+		if !xpBoolean(l) {
+			return false, nil
+		}
+	case "or":
+		if xpBoolean(l) {
+			return true, nil
+		}
+	}
+	r, err := c.eval(e.r)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "and", "or":
+		return xpBoolean(r), nil
+	case "|":
+		ln, lok := l.([]*DataNode)
+		rn, rok := r.([]*DataNode)
+		if !lok || !rok {
+			return nil, fmt.Errorf("operands of | must be node-sets")
+		}
+		return docOrder(append(append([]*DataNode{}, ln...), rn...)), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return xpCompare(e.op, l, r), nil
+	}
+	a, b := xpNumberOf(l), xpNumberOf(r)
+	switch e.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "div":
+		return a / b, nil
+	case "mod":
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", e.op)
+}
+
+// xpCompare compares l and r with op following the rules of XPath 1.0
+// section 3.4.
+func xpCompare(op string, l, r interface{}) bool {
+	ln, lok := l.([]*DataNode)
+	rn, rok := r.([]*DataNode)
+	switch {
+	case lok && rok:
+		for _, a := range ln {
+			for _, b := range rn {
+				if xpCompareAtoms(op, a.StringValue(), b.StringValue()) {
+					return true
+				}
+			}
+		}
+		return false
+	case lok:
+		if b, ok := r.(bool); ok {
+			return xpCompareAtoms(op, len(ln) > 0, b)
+		}
+		for _, a := range ln {
+			if xpCompareAtoms(op, xpConvertLike(a.StringValue(), r), r) {
+				return true
+			}
+		}
+		return false
+	case rok:
+		if a, ok := l.(bool); ok {
+			return xpCompareAtoms(op, a, len(rn) > 0)
+		}
+		for _, b := range rn {
+			if xpCompareAtoms(op, l, xpConvertLike(b.StringValue(), l)) {
+				return true
+			}
+		}
+		return false
+	}
+	return xpCompareAtoms(op, l, r)
+}
+
+// xpConvertLike converts the string s to the type of v, which is a number
+// or a string.
+func xpConvertLike(s string, v interface{}) interface{} {
+	if _, ok := v.(float64); ok {
+		return xpNumberOf(s)
+	}
+	return s
+}
+
+// xpCompareAtoms compares two values that are not node-sets.
+func xpCompareAtoms(op string, l, r interface{}) bool {
+	switch op {
+	case "=", "!=":
+		var eq bool
+		_, lb := l.(bool)
+		_, rb := r.(bool)
+		_, lf := l.(float64)
+		_, rf := r.(float64)
+		switch {
+		case lb || rb:
+			eq = xpBoolean(l) == xpBoolean(r)
+		case lf || rf:
+			eq = xpNumberOf(l) == xpNumberOf(r)
+		default:
+			eq = xpString(l) == xpString(r)
+		}
+		return eq == (op == "=")
+	}
+	a, b := xpNumberOf(l), xpNumberOf(r)
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	}
+	return a >= b
+}
+
+// filter returns the nodes of ns that satisfy all of preds.
+func (c *xpContext) filter(ns []*DataNode, preds []xpExpr) ([]*DataNode, error) {
+	for _, pred := range preds {
+		var out []*DataNode
+		for i, n := range ns {
+			v, err := c.with(n, i+1, len(ns)).eval(pred)
+			if err != nil {
+				return nil, err
+			}
+			keep := false
+			if f, ok := v.(float64); ok {
+				keep = f == float64(i+1)
+			} else {
+				keep = xpBoolean(v)
+			}
+			if keep {
+				out = append(out, n)
+			}
+		}
+		ns = out
+	}
+	return ns, nil
+}
+
+func (c *xpContext) path(p *xpPath) (interface{}, error) {
+	var ns []*DataNode
+	switch {
+	case p.filter != nil:
+		v, err := c.eval(p.filter)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if ns, ok = v.([]*DataNode); !ok {
+			return nil, fmt.Errorf("path applied to a non node-set")
+		}
+	case p.absolute:
+		ns = []*DataNode{c.node.Root()}
+	default:
+		ns = []*DataNode{c.node}
+	}
+	for _, s := range p.steps {
+		var out []*DataNode
+		for _, n := range ns {
+			found, err := c.step(n, s)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, found...)
+		}
+		ns = docOrder(out)
+	}
+	return ns, nil
+}
+
+// step returns the nodes selected by s from context node n.
+func (c *xpContext) step(n *DataNode, s *xpStep) ([]*DataNode, error) {
+	var ns []*DataNode
+	for _, a := range xpAxis(n, s.axis) {
+		if s.test.matches(a, c.current) {
+			ns = append(ns, a)
+		}
+	}
+	// Predicates are applied with positions in axis order, which is
+	// reverse document order for the reverse axes.
+	return c.filter(ns, s.preds)
+}
+
+// matches returns true if n passes test t.  current is the current node,
+// whose module is the namespace of unprefixed names.
+func (t xpNodeTest) matches(n, current *DataNode) bool {
+	switch t.kind {
+	case "node":
+		return true
+	case "name":
+		if n.IsRoot() {
+			return false
+		}
+		module := t.module
+		if !t.prefixed && current != nil && current.Module != "" {
+			module = current.Module
+		}
+		return (t.local == "" || t.local == n.Name) && (module == "" || module == n.Module)
+	}
+	// Leaf values are held in their nodes rather than in text children.
+	return false
+}
+
+// xpAxis returns the nodes along axis from n in axis order.
+func xpAxis(n *DataNode, axis string) []*DataNode {
+	var ns []*DataNode
+	switch axis {
+	case "self":
+		return []*DataNode{n}
+	case "child":
+		return n.Children
+	case "parent":
+		if n.Parent != nil {
+			return []*DataNode{n.Parent}
+		}
+	case "ancestor-or-self":
+		ns = append(ns, n)
+		fallthrough
+	case "ancestor":
+		for p := n.Parent; p != nil; p = p.Parent {
+			ns = append(ns, p)
+		}
+	case "descendant-or-self":
+		ns = append(ns, n)
+		fallthrough
+	case "descendant":
+		for _, c := range n.Children {
+			ns = append(ns, xpAxis(c, "descendant-or-self")...)
+		}
+	case "following-sibling", "preceding-sibling":
+		if n.Parent == nil {
+			return nil
+		}
+		sibs := n.Parent.Children
+		for i, s := range sibs {
+			if s != n {
+				continue
+			}
+			if axis == "following-sibling" {
+				return sibs[i+1:]
+			}
+			for j := i - 1; j >= 0; j-- {
+				ns = append(ns, sibs[j])
+			}
+			return ns
+		}
+	case "following", "preceding":
+		all := xpAxis(n.Root(), "descendant-or-self")
+		i := 0
+		for ; i < len(all) && all[i] != n; i++ {
+		}
+		if axis == "following" {
+			for j := i + 1; j < len(all); j++ {
+				if !isAncestor(n, all[j]) {
+					ns = append(ns, all[j])
+				}
+			}
+			return ns
+		}
+		for j := i - 1; j >= 0; j-- {
+			if !isAncestor(all[j], n) {
+				ns = append(ns, all[j])
+			}
+		}
+	}
+	// The attribute and namespace axes are always empty.
+	return ns
+}
+
+// isAncestor returns true if a is a proper ancestor of n.
+func isAncestor(a, n *DataNode) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == a {
+			return true
+		}
+	}
+	return false
+}
+
+// docOrder sorts ns into document order and removes duplicates.
+func docOrder(ns []*DataNode) []*DataNode {
+	if len(ns) < 2 {
+		return ns
+	}
+	index := map[*DataNode][]int{}
+	var key func(*DataNode) []int
+	key = func(n *DataNode) []int {
+		if k, ok := index[n]; ok {
+			return k
+		}
+		var k []int
+		if n.Parent != nil {
+			k = append(k, key(n.Parent)...)
+			for i, c := range n.Parent.Children {
+				if c == n {
+					k = append(k, i)
+					break
+				}
+			}
+		}
+		index[n] = k
+		return k
+	}
+	seen := map[*DataNode]bool{}
+	out := make([]*DataNode, 0, len(ns))
+	for _, n := range ns {
+		if !seen[n] {
+			seen[n] = true
+			key(n)
+			out = append(out, n)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := index[out[i]], index[out[j]]
+		for x := 0; x < len(a) && x < len(b); x++ {
+			if a[x] != b[x] {
+				return a[x] < b[x]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return out
+}
+
+// xpString converts v to a string as the XPath string() function does.
+func xpString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == math.Trunc(v) && math.Abs(v) < 1e15:
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []*DataNode:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].StringValue()
+	}
+	return ""
+}
+
+// xpNumberOf converts v to a number as the XPath number() function does.
+func xpNumberOf(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	s := strings.TrimSpace(xpString(v))
+	// XPath numbers have no exponent, sign prefix other than -, or
+	// special values.
+	if s == "" || strings.ContainsAny(s, "eE+xXnN") {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// xpBoolean converts v to a boolean as the XPath boolean() function does.
+func xpBoolean(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case []*DataNode:
+		return len(v) > 0
+	}
+	return false
+}
+
+// An xpFunction is a function in the XPath function library.  maxArgs is -1
+// for functions with a variable number of arguments.
+type xpFunction struct {
+	minArgs, maxArgs int
+	fn               func(c *xpContext, f *xpFunc, args []interface{}) (interface{}, error)
+}
+
+// call evaluates the arguments of f and calls its function.
+func (fn *xpFunction) call(c *xpContext, f *xpFunc) (interface{}, error) {
+	args := make([]interface{}, len(f.args))
+	for i, a := range f.args {
+		v, err := c.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := fn.fn(c, f, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %v", f.name, err)
+	}
+	return v, nil
+}
+
+// nodeSetArg returns args[i] as a node-set.
+func nodeSetArg(args []interface{}, i int) ([]*DataNode, error) {
+	ns, ok := args[i].([]*DataNode)
+	if !ok {
+		return nil, fmt.Errorf("argument %d is not a node-set", i+1)
+	}
+	return ns, nil
+}
+
+// stringArg returns args[i] as a string, or the string-value of the context
+// node if there is no args[i].
+func stringArg(c *xpContext, args []interface{}, i int) string {
+	if i >= len(args) {
+		return c.node.StringValue()
+	}
+	return xpString(args[i])
+}
+
+// firstNodeArg returns the first node of the node-set in args[0], or the
+// context node if there are no arguments.  nil is returned for an empty
+// node-set.
+func firstNodeArg(c *xpContext, args []interface{}) (*DataNode, error) {
+	if len(args) == 0 {
+		return c.node, nil
+	}
+	ns, err := nodeSetArg(args, 0)
+	if err != nil || len(ns) == 0 {
+		return nil, err
+	}
+	return ns[0], nil
+}
+
+var xpFunctions map[string]*xpFunction
+
+func init() {
+	// xpFunctions is initialized here as some of the functions refer to
+	// compiled expressions that in turn refer to xpFunctions.
+	xpFunctions = map[string]*xpFunction{
+		// Node set functions.
+		"last": {0, 0, func(c *xpContext, _ *xpFunc, _ []interface{}) (interface{}, error) {
+			return float64(c.size), nil
+		}},
+		"position": {0, 0, func(c *xpContext, _ *xpFunc, _ []interface{}) (interface{}, error) {
+			return float64(c.pos), nil
+		}},
+		"count": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			ns, err := nodeSetArg(args, 0)
+			return float64(len(ns)), err
+		}},
+		"local-name": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			n, err := firstNodeArg(c, args)
+			if n == nil {
+				return "", err
+			}
+			return n.Name, nil
+		}},
+		"namespace-uri": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			n, err := firstNodeArg(c, args)
+			if n == nil || n.Schema == nil {
+				return "", err
+			}
+			if m := RootNode(n.Schema.Node); m != nil && m.Namespace != nil {
+				return m.Namespace.Name, nil
+			}
+			return "", nil
+		}},
+		"name": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			n, err := firstNodeArg(c, args)
+			if n == nil || n.IsRoot() {
+				return "", err
+			}
+			return n.Module + ":" + n.Name, nil
+		}},
+
+		// String functions.
+		"string": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return stringArg(c, args, 0), nil
+		}},
+		"concat": {2, -1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			var b strings.Builder
+			for _, a := range args {
+				b.WriteString(xpString(a))
+			}
+			return b.String(), nil
+		}},
+		"starts-with": {2, 2, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return strings.HasPrefix(xpString(args[0]), xpString(args[1])), nil
+		}},
+		"contains": {2, 2, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return strings.Contains(xpString(args[0]), xpString(args[1])), nil
+		}},
+		"substring-before": {2, 2, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			s, sep := xpString(args[0]), xpString(args[1])
+			if i := strings.Index(s, sep); i >= 0 {
+				return s[:i], nil
+			}
+			return "", nil
+		}},
+		"substring-after": {2, 2, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			s, sep := xpString(args[0]), xpString(args[1])
+			if i := strings.Index(s, sep); i >= 0 {
+				return s[i+len(sep):], nil
+			}
+			return "", nil
+		}},
+		"substring": {2, 3, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			s := []rune(xpString(args[0]))
+			start := xpRound(xpNumberOf(args[1]))
+			end := math.Inf(1)
+			if len(args) == 3 {
+				end = start + xpRound(xpNumberOf(args[2]))
+			}
+			var b strings.Builder
+			for i, r := range s {
+				if p := float64(i + 1); p >= start && p < end {
+					b.WriteRune(r)
+				}
+			}
+			return b.String(), nil
+		}},
+		"string-length": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return float64(len([]rune(stringArg(c, args, 0)))), nil
+		}},
+		"normalize-space": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return strings.Join(strings.Fields(stringArg(c, args, 0)), " "), nil
+		}},
+		"translate": {3, 3, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			from, to := []rune(xpString(args[1])), []rune(xpString(args[2]))
+			var b strings.Builder
+		outer:
+			for _, r := range xpString(args[0]) {
+				for i, f := range from {
+					if f == r {
+						if i < len(to) {
+							b.WriteRune(to[i])
+						}
+						continue outer
+					}
+				}
+				b.WriteRune(r)
+			}
+			return b.String(), nil
+		}},
+
+		// Boolean functions.
+		"boolean": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return xpBoolean(args[0]), nil
+		}},
+		"not": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return !xpBoolean(args[0]), nil
+		}},
+		"true": {0, 0, func(*xpContext, *xpFunc, []interface{}) (interface{}, error) {
+			return true, nil
+		}},
+		"false": {0, 0, func(*xpContext, *xpFunc, []interface{}) (interface{}, error) {
+			return false, nil
+		}},
+		// Instance data carries no xml:lang attributes.
+		"lang": {1, 1, func(*xpContext, *xpFunc, []interface{}) (interface{}, error) {
+			return false, nil
+		}},
+
+		// Number functions.
+		"number": {0, 1, func(c *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			if len(args) == 0 {
+				return xpNumberOf(c.node.StringValue()), nil
+			}
+			return xpNumberOf(args[0]), nil
+		}},
+		"sum": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			ns, err := nodeSetArg(args, 0)
+			var sum float64
+			for _, n := range ns {
+				sum += xpNumberOf(n.StringValue())
+			}
+			return sum, err
+		}},
+		"floor": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return math.Floor(xpNumberOf(args[0])), nil
+		}},
+		"ceiling": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return math.Ceil(xpNumberOf(args[0])), nil
+		}},
+		"round": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			return xpRound(xpNumberOf(args[0])), nil
+		}},
+
+		// YANG functions, RFC 7950 section 10.
+		"current": {0, 0, func(c *xpContext, _ *xpFunc, _ []interface{}) (interface{}, error) {
+			return []*DataNode{c.current}, nil
+		}},
+		"deref": {1, 1, xpDeref},
+		"derived-from": {2, 2, func(c *xpContext, f *xpFunc, args []interface{}) (interface{}, error) {
+			return xpDerivedFrom(c, args, false)
+		}},
+		"derived-from-or-self": {2, 2, func(c *xpContext, f *xpFunc, args []interface{}) (interface{}, error) {
+			return xpDerivedFrom(c, args, true)
+		}},
+		"re-match": {2, 2, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			p, err := CompilePattern(xpString(args[1]), false)
+			if err != nil {
+				return nil, err
+			}
+			return p.MatchString(xpString(args[0])), nil
+		}},
+		"enum-value": {1, 1, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			ns, err := nodeSetArg(args, 0)
+			if err != nil || len(ns) == 0 {
+				return math.NaN(), err
+			}
+			n := ns[0]
+			if n.Schema == nil || n.Schema.Type == nil || n.Schema.Type.Kind != Yenum || !n.Schema.Type.Enum.IsDefined(n.Value) {
+				return math.NaN(), nil
+			}
+			return float64(n.Schema.Type.Enum.Value(n.Value)), nil
+		}},
+		"bit-is-set": {2, 2, func(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+			ns, err := nodeSetArg(args, 0)
+			if err != nil || len(ns) == 0 {
+				return false, err
+			}
+			n, bit := ns[0], xpString(args[1])
+			if n.Schema == nil || n.Schema.Type == nil || n.Schema.Type.Kind != Ybits {
+				return false, nil
+			}
+			for _, b := range strings.Fields(n.Value) {
+				if b == bit {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+	}
+}
+
+// xpRound implements the XPath round() function, which rounds halves
+// towards positive infinity.
+func xpRound(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
+
+// xpDeref implements deref() for leafref and instance-identifier nodes.
+func xpDeref(_ *xpContext, _ *xpFunc, args []interface{}) (interface{}, error) {
+	ns, err := nodeSetArg(args, 0)
+	if err != nil || len(ns) == 0 {
+		return []*DataNode{}, err
+	}
+	n := ns[0]
+	if n.Schema == nil || n.Schema.Type == nil {
+		return []*DataNode{}, nil
+	}
+	switch n.Schema.Type.Kind {
+	case Yleafref:
+		x, err := CompileXPath(n.Schema.Node, n.Schema.Type.Path)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := (&xpContext{x: x, node: n, pos: 1, size: 1, current: n}).eval(x.root)
+		if err != nil {
+			return nil, err
+		}
+		tns, ok := targets.([]*DataNode)
+		if !ok {
+			return nil, fmt.Errorf("leafref path %q is not a node-set", x.expr)
+		}
+		var out []*DataNode
+		for _, t := range tns {
+			if t.Value == n.Value {
+				out = append(out, t)
+			}
+		}
+		return out, nil
+	case YinstanceIdentifier:
+		x, err := compileDataPath(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		return x.EvaluateNodes(n.Root())
+	}
+	return []*DataNode{}, nil
+}
+
+// compileDataPath compiles an RFC 7951 instance-identifier value, whose
+// prefixes are module names rather than YANG prefixes.
+func compileDataPath(s string) (*XPath, error) {
+	x := &XPath{expr: s, moduleNames: true}
+	tokens, err := xpLex(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance-identifier %q: %v", s, err)
+	}
+	p := &xpParser{tokens: tokens, x: x}
+	if x.root, err = p.orExpr(); err == nil && p.peek().kind != xtEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance-identifier %q: %v", s, err)
+	}
+	return x, nil
+}
+
+// xpDerivedFrom implements derived-from() and derived-from-or-self().  The
+// identity named by the second argument is resolved in the context of the
+// expression, the identityref values of the nodes in the first argument are
+// in their RFC 7951 form, module:identity.
+func xpDerivedFrom(c *xpContext, args []interface{}, orSelf bool) (interface{}, error) {
+	ns, err := nodeSetArg(args, 0)
+	if err != nil {
+		return false, err
+	}
+	prefix, name := getPrefix(xpString(args[1]))
+	mod, err := c.x.resolvePrefix(prefix)
+	if err != nil {
+		return false, err
+	}
+	if mod == nil {
+		return false, fmt.Errorf("cannot resolve identity %q without a module", xpString(args[1]))
+	}
+	base := lookupIdentity(mod, mod.Name+":"+name)
+	if base == nil {
+		return false, fmt.Errorf("unknown identity %s", xpString(args[1]))
+	}
+	for _, n := range ns {
+		if n.Schema == nil || n.Schema.Type == nil || n.Schema.Type.Kind != Yidentityref {
+			continue
+		}
+		v := n.Value
+		if !strings.Contains(v, ":") {
+			v = n.Module + ":" + v
+		}
+		id := lookupIdentity(mod, v)
+		if id == nil {
+			continue
+		}
+		if orSelf && id == base || id.IsDerivedFrom(base) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lookupIdentity returns the identity with the module qualified name key,
+// searching the identities known to the Modules that m was parsed by.
+func lookupIdentity(m *Module, key string) *Identity {
+	if m == nil || m.Modules == nil {
+		return nil
+	}
+	return m.Modules.FindIdentity(key)
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import "fmt"
+
+// This file resolves the location paths of compiled must and when
+// expressions against the Entry tree, as is done for leafref paths, to find
+// the names that do not match any schema node.  Only the child, parent and
+// self axes with name tests are followed, a path is not checked past a step
+// that uses another axis, a wildcard or a node type test, or that starts
+// with a function other than current().
+
+// An xpSchemaChecker resolves the location paths of an expression.
+type xpSchemaChecker struct {
+	r *leafrefResolver // resolves names, r.leaf is current()
+}
+
+// checkXPathSchema resolves the location paths of x with ctx as the context
+// entry, which is also the entry returned by current().  A nil ctx is the
+// root of the data tree.  It returns an error for the first name test that
+// does not match a schema node.
+func checkXPathSchema(x *XPath, ctx *Entry) error {
+	c := &xpSchemaChecker{r: &leafrefResolver{x: x, leaf: ctx, seen: map[*Entry]bool{}}}
+	return c.expr(ctx, true, x.root)
+}
+
+// expr checks the paths in the expression x evaluated with e as the context
+// entry.  known is false if the context is not known, in which case only
+// absolute paths and paths starting with current() are checked.
+func (c *xpSchemaChecker) expr(e *Entry, known bool, x xpExpr) error {
+	switch x := x.(type) {
+	case *xpBinary:
+		if err := c.expr(e, known, x.l); err != nil {
+			return err
+		}
+		return c.expr(e, known, x.r)
+	case *xpNegate:
+		return c.expr(e, known, x.x)
+	case *xpFunc:
+		for _, a := range x.args {
+			if err := c.expr(e, known, a); err != nil {
+				return err
+			}
+		}
+	case *xpFilter:
+		if err := c.expr(e, known, x.primary); err != nil {
+			return err
+		}
+		// The context of the predicates is the result of the
+		// primary expression.
+		for _, p := range x.preds {
+			if err := c.expr(nil, false, p); err != nil {
+				return err
+			}
+		}
+	case *xpPath:
+		return c.path(e, known, x)
+	}
+	return nil
+}
+
+// path checks the location path p evaluated with e as the context entry.
+func (c *xpSchemaChecker) path(e *Entry, known bool, p *xpPath) error {
+	switch {
+	case p.filter != nil:
+		if f, ok := p.filter.(*xpFunc); ok && f.name == "current" {
+			e, known = c.r.leaf, true
+			break
+		}
+		if err := c.expr(e, known, p.filter); err != nil {
+			return err
+		}
+		known = false
+	case p.absolute:
+		e, known = nil, true
+	}
+	for _, s := range p.steps {
+		if known {
+			var err error
+			if e, known, err = c.step(e, s); err != nil {
+				return err
+			}
+		}
+		for _, pred := range s.preds {
+			if err := c.expr(e, known, pred); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// step returns the entry selected by s from e, where a nil entry is the root
+// of the data tree.  known is false if the entry cannot be determined.
+func (c *xpSchemaChecker) step(e *Entry, s *xpStep) (t *Entry, known bool, err error) {
+	switch {
+	case s.axis == "self" && s.test.kind == "node":
+		return e, true, nil
+	case s.axis == "parent" && s.test.kind == "node":
+		if e == nil {
+			return nil, false, fmt.Errorf("path goes above the root")
+		}
+		t = e.Parent
+		for t != nil && (t.IsChoice() || t.IsCase()) {
+			t = t.Parent
+		}
+		switch {
+		case t == nil:
+			return nil, false, nil
+		case t.RPC != nil, t.Kind == InputEntry, t.Kind == OutputEntry, t.Kind == NotificationEntry:
+			// The parent of the nodes of an operation or
+			// notification depends on where it is used.
+			return nil, false, nil
+		case isAugment(t.Node):
+			// The target of an augment that was not applied is
+			// unknown.
+			return nil, false, nil
+		case t.Parent == nil:
+			// The module entry is the root of the data tree.
+			return nil, true, nil
+		}
+		return t, true, nil
+	case s.axis == "child" && s.test.kind == "name" && s.test.local != "":
+		if e != nil && e.Kind != DirectoryEntry && e.Kind != InputEntry && e.Kind != OutputEntry && e.Kind != NotificationEntry && e.RPC == nil {
+			// Leaves and anydata have no schema children.
+			return nil, false, nil
+		}
+		t, err := c.r.child(e, s.test)
+		if err != nil {
+			return nil, false, err
+		}
+		return t, true, nil
+	}
+	return nil, false, nil
+}
+
+// isAugment returns true if n is an augment statement.
+func isAugment(n Node) bool {
+	_, ok := n.(*Augment)
+	return ok
+}
@@ -0,0 +1,456 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const xpathTestModule = `
+module xp {
+  prefix x;
+  namespace "urn:xp";
+  yang-version 1.1;
+
+  identity crypto;
+  identity aes { base crypto; }
+  identity aes-256 { base aes; }
+  identity des { base crypto; }
+
+  container top {
+    leaf mtu { type uint32; }
+    leaf name { type string; }
+    leaf algo { type identityref { base crypto; } }
+    leaf color {
+      type enumeration {
+        enum red { value 4; }
+        enum blue;
+      }
+    }
+    leaf flags {
+      type bits {
+        bit up;
+        bit running;
+      }
+    }
+    leaf ref {
+      type leafref { path "../intf/name"; }
+    }
+    leaf iid { type instance-identifier; }
+    list intf {
+      key name;
+      leaf name { type string; }
+      leaf speed { type uint32; }
+      leaf enabled { type boolean; }
+    }
+    choice ch {
+      leaf in-choice { type string; }
+    }
+  }
+}
+`
+
+const xpathTestData = `{
+  "xp:top": {
+    "mtu": 1500,
+    "name": "  hello   world ",
+    "algo": "xp:aes-256",
+    "color": "red",
+    "flags": "up running",
+    "ref": "eth1",
+    "iid": "/xp:top/xp:intf[xp:name='eth0']/xp:speed",
+    "intf": [
+      {"name": "eth0", "speed": 10, "enabled": true},
+      {"name": "eth1", "speed": 100, "enabled": false},
+      {"name": "eth2", "speed": 1000}
+    ],
+    "in-choice": "c"
+  }
+}`
+
+// xpathTestTree returns the entry for the top container of xpathTestModule
+// and the data tree for xpathTestData.
+func xpathTestTree(t *testing.T) (*Entry, *DataNode) {
+	t.Helper()
+	ms := NewModules()
+	if err := ms.Parse(xpathTestModule, "xp.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	e := ToEntry(ms.Modules["xp"])
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(xpathTestData), &data); err != nil {
+		t.Fatalf("could not unmarshal data: %v", err)
+	}
+	root, err := NewDataTree([]*Entry{e}, data)
+	if err != nil {
+		t.Fatalf("NewDataTree: %v", err)
+	}
+	return e.Dir["top"], root
+}
+
+func TestXPathEvaluate(t *testing.T) {
+	top, root := xpathTestTree(t)
+	ctx := root.Child("xp", "top").Child("xp", "mtu")
+
+	tests := []struct {
+		expr    string
+		want    interface{}
+		wantErr string
+	}{
+		// Literals and arithmetic.
+		{expr: "1 + 2 * 3", want: 7.0},
+		{expr: "(1 + 2) * 3", want: 9.0},
+		{expr: "7 mod 3", want: 1.0},
+		{expr: "7 div 2", want: 3.5},
+		{expr: "-2 - -3", want: 1.0},
+		{expr: "'abc'", want: "abc"},
+		{expr: `"a'b"`, want: "a'b"},
+		{expr: "1 = 1 and 2 != 2", want: false},
+		{expr: "1 < 2 or false()", want: true},
+		// Paths.
+		{expr: ".", want: "1500"},
+		{expr: "string(../name)", want: "  hello   world "},
+		{expr: ". = 1500", want: true},
+		{expr: ". > 1000 and . <= 9000", want: true},
+		{expr: "../x:mtu = current()", want: true},
+		{expr: "/x:top/mtu", want: "1500"},
+		{expr: "count(../intf)", want: 3.0},
+		{expr: "count(//x:speed)", want: 3.0},
+		{expr: "count(/descendant::intf/child::name)", want: 3.0},
+		{expr: "count(../*)", want: 11.0},
+		{expr: "count(../x:*)", want: 11.0},
+		{expr: "count(ancestor::*)", want: 1.0},
+		{expr: "count(ancestor-or-self::node())", want: 3.0},
+		{expr: "../intf[name = 'eth1']/speed", want: "100"},
+		{expr: "../intf[2]/name", want: "eth1"},
+		{expr: "../intf[last()]/name", want: "eth2"},
+		{expr: "../intf[position() > 1][1]/name", want: "eth1"},
+		{expr: "../intf[speed > 50]/name = 'eth2'", want: true},
+		{expr: "../intf[not(enabled)]/name", want: "eth2"},
+		{expr: "../intf/speed = 100", want: true},
+		{expr: "../intf/speed != 10", want: true},
+		{expr: "sum(../intf/speed)", want: 1110.0},
+		{expr: "count(../intf/name | ../name | ../intf/name)", want: 4.0},
+		// Members are added in sorted order, so intf precedes mtu.
+		{expr: "name((../mtu | ../intf)[1])", want: "xp:intf"},
+		{expr: "count(following-sibling::*)", want: 2.0},
+		{expr: "preceding-sibling::*[1]/name", want: "eth2"},
+		{expr: "preceding-sibling::*[last()]", want: "xp:aes-256"},
+		{expr: "../in-choice", want: "c"},
+		{expr: "local-name(..)", want: "top"},
+		{expr: "namespace-uri(..)", want: "urn:xp"},
+		// String functions.
+		{expr: "concat('a', 1, true())", want: "a1true"},
+		{expr: "starts-with(../name, '  he')", want: true},
+		{expr: "contains(../name, 'world')", want: true},
+		{expr: "substring-before('a-b-c', '-')", want: "a"},
+		{expr: "substring-after('a-b-c', '-')", want: "b-c"},
+		{expr: "substring('12345', 2, 3)", want: "234"},
+		{expr: "substring('12345', 1.5, 2.6)", want: "234"},
+		{expr: "string-length('abc')", want: 3.0},
+		{expr: "normalize-space(../name)", want: "hello world"},
+		{expr: "translate('bar', 'abc', 'ABC')", want: "BAr"},
+		{expr: "string(1.5)", want: "1.5"},
+		{expr: "string(0 div 0)", want: "NaN"},
+		// Number functions.
+		{expr: "number('12')", want: 12.0},
+		{expr: "floor(1.5)", want: 1.0},
+		{expr: "ceiling(1.5)", want: 2.0},
+		{expr: "round(2.5)", want: 3.0},
+		{expr: "round(-2.5)", want: -2.0},
+		// YANG functions.
+		{expr: "re-match(../name, '.*hello.*')", want: true},
+		{expr: "re-match('1.22.333', '\\d{1,3}\\.\\d{1,3}\\.\\d{1,3}')", want: true},
+		{expr: "re-match('abc', 'b')", want: false},
+		{expr: "derived-from(../algo, 'x:aes')", want: true},
+		{expr: "derived-from(../algo, 'x:crypto')", want: true},
+		{expr: "derived-from(../algo, 'x:aes-256')", want: false},
+		{expr: "derived-from-or-self(../algo, 'x:aes-256')", want: true},
+		{expr: "derived-from(../algo, 'x:des')", want: false},
+		{expr: "derived-from(../algo, 'x:none')", wantErr: "unknown identity x:none"},
+		{expr: "enum-value(../color)", want: 4.0},
+		{expr: "bit-is-set(../flags, 'running')", want: true},
+		{expr: "bit-is-set(../flags, 'down')", want: false},
+		{expr: "deref(../ref)/../speed", want: "100"},
+		{expr: "deref(../iid)", want: "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := CompileXPath(top.Dir["mtu"].Node, tt.expr)
+			if err != nil {
+				t.Fatalf("CompileXPath: %v", err)
+			}
+			got, err := x.Evaluate(ctx)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			// Compare node-sets by their string value.
+			if ns, ok := got.([]*DataNode); ok {
+				got = xpString(ns)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXPathNaN(t *testing.T) {
+	for _, expr := range []string{"number('abc')", "0 div 0", "number('1e3')", "enum-value(.)"} {
+		x, err := CompileXPath(nil, expr)
+		if err != nil {
+			t.Fatalf("CompileXPath(%q): %v", expr, err)
+		}
+		got, err := x.Evaluate(&DataNode{})
+		if err != nil {
+			t.Fatalf("Evaluate(%q): %v", expr, err)
+		}
+		if f, ok := got.(float64); !ok || !math.IsNaN(f) {
+			t.Errorf("%s: got %v, want NaN", expr, got)
+		}
+	}
+}
+
+func TestCompileXPathErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "", wantErr: "expected a location step"},
+		{expr: "a/", wantErr: "expected a location step"},
+		{expr: "(a", wantErr: `expected ")" at end of expression`},
+		{expr: "a]", wantErr: `unexpected "]"`},
+		{expr: "'abc", wantErr: "unterminated string literal"},
+		{expr: "a # b", wantErr: "unexpected character '#'"},
+		{expr: "a b", wantErr: `unexpected name "b"`},
+		{expr: "$var", wantErr: "variable $var is not supported"},
+		{expr: "foo(a)", wantErr: "unknown function foo"},
+		{expr: "count()", wantErr: "wrong number of arguments to count(): 0"},
+		{expr: "not(a, b)", wantErr: "wrong number of arguments to not(): 2"},
+		{expr: "concat('a')", wantErr: "wrong number of arguments to concat(): 1"},
+		{expr: "bogus::a", wantErr: "unknown axis bogus"},
+		{expr: "q:a", wantErr: `unknown prefix "q"`},
+		{expr: "../a[1]/b[c = 'd' and e]/*", wantErr: ""},
+		{expr: "2*3", wantErr: ""},
+		{expr: "a/*/b", wantErr: ""},
+		{expr: "div div div", wantErr: ""},
+		{expr: "processing-instruction('x') | comment() | text()", wantErr: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := CompileXPath(nil, tt.expr)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestXPathStatements(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc: "valid statements",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  container c {
+    must "count(l) < 2";
+    leaf l { type string; when "../s:m = 'x'"; }
+    leaf m { type string; must ". != 'y'"; }
+  }
+  augment /s:c {
+    when "s:m";
+    leaf n { type string; }
+  }
+}`,
+	}, {
+		desc: "invalid must",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  leaf l { type string; must "count(."; }
+}`,
+		wantErr: `st.yang:4:25: must invalid XPath "count(."`,
+	}, {
+		desc: "invalid when",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  leaf l { type string; when "../q:m"; }
+}`,
+		wantErr: `st.yang:4:25: when invalid XPath "../q:m": unknown prefix "q"`,
+	}, {
+		desc: "invalid when in grouping",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  grouping g {
+    leaf l { type string; when "nope()"; }
+  }
+  container a { uses g; }
+  container b { uses g; }
+}`,
+		wantErr: "unknown function nope",
+	}, {
+		desc: "resolved paths",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  container c {
+    list e {
+      key k;
+      leaf k { type string; }
+      choice ch { case a { leaf v { type string; must "../k != ."; } } }
+    }
+    leaf r {
+      type string;
+      must "/s:c/e[k = current()]/v";
+      must "count(../e[s:k = 'x']) = 0";
+    }
+    leaf w { type string; must "../*/x or //x or e/v/x"; }
+  }
+  augment /s:c/s:e/s:ch {
+    when "k = 'a'";
+    case b { leaf z { type string; } }
+  }
+  rpc op { input { leaf i { type string; must "../j"; } leaf j { type string; } } }
+}`,
+	}, {
+		desc: "unknown node in must",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  container c {
+    leaf l { type string; must "../s:m = 'x'"; }
+  }
+}`,
+		wantErr: `st.yang:5:27: must "../s:m = 'x'": /st/c/st:m not found`,
+	}, {
+		desc: "unknown node in when",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  container c { leaf l { type string; } }
+  leaf m { type string; when "/s:c/n"; }
+}`,
+		wantErr: `st.yang:5:25: when "/s:c/n": /st/c/st:n not found`,
+	}, {
+		desc: "unknown node in predicate",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  list e { key k; leaf k { type string; } }
+  leaf m { type string; must "/s:e[q = current()]"; }
+}`,
+		wantErr: `/st/e/st:q not found`,
+	}, {
+		desc: "unknown node in augment when",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  container c { leaf l { type string; } }
+  augment /s:c {
+    when "s:q";
+    leaf n { type string; }
+  }
+}`,
+		wantErr: `when "s:q": /st/c/st:q not found`,
+	}, {
+		desc: "path above the root",
+		in: `module st {
+  prefix s;
+  namespace "urn:st";
+  leaf m { type string; must "../../x"; }
+}`,
+		wantErr: `must "../../x": path goes above the root`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(tt.in, "st.yang"); err != nil {
+				t.Fatalf("could not parse module: %v", err)
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("%s", diff)
+			}
+			if tt.wantErr != "" && len(errs) != 1 {
+				t.Errorf("got %d errors, want 1: %v", len(errs), errs)
+			}
+		})
+	}
+}
+
+func TestEntryXPaths(t *testing.T) {
+	top, _ := xpathTestTree(t)
+	x, err := top.Dir["mtu"].WhenXPath()
+	if err != nil || x != nil {
+		t.Errorf("WhenXPath of leaf without when: got %v, %v, want nil, nil", x, err)
+	}
+
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix m;
+  namespace "urn:m";
+  leaf a { type string; }
+  leaf l {
+    type string;
+    when "../a";
+    must ". != 'x'";
+    must "string-length(.) < 5";
+  }
+}`, "m.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	l := ToEntry(ms.Modules["m"]).Dir["l"]
+	if x, err = l.WhenXPath(); err != nil || x.String() != "../a" {
+		t.Errorf("WhenXPath: got %v, %v, want ../a", x, err)
+	}
+	musts, err := l.MustXPaths()
+	if err != nil {
+		t.Fatalf("MustXPaths: %v", err)
+	}
+	var got []string
+	for _, m := range musts {
+		got = append(got, m.String())
+	}
+	if diff := cmp.Diff([]string{". != 'x'", "string-length(.) < 5"}, got); diff != "" {
+		t.Errorf("MustXPaths (-want, +got):\n%s", diff)
+	}
+}
@@ -128,6 +128,12 @@ type Module struct {
 	Uses         []*Uses         `yang:"uses"`
 	YangVersion  *Value          `yang:"yang-version,nomerge"`
 
+	// Structure and AugmentStructure are built from the structure,
+	// yang-data and augment-structure extensions in Extensions.  They
+	// are not data nodes of the module.
+	Structure        []*Structure        `json:"-"`
+	AugmentStructure []*AugmentStructure `json:"-"`
+
 	// Modules references the Modules object from which this Module node
 	// was parsed.
 	Modules *Modules
@@ -308,7 +314,8 @@ type Type struct {
 	Parent     Node         `yang:"Parent,nomerge"`
 	Extensions []*Statement `yang:"Ext"`
 
-	IdentityBase    *Value     `yang:"base"` // Name == identityref
+	IdentityBase    *Value     // the first of IdentityBases
+	IdentityBases   []*Value   `yang:"base"` // Name == identityref
 	Bit             []*Bit     `yang:"bit"`
 	Enum            []*Enum    `yang:"enum"`
 	FractionDigits  *Value     `yang:"fraction-digits"` // Name == decimal64
@@ -813,6 +820,70 @@ func (s *Augment) NName() string         { return s.Name }
 func (s *Augment) Statement() *Statement { return s.Source }
 func (s *Augment) Exts() []*Statement    { return s.Extensions }
 
+// A Structure is defined in: https://tools.ietf.org/html/rfc8791#section-4
+// ("structure" extension) and https://tools.ietf.org/html/rfc8040#section-8
+// ("yang-data" extension).  It is built from the extension statement by
+// buildStructures.
+type Structure struct {
+	Name       string       `yang:"Name,nomerge"`
+	Source     *Statement   `yang:"Statement,nomerge"`
+	Parent     Node         `yang:"Parent,nomerge"`
+	Extensions []*Statement `yang:"Ext"`
+
+	Anydata     []*AnyData   `yang:"anydata"`
+	Anyxml      []*AnyXML    `yang:"anyxml"`
+	Choice      []*Choice    `yang:"choice"`
+	Container   []*Container `yang:"container"`
+	Description *Value       `yang:"description"`
+	Grouping    []*Grouping  `yang:"grouping"`
+	Leaf        []*Leaf      `yang:"leaf"`
+	LeafList    []*LeafList  `yang:"leaf-list"`
+	List        []*List      `yang:"list"`
+	Must        []*Must      `yang:"must"`
+	Reference   *Value       `yang:"reference"`
+	Status      *Value       `yang:"status"`
+	Typedef     []*Typedef   `yang:"typedef"`
+	Uses        []*Uses      `yang:"uses"`
+}
+
+func (Structure) Kind() string              { return "structure" }
+func (s *Structure) ParentNode() Node       { return s.Parent }
+func (s *Structure) NName() string          { return s.Name }
+func (s *Structure) Statement() *Statement  { return s.Source }
+func (s *Structure) Exts() []*Statement     { return s.Extensions }
+func (s *Structure) Groupings() []*Grouping { return s.Grouping }
+func (s *Structure) Typedefs() []*Typedef   { return s.Typedef }
+
+// An AugmentStructure is defined in:
+// https://tools.ietf.org/html/rfc8791#section-4 ("augment-structure"
+// extension).  Its Name is the path of the node it augments, starting with
+// the name of a structure.
+type AugmentStructure struct {
+	Name       string       `yang:"Name,nomerge"`
+	Source     *Statement   `yang:"Statement,nomerge"`
+	Parent     Node         `yang:"Parent,nomerge"`
+	Extensions []*Statement `yang:"Ext"`
+
+	Anydata     []*AnyData   `yang:"anydata"`
+	Anyxml      []*AnyXML    `yang:"anyxml"`
+	Case        []*Case      `yang:"case"`
+	Choice      []*Choice    `yang:"choice"`
+	Container   []*Container `yang:"container"`
+	Description *Value       `yang:"description"`
+	Leaf        []*Leaf      `yang:"leaf"`
+	LeafList    []*LeafList  `yang:"leaf-list"`
+	List        []*List      `yang:"list"`
+	Reference   *Value       `yang:"reference"`
+	Status      *Value       `yang:"status"`
+	Uses        []*Uses      `yang:"uses"`
+}
+
+func (AugmentStructure) Kind() string             { return "augment-structure" }
+func (s *AugmentStructure) ParentNode() Node      { return s.Parent }
+func (s *AugmentStructure) NName() string         { return s.Name }
+func (s *AugmentStructure) Statement() *Statement { return s.Source }
+func (s *AugmentStructure) Exts() []*Statement    { return s.Extensions }
+
 // An Identity is defined in: http://tools.ietf.org/html/rfc6020#section-7.16
 type Identity struct {
 	Name       string       `yang:"Name,nomerge"`
@@ -826,6 +897,9 @@ type Identity struct {
 	Reference   *Value      `yang:"reference" json:"-"`
 	Status      *Value      `yang:"status" json:"-"`
 	Values      []*Identity `json:",omitempty"`
+
+	// bases are the resolved identities of Base.
+	bases []*Identity
 }
 
 func (Identity) Kind() string             { return "identity" }
@@ -839,8 +913,10 @@ func (s *Identity) PrefixedName() string {
 	return fmt.Sprintf("%s:%s", RootNode(s).GetPrefix(), s.Name)
 }
 
-// modulePrefixedName returns the module-qualified name for the identity.
-func (s *Identity) modulePrefixedName() string {
+// QualifiedName returns the module-qualified name for the identity, as in
+// module:identity.  The name of the module an identity of a submodule
+// belongs to is used.
+func (s *Identity) QualifiedName() string {
 	return fmt.Sprintf("%s:%s", module(s).Name, s.Name)
 }
 
@@ -1062,6 +1138,7 @@ type Pattern struct {
 	Description  *Value `yang:"description"`
 	ErrorAppTag  *Value `yang:"error-app-tag"`
 	ErrorMessage *Value `yang:"error-message"`
+	Modifier     *Value `yang:"modifier"`
 	Reference    *Value `yang:"reference"`
 }
 
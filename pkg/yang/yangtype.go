@@ -236,9 +236,10 @@ type YangType struct {
 	Kind             TypeKind    // Ynone if not a base type
 	Base             *Type       `json:"-"`          // Base type for non-builtin types
 	IdentityBase     *Identity   `json:",omitempty"` // Base statement for a type using identityref
+	IdentityBases    []*Identity `json:"-"`          // All the bases of an identityref, IdentityBase first
 	Root             *YangType   `json:"-"`          // root of this type that is the same
-	Bit              *EnumType   `json:",omitempty"` // bit position, "status" is lost
-	Enum             *EnumType   `json:",omitempty"` // enum name to value, "status" is lost
+	Bit              *EnumType   `json:",omitempty"` // bit position and status
+	Enum             *EnumType   `json:",omitempty"` // enum name to value and status
 	Units            string      `json:",omitempty"` // units to be used for this type
 	Default          string      `json:",omitempty"` // default value, if any
 	HasDefault       bool        `json:",omitempty"` // whether the type has a default.
@@ -247,9 +248,14 @@ type YangType struct {
 	OptionalInstance bool        `json:",omitempty"` // !require-instances which defaults to true
 	Path             string      `json:",omitempty"` // the path in a leafref
 	Pattern          []string    `json:",omitempty"` // limiting XSD-TYPES expressions on strings
+	InvertPattern    []string    `json:",omitempty"` // XSD-TYPES expressions strings must not match (modifier invert-match)
 	POSIXPattern     []string    `json:",omitempty"` // limiting POSIX ERE on strings (specified by openconfig-extensions:posix-pattern)
 	Range            YangRange   `json:",omitempty"` // range for integers
 	Type             []*YangType `json:",omitempty"` // for unions
+
+	// CompiledPatterns holds the compiled forms of Pattern and
+	// InvertPattern, in the order the pattern statements were found.
+	CompiledPatterns []*CompiledPattern `json:"-"`
 }
 
 // Equal returns true if y and t describe the same type.
@@ -267,17 +273,19 @@ func (y *YangType) Equal(t *YangType) bool {
 		y.HasDefault != t.HasDefault,
 		y.FractionDigits != t.FractionDigits,
 		y.IdentityBase != t.IdentityBase,
+		!identitiesEqual(y.IdentityBases, t.IdentityBases),
 		len(y.Length) != len(t.Length),
 		!y.Length.Equal(t.Length),
 		y.OptionalInstance != t.OptionalInstance,
 		y.Path != t.Path,
 		!ssEqual(y.Pattern, t.Pattern),
+		!ssEqual(y.InvertPattern, t.InvertPattern),
 		!ssEqual(y.POSIXPattern, t.POSIXPattern),
 		len(y.Range) != len(t.Range),
 		!y.Range.Equal(t.Range),
 		!tsEqual(y.Type, t.Type),
 		!cmp.Equal(y.Enum, t.Enum, cmp.Comparer(func(t, u EnumType) bool {
-			return cmp.Equal(t.unique, u.unique) && cmp.Equal(t.toInt, u.toInt) && cmp.Equal(t.toString, u.toString)
+			return cmp.Equal(t.unique, u.unique) && cmp.Equal(t.toInt, u.toInt) && cmp.Equal(t.toString, u.toString) && cmp.Equal(t.status, u.status)
 		})):
 
 		return false
@@ -328,3 +336,17 @@ func tsEqual(t1, t2 []*YangType) bool {
 	}
 	return true
 }
+
+// identitiesEqual returns true if the two Identity slices hold the same
+// identities in the same order.
+func identitiesEqual(i1, i2 []*Identity) bool {
+	if len(i1) != len(i2) {
+		return false
+	}
+	for x, i := range i1 {
+		if i != i2[x] {
+			return false
+		}
+	}
+	return true
+}
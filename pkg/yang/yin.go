@@ -0,0 +1,500 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the YIN syntax of YANG, the XML representation of a
+// module defined in https://tools.ietf.org/html/rfc7950#section-13.  A YIN
+// document is read into the same Statement tree that Parse produces, so the
+// rest of the processing does not know which syntax a module was written in.
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// YINNamespace is the XML namespace of the YIN elements.
+const YINNamespace = "urn:ietf:params:xml:ns:yang:yin:1"
+
+// A yinArgument describes how the argument of a statement is mapped to YIN.
+// The argument is either the attribute called name or, when element is true,
+// the text of the child element called name.
+type yinArgument struct {
+	name    string
+	element bool
+}
+
+// yinArguments is the table in RFC 7950 section 13.1 giving the YIN mapping
+// of the argument of each YANG keyword.  Keywords without an argument map to
+// the zero value.
+var yinArguments = map[string]yinArgument{
+	"action":           {name: "name"},
+	"anydata":          {name: "name"},
+	"anyxml":           {name: "name"},
+	"argument":         {name: "name"},
+	"augment":          {name: "target-node"},
+	"base":             {name: "name"},
+	"belongs-to":       {name: "module"},
+	"bit":              {name: "name"},
+	"case":             {name: "name"},
+	"choice":           {name: "name"},
+	"config":           {name: "value"},
+	"contact":          {name: "text", element: true},
+	"container":        {name: "name"},
+	"default":          {name: "value"},
+	"description":      {name: "text", element: true},
+	"deviate":          {name: "value"},
+	"deviation":        {name: "target-node"},
+	"enum":             {name: "name"},
+	"error-app-tag":    {name: "value"},
+	"error-message":    {name: "value", element: true},
+	"extension":        {name: "name"},
+	"feature":          {name: "name"},
+	"fraction-digits":  {name: "value"},
+	"grouping":         {name: "name"},
+	"identity":         {name: "name"},
+	"if-feature":       {name: "name"},
+	"import":           {name: "module"},
+	"include":          {name: "module"},
+	"input":            {},
+	"key":              {name: "value"},
+	"leaf":             {name: "name"},
+	"leaf-list":        {name: "name"},
+	"length":           {name: "value"},
+	"list":             {name: "name"},
+	"mandatory":        {name: "value"},
+	"max-elements":     {name: "value"},
+	"min-elements":     {name: "value"},
+	"modifier":         {name: "value"},
+	"module":           {name: "name"},
+	"must":             {name: "condition"},
+	"namespace":        {name: "uri"},
+	"notification":     {name: "name"},
+	"ordered-by":       {name: "value"},
+	"organization":     {name: "text", element: true},
+	"output":           {},
+	"path":             {name: "value"},
+	"pattern":          {name: "value"},
+	"position":         {name: "value"},
+	"prefix":           {name: "value"},
+	"presence":         {name: "value"},
+	"range":            {name: "value"},
+	"reference":        {name: "text", element: true},
+	"refine":           {name: "target-node"},
+	"require-instance": {name: "value"},
+	"revision":         {name: "date"},
+	"revision-date":    {name: "date"},
+	"rpc":              {name: "name"},
+	"status":           {name: "value"},
+	"submodule":        {name: "name"},
+	"type":             {name: "name"},
+	"typedef":          {name: "name"},
+	"unique":           {name: "tag"},
+	"units":            {name: "name"},
+	"uses":             {name: "name"},
+	"value":            {name: "value"},
+	"when":             {name: "condition"},
+	"yang-version":     {name: "value"},
+	"yin-element":      {name: "value"},
+}
+
+// A yinElement is an XML element of a YIN document.
+type yinElement struct {
+	prefix, local string // the name as written
+	space         string // the namespace the prefix resolves to
+	attrs         []xml.Attr
+	children      []*yinElement
+	text          strings.Builder
+	line, col     int
+}
+
+// ParseYIN parses the YIN document input, read from path, and returns the
+// module or submodule statement it holds.
+func ParseYIN(input, path string) ([]*Statement, error) {
+	root, err := readYIN(input, path)
+	if err != nil {
+		return nil, err
+	}
+	p := &yinParser{path: path, exts: map[string]yinArgument{}}
+	if root.space != YINNamespace || (root.local != "module" && root.local != "submodule") {
+		return nil, p.errorf(root, "root element must be a YIN module or submodule, not %s", root.local)
+	}
+	p.extensions(root)
+	s, err := p.statement(root)
+	if err != nil {
+		return nil, err
+	}
+	return []*Statement{s}, nil
+}
+
+// readYIN returns the root element of the XML document input.
+func readYIN(input, path string) (*yinElement, error) {
+	// lines holds the offset of the start of each line in input.
+	lines := []int{0}
+	for i, c := range input {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	position := func(offset int64) (int, int) {
+		n := sort.Search(len(lines), func(i int) bool { return int64(lines[i]) > offset })
+		return n, int(offset) - lines[n-1] + 1
+	}
+
+	d := xml.NewDecoder(strings.NewReader(input))
+	var root *yinElement
+	var stack []*yinElement
+	var scopes []map[string]string
+	for {
+		offset := d.InputOffset()
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if serr, ok := err.(*xml.SyntaxError); ok {
+				return nil, fmt.Errorf("%s:%d: %s", path, serr.Line, serr.Msg)
+			}
+			line, col := position(offset)
+			return nil, fmt.Errorf("%s:%d:%d: %v", path, line, col, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			scope := map[string]string{}
+			if len(scopes) > 0 {
+				for k, v := range scopes[len(scopes)-1] {
+					scope[k] = v
+				}
+			}
+			e := &yinElement{prefix: t.Name.Space, local: t.Name.Local}
+			e.line, e.col = position(offset)
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					scope[""] = a.Value
+				case a.Name.Space == "xmlns":
+					scope[a.Name.Local] = a.Value
+				default:
+					e.attrs = append(e.attrs, a)
+				}
+			}
+			e.space = scope[e.prefix]
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			case root != nil:
+				return nil, fmt.Errorf("%s:%d:%d: more than one root element", path, e.line, e.col)
+			default:
+				root = e
+			}
+			stack = append(stack, e)
+			scopes = append(scopes, scope)
+		case xml.EndElement:
+			// RawToken does not check that the elements nest.
+			if len(stack) == 0 || stack[len(stack)-1].prefix != t.Name.Space || stack[len(stack)-1].local != t.Name.Local {
+				line, col := position(offset)
+				return nil, fmt.Errorf("%s:%d:%d: unexpected end element </%s>", path, line, col, xmlName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+			scopes = scopes[:len(scopes)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	switch {
+	case len(stack) > 0:
+		e := stack[len(stack)-1]
+		return nil, fmt.Errorf("%s:%d:%d: element <%s> is not closed", path, e.line, e.col, xmlName(xml.Name{Space: e.prefix, Local: e.local}))
+	case root == nil:
+		return nil, fmt.Errorf("%s: no YIN module found", path)
+	}
+	return root, nil
+}
+
+// xmlName returns n as written in the document.
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// A yinParser converts the elements of a YIN document into Statements.
+type yinParser struct {
+	path   string
+	prefix string                 // prefix of the module
+	exts   map[string]yinArgument // extensions defined by the module
+}
+
+func (p *yinParser) errorf(e *yinElement, format string, v ...interface{}) error {
+	return fmt.Errorf("%s:%d:%d: %s", p.path, e.line, e.col, fmt.Sprintf(format, v...))
+}
+
+// extensions records the prefix of module m and the argument mapping of the
+// extensions it defines, which are needed to find the argument of extension
+// statements that use them.
+func (p *yinParser) extensions(m *yinElement) {
+	for _, c := range m.children {
+		if c.space != YINNamespace {
+			continue
+		}
+		switch c.local {
+		case "prefix":
+			p.prefix = attr(c, "value")
+		case "belongs-to":
+			for _, cc := range c.children {
+				if cc.space == YINNamespace && cc.local == "prefix" {
+					p.prefix = attr(cc, "value")
+				}
+			}
+		case "extension":
+			var arg yinArgument
+			for _, a := range c.children {
+				if a.space != YINNamespace || a.local != "argument" {
+					continue
+				}
+				arg.name = attr(a, "name")
+				for _, y := range a.children {
+					if y.space == YINNamespace && y.local == "yin-element" {
+						arg.element = attr(y, "value") == "true"
+					}
+				}
+			}
+			p.exts[attr(c, "name")] = arg
+		}
+	}
+}
+
+// attr returns the value of the unqualified attribute name of e.
+func attr(e *yinElement, name string) string {
+	for _, a := range e.attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// statement returns the Statement represented by e and its children.
+func (p *yinParser) statement(e *yinElement) (*Statement, error) {
+	s := &Statement{file: p.path, line: e.line, col: e.col}
+	var arg yinArgument
+	switch {
+	case e.space == YINNamespace:
+		var ok bool
+		if arg, ok = yinArguments[e.local]; !ok {
+			return nil, p.errorf(e, "unknown YIN statement %s", e.local)
+		}
+		s.Keyword = e.local
+	case e.prefix == "":
+		return nil, p.errorf(e, "element %s is not in the YIN namespace", e.local)
+	default:
+		s.Keyword = e.prefix + ":" + e.local
+		if a, ok := p.exts[e.local]; ok && e.prefix == p.prefix {
+			arg = a
+			break
+		}
+		// The definition of the extension is in another module.  A
+		// lone attribute is its argument, as is a child element of
+		// the extension's namespace holding just text, which no
+		// substatement can be.
+		if len(e.attrs) == 1 {
+			arg.name = e.attrs[0].Name.Local
+			break
+		}
+		for _, c := range e.children {
+			if c.prefix == e.prefix && len(c.attrs) == 0 && len(c.children) == 0 && strings.TrimSpace(c.text.String()) != "" {
+				arg = yinArgument{name: c.local, element: true}
+				break
+			}
+		}
+	}
+
+	for _, a := range e.attrs {
+		switch {
+		case a.Name.Space != "":
+			// Qualified attributes are not part of YANG.
+		case arg.name != "" && !arg.element && a.Name.Local == arg.name:
+			s.HasArgument = true
+			s.Argument = a.Value
+		default:
+			return nil, p.errorf(e, "unexpected attribute %s on %s", a.Name.Local, s.Keyword)
+		}
+	}
+
+	for _, c := range e.children {
+		if arg.element && !s.HasArgument && c.local == arg.name && c.prefix == e.prefix {
+			if len(c.children) > 0 {
+				return nil, p.errorf(c, "%s of %s must only contain text", c.local, s.Keyword)
+			}
+			s.HasArgument = true
+			s.Argument = c.text.String()
+			continue
+		}
+		cs, err := p.statement(c)
+		if err != nil {
+			return nil, err
+		}
+		s.statements = append(s.statements, cs)
+	}
+	if arg.name != "" && !s.HasArgument {
+		return nil, p.errorf(e, "%s is missing its %s argument", s.Keyword, arg.name)
+	}
+	if strings.TrimSpace(e.text.String()) != "" {
+		return nil, p.errorf(e, "unexpected text in %s", s.Keyword)
+	}
+	return s, nil
+}
+
+// isYIN returns true if the module in data, read from name, is in the YIN
+// syntax rather than the YANG syntax.  A YANG module cannot start with a '<'.
+func isYIN(data, name string) bool {
+	return strings.HasSuffix(name, ".yin") || strings.HasPrefix(strings.TrimLeft(data, "\ufeff \t\r\n"), "<")
+}
+
+// FormatYIN returns the module or submodule m in the YIN syntax.  The
+// imported modules of m must have been found, which Process does, so that
+// the namespaces of their prefixes and the arguments of their extensions are
+// known.
+func FormatYIN(m *Module) ([]byte, error) {
+	if m.Source == nil {
+		return nil, fmt.Errorf("%s: module has no source statements", m.Name)
+	}
+	y := &yinWriter{m: m}
+	var b bytes.Buffer
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	if err := y.statement(&b, m.Source, "", y.namespaces()); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// A yinWriter writes the statements of a module as YIN.
+type yinWriter struct {
+	m *Module
+}
+
+// namespaces returns the xmlns attributes of the root element, the YIN
+// namespace followed by the namespace of the module and of each import.
+func (y *yinWriter) namespaces() []string {
+	ns := []string{fmt.Sprintf("xmlns=%s", yinAttr(YINNamespace))}
+	add := func(prefix string, m *Module) {
+		if m != nil && m.Namespace != nil {
+			ns = append(ns, fmt.Sprintf("xmlns:%s=%s", prefix, yinAttr(m.Namespace.Name)))
+		}
+	}
+	if y.m.Kind() == "module" {
+		add(y.m.GetPrefix(), y.m)
+	} else if y.m.Modules != nil {
+		add(y.m.GetPrefix(), y.m.Modules.Modules[y.m.BelongsTo.Name])
+	}
+	for _, i := range y.m.Import {
+		add(i.Prefix.Name, i.Module)
+	}
+	return ns
+}
+
+// argument returns the YIN mapping of the argument of s.
+func (y *yinWriter) argument(s *Statement) (yinArgument, error) {
+	i := strings.Index(s.Keyword, ":")
+	if i < 0 {
+		arg, ok := yinArguments[s.Keyword]
+		if !ok {
+			return arg, fmt.Errorf("%s: unknown statement %s", s.Location(), s.Keyword)
+		}
+		return arg, nil
+	}
+	prefix, name := s.Keyword[:i], s.Keyword[i+1:]
+	m := FindModuleByPrefix(y.m, prefix)
+	if m == nil {
+		return yinArgument{}, fmt.Errorf("%s: no module found for prefix %s", s.Location(), prefix)
+	}
+	for _, e := range m.Extension {
+		if e.Name != name {
+			continue
+		}
+		var arg yinArgument
+		if e.Argument != nil {
+			arg.name = e.Argument.Name
+			arg.element = e.Argument.YinElement != nil && e.Argument.YinElement.Name == "true"
+		}
+		return arg, nil
+	}
+	return yinArgument{}, fmt.Errorf("%s: extension %s not found in module %s", s.Location(), name, m.Name)
+}
+
+// statement writes s, indented by indent, to b.  Any extra attributes are
+// written after the argument.
+func (y *yinWriter) statement(b *bytes.Buffer, s *Statement, indent string, extra []string) error {
+	arg, err := y.argument(s)
+	if err != nil {
+		return err
+	}
+	b.WriteString(indent + "<" + s.Keyword)
+	var attrs []string
+	if arg.name != "" && !arg.element {
+		attrs = append(attrs, fmt.Sprintf("%s=%s", arg.name, yinAttr(s.Argument)))
+	}
+	attrs = append(attrs, extra...)
+	for i, a := range attrs {
+		if i > 0 && len(extra) > 0 {
+			// Put each attribute of the root element on its own line.
+			b.WriteString("\n" + indent + strings.Repeat(" ", len(s.Keyword)+1))
+		}
+		b.WriteString(" " + a)
+	}
+	if len(s.statements) == 0 && !arg.element {
+		b.WriteString("/>\n")
+		return nil
+	}
+	b.WriteString(">\n")
+	inner := indent + "  "
+	if arg.element {
+		// The argument element is in the namespace of the statement.
+		name := arg.name
+		if i := strings.Index(s.Keyword, ":"); i >= 0 {
+			name = s.Keyword[:i+1] + name
+		}
+		fmt.Fprintf(b, "%s<%s>%s</%s>\n", inner, name, yinText(s.Argument), name)
+	}
+	for _, c := range s.statements {
+		if err := y.statement(b, c, inner, nil); err != nil {
+			return err
+		}
+	}
+	b.WriteString(indent + "</" + s.Keyword + ">\n")
+	return nil
+}
+
+var (
+	yinAttrReplacer = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+	yinTextReplacer = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", "\r", "&#xD;")
+)
+
+// yinAttr returns s quoted as an XML attribute value.
+func yinAttr(s string) string {
+	return `"` + yinAttrReplacer.Replace(s) + `"`
+}
+
+// yinText returns s escaped as XML character data.
+func yinText(s string) string {
+	return yinTextReplacer.Replace(s)
+}
@@ -0,0 +1,283 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+// statementText returns the statements in ss as written by Write.
+func statementText(ss []*Statement) string {
+	var b bytes.Buffer
+	for _, s := range ss {
+		s.Write(&b, "")
+	}
+	return b.String()
+}
+
+func TestParseYIN(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		want    string // the equivalent YANG source
+		wantErr string
+	}{{
+		desc: "module",
+		in: `<?xml version="1.0" encoding="UTF-8"?>
+<!-- a comment -->
+<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1" xmlns:m="urn:m">
+  <namespace uri="urn:m"/>
+  <prefix value="m"/>
+  <description>
+    <text>Two
+lines &amp; "quotes".</text>
+  </description>
+  <rpc name="r">
+    <input>
+      <leaf name="a"><type name="string"/></leaf>
+    </input>
+  </rpc>
+  <leaf name="l">
+    <type name="string">
+      <pattern value="[a-z]+">
+        <error-message><value>bad &lt;name&gt;</value></error-message>
+      </pattern>
+    </type>
+    <must condition="../a = 'x'"/>
+  </leaf>
+</module>`,
+		want: `module m {
+  namespace "urn:m";
+  prefix m;
+  description "Two
+lines & \"quotes\".";
+  rpc r { input { leaf a { type string; } } }
+  leaf l {
+    type string { pattern "[a-z]+" { error-message "bad <name>"; } }
+    must "../a = 'x'";
+  }
+}`,
+	}, {
+		desc: "extensions",
+		in: `<submodule name="s" xmlns="urn:ietf:params:xml:ns:yang:yin:1"
+    xmlns:y="urn:ietf:params:xml:ns:yang:yin:1" xmlns:s="urn:m" xmlns:o="urn:o">
+  <belongs-to module="m"><prefix value="s"/></belongs-to>
+  <extension name="note">
+    <argument name="text"><yin-element value="true"/></argument>
+  </extension>
+  <extension name="flag"/>
+  <y:leaf name="l">
+    <y:type name="string"/>
+    <s:note><s:text>some text</s:text></s:note>
+    <s:flag/>
+    <o:ext name="x"/>
+    <o:doc>
+      <o:text>from another module</o:text>
+    </o:doc>
+  </y:leaf>
+</submodule>`,
+		want: `submodule s {
+  belongs-to m { prefix s; }
+  extension note { argument text { yin-element true; } }
+  extension flag;
+  leaf l {
+    type string;
+    s:note "some text";
+    s:flag;
+    o:ext x;
+    o:doc "from another module";
+  }
+}`,
+	}, {
+		desc:    "not yin",
+		in:      `<module name="m"/>`,
+		wantErr: "test.yin:1:1: root element must be a YIN module or submodule, not module",
+	}, {
+		desc:    "not a module",
+		in:      `<container name="c" xmlns="urn:ietf:params:xml:ns:yang:yin:1"/>`,
+		wantErr: "root element must be a YIN module or submodule, not container",
+	}, {
+		desc: "unknown statement",
+		in: `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1">
+  <leaves name="l"/>
+</module>`,
+		wantErr: "test.yin:2:3: unknown YIN statement leaves",
+	}, {
+		desc:    "missing argument",
+		in:      `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><leaf/></module>`,
+		wantErr: "test.yin:1:60: leaf is missing its name argument",
+	}, {
+		desc:    "missing text argument",
+		in:      `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><description/></module>`,
+		wantErr: "description is missing its text argument",
+	}, {
+		desc:    "unexpected attribute",
+		in:      `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><leaf name="l" value="v"/></module>`,
+		wantErr: "unexpected attribute value on leaf",
+	}, {
+		desc:    "unexpected text",
+		in:      `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1">text</module>`,
+		wantErr: "unexpected text in module",
+	}, {
+		desc:    "mismatched element",
+		in:      `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><leaf name="l"></container></module>`,
+		wantErr: "unexpected end element </container>",
+	}, {
+		desc:    "unclosed element",
+		in:      `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><leaf name="l">`,
+		wantErr: "test.yin:1:60: element <leaf> is not closed",
+	}, {
+		desc:    "empty",
+		in:      ``,
+		wantErr: "test.yin: no YIN module found",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseYIN(tt.in, "test.yin")
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("ParseYIN: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			want, err := Parse(tt.want, "test.yang")
+			if err != nil {
+				t.Fatalf("Parse of want: %v", err)
+			}
+			if diff := cmp.Diff(statementText(want), statementText(got)); diff != "" {
+				t.Errorf("ParseYIN (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatYIN(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"other.yang": `module other {
+  namespace "urn:other";
+  prefix o;
+  extension note { argument text { yin-element true; } }
+  extension tag { argument name; }
+}`,
+		"m.yang": `module m {
+  namespace "urn:m";
+  prefix m;
+  import other { prefix o; }
+  description "A <module> & \"more\".
+Second line.";
+  leaf l {
+    type string;
+    o:note "a note";
+    o:tag "t";
+    must "../l != 'a\tb'";
+  }
+  rpc r { input { leaf a { type int8; } } }
+}`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatalf("Parse %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	m := ms.Modules["m"]
+	got, err := FormatYIN(m)
+	if err != nil {
+		t.Fatalf("FormatYIN: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<module name="m"
+        xmlns="urn:ietf:params:xml:ns:yang:yin:1"
+        xmlns:m="urn:m"
+        xmlns:o="urn:other">
+  <namespace uri="urn:m"/>
+  <prefix value="m"/>
+  <import module="other">
+    <prefix value="o"/>
+  </import>
+  <description>
+    <text>A &lt;module&gt; &amp; "more".
+Second line.</text>
+  </description>
+  <leaf name="l">
+    <type name="string"/>
+    <o:note>
+      <o:text>a note</o:text>
+    </o:note>
+    <o:tag name="t"/>
+    <must condition="../l != 'a&#x9;b'"/>
+  </leaf>
+  <rpc name="r">
+    <input>
+      <leaf name="a">
+        <type name="int8"/>
+      </leaf>
+    </input>
+  </rpc>
+</module>
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("FormatYIN (-want, +got):\n%s", diff)
+	}
+
+	// Reading the YIN back must give the statements of the YANG source.
+	ss, err := ParseYIN(string(got), "m.yin")
+	if err != nil {
+		t.Fatalf("ParseYIN: %v", err)
+	}
+	if diff := cmp.Diff(statementText([]*Statement{m.Source}), statementText(ss)); diff != "" {
+		t.Errorf("ParseYIN of FormatYIN (-want, +got):\n%s", diff)
+	}
+}
+
+func TestModulesParseYIN(t *testing.T) {
+	ms := NewModules()
+	in := `
+<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1">
+  <namespace uri="urn:m"/>
+  <prefix value="m"/>
+  <container name="c">
+    <leaf name="l">
+      <type name="uint8"/>
+      <default value="3"/>
+    </leaf>
+  </container>
+</module>`
+	// The name does not end in .yin, the content is recognised as YIN.
+	if err := ms.Parse(in, "m"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	e, errs := ms.GetModule("m")
+	if len(errs) > 0 {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	l := e.Dir["c"].Dir["l"]
+	if l == nil {
+		t.Fatalf("leaf c/l not found")
+	}
+	if got, want := l.Type.Kind, Yuint8; got != want {
+		t.Errorf("got type %v, want %v", got, want)
+	}
+	if got, want := l.Node.Statement().Location(), "m:6:5"; got != want {
+		t.Errorf("got location %s, want %s", got, want)
+	}
+}
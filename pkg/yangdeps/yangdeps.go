@@ -0,0 +1,431 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yangdeps builds the dependency graph of a set of YANG modules, the
+// modules and submodules each one imports, includes, augments or deviates.
+// The graph can be checked for cycles, ordered so that every module comes
+// after the modules it depends on, and written as Graphviz DOT or as a text
+// tree.  The Graph type marshals to JSON.
+package yangdeps
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// An EdgeKind is the kind of a dependency of one module on another.
+type EdgeKind string
+
+// The kinds of dependencies.
+const (
+	Import    EdgeKind = "import"
+	Include   EdgeKind = "include"
+	Augment   EdgeKind = "augment"
+	Deviation EdgeKind = "deviation"
+)
+
+// A Module is a module or submodule of a Graph.
+type Module struct {
+	Name      string `json:"name"`
+	Revision  string `json:"revision,omitempty"`
+	Submodule bool   `json:"submodule,omitempty"`
+	File      string `json:"file,omitempty"` // the file it was read from
+}
+
+// An Edge is a dependency of the module From on the module To.
+type Edge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// A Graph is the dependency graph of a set of modules.  The modules are
+// sorted by name and the edges by From, To and Kind.  An edge may lead to a
+// module that is not in the graph if it was not read.
+type Graph struct {
+	Modules []*Module `json:"modules"`
+	Edges   []*Edge   `json:"edges"`
+
+	byName map[string]*Module
+	deps   map[string][]string // sorted names of the modules depended on
+}
+
+// Build returns the dependency graph of the modules and submodules of ms,
+// which must have been processed.  Only the current revision of a module,
+// the one found by its name, is in the graph.
+func Build(ms *yang.Modules) *Graph {
+	g := &Graph{byName: map[string]*Module{}, deps: map[string][]string{}}
+	edges := map[Edge]bool{}
+	add := func(from, to string, kind EdgeKind) {
+		if from == to || to == "" {
+			return
+		}
+		e := Edge{From: from, To: to, Kind: kind}
+		if !edges[e] {
+			edges[e] = true
+			g.Edges = append(g.Edges, &e)
+		}
+	}
+	for _, mods := range []map[string]*yang.Module{ms.Modules, ms.SubModules} {
+		for _, m := range mods {
+			if mods[m.Name] != m || g.byName[m.Name] != nil {
+				continue
+			}
+			gm := &Module{
+				Name:      m.Name,
+				Revision:  m.Current(),
+				Submodule: m.Kind() == "submodule",
+				File:      yang.NodeLocation(m).File,
+			}
+			g.byName[m.Name] = gm
+			g.Modules = append(g.Modules, gm)
+
+			for _, i := range m.Import {
+				add(m.Name, i.Name, Import)
+			}
+			for _, i := range m.Include {
+				add(m.Name, i.Name, Include)
+			}
+			for _, a := range m.Augment {
+				add(m.Name, targetModule(m, a.Name), Augment)
+			}
+			for _, a := range m.AugmentStructure {
+				add(m.Name, targetModule(m, a.Name), Augment)
+			}
+			for _, d := range m.Deviation {
+				add(m.Name, targetModule(m, d.Name), Deviation)
+			}
+		}
+	}
+
+	sort.Slice(g.Modules, func(i, j int) bool {
+		return g.Modules[i].Name < g.Modules[j].Name
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		switch {
+		case a.From != b.From:
+			return a.From < b.From
+		case a.To != b.To:
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	for _, e := range g.Edges {
+		if d := g.deps[e.From]; len(d) == 0 || d[len(d)-1] != e.To {
+			g.deps[e.From] = append(d, e.To)
+		}
+	}
+	return g
+}
+
+// targetModule returns the name of the module of the first node of path,
+// the target of an augment or deviation in the module m, or "" if it is m
+// or is not known.
+func targetModule(m *yang.Module, path string) string {
+	first := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	i := strings.Index(first, ":")
+	if i < 0 {
+		return ""
+	}
+	tm := yang.FindModuleByPrefix(m, first[:i])
+	switch {
+	case tm == nil:
+		return ""
+	case tm.Kind() == "submodule" && tm.BelongsTo != nil:
+		return tm.BelongsTo.Name
+	}
+	return tm.Name
+}
+
+// Module returns the module named name, or nil if it is not in g.
+func (g *Graph) Module(name string) *Module {
+	return g.byName[name]
+}
+
+// components returns the strongly connected components of g, each sorted
+// by name, in an order where every component comes after the components it
+// depends on.
+func (g *Graph) components() [][]string {
+	// Tarjan's algorithm finishes a component after all of the
+	// components reachable from it.
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var comps [][]string
+
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, d := range g.deps[n] {
+			if _, ok := index[d]; !ok {
+				visit(d)
+				if low[d] < low[n] {
+					low[n] = low[d]
+				}
+			} else if onStack[d] && index[d] < low[n] {
+				low[n] = index[d]
+			}
+		}
+		if low[n] != index[n] {
+			return
+		}
+		var comp []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			comp = append(comp, top)
+			if top == n {
+				break
+			}
+		}
+		sort.Strings(comp)
+		comps = append(comps, comp)
+	}
+	for _, m := range g.Modules {
+		if _, ok := index[m.Name]; !ok {
+			visit(m.Name)
+		}
+	}
+	return comps
+}
+
+// Cycles returns the sets of modules that depend on each other, each sorted
+// by name, or nil if there are none.  Submodules of a YANG 1.1 module may
+// include each other, otherwise a cycle is an error.
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	for _, c := range g.components() {
+		if len(c) > 1 {
+			cycles = append(cycles, c)
+		}
+	}
+	return cycles
+}
+
+// LoadOrder returns the names of the modules of g, and of the modules they
+// depend on that are not in g, in an order where every module comes after
+// the modules it depends on.  The modules of a cycle are sorted by name.
+func (g *Graph) LoadOrder() []string {
+	var order []string
+	for _, c := range g.components() {
+		order = append(order, c...)
+	}
+	return order
+}
+
+// Closure returns the names of the modules that the module name depends on,
+// directly or indirectly, in load order.  The closure does not include name
+// itself, even if it is in a cycle.
+func (g *Graph) Closure(name string) ([]string, error) {
+	if g.byName[name] == nil {
+		return nil, fmt.Errorf("unknown module %s", name)
+	}
+	reached := map[string]bool{}
+	var walk func(n string)
+	walk = func(n string) {
+		for _, d := range g.deps[n] {
+			if !reached[d] {
+				reached[d] = true
+				walk(d)
+			}
+		}
+	}
+	walk(name)
+	var closure []string
+	for _, n := range g.LoadOrder() {
+		if reached[n] && n != name {
+			closure = append(closure, n)
+		}
+	}
+	return closure, nil
+}
+
+// Extensions returns the names of the modules that augment or deviate the
+// module name, sorted by name.
+func (g *Graph) Extensions(name string) []string {
+	var names []string
+	for _, e := range g.Edges {
+		if e.To != name || (e.Kind != Augment && e.Kind != Deviation) {
+			continue
+		}
+		if len(names) == 0 || names[len(names)-1] != e.From {
+			names = append(names, e.From)
+		}
+	}
+	return names
+}
+
+// SchemaClosure returns the names of the modules needed to build the schema
+// of the module name, in load order: the modules in its closure, the modules
+// that augment or deviate it, and the modules in their closures.  As with
+// Closure, name itself is not included.
+func (g *Graph) SchemaClosure(name string) ([]string, error) {
+	closure, err := g.Closure(name)
+	if err != nil {
+		return nil, err
+	}
+	reached := map[string]bool{}
+	for _, n := range closure {
+		reached[n] = true
+	}
+	for _, x := range g.Extensions(name) {
+		reached[x] = true
+		if g.byName[x] == nil {
+			continue
+		}
+		xc, err := g.Closure(x)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range xc {
+			reached[n] = true
+		}
+	}
+	var schema []string
+	for _, n := range g.LoadOrder() {
+		if reached[n] && n != name {
+			schema = append(schema, n)
+		}
+	}
+	return schema, nil
+}
+
+// Files returns the files of the modules in the closure of the module name,
+// in load order.  Modules that were not read from a file are left out.
+func (g *Graph) Files(name string) ([]string, error) {
+	closure, err := g.Closure(name)
+	if err != nil {
+		return nil, err
+	}
+	return g.files(closure), nil
+}
+
+// SchemaFiles returns the files of the modules in the schema closure of the
+// module name, as returned by SchemaClosure, in load order.  Modules that
+// were not read from a file are left out.
+func (g *Graph) SchemaFiles(name string) ([]string, error) {
+	closure, err := g.SchemaClosure(name)
+	if err != nil {
+		return nil, err
+	}
+	return g.files(closure), nil
+}
+
+// files returns the files of the modules names, leaving out the modules
+// that were not read from a file.
+func (g *Graph) files(names []string) []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, n := range names {
+		if m := g.byName[n]; m != nil && m.File != "" && !seen[m.File] {
+			seen[m.File] = true
+			files = append(files, m.File)
+		}
+	}
+	return files
+}
+
+// WriteDOT writes g to w in the Graphviz DOT language.  Submodules are drawn
+// as ellipses and modules as boxes, the edges are labeled with their kind and
+// those within a cycle are red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	inCycle := map[string]int{}
+	for i, c := range g.Cycles() {
+		for _, n := range c {
+			inCycle[n] = i + 1
+		}
+	}
+	var b strings.Builder
+	b.WriteString("digraph modules {\n")
+	for _, m := range g.Modules {
+		shape := "box"
+		if m.Submodule {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(&b, "  %q [shape=%s];\n", m.Name, shape)
+	}
+	for _, e := range g.Edges {
+		attrs := fmt.Sprintf("label=%q", e.Kind)
+		if c := inCycle[e.From]; c != 0 && c == inCycle[e.To] {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [%s];\n", e.From, e.To, attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteTree writes the dependencies of the modules names to w as a text
+// tree, or those of all the modules no other module depends on if there are
+// no names.  Each line is the kind of an edge followed by the module it
+// leads to.  The dependencies of a module are only written the first time
+// it is reached, later lines end in "..." instead, and an edge back to a
+// module being written ends in "(cycle)".
+func (g *Graph) WriteTree(w io.Writer, names ...string) error {
+	if len(names) == 0 {
+		depended := map[string]bool{}
+		for _, e := range g.Edges {
+			depended[e.To] = true
+		}
+		for _, m := range g.Modules {
+			if !depended[m.Name] {
+				names = append(names, m.Name)
+			}
+		}
+	}
+	var b strings.Builder
+	written := map[string]bool{}
+	active := map[string]bool{}
+	var write func(name, indent string)
+	write = func(name, indent string) {
+		written[name] = true
+		active[name] = true
+		for _, e := range g.Edges {
+			if e.From != name {
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s %s", indent, e.Kind, e.To)
+			switch {
+			case active[e.To]:
+				b.WriteString(" (cycle)\n")
+			case written[e.To] && len(g.deps[e.To]) > 0:
+				b.WriteString(" ...\n")
+			default:
+				b.WriteString("\n")
+				write(e.To, indent+"  ")
+			}
+		}
+		active[name] = false
+	}
+	for _, n := range names {
+		if g.byName[n] == nil {
+			return fmt.Errorf("unknown module %s", n)
+		}
+		b.WriteString(n + "\n")
+		write(n, "  ")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangdeps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var testModules = []struct {
+	file, content string
+}{{
+	file: "types.yang",
+	content: `module types {
+  namespace "urn:types";
+  prefix t;
+  typedef name { type string; }
+}`,
+}, {
+	file: "sys.yang",
+	content: `module sys {
+  yang-version 1.1;
+  namespace "urn:sys";
+  prefix s;
+  import types { prefix t; }
+  include sys-a;
+  include sys-b;
+  container system { leaf name { type t:name; } }
+}`,
+}, {
+	file: "sys-a.yang",
+	content: `submodule sys-a {
+  yang-version 1.1;
+  belongs-to sys { prefix s; }
+  include sys-b;
+  leaf a { type string; }
+}`,
+}, {
+	file: "sys-b.yang",
+	content: `submodule sys-b {
+  yang-version 1.1;
+  belongs-to sys { prefix s; }
+  include sys-a;
+  leaf b { type string; }
+}`,
+}, {
+	file: "sys-ext.yang",
+	content: `module sys-ext {
+  namespace "urn:sys-ext";
+  prefix x;
+  import sys { prefix s; }
+  augment /s:system { leaf mtu { type uint16; } }
+}`,
+}, {
+	file: "sys-dev.yang",
+	content: `module sys-dev {
+  namespace "urn:sys-dev";
+  prefix d;
+  import sys { prefix s; }
+  deviation /s:system/s:name { deviate not-supported; }
+}`,
+}}
+
+func buildTest(t *testing.T) *Graph {
+	t.Helper()
+	ms := yang.NewModules()
+	// The submodules sys-a and sys-b include each other.
+	ms.ParseOptions.IgnoreSubmoduleCircularDependencies = true
+	for _, m := range testModules {
+		if err := ms.Parse(m.content, m.file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("Process: %v", errs)
+	}
+	return Build(ms)
+}
+
+func TestBuild(t *testing.T) {
+	g := buildTest(t)
+	want := []*Edge{
+		{From: "sys", To: "sys-a", Kind: Include},
+		{From: "sys", To: "sys-b", Kind: Include},
+		{From: "sys", To: "types", Kind: Import},
+		{From: "sys-a", To: "sys-b", Kind: Include},
+		{From: "sys-b", To: "sys-a", Kind: Include},
+		{From: "sys-dev", To: "sys", Kind: Deviation},
+		{From: "sys-dev", To: "sys", Kind: Import},
+		{From: "sys-ext", To: "sys", Kind: Augment},
+		{From: "sys-ext", To: "sys", Kind: Import},
+	}
+	if diff := cmp.Diff(want, g.Edges); diff != "" {
+		t.Errorf("Edges (-want, +got):\n%s", diff)
+	}
+	if m := g.Module("sys-a"); m == nil || !m.Submodule || m.File != "sys-a.yang" {
+		t.Errorf("Module(sys-a): got %+v, want the submodule read from sys-a.yang", m)
+	}
+	if diff := cmp.Diff([][]string{{"sys-a", "sys-b"}}, g.Cycles()); diff != "" {
+		t.Errorf("Cycles (-want, +got):\n%s", diff)
+	}
+	wantOrder := []string{"sys-a", "sys-b", "types", "sys", "sys-dev", "sys-ext"}
+	if diff := cmp.Diff(wantOrder, g.LoadOrder()); diff != "" {
+		t.Errorf("LoadOrder (-want, +got):\n%s", diff)
+	}
+}
+
+func TestClosure(t *testing.T) {
+	g := buildTest(t)
+	tests := []struct {
+		desc      string
+		in        string
+		want      []string
+		wantFiles []string
+		wantErr   string
+	}{{
+		desc:      "module",
+		in:        "sys-ext",
+		want:      []string{"sys-a", "sys-b", "types", "sys"},
+		wantFiles: []string{"sys-a.yang", "sys-b.yang", "types.yang", "sys.yang"},
+	}, {
+		desc:      "submodule in a cycle",
+		in:        "sys-a",
+		want:      []string{"sys-b"},
+		wantFiles: []string{"sys-b.yang"},
+	}, {
+		desc: "no dependencies",
+		in:   "types",
+	}, {
+		desc:    "unknown",
+		in:      "other",
+		wantErr: "unknown module other",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := g.Closure(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Closure (-want, +got):\n%s", diff)
+			}
+			files, _ := g.Files(tt.in)
+			if diff := cmp.Diff(tt.wantFiles, files); diff != "" {
+				t.Errorf("Files (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSchemaClosure(t *testing.T) {
+	g := buildTest(t)
+	tests := []struct {
+		desc      string
+		in        string
+		want      []string
+		wantFiles []string
+		wantErr   string
+	}{{
+		desc:      "augmented and deviated",
+		in:        "sys",
+		want:      []string{"sys-a", "sys-b", "types", "sys-dev", "sys-ext"},
+		wantFiles: []string{"sys-a.yang", "sys-b.yang", "types.yang", "sys-dev.yang", "sys-ext.yang"},
+	}, {
+		desc:      "no extensions",
+		in:        "sys-ext",
+		want:      []string{"sys-a", "sys-b", "types", "sys"},
+		wantFiles: []string{"sys-a.yang", "sys-b.yang", "types.yang", "sys.yang"},
+	}, {
+		desc:    "unknown",
+		in:      "other",
+		wantErr: "unknown module other",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := g.SchemaClosure(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SchemaClosure (-want, +got):\n%s", diff)
+			}
+			files, _ := g.SchemaFiles(tt.in)
+			if diff := cmp.Diff(tt.wantFiles, files); diff != "" {
+				t.Errorf("SchemaFiles (-want, +got):\n%s", diff)
+			}
+		})
+	}
+	if diff := cmp.Diff([]string{"sys-dev", "sys-ext"}, g.Extensions("sys")); diff != "" {
+		t.Errorf("Extensions (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	g := buildTest(t)
+
+	var b strings.Builder
+	if err := g.WriteTree(&b); err != nil {
+		t.Fatal(err)
+	}
+	wantTree := `sys-dev
+  deviation sys
+    include sys-a
+      include sys-b
+        include sys-a (cycle)
+    include sys-b ...
+    import types
+  import sys ...
+sys-ext
+  augment sys ...
+  import sys ...
+`
+	if diff := cmp.Diff(wantTree, b.String()); diff != "" {
+		t.Errorf("WriteTree (-want, +got):\n%s", diff)
+	}
+
+	b.Reset()
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"sys-a" [shape=ellipse];`,
+		`"sys" [shape=box];`,
+		`"sys-a" -> "sys-b" [label="include", color=red];`,
+		`"sys-ext" -> "sys" [label="augment"];`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteDOT: missing %s in:\n%s", want, b.String())
+		}
+	}
+
+	if err := g.WriteTree(&b, "other"); err == nil {
+		t.Errorf("WriteTree of an unknown module: got no error")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangdiff
+
+// This file compares the types of leaves and leaf-lists.  A type may only be
+// changed in ways that keep every old value valid, e.g., widening a range or
+// adding an enum.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// types compares o and n, the old and new types of the node at path.
+func (d *differ) types(path string, o, n *yang.YangType) {
+	if o.Kind != n.Kind {
+		d.add(path, TypeChanged, true, o.Kind.String(), n.Kind.String(), "type changed from %s to %s", o.Kind, n.Kind)
+		return
+	}
+	switch o.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64,
+		yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64:
+		d.ranges(path, RangeChanged, "range", o.Range, n.Range)
+	case yang.Ydecimal64:
+		if o.FractionDigits != n.FractionDigits {
+			d.add(path, TypeChanged, true, fmt.Sprint(o.FractionDigits), fmt.Sprint(n.FractionDigits),
+				"fraction-digits changed from %d to %d", o.FractionDigits, n.FractionDigits)
+			return
+		}
+		d.ranges(path, RangeChanged, "range", o.Range, n.Range)
+	case yang.Ystring:
+		d.ranges(path, LengthChanged, "length", o.Length, n.Length)
+		d.patterns(path, "pattern", o.Pattern, n.Pattern)
+		d.patterns(path, "inverted pattern", o.InvertPattern, n.InvertPattern)
+	case yang.Ybinary:
+		d.ranges(path, LengthChanged, "length", o.Length, n.Length)
+	case yang.Yenum:
+		d.enums(path, EnumChanged, "enum", o.Enum, n.Enum)
+	case yang.Ybits:
+		d.enums(path, BitChanged, "bit", o.Bit, n.Bit)
+	case yang.Yidentityref:
+		d.identityrefs(path, o, n)
+	case yang.Yleafref:
+		if o.Path != n.Path {
+			d.add(path, LeafrefChanged, true, o.Path, n.Path, "leafref path changed from %q to %q", o.Path, n.Path)
+		}
+	case yang.Yunion:
+		for i, om := range o.Type {
+			if i >= len(n.Type) {
+				d.add(path, UnionMembersChanged, true, om.Kind.String(), "", "union member %d (%s) was removed", i+1, om.Kind)
+				continue
+			}
+			d.types(path, om, n.Type[i])
+		}
+		for i := len(o.Type); i < len(n.Type); i++ {
+			d.add(path, UnionMembersChanged, false, "", n.Type[i].Kind.String(), "union member %d (%s) was added", i+1, n.Type[i].Kind)
+		}
+	}
+}
+
+// ranges compares the old and new range or length restrictions o and n.
+// The new restriction must allow all the values the old one did.  An empty
+// restriction allows all the values of the base type, so adding one to an
+// unrestricted type is always reported as narrowing it.
+func (d *differ) ranges(path string, kind ChangeKind, what string, o, n yang.YangRange) {
+	if o.Equal(n) {
+		return
+	}
+	os, ns := rangeString(o), rangeString(n)
+	// Contains treats an empty argument as contained in any range.
+	if len(o) > 0 && n.Contains(o) {
+		d.add(path, kind, false, os, ns, "%s widened from %s to %s", what, os, ns)
+		return
+	}
+	d.add(path, kind, true, os, ns, "%s narrowed from %s to %s", what, os, ns)
+}
+
+func rangeString(r yang.YangRange) string {
+	if len(r) == 0 {
+		return "min..max"
+	}
+	return r.String()
+}
+
+// patterns compares the old and new patterns o and n.  Adding a pattern
+// restricts the allowed values, removing one does not.
+func (d *differ) patterns(path, what string, o, n []string) {
+	was := map[string]bool{}
+	for _, p := range o {
+		was[p] = true
+	}
+	have := map[string]bool{}
+	for _, p := range n {
+		have[p] = true
+		if !was[p] {
+			d.add(path, PatternChanged, true, "", p, "%s %q was added", what, p)
+		}
+	}
+	for _, p := range o {
+		if !have[p] {
+			d.add(path, PatternChanged, false, p, "", "%s %q was removed", what, p)
+		}
+	}
+}
+
+// enums compares the old and new enumerations or bits o and n.  Removing a
+// name or changing its value or position is breaking.
+func (d *differ) enums(path string, kind ChangeKind, what string, o, n *yang.EnumType) {
+	if o == nil || n == nil {
+		return
+	}
+	om, nm := o.NameMap(), n.NameMap()
+	for _, name := range o.Names() {
+		nv, ok := nm[name]
+		switch {
+		case !ok:
+			d.add(path, kind, true, name, "", "%s %s was removed", what, name)
+		case nv != om[name]:
+			d.add(path, kind, true, fmt.Sprintf("%s=%d", name, om[name]), fmt.Sprintf("%s=%d", name, nv),
+				"%s %s changed value from %d to %d", what, name, om[name], nv)
+		}
+	}
+	for _, name := range n.Names() {
+		if _, ok := om[name]; !ok {
+			d.add(path, kind, false, "", name, "%s %s was added", what, name)
+		}
+	}
+}
+
+// identityrefs compares the identities allowed by the old and new
+// identityref types o and n.
+func (d *differ) identityrefs(path string, o, n *yang.YangType) {
+	oi, ni := o.AllowedIdentities(), n.AllowedIdentities()
+	var removed, added []string
+	for _, id := range oi {
+		if !contains(ni, id) {
+			removed = append(removed, id)
+		}
+	}
+	for _, id := range ni {
+		if !contains(oi, id) {
+			added = append(added, id)
+		}
+	}
+	if len(removed) > 0 {
+		s := strings.Join(removed, ", ")
+		d.add(path, IdentityrefChanged, true, s, "", "identities %s are no longer allowed", s)
+	}
+	if len(added) > 0 {
+		s := strings.Join(added, ", ")
+		d.add(path, IdentityrefChanged, false, "", s, "identities %s are now allowed", s)
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,404 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yangdiff compares two revisions of a set of YANG modules and
+// reports the differences between their schema trees, classifying each one
+// as backwards compatible or breaking according to the update rules of
+// RFC 7950 section 11, https://tools.ietf.org/html/rfc7950#section-11.
+//
+// A breaking change is one that can make instance data or requests that were
+// valid for the old revision invalid for the new one, e.g., removing a node,
+// narrowing the range of a type or adding a mandatory node.
+package yangdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// A ChangeKind identifies the kind of a Change.
+type ChangeKind string
+
+// The kinds of changes reported.
+const (
+	ModuleRemoved       ChangeKind = "module-removed"
+	ModuleAdded         ChangeKind = "module-added"
+	NodeRemoved         ChangeKind = "node-removed"
+	NodeAdded           ChangeKind = "node-added"
+	MandatoryAdded      ChangeKind = "mandatory-added"
+	NodeKindChanged     ChangeKind = "node-kind-changed"
+	PresenceChanged     ChangeKind = "presence-changed"
+	KeyChanged          ChangeKind = "key-changed"
+	ConfigChanged       ChangeKind = "config-changed"
+	ElementsChanged     ChangeKind = "elements-changed"
+	DefaultChanged      ChangeKind = "default-changed"
+	UnitsChanged        ChangeKind = "units-changed"
+	MustChanged         ChangeKind = "must-changed"
+	WhenChanged         ChangeKind = "when-changed"
+	IdentityRemoved     ChangeKind = "identity-removed"
+	IdentityAdded       ChangeKind = "identity-added"
+	TypeChanged         ChangeKind = "type-changed"
+	RangeChanged        ChangeKind = "range-changed"
+	LengthChanged       ChangeKind = "length-changed"
+	PatternChanged      ChangeKind = "pattern-changed"
+	EnumChanged         ChangeKind = "enum-changed"
+	BitChanged          ChangeKind = "bit-changed"
+	IdentityrefChanged  ChangeKind = "identityref-changed"
+	LeafrefChanged      ChangeKind = "leafref-changed"
+	UnionMembersChanged ChangeKind = "union-members-changed"
+)
+
+// A Change is a single difference between the old and new revision of a
+// schema node.
+type Change struct {
+	Path     string     `json:"path"`          // schema path of the node, e.g., /mod/container/leaf
+	Kind     ChangeKind `json:"kind"`          // the kind of change
+	Breaking bool       `json:"breaking"`      // the change is not backwards compatible
+	Old      string     `json:"old,omitempty"` // the old value, if any
+	New      string     `json:"new,omitempty"` // the new value, if any
+	Message  string     `json:"message"`       // description of the change
+}
+
+func (c *Change) String() string {
+	severity := "compatible"
+	if c.Breaking {
+		severity = "BREAKING"
+	}
+	return fmt.Sprintf("%s %s: %s", severity, c.Path, c.Message)
+}
+
+// A Report is the result of comparing two revisions of a set of modules.
+type Report struct {
+	Breaking bool      `json:"breaking"` // at least one change is breaking
+	Changes  []*Change `json:"changes"`
+}
+
+// A differ accumulates the changes found while comparing schema trees.
+type differ struct {
+	changes []*Change
+}
+
+func (d *differ) add(path string, kind ChangeKind, breaking bool, old, new, format string, args ...interface{}) {
+	d.changes = append(d.changes, &Change{
+		Path:     path,
+		Kind:     kind,
+		Breaking: breaking,
+		Old:      old,
+		New:      new,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Compare compares old and new, the module Entry trees of the old and new
+// revisions of a set of modules, and returns the report of their
+// differences.  Modules are matched by name.
+func Compare(old, new []*yang.Entry) *Report {
+	d := &differ{}
+	newMods := map[string]*yang.Entry{}
+	for _, m := range new {
+		newMods[m.Name] = m
+	}
+	oldMods := map[string]bool{}
+	for _, o := range sortEntries(old) {
+		oldMods[o.Name] = true
+		n := newMods[o.Name]
+		if n == nil {
+			d.add("/"+o.Name, ModuleRemoved, true, "", "", "module %s was removed", o.Name)
+			continue
+		}
+		d.identities(o, n)
+		d.children(o, n)
+	}
+	for _, n := range sortEntries(new) {
+		if !oldMods[n.Name] {
+			d.add("/"+n.Name, ModuleAdded, false, "", "", "module %s was added", n.Name)
+		}
+	}
+	r := &Report{Changes: d.changes}
+	for _, c := range d.changes {
+		if c.Breaking {
+			r.Breaking = true
+		}
+	}
+	return r
+}
+
+// CompareModules compares the named modules of old and new, which must have
+// been processed.  All the modules of old are compared if no names are
+// given.
+func CompareModules(old, new *yang.Modules, names ...string) *Report {
+	if len(names) == 0 {
+		for _, m := range old.Modules {
+			names = append(names, m.Name)
+		}
+	}
+	var oldEntries, newEntries []*yang.Entry
+	seen := map[string]bool{}
+	for _, name := range names {
+		m := old.Modules[name]
+		if m == nil || seen[m.Name] {
+			continue
+		}
+		seen[m.Name] = true
+		oldEntries = append(oldEntries, yang.ToEntry(m))
+		if m := new.Modules[m.Name]; m != nil {
+			newEntries = append(newEntries, yang.ToEntry(m))
+		}
+	}
+	return Compare(oldEntries, newEntries)
+}
+
+// sortEntries returns es sorted by name.
+func sortEntries(es []*yang.Entry) []*yang.Entry {
+	out := append([]*yang.Entry{}, es...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// sortedKeys returns the names in dir in sorted order.
+func sortedKeys(dir map[string]*yang.Entry) []string {
+	keys := make([]string, 0, len(dir))
+	for k := range dir {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// identities compares the identities defined by the modules o and n.
+// Removing an identity is breaking as data may refer to it.
+func (d *differ) identities(o, n *yang.Entry) {
+	have := map[string]bool{}
+	for _, id := range n.Identities {
+		have[id.Name] = true
+	}
+	was := map[string]bool{}
+	for _, id := range o.Identities {
+		was[id.Name] = true
+		if !have[id.Name] {
+			d.add("/"+o.Name, IdentityRemoved, true, id.Name, "", "identity %s was removed", id.Name)
+		}
+	}
+	for _, id := range n.Identities {
+		if !was[id.Name] {
+			d.add("/"+n.Name, IdentityAdded, false, "", id.Name, "identity %s was added", id.Name)
+		}
+	}
+}
+
+// children compares the children of o and n, the old and new revisions of
+// the same node.
+func (d *differ) children(o, n *yang.Entry) {
+	od, nd := childMap(o), childMap(n)
+	for _, name := range sortedKeys(od) {
+		oc := od[name]
+		nc := nd[name]
+		if nc == nil {
+			d.add(oc.Path(), NodeRemoved, true, "", "", "%s %s was removed", kind(oc), oc.Name)
+			continue
+		}
+		d.node(oc, nc)
+	}
+	for _, name := range sortedKeys(nd) {
+		if od[name] != nil {
+			continue
+		}
+		nc := nd[name]
+		if mandatory(nc) && !nc.ReadOnly() {
+			d.add(nc.Path(), MandatoryAdded, true, "", "", "mandatory %s %s was added", kind(nc), nc.Name)
+			continue
+		}
+		d.add(nc.Path(), NodeAdded, false, "", "", "%s %s was added", kind(nc), nc.Name)
+	}
+}
+
+// childMap returns the children of e, including the input and output of an
+// RPC or action.
+func childMap(e *yang.Entry) map[string]*yang.Entry {
+	if e.RPC == nil {
+		return e.Dir
+	}
+	m := map[string]*yang.Entry{}
+	if e.RPC.Input != nil {
+		m["input"] = e.RPC.Input
+	}
+	if e.RPC.Output != nil {
+		m["output"] = e.RPC.Output
+	}
+	return m
+}
+
+// kind returns the YANG statement keyword of the node e.
+func kind(e *yang.Entry) string {
+	if e.IsLeafList() {
+		return "leaf-list"
+	}
+	if e.Node != nil {
+		return e.Node.Kind()
+	}
+	return e.Kind.String()
+}
+
+// presence reports whether e is a presence container.
+func presence(e *yang.Entry) bool {
+	c, ok := e.Node.(*yang.Container)
+	return ok && c.Presence != nil
+}
+
+// mandatory reports whether e is a mandatory node as defined in RFC 7950
+// section 3: a mandatory leaf, anydata, anyxml or choice, a list or
+// leaf-list with a positive min-elements, or a non-presence container with a
+// mandatory child.  A case is never mandatory on its own.
+func mandatory(e *yang.Entry) bool {
+	switch {
+	case e.IsCase():
+		return false
+	case e.IsList() || e.IsLeafList():
+		return e.ListAttr != nil && e.ListAttr.MinElements > 0
+	case e.IsContainer():
+		if presence(e) {
+			return false
+		}
+		for _, c := range e.Dir {
+			if mandatory(c) {
+				return true
+			}
+		}
+		return false
+	}
+	return e.Mandatory == yang.TSTrue
+}
+
+// node compares o and n, the old and new revisions of the same node.
+func (d *differ) node(o, n *yang.Entry) {
+	path := o.Path()
+	if ok, nk := kind(o), kind(n); ok != nk {
+		d.add(path, NodeKindChanged, true, ok, nk, "changed from %s to %s", ok, nk)
+		return
+	}
+	if op, np := presence(o), presence(n); op != np {
+		d.add(path, PresenceChanged, true, fmt.Sprint(op), fmt.Sprint(np), "presence changed from %t to %t", op, np)
+	}
+	if o.Key != n.Key {
+		d.add(path, KeyChanged, true, o.Key, n.Key, "key changed from %q to %q", o.Key, n.Key)
+	}
+	switch or, nr := o.ReadOnly(), n.ReadOnly(); {
+	case !or && nr:
+		d.add(path, ConfigChanged, true, "true", "false", "config changed from true to false")
+	case or && !nr:
+		d.add(path, ConfigChanged, false, "false", "true", "config changed from false to true")
+	}
+	if !n.ReadOnly() && !mandatory(o) && mandatory(n) {
+		d.add(path, MandatoryAdded, true, "", "", "became mandatory")
+	}
+	d.elements(o, n)
+	d.defaults(o, n)
+	if o.Units != n.Units {
+		d.add(path, UnitsChanged, o.Units != "", o.Units, n.Units, "units changed from %q to %q", o.Units, n.Units)
+	}
+	d.constraints(o, n)
+	if o.Type != nil && n.Type != nil {
+		d.types(path, o.Type, n.Type)
+	}
+	d.children(o, n)
+}
+
+// elements compares the min-elements and max-elements of o and n.
+func (d *differ) elements(o, n *yang.Entry) {
+	if o.ListAttr == nil || n.ListAttr == nil {
+		return
+	}
+	oa, na := o.ListAttr, n.ListAttr
+	if oa.MinElements != na.MinElements {
+		d.add(o.Path(), ElementsChanged, na.MinElements > oa.MinElements && !n.ReadOnly(),
+			fmt.Sprint(oa.MinElements), fmt.Sprint(na.MinElements),
+			"min-elements changed from %d to %d", oa.MinElements, na.MinElements)
+	}
+	if oa.MaxElements != na.MaxElements {
+		d.add(o.Path(), ElementsChanged, na.MaxElements < oa.MaxElements && !n.ReadOnly(),
+			maxElements(oa.MaxElements), maxElements(na.MaxElements),
+			"max-elements changed from %s to %s", maxElements(oa.MaxElements), maxElements(na.MaxElements))
+	}
+}
+
+func maxElements(n uint64) string {
+	if n == yang.NewDefaultListAttr().MaxElements {
+		return "unbounded"
+	}
+	return fmt.Sprint(n)
+}
+
+// defaults compares the default values of o and n.  Adding a default to a
+// node that had none is allowed, changing or removing one is not.  Values
+// are compared in their canonical form when the type allows it.
+func (d *differ) defaults(o, n *yang.Entry) {
+	od, nd := canonicalDefaults(o), canonicalDefaults(n)
+	ov, nv := strings.Join(od, ", "), strings.Join(nd, ", ")
+	switch {
+	case ov == nv:
+	case len(od) == 0:
+		d.add(o.Path(), DefaultChanged, false, ov, nv, "default %q was added", nv)
+	case len(nd) == 0:
+		d.add(o.Path(), DefaultChanged, true, ov, nv, "default %q was removed", ov)
+	default:
+		d.add(o.Path(), DefaultChanged, true, ov, nv, "default changed from %q to %q", ov, nv)
+	}
+}
+
+// canonicalDefaults returns the default values of e in canonical form.
+func canonicalDefaults(e *yang.Entry) []string {
+	var out []string
+	for _, v := range e.DefaultValues() {
+		if e.Type != nil {
+			if c, err := e.Type.FormatCanonical(v); err == nil {
+				v = c
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// constraints compares the must and when statements of o and n.  Any new
+// must or when expression may invalidate existing data.
+func (d *differ) constraints(o, n *yang.Entry) {
+	was := map[string]bool{}
+	for _, m := range yang.Musts(o.Node) {
+		was[m.Name] = true
+	}
+	have := map[string]bool{}
+	for _, m := range yang.Musts(n.Node) {
+		have[m.Name] = true
+		if !was[m.Name] {
+			d.add(o.Path(), MustChanged, true, "", m.Name, "must %q was added", m.Name)
+		}
+	}
+	for _, m := range yang.Musts(o.Node) {
+		if !have[m.Name] {
+			d.add(o.Path(), MustChanged, false, m.Name, "", "must %q was removed", m.Name)
+		}
+	}
+	ow, _ := o.GetWhenXPath()
+	nw, _ := n.GetWhenXPath()
+	switch {
+	case ow == nw:
+	case nw == "":
+		d.add(o.Path(), WhenChanged, false, ow, nw, "when %q was removed", ow)
+	default:
+		d.add(o.Path(), WhenChanged, true, ow, nw, "when changed from %q to %q", ow, nw)
+	}
+}
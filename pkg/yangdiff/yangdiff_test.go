@@ -0,0 +1,208 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangdiff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+const oldModule = `
+module sys {
+  namespace "urn:sys";
+  prefix s;
+
+  identity proto;
+  identity tcp { base proto; }
+  identity udp { base proto; }
+
+  container system {
+    leaf host-name { type string { length "1..64"; } }
+    leaf mtu { type uint16 { range "68..9000"; } default 1500; }
+    leaf ratio { type decimal64 { fraction-digits 2; } default 0.50; }
+    leaf mode { type enumeration { enum auto; enum manual; enum off; } }
+    leaf flags { type bits { bit a { position 0; } bit b { position 1; } } }
+    leaf proto { type identityref { base proto; } }
+    leaf id { type string { pattern "[a-z]+"; } }
+    leaf counter { type uint32; config false; }
+    leaf size { type int32; units "bytes"; }
+    leaf mixed { type union { type int8; type string; } }
+    leaf old { type string; }
+    leaf kind { type string; }
+    leaf optional { type string; }
+    container opts { presence "enables options"; }
+    list server {
+      key "name";
+      max-elements 10;
+      leaf name { type string; }
+      leaf port { type uint16; must ". > 0"; }
+    }
+  }
+}
+`
+
+const newModule = `
+module sys {
+  namespace "urn:sys";
+  prefix s;
+
+  identity proto;
+  identity tcp { base proto; }
+  identity sctp { base proto; }
+
+  container system {
+    config true;
+    leaf host-name { type string { length "1..255"; } }
+    leaf mtu { type uint16 { range "1280..9000"; } default "01500"; }
+    leaf ratio { type decimal64 { fraction-digits 2; } default 0.6; }
+    leaf mode { type enumeration { enum auto; enum manual { value 5; } enum on; } }
+    leaf flags { type bits { bit a { position 0; } bit b { position 1; } bit c { position 2; } } }
+    leaf proto { type identityref { base proto; } }
+    leaf id { type string { pattern "[a-z]+"; pattern "x.*"; } }
+    leaf counter { type uint32; config false; }
+    leaf size { type int64; units "bytes"; }
+    leaf mixed { type union { type int8; type string; type boolean; } }
+    container kind { }
+    leaf optional { type string; mandatory true; }
+    leaf note { type string; }
+    leaf required { type string; mandatory true; }
+    container opts { }
+    list server {
+      key "name port";
+      max-elements 5;
+      leaf name { type string; }
+      leaf port { type uint16; when "../name != 'x'"; }
+    }
+  }
+}
+`
+
+func load(t *testing.T, src string) *yang.Modules {
+	t.Helper()
+	ms := yang.NewModules()
+	if err := ms.Parse(src, "sys.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	return ms
+}
+
+func TestCompareModules(t *testing.T) {
+	r := CompareModules(load(t, oldModule), load(t, newModule))
+	var got []string
+	for _, c := range r.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"BREAKING /sys: identity udp was removed",
+		"compatible /sys: identity sctp was added",
+		"BREAKING /sys/system: became mandatory",
+		"compatible /sys/system/flags: bit c was added",
+		"compatible /sys/system/host-name: length widened from 1..64 to 1..255",
+		"BREAKING /sys/system/id: pattern \"x.*\" was added",
+		"BREAKING /sys/system/kind: changed from leaf to container",
+		"compatible /sys/system/mixed: union member 3 (boolean) was added",
+		"BREAKING /sys/system/mode: enum manual changed value from 1 to 5",
+		"BREAKING /sys/system/mode: enum off was removed",
+		"compatible /sys/system/mode: enum on was added",
+		"BREAKING /sys/system/mtu: range narrowed from 68..9000 to 1280..9000",
+		"BREAKING /sys/system/old: leaf old was removed",
+		"BREAKING /sys/system/optional: became mandatory",
+		"BREAKING /sys/system/opts: presence changed from true to false",
+		"BREAKING /sys/system/proto: identities sys:udp are no longer allowed",
+		"compatible /sys/system/proto: identities sys:sctp are now allowed",
+		"BREAKING /sys/system/ratio: default changed from \"0.5\" to \"0.6\"",
+		"BREAKING /sys/system/server: key changed from \"name\" to \"name port\"",
+		"BREAKING /sys/system/server: max-elements changed from 10 to 5",
+		"compatible /sys/system/server/port: must \". > 0\" was removed",
+		"BREAKING /sys/system/server/port: when changed from \"\" to \"../name != 'x'\"",
+		"BREAKING /sys/system/size: type changed from int32 to int64",
+		"compatible /sys/system/note: leaf note was added",
+		"BREAKING /sys/system/required: mandatory leaf required was added",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CompareModules (-want, +got):\n%s", diff)
+	}
+	if !r.Breaking {
+		t.Errorf("CompareModules got Breaking false, want true")
+	}
+}
+
+func TestCompareCompatible(t *testing.T) {
+	r := CompareModules(load(t, oldModule), load(t, oldModule))
+	if len(r.Changes) != 0 || r.Breaking {
+		t.Errorf("comparing a module with itself got %v, want no changes", r.Changes)
+	}
+
+	o := load(t, `module m { namespace "urn:m"; prefix m; leaf a { type string; } }`)
+	n := load(t, `module m { namespace "urn:m"; prefix m; leaf a { type string; default "x"; } leaf-list b { type string; min-elements 1; config false; } }`)
+	r = CompareModules(o, n)
+	var got []string
+	for _, c := range r.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`compatible /m/a: default "x" was added`,
+		"compatible /m/b: leaf-list b was added",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CompareModules (-want, +got):\n%s", diff)
+	}
+	if r.Breaking {
+		t.Errorf("CompareModules got Breaking true, want false")
+	}
+}
+
+func TestRestrictionAdded(t *testing.T) {
+	o := load(t, `module d { namespace "urn:d"; prefix d;
+  leaf s { type string; }
+  leaf b { type binary; }
+  leaf i { type int32; }
+  leaf w { type string { length "1..5"; } }
+}`)
+	n := load(t, `module d { namespace "urn:d"; prefix d;
+  leaf s { type string { length "1..5"; } }
+  leaf b { type binary { length "0..10"; } }
+  leaf i { type int32 { range "0..10"; } }
+  leaf w { type string; }
+}`)
+	r := CompareModules(o, n)
+	var got []string
+	for _, c := range r.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"BREAKING /d/b: length narrowed from min..max to 0..10",
+		"BREAKING /d/i: range narrowed from -2147483648..2147483647 to 0..10",
+		"BREAKING /d/s: length narrowed from min..max to 1..5",
+		"compatible /d/w: length widened from 1..5 to min..max",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CompareModules (-want, +got):\n%s", diff)
+	}
+}
+
+func TestModuleRemoved(t *testing.T) {
+	o := load(t, oldModule)
+	r := Compare([]*yang.Entry{yang.ToEntry(o.Modules["sys"])}, nil)
+	want := []*Change{{Path: "/sys", Kind: ModuleRemoved, Breaking: true, Message: "module sys was removed"}}
+	if diff := cmp.Diff(want, r.Changes); diff != "" {
+		t.Errorf("Compare (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yanglib
+
+// This file reads the schema-mounts data of ietf-yang-schema-mount, RFC
+// 8528, which lists the mount points of a server, and mounts the schema of
+// each, given as a yang-library, in a yang.Modules.
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// SchemaMountNamespace is the namespace of the ietf-yang-schema-mount module.
+const SchemaMountNamespace = "urn:ietf:params:xml:ns:yang:ietf-yang-schema-mount"
+
+// schemaMountsJSONName is the member name of the schema-mounts container in
+// the RFC 7951 encoding.
+const schemaMountsJSONName = "ietf-yang-schema-mount:schema-mounts"
+
+// SchemaMounts is the schema-mounts container of ietf-yang-schema-mount.
+type SchemaMounts struct {
+	XMLName     xml.Name          `json:"-" xml:"urn:ietf:params:xml:ns:yang:ietf-yang-schema-mount schema-mounts"`
+	Namespaces  []*MountNamespace `json:"namespace,omitempty" xml:"namespace"`
+	MountPoints []*MountPoint     `json:"mount-point,omitempty" xml:"mount-point"`
+}
+
+// A MountNamespace binds a prefix used in the parent references of shared
+// schemas to a namespace.
+type MountNamespace struct {
+	Prefix string `json:"prefix" xml:"prefix"`
+	URI    string `json:"uri" xml:"uri"`
+}
+
+// A MountPoint is a mount point, identified by the module that defines it
+// and its label.  Exactly one of Inline and SharedSchema is set.
+type MountPoint struct {
+	Module       string        `json:"module" xml:"module"`
+	Label        string        `json:"label" xml:"label"`
+	Config       *bool         `json:"config,omitempty" xml:"config,omitempty"` // true if nil
+	Inline       *struct{}     `json:"inline,omitempty" xml:"inline"`
+	SharedSchema *SharedSchema `json:"shared-schema,omitempty" xml:"shared-schema"`
+}
+
+// A SharedSchema is a schema mounted at every instance of a mount point.
+// ParentReferences are XPath expressions, using the prefixes of the
+// namespaces of the schema mounts, selecting the nodes of the parent schema
+// that leafrefs in the mounted schema may refer to.
+type SharedSchema struct {
+	ParentReferences []string `json:"parent-reference,omitempty" xml:"parent-reference"`
+}
+
+// Key returns the module and label of p as MODULE:LABEL.
+func (p *MountPoint) Key() string {
+	return p.Module + ":" + p.Label
+}
+
+// MarshalJSON returns sm in the RFC 7951 encoding, as the schema-mounts
+// member of a JSON object.
+func (sm *SchemaMounts) MarshalJSON() ([]byte, error) {
+	type schemaMounts SchemaMounts
+	return json.Marshal(map[string]*schemaMounts{schemaMountsJSONName: (*schemaMounts)(sm)})
+}
+
+// UnmarshalJSON sets sm from the RFC 7951 encoding of a schema-mounts
+// container, as returned by MarshalJSON.
+func (sm *SchemaMounts) UnmarshalJSON(b []byte) error {
+	type schemaMounts SchemaMounts
+	var data map[string]*schemaMounts
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	s := data[schemaMountsJSONName]
+	if s == nil {
+		return fmt.Errorf("no %s member found", schemaMountsJSONName)
+	}
+	*sm = SchemaMounts(*s)
+	return nil
+}
+
+// ParseSchemaMounts returns the schema-mounts data in b, in either the JSON
+// or the XML encoding.  The XML schema-mounts element may be nested in
+// other elements, such as the data element of a NETCONF reply.
+func ParseSchemaMounts(b []byte) (*SchemaMounts, error) {
+	if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '{' {
+		sm := &SchemaMounts{}
+		if err := json.Unmarshal(b, sm); err != nil {
+			return nil, err
+		}
+		return sm, nil
+	}
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("no schema-mounts element found: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Space == SchemaMountNamespace && se.Name.Local == "schema-mounts" {
+			sm := &SchemaMounts{}
+			if err := d.DecodeElement(sm, &se); err != nil {
+				return nil, err
+			}
+			return sm, nil
+		}
+	}
+}
+
+// Mount mounts the schema of each mount point of sm in ms, which must have
+// been processed.  The schema of a mount point is described by
+// libraries[MODULE:LABEL], see MountPoint.Key.  Its modules are loaded into
+// a new yang.Modules, searching ms.Path and using the parse options of ms
+// other than the features, which are those of the library.  The nodes
+// mounted at a mount point with config false are state data.
+func (sm *SchemaMounts) Mount(ms *yang.Modules, libraries map[string]*Library) []error {
+	var errs []error
+	for _, p := range sm.MountPoints {
+		l := libraries[p.Key()]
+		if l == nil {
+			errs = append(errs, fmt.Errorf("mount point %s: no yang-library describes its schema", p.Key()))
+			continue
+		}
+		mounted := yang.NewModules()
+		mounted.AddPath(ms.Path...)
+		mounted.ParseOptions = ms.ParseOptions
+		mounted.ParseOptions.Features = nil
+		if lerrs := l.Load(mounted); len(lerrs) > 0 {
+			errs = append(errs, prefixErrors(p, lerrs)...)
+			continue
+		}
+		if perrs := mounted.Process(); len(perrs) > 0 {
+			errs = append(errs, prefixErrors(p, perrs)...)
+			continue
+		}
+		if err := ms.Mount(&yang.Mount{
+			Module:   p.Module,
+			Label:    p.Label,
+			Schema:   mounted,
+			ReadOnly: p.Config != nil && !*p.Config,
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// prefixErrors returns errs prefixed by the mount point p they were found in.
+func prefixErrors(p *MountPoint, errs []error) []error {
+	perrs := make([]error, len(errs))
+	for i, err := range errs {
+		perrs[i] = fmt.Errorf("mount point %s: %w", p.Key(), err)
+	}
+	return perrs
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yanglib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var mountModules = map[string]string{
+	"ietf-yang-schema-mount.yang": `module ietf-yang-schema-mount {
+  namespace "urn:ietf:params:xml:ns:yang:ietf-yang-schema-mount";
+  prefix yangmnt;
+  extension mount-point { argument label; }
+}`,
+	"host.yang": `module host {
+  namespace "urn:host";
+  prefix h;
+  import ietf-yang-schema-mount { prefix yangmnt; }
+  container devices {
+    list device {
+      key name;
+      leaf name { type string; }
+      container root { yangmnt:mount-point device-root; }
+    }
+  }
+}`,
+}
+
+const schemaMountsJSON = `{
+  "ietf-yang-schema-mount:schema-mounts": {
+    "mount-point": [
+      {"module": "host", "label": "device-root", "config": false, "inline": {}}
+    ]
+  }
+}`
+
+const schemaMountsXML = `<data xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <schema-mounts xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-schema-mount">
+    <namespace><prefix>h</prefix><uri>urn:host</uri></namespace>
+    <mount-point>
+      <module>host</module>
+      <label>device-root</label>
+      <config>false</config>
+      <shared-schema><parent-reference>/h:devices</parent-reference></shared-schema>
+    </mount-point>
+  </schema-mounts>
+</data>`
+
+func TestParseSchemaMounts(t *testing.T) {
+	no := false
+	tests := []struct {
+		desc    string
+		in      string
+		want    *SchemaMounts
+		wantErr string
+	}{{
+		desc: "json",
+		in:   schemaMountsJSON,
+		want: &SchemaMounts{MountPoints: []*MountPoint{{
+			Module: "host", Label: "device-root", Config: &no, Inline: &struct{}{},
+		}}},
+	}, {
+		desc: "xml",
+		in:   schemaMountsXML,
+		want: &SchemaMounts{
+			Namespaces: []*MountNamespace{{Prefix: "h", URI: "urn:host"}},
+			MountPoints: []*MountPoint{{
+				Module: "host", Label: "device-root", Config: &no,
+				SharedSchema: &SharedSchema{ParentReferences: []string{"/h:devices"}},
+			}},
+		},
+	}, {
+		desc:    "json without schema-mounts",
+		in:      `{"other": {}}`,
+		wantErr: "no ietf-yang-schema-mount:schema-mounts member found",
+	}, {
+		desc:    "xml without schema-mounts",
+		in:      `<data/>`,
+		wantErr: "no schema-mounts element found",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseSchemaMounts([]byte(tt.in))
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			got.XMLName.Space, got.XMLName.Local = "", ""
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseSchemaMounts (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMount(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	for name, text := range mountModules {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lib := testLibrary(t, dir, "sys")
+
+	ms := yang.NewModules()
+	ms.AddPath(dir)
+	if err := ms.Read("host"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	sm, err := ParseSchemaMounts([]byte(schemaMountsJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := sm.Mount(ms, map[string]*Library{"host:device-root": lib}); errs != nil {
+		t.Fatalf("Mount: %v", errs)
+	}
+
+	host, _ := ms.GetModule("host")
+	e := host.Find("devices/device/root/system/host-name")
+	if e == nil {
+		t.Fatal("mounted leaf host-name not found")
+	}
+	if got, err := e.InstantiatingModule(); err != nil || got != "sys" {
+		t.Errorf("InstantiatingModule: got %s, %v, want sys", got, err)
+	}
+	if !e.ReadOnly() {
+		t.Errorf("mounted leaf with config false is not read-only")
+	}
+	// The features of the library, f1 and f3, are used for the mounted
+	// schema, so the location leaf, needing f2, is not mounted.
+	if host.Find("devices/device/root/system/location") != nil {
+		t.Errorf("system/location is mounted without feature f2")
+	}
+
+	errs := sm.Mount(ms, nil)
+	if len(errs) != 1 {
+		t.Fatalf("Mount without libraries got errors %v, want 1", errs)
+	}
+	if diff := errdiff.Substring(errs[0], "mount point host:device-root: no yang-library describes its schema"); diff != "" {
+		t.Errorf("Mount: %s", diff)
+	}
+}
@@ -0,0 +1,507 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yanglib generates the YANG library of RFC 8525,
+// https://tools.ietf.org/html/rfc8525, for a set of YANG modules: the
+// modules, revisions, submodules, features and deviations a server
+// implements, grouped into a module set, a schema and the datastores using
+// the schema.  The library is written as instance data of the
+// ietf-yang-library module, in either the JSON encoding of RFC 7951 or the
+// XML encoding of RFC 7950.
+//
+// A library may also be read back and its modules loaded into a
+// yang.Modules, finding each module and submodule revision on the search
+// path and enabling the listed features.
+package yanglib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// The namespaces of the ietf-yang-library and ietf-datastores modules.
+const (
+	Namespace           = "urn:ietf:params:xml:ns:yang:ietf-yang-library"
+	DatastoresNamespace = "urn:ietf:params:xml:ns:yang:ietf-datastores"
+)
+
+// jsonName is the member name of the library in the RFC 7951 encoding.
+const jsonName = "ietf-yang-library:yang-library"
+
+// A Library is the yang-library container of the ietf-yang-library module.
+type Library struct {
+	XMLName    xml.Name     `json:"-" xml:"urn:ietf:params:xml:ns:yang:ietf-yang-library yang-library"`
+	ModuleSets []*ModuleSet `json:"module-set,omitempty" xml:"module-set"`
+	Schemas    []*Schema    `json:"schema,omitempty" xml:"schema"`
+	Datastores []*Datastore `json:"datastore,omitempty" xml:"datastore"`
+	ContentID  string       `json:"content-id" xml:"content-id"`
+}
+
+// A ModuleSet is a set of implemented and import-only modules.
+type ModuleSet struct {
+	Name       string              `json:"name" xml:"name"`
+	Modules    []*Module           `json:"module,omitempty" xml:"module"`
+	ImportOnly []*ImportOnlyModule `json:"import-only-module,omitempty" xml:"import-only-module"`
+}
+
+// A Module is an implemented module.  Features lists the supported features
+// of the module and Deviations the modules that deviate it.
+type Module struct {
+	Name       string       `json:"name" xml:"name"`
+	Revision   string       `json:"revision,omitempty" xml:"revision,omitempty"`
+	Namespace  string       `json:"namespace" xml:"namespace"`
+	Location   []string     `json:"location,omitempty" xml:"location"`
+	Submodules []*Submodule `json:"submodule,omitempty" xml:"submodule"`
+	Features   []string     `json:"feature,omitempty" xml:"feature"`
+	Deviations []string     `json:"deviation,omitempty" xml:"deviation"`
+}
+
+// An ImportOnlyModule is a module whose typedefs, groupings and identities
+// are used by other modules but whose schema nodes are not implemented.
+// Revision is a key of the list and is empty if the module has no revision.
+type ImportOnlyModule struct {
+	Name       string       `json:"name" xml:"name"`
+	Revision   string       `json:"revision" xml:"revision"`
+	Namespace  string       `json:"namespace" xml:"namespace"`
+	Location   []string     `json:"location,omitempty" xml:"location"`
+	Submodules []*Submodule `json:"submodule,omitempty" xml:"submodule"`
+}
+
+// A Submodule is a submodule included by a module.
+type Submodule struct {
+	Name     string   `json:"name" xml:"name"`
+	Revision string   `json:"revision,omitempty" xml:"revision,omitempty"`
+	Location []string `json:"location,omitempty" xml:"location"`
+}
+
+// A Schema is the union of one or more module sets.
+type Schema struct {
+	Name       string   `json:"name" xml:"name"`
+	ModuleSets []string `json:"module-set,omitempty" xml:"module-set"`
+}
+
+// A Datastore names the schema of a datastore.  Name is the datastore
+// identity qualified by its module name, e.g., ietf-datastores:running.
+type Datastore struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// Options are the options for New.
+type Options struct {
+	// ModuleSet and Schema are the names of the module set and of the
+	// schema, "complete" by default.
+	ModuleSet string
+	Schema    string
+	// Datastores are the names of the datastores that use the schema,
+	// either qualified by their module name or the name of an identity
+	// of the ietf-datastores module.  The default is running and
+	// operational.
+	Datastores []string
+	// Implemented lists the names of the implemented modules, the other
+	// modules are import-only.  When empty a module is implemented if no
+	// other module imports it, if it or one of its submodules defines
+	// schema nodes, augments or deviations, or if it is deviated.
+	Implemented []string
+}
+
+// New returns the library of the modules in ms.  The modules should have
+// been processed so that the includes of each module are resolved.  The
+// features of each module are those enabled by ms.ParseOptions.Features.
+func New(ms *yang.Modules, opts *Options) *Library {
+	if opts == nil {
+		opts = &Options{}
+	}
+	setName := opts.ModuleSet
+	if setName == "" {
+		setName = "complete"
+	}
+	schemaName := opts.Schema
+	if schemaName == "" {
+		schemaName = "complete"
+	}
+	datastores := opts.Datastores
+	if len(datastores) == 0 {
+		datastores = []string{"running", "operational"}
+	}
+
+	mods := modules(ms)
+	deviations := deviations(ms, mods)
+	implemented := map[*yang.Module]bool{}
+	if len(opts.Implemented) > 0 {
+		names := map[string]bool{}
+		for _, n := range opts.Implemented {
+			names[n] = true
+		}
+		for _, m := range mods {
+			implemented[m] = names[m.Name]
+		}
+	} else {
+		imported := map[string]bool{}
+		for _, m := range mods {
+			for _, i := range m.Import {
+				imported[i.Name] = true
+			}
+		}
+		for _, m := range mods {
+			implemented[m] = !imported[m.Name] || deviations[m.Name] != nil || definesSchema(ms, m)
+		}
+	}
+	// Only one revision of a module can be implemented, the latest one.
+	for _, m := range mods {
+		if latest := ms.Modules[m.Name]; implemented[m] && latest != nil && latest != m {
+			implemented[m] = false
+		}
+	}
+
+	set := &ModuleSet{Name: setName}
+	for _, m := range mods {
+		var subs []*Submodule
+		for _, s := range submodules(ms, m) {
+			subs = append(subs, &Submodule{Name: s.Name, Revision: s.Current()})
+		}
+		ns := ""
+		if m.Namespace != nil {
+			ns = m.Namespace.Name
+		}
+		if !implemented[m] {
+			set.ImportOnly = append(set.ImportOnly, &ImportOnlyModule{
+				Name:       m.Name,
+				Revision:   m.Current(),
+				Namespace:  ns,
+				Submodules: subs,
+			})
+			continue
+		}
+		set.Modules = append(set.Modules, &Module{
+			Name:       m.Name,
+			Revision:   m.Current(),
+			Namespace:  ns,
+			Submodules: subs,
+			Features:   features(ms, m),
+			Deviations: deviations[m.Name],
+		})
+	}
+
+	l := &Library{
+		ModuleSets: []*ModuleSet{set},
+		Schemas:    []*Schema{{Name: schemaName, ModuleSets: []string{setName}}},
+	}
+	for _, d := range datastores {
+		if !strings.Contains(d, ":") {
+			d = "ietf-datastores:" + d
+		}
+		l.Datastores = append(l.Datastores, &Datastore{Name: d, Schema: schemaName})
+	}
+	l.ContentID = l.contentID()
+	return l
+}
+
+// contentID returns a hash of the module sets, schemas and datastores of l.
+func (l *Library) contentID() string {
+	b, _ := json.Marshal(&Library{ModuleSets: l.ModuleSets, Schemas: l.Schemas, Datastores: l.Datastores})
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// modules returns the modules of ms, each revision once, sorted by name and
+// revision.
+func modules(ms *yang.Modules) []*yang.Module {
+	seen := map[*yang.Module]bool{}
+	var mods []*yang.Module
+	for _, m := range ms.Modules {
+		if !seen[m] {
+			seen[m] = true
+			mods = append(mods, m)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Name != mods[j].Name {
+			return mods[i].Name < mods[j].Name
+		}
+		return mods[i].Current() < mods[j].Current()
+	})
+	return mods
+}
+
+// include returns the submodule included by i.
+func include(ms *yang.Modules, i *yang.Include) *yang.Module {
+	if i.Module != nil {
+		return i.Module
+	}
+	if i.RevisionDate != nil {
+		if s := ms.SubModules[i.Name+"@"+i.RevisionDate.Name]; s != nil {
+			return s
+		}
+	}
+	return ms.SubModules[i.Name]
+}
+
+// submodules returns the submodules included by m, directly or through
+// other submodules, in the order they are included.
+func submodules(ms *yang.Modules, m *yang.Module) []*yang.Module {
+	seen := map[*yang.Module]bool{m: true}
+	var subs []*yang.Module
+	var add func(m *yang.Module)
+	add = func(m *yang.Module) {
+		for _, i := range m.Include {
+			s := include(ms, i)
+			if s == nil || seen[s] {
+				continue
+			}
+			seen[s] = true
+			subs = append(subs, s)
+			add(s)
+		}
+	}
+	add(m)
+	return subs
+}
+
+// features returns the names of the features of m, and of its submodules,
+// that are enabled by ms.ParseOptions.Features.
+func features(ms *yang.Modules, m *yang.Module) []string {
+	var names []string
+	for _, s := range append([]*yang.Module{m}, submodules(ms, m)...) {
+		for _, f := range s.Feature {
+			if ms.ParseOptions.Features.Enabled(m.Name, f.Name) {
+				names = append(names, f.Name)
+			}
+		}
+	}
+	return names
+}
+
+// definesSchema returns true if m or one of its submodules defines schema
+// nodes, augments or deviations.
+func definesSchema(ms *yang.Modules, m *yang.Module) bool {
+	for _, s := range append([]*yang.Module{m}, submodules(ms, m)...) {
+		if len(s.Anydata)+len(s.Anyxml)+len(s.Augment)+len(s.Choice)+len(s.Container)+
+			len(s.Deviation)+len(s.Leaf)+len(s.LeafList)+len(s.List)+len(s.Notification)+
+			len(s.RPC)+len(s.Uses) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// deviations returns, by the name of the deviated module, the sorted names
+// of the modules among mods with deviation statements that target it.
+func deviations(ms *yang.Modules, mods []*yang.Module) map[string][]string {
+	deviated := map[string]map[string]bool{}
+	for _, m := range mods {
+		for _, s := range append([]*yang.Module{m}, submodules(ms, m)...) {
+			for _, d := range s.Deviation {
+				target := strings.TrimPrefix(d.Name, "/")
+				if i := strings.Index(target, "/"); i >= 0 {
+					target = target[:i]
+				}
+				prefix := ""
+				if i := strings.Index(target, ":"); i >= 0 {
+					prefix = target[:i]
+				}
+				tm := yang.FindModuleByPrefix(d, prefix)
+				if tm == nil {
+					continue
+				}
+				name := tm.Name
+				if tm.Kind() == "submodule" {
+					name = tm.BelongsTo.Name
+				}
+				if deviated[name] == nil {
+					deviated[name] = map[string]bool{}
+				}
+				deviated[name][m.Name] = true
+			}
+		}
+	}
+	result := map[string][]string{}
+	for name, by := range deviated {
+		for d := range by {
+			result[name] = append(result[name], d)
+		}
+		sort.Strings(result[name])
+	}
+	return result
+}
+
+// MarshalJSON returns l in the RFC 7951 encoding, as the yang-library
+// member of a JSON object.
+func (l *Library) MarshalJSON() ([]byte, error) {
+	type library Library
+	return json.Marshal(map[string]*library{jsonName: (*library)(l)})
+}
+
+// UnmarshalJSON sets l from the RFC 7951 encoding of a yang-library
+// container, as returned by MarshalJSON.
+func (l *Library) UnmarshalJSON(b []byte) error {
+	type library Library
+	var data map[string]*library
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	ll := data[jsonName]
+	if ll == nil {
+		return fmt.Errorf("no %s member found", jsonName)
+	}
+	*l = Library(*ll)
+	return nil
+}
+
+// MarshalXML writes the name of d with a prefix bound to the namespace of
+// ietf-datastores, the only module known to define datastores.
+func (d *Datastore) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	i := strings.Index(d.Name, ":")
+	if i < 0 || d.Name[:i] != "ietf-datastores" {
+		return fmt.Errorf("datastore %s is not defined by ietf-datastores", d.Name)
+	}
+	x := struct {
+		Name struct {
+			NS    string `xml:"xmlns:ds,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"name"`
+		Schema string `xml:"schema"`
+	}{Schema: d.Schema}
+	x.Name.NS = DatastoresNamespace
+	x.Name.Value = "ds:" + d.Name[i+1:]
+	return e.EncodeElement(x, start)
+}
+
+// UnmarshalXML reads a datastore element.  The name of the datastore is
+// taken to be an identity of the ietf-datastores module, whatever its
+// prefix.
+func (d *Datastore) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	var x struct {
+		Name   string `xml:"name"`
+		Schema string `xml:"schema"`
+	}
+	if err := dec.DecodeElement(&x, &start); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(x.Name)
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	d.Name = "ietf-datastores:" + name
+	d.Schema = strings.TrimSpace(x.Schema)
+	return nil
+}
+
+// JSON returns l in the RFC 7951 encoding, indented.
+func (l *Library) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// XML returns l as the yang-library element of the RFC 7950 XML encoding,
+// indented.
+func (l *Library) XML() ([]byte, error) {
+	b, err := xml.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Parse returns the library in b, in either the JSON or the XML encoding.
+// The XML yang-library element may be nested in other elements, such as the
+// data element of a NETCONF reply.
+func Parse(b []byte) (*Library, error) {
+	if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '{' {
+		l := &Library{}
+		if err := json.Unmarshal(b, l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("no yang-library element found: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Space == Namespace && se.Name.Local == "yang-library" {
+			l := &Library{}
+			if err := d.DecodeElement(l, &se); err != nil {
+				return nil, err
+			}
+			return l, nil
+		}
+	}
+}
+
+// Load reads the modules and submodules of every module set of l into ms,
+// using ms.Path to find them, and enables the listed features of the
+// implemented modules in ms.ParseOptions.Features.  Modules whose features
+// are already listed in ms.ParseOptions.Features keep them.  A module of a
+// given revision is first looked for in a file named name@revision and
+// then in a file named name, which must hold that revision.  Modules
+// already in ms are not read again.  ms must then be processed.
+func (l *Library) Load(ms *yang.Modules) []error {
+	var errs []error
+	read := func(kind string, mods map[string]*yang.Module, name, rev string) {
+		full := name
+		if rev != "" {
+			full += "@" + rev
+		}
+		if mods[full] != nil {
+			return
+		}
+		err := ms.Read(full)
+		if err == nil || rev == "" {
+			if err != nil {
+				errs = append(errs, err)
+			}
+			return
+		}
+		if err := ms.Read(name); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %v", kind, full, err))
+			return
+		}
+		if m := mods[name]; m != nil && m.Current() != rev {
+			errs = append(errs, fmt.Errorf("%s %s: found revision %q", kind, full, m.Current()))
+		}
+	}
+	submodules := func(subs []*Submodule) {
+		for _, s := range subs {
+			read("submodule", ms.SubModules, s.Name, s.Revision)
+		}
+	}
+	for _, set := range l.ModuleSets {
+		for _, m := range set.Modules {
+			read("module", ms.Modules, m.Name, m.Revision)
+			submodules(m.Submodules)
+			if ms.ParseOptions.Features == nil {
+				ms.ParseOptions.Features = yang.FeatureSet{}
+			}
+			if _, ok := ms.ParseOptions.Features[m.Name]; !ok {
+				ms.ParseOptions.Features[m.Name] = append([]string{}, m.Features...)
+			}
+		}
+		for _, m := range set.ImportOnly {
+			read("module", ms.Modules, m.Name, m.Revision)
+			submodules(m.Submodules)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,268 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yanglib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var testModules = map[string]string{
+	"types@2020-01-01.yang": `module types {
+  namespace "urn:types";
+  prefix t;
+  revision 2020-01-01;
+  typedef name { type string; }
+}`,
+	"sys.yang": `module sys {
+  namespace "urn:sys";
+  prefix s;
+  import types { prefix t; }
+  include sys-sub;
+  revision 2021-02-01;
+  revision 2021-01-01;
+  feature f1;
+  feature f2;
+  container system {
+    leaf host-name { type t:name; }
+    leaf location { if-feature f2; type string; }
+  }
+}`,
+	"sys-sub.yang": `submodule sys-sub {
+  belongs-to sys { prefix s; }
+  revision 2021-01-15;
+  feature f3;
+}`,
+	"dev.yang": `module dev {
+  namespace "urn:dev";
+  prefix d;
+  import sys { prefix s; }
+  deviation /s:system/s:host-name { deviate not-supported; }
+}`,
+}
+
+// testDir writes testModules into a new directory.
+func testDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "yanglib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range testModules {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// testLibrary returns the library of the modules in dir, read starting
+// from names.
+func testLibrary(t *testing.T, dir string, names ...string) *Library {
+	t.Helper()
+	ms := yang.NewModules()
+	ms.AddPath(dir)
+	ms.ParseOptions.Features = yang.FeatureSet{"sys": {"f1", "f3"}}
+	for _, err := range ms.ReadAll(names...) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	return New(ms, nil)
+}
+
+func TestNew(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	l := testLibrary(t, dir, "dev")
+	if l.ContentID == "" {
+		t.Errorf("no content-id")
+	}
+	want := &Library{
+		ModuleSets: []*ModuleSet{{
+			Name: "complete",
+			Modules: []*Module{{
+				Name:      "dev",
+				Namespace: "urn:dev",
+			}, {
+				Name:       "sys",
+				Revision:   "2021-02-01",
+				Namespace:  "urn:sys",
+				Submodules: []*Submodule{{Name: "sys-sub", Revision: "2021-01-15"}},
+				Features:   []string{"f1", "f3"},
+				Deviations: []string{"dev"},
+			}},
+			ImportOnly: []*ImportOnlyModule{{
+				Name:      "types",
+				Revision:  "2020-01-01",
+				Namespace: "urn:types",
+			}},
+		}},
+		Schemas: []*Schema{{Name: "complete", ModuleSets: []string{"complete"}}},
+		Datastores: []*Datastore{
+			{Name: "ietf-datastores:running", Schema: "complete"},
+			{Name: "ietf-datastores:operational", Schema: "complete"},
+		},
+		ContentID: l.ContentID,
+	}
+	if diff := cmp.Diff(want, l); diff != "" {
+		t.Errorf("New (-want, +got):\n%s", diff)
+	}
+
+	ms := yang.NewModules()
+	ms.AddPath(dir)
+	if err := ms.Read("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	l = New(ms, &Options{
+		ModuleSet:   "set",
+		Schema:      "schema",
+		Datastores:  []string{"running", "ietf-datastores:candidate"},
+		Implemented: []string{"dev"},
+	})
+	set := l.ModuleSets[0]
+	if got := set.Name; got != "set" {
+		t.Errorf("got module set %s, want set", got)
+	}
+	if len(set.Modules) != 1 || set.Modules[0].Name != "dev" || len(set.ImportOnly) != 2 {
+		t.Errorf("got %d implemented and %d import-only modules, want only dev implemented", len(set.Modules), len(set.ImportOnly))
+	}
+	if diff := cmp.Diff([]*Datastore{
+		{Name: "ietf-datastores:running", Schema: "schema"},
+		{Name: "ietf-datastores:candidate", Schema: "schema"},
+	}, l.Datastores); diff != "" {
+		t.Errorf("datastores (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	l := testLibrary(t, dir, "dev")
+
+	j, err := l.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if !strings.HasPrefix(string(j), "{\n  \"ietf-yang-library:yang-library\": {\n    \"module-set\": [") {
+		t.Errorf("JSON does not start with the yang-library member:\n%s", j)
+	}
+	x, err := l.XML()
+	if err != nil {
+		t.Fatalf("XML: %v", err)
+	}
+	for _, s := range []string{
+		`<yang-library xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-library">`,
+		`<name xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">ds:running</name>`,
+		`<revision>2020-01-01</revision>`,
+	} {
+		if !strings.Contains(string(x), s) {
+			t.Errorf("XML does not contain %s:\n%s", s, x)
+		}
+	}
+
+	for _, tt := range []struct {
+		desc string
+		in   string
+	}{
+		{"json", string(j)},
+		{"xml", string(x)},
+		{"netconf reply", `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><data>` + string(x) + `</data></rpc-reply>`},
+	} {
+		got, err := Parse([]byte(tt.in))
+		if err != nil {
+			t.Errorf("%s: Parse: %v", tt.desc, err)
+			continue
+		}
+		if diff := cmp.Diff(l, got, cmpopts.IgnoreFields(Library{}, "XMLName")); diff != "" {
+			t.Errorf("%s: Parse (-want, +got):\n%s", tt.desc, diff)
+		}
+	}
+
+	for _, tt := range []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{
+		{"no member", `{"yang-library": {}}`, "no ietf-yang-library:yang-library member found"},
+		{"bad json", `{`, "unexpected end of JSON input"},
+		{"no element", `<data/>`, "no yang-library element found"},
+	} {
+		_, err := Parse([]byte(tt.in))
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("%s: Parse: %s", tt.desc, diff)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	want := testLibrary(t, dir, "dev")
+
+	ms := yang.NewModules()
+	ms.AddPath(dir)
+	if errs := want.Load(ms); errs != nil {
+		t.Fatalf("Load: %v", errs)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	if diff := cmp.Diff(want, New(ms, nil)); diff != "" {
+		t.Errorf("library of the loaded modules (-want, +got):\n%s", diff)
+	}
+	e, errs := ms.GetModule("sys")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	if e.Find("system/location") != nil {
+		t.Errorf("system/location is present without feature f2")
+	}
+
+	l := &Library{ModuleSets: []*ModuleSet{{
+		Name: "complete",
+		ImportOnly: []*ImportOnlyModule{
+			{Name: "types", Revision: "2019-01-01"},
+			{Name: "missing"},
+		},
+	}}}
+	ms = yang.NewModules()
+	ms.AddPath(dir)
+	errs = l.Load(ms)
+	if len(errs) != 2 {
+		t.Fatalf("Load got errors %v, want 2", errs)
+	}
+	if diff := errdiff.Substring(errs[0], `module types@2019-01-01: found revision "2020-01-01"`); diff != "" {
+		t.Errorf("Load: %s", diff)
+	}
+	if diff := errdiff.Substring(errs[1], "no such file: missing.yang"); diff != "" {
+		t.Errorf("Load: %s", diff)
+	}
+}
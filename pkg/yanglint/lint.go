@@ -0,0 +1,205 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yanglint checks YANG modules against style guidelines, such as
+// those of RFC 8407 and OpenConfig.  Each guideline is checked by a Rule.
+// The built-in rules are registered by this package, others may be added
+// with Register.  Problems are reported as yang.Diagnostics whose Code is
+// the name of the rule.
+package yanglint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// A Rule checks a module for one guideline.
+type Rule struct {
+	Name     string        // name of the rule, used as the diagnostic code
+	Doc      string        // one line description of the guideline
+	Severity yang.Severity // severity of the problems found, unless configured
+	Check    func(*Pass)   // reports the problems found in Pass.Module
+}
+
+// rules are the registered rules by name.
+var rules = map[string]*Rule{}
+
+// Register registers r.  It panics if a rule with the same name is already
+// registered.
+func Register(r *Rule) {
+	if rules[r.Name] != nil {
+		panic(fmt.Sprintf("yanglint: rule %s registered twice", r.Name))
+	}
+	rules[r.Name] = r
+}
+
+// Rules returns the registered rules sorted by name.
+func Rules() []*Rule {
+	rs := make([]*Rule, 0, len(rules))
+	for _, r := range rules {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Name < rs[j].Name })
+	return rs
+}
+
+// A Pass is a run of a Rule over one module or submodule.
+type Pass struct {
+	Module *yang.Module // the module or submodule checked
+	Entry  *yang.Entry  // the Entry tree of Module
+
+	rule     *Rule
+	severity yang.Severity
+	diags    yang.Diagnostics
+}
+
+// Reportf reports a problem with n, described by format and v.
+func (p *Pass) Reportf(n yang.Node, format string, v ...interface{}) {
+	d := &yang.Diagnostic{
+		Location: yang.NodeLocation(n),
+		Severity: p.severity,
+		Code:     yang.Code(p.rule.Name),
+		Message:  fmt.Sprintf(format, v...),
+	}
+	if n != nil && n.Statement() != nil {
+		d.Keyword = n.Statement().Keyword
+	}
+	p.diags = append(p.diags, d)
+}
+
+// Config selects the rules run by Lint and their severity.
+type Config struct {
+	// Rules are the names of the rules to run.  All rules are run when
+	// Rules is empty.
+	Rules []string
+
+	// Disable suppresses rules, given either as RULE, for all modules, or
+	// as RULE@MODULE, for one module and its submodules.
+	Disable []string
+
+	// Severity overrides the severity of rules by name.
+	Severity map[string]yang.Severity
+}
+
+// ParseSeverities parses a list of RULE=SEVERITY settings, as used on the
+// command line, where SEVERITY is error or warning.
+func ParseSeverities(settings ...string) (map[string]yang.Severity, error) {
+	m := map[string]yang.Severity{}
+	for _, s := range settings {
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid severity setting %q, want RULE=SEVERITY", s)
+		}
+		switch sev := yang.Severity(s[i+1:]); sev {
+		case yang.SeverityError, yang.SeverityWarning:
+			m[s[:i]] = sev
+		default:
+			return nil, fmt.Errorf("invalid severity %q for rule %s, want error or warning", sev, s[:i])
+		}
+	}
+	return m, nil
+}
+
+// check returns an error if a rule named in c is not registered.
+func (c *Config) check() error {
+	var names []string
+	names = append(names, c.Rules...)
+	for _, d := range c.Disable {
+		names = append(names, strings.SplitN(d, "@", 2)[0])
+	}
+	for name := range c.Severity {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if rules[name] == nil {
+			return fmt.Errorf("unknown lint rule %q", name)
+		}
+	}
+	return nil
+}
+
+// disabled returns true if c suppresses the rule named name for m.
+func (c *Config) disabled(name string, m *yang.Module) bool {
+	mod := m.Name
+	if m.Kind() == "submodule" && m.BelongsTo != nil {
+		mod = m.BelongsTo.Name
+	}
+	for _, d := range c.Disable {
+		if d == name || d == name+"@"+mod || d == name+"@"+m.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint runs the rules selected by c, all rules if c is nil, over mods,
+// which must have been processed.  It returns the problems found, sorted
+// by location.  An error is returned if c names a rule that is not
+// registered.
+func Lint(mods []*yang.Module, c *Config) (yang.Diagnostics, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	selected := Rules()
+	if len(c.Rules) > 0 {
+		selected = nil
+		for _, name := range c.Rules {
+			selected = append(selected, rules[name])
+		}
+	}
+
+	var diags yang.Diagnostics
+	seen := map[string]bool{}
+	for _, m := range mods {
+		e := yang.ToEntry(m)
+		for _, r := range selected {
+			if c.disabled(r.Name, m) {
+				continue
+			}
+			p := &Pass{Module: m, Entry: e, rule: r, severity: r.Severity}
+			if sev, ok := c.Severity[r.Name]; ok {
+				p.severity = sev
+			}
+			r.Check(p)
+			for _, d := range p.diags {
+				// The same node may be reached from several modules,
+				// such as through a grouping.
+				if key := string(d.Code) + d.Error(); !seen[key] {
+					seen[key] = true
+					diags = append(diags, d)
+				}
+			}
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		switch {
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Col != b.Col:
+			return a.Col < b.Col
+		default:
+			return a.Code < b.Code
+		}
+	})
+	return diags, nil
+}
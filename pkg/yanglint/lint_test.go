@@ -0,0 +1,161 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yanglint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+// lintModules parses and processes the modules in texts, named m0.yang,
+// m1.yang and so on, and lints the first one with c.
+func lintModules(t *testing.T, c *Config, texts ...string) (yang.Diagnostics, error) {
+	t.Helper()
+	ms := yang.NewModules()
+	for i, text := range texts {
+		if err := ms.Parse(text, "m"+string(rune('0'+i))+".yang"); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	var first *yang.Module
+	for _, mods := range []map[string]*yang.Module{ms.Modules, ms.SubModules} {
+		for _, m := range mods {
+			if yang.NodeLocation(m).File == "m0.yang" {
+				first = m
+			}
+		}
+	}
+	return Lint([]*yang.Module{first}, c)
+}
+
+// summary returns the location, severity and code of each diagnostic.
+func summary(ds yang.Diagnostics) []string {
+	var s []string
+	for _, d := range ds {
+		s = append(s, d.Location.String()+" "+string(d.Severity)+" "+string(d.Code))
+	}
+	return s
+}
+
+const lintModule = `module m {
+  namespace "urn:example:m";
+  prefix m;
+  description "m";
+  revision 2021-01-01 { description "r"; }
+  leaf Bad { type string; description "d"; }
+}`
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		desc    string
+		config  *Config
+		want    []string
+		wantErr string
+	}{{
+		desc: "all rules",
+		want: []string{"m0.yang:6:3 warning naming-convention"},
+	}, {
+		desc:   "selected rules",
+		config: &Config{Rules: []string{"missing-description"}},
+	}, {
+		desc:   "disabled",
+		config: &Config{Disable: []string{"naming-convention"}},
+	}, {
+		desc:   "disabled for the module",
+		config: &Config{Disable: []string{"naming-convention@m"}},
+	}, {
+		desc:   "disabled for another module",
+		config: &Config{Disable: []string{"naming-convention@n"}},
+		want:   []string{"m0.yang:6:3 warning naming-convention"},
+	}, {
+		desc:   "severity",
+		config: &Config{Severity: map[string]yang.Severity{"naming-convention": yang.SeverityError}},
+		want:   []string{"m0.yang:6:3 error naming-convention"},
+	}, {
+		desc:    "unknown rule",
+		config:  &Config{Disable: []string{"no-such-rule"}},
+		wantErr: `unknown lint rule "no-such-rule"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ds, err := lintModules(t, tt.config, lintModule)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Lint: %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, summary(ds)); diff != "" {
+				t.Errorf("Lint (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := &Rule{
+		Name:     "test-no-leaves",
+		Severity: yang.SeverityError,
+		Check: func(p *Pass) {
+			for _, e := range sortedDir(p.Entry) {
+				if e.IsLeaf() {
+					p.Reportf(e.Node, "leaf %s", e.Name)
+				}
+			}
+		},
+	}
+	Register(r)
+	defer delete(rules, r.Name)
+
+	ds, err := lintModules(t, &Config{Rules: []string{r.Name}}, lintModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := yang.Diagnostics{{
+		Location: yang.Location{File: "m0.yang", Line: 6, Col: 3},
+		Severity: yang.SeverityError,
+		Code:     "test-no-leaves",
+		Keyword:  "leaf",
+		Message:  "leaf Bad",
+	}}
+	if diff := cmp.Diff(want, ds, cmp.AllowUnexported(yang.Diagnostic{})); diff != "" {
+		t.Errorf("Lint (-want, +got):\n%s", diff)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a rule twice did not panic")
+		}
+	}()
+	Register(r)
+}
+
+func TestParseSeverities(t *testing.T) {
+	got, err := ParseSeverities("a=error", "b=warning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]yang.Severity{"a": yang.SeverityError, "b": yang.SeverityWarning}, got); diff != "" {
+		t.Errorf("ParseSeverities (-want, +got):\n%s", diff)
+	}
+	for _, in := range []string{"a", "a=fatal"} {
+		if _, err := ParseSeverities(in); err == nil {
+			t.Errorf("ParseSeverities(%q) succeeded", in)
+		}
+	}
+}
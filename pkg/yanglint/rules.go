@@ -0,0 +1,336 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yanglint
+
+// This file has the built-in rules.  Rules over statements check the
+// module as written; rules over the Entry tree check it after groupings
+// are expanded and augments applied.
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+func init() {
+	for _, r := range []*Rule{{
+		Name:     "missing-description",
+		Doc:      "modules, definitions and data nodes have a description",
+		Severity: yang.SeverityWarning,
+		Check:    checkDescriptions,
+	}, {
+		Name:     "missing-revision",
+		Doc:      "modules and submodules have a revision statement",
+		Severity: yang.SeverityWarning,
+		Check:    checkRevision,
+	}, {
+		Name:     "naming-convention",
+		Doc:      "identifiers are lower case words separated by hyphens",
+		Severity: yang.SeverityWarning,
+		Check:    checkNames,
+	}, {
+		Name:     "unused-import",
+		Doc:      "the prefix of every import is used",
+		Severity: yang.SeverityWarning,
+		Check:    checkImports,
+	}, {
+		Name:     "unused-grouping",
+		Doc:      "nested groupings are used",
+		Severity: yang.SeverityWarning,
+		Check:    func(p *Pass) { checkUnused(p, "grouping", "uses") },
+	}, {
+		Name:     "unused-typedef",
+		Doc:      "nested typedefs are used",
+		Severity: yang.SeverityWarning,
+		Check:    func(p *Pass) { checkUnused(p, "typedef", "type") },
+	}, {
+		Name:     "prefix-mismatch",
+		Doc:      "the prefix of a module is its name or an abbreviation of it",
+		Severity: yang.SeverityWarning,
+		Check:    checkPrefix,
+	}, {
+		Name:     "namespace-format",
+		Doc:      "namespaces are URNs or HTTP URLs, IETF modules use urn:ietf:params:xml:ns:yang:NAME",
+		Severity: yang.SeverityError,
+		Check:    checkNamespace,
+	}, {
+		Name:     "top-level-mandatory",
+		Doc:      "top-level configuration nodes are not mandatory",
+		Severity: yang.SeverityError,
+		Check:    checkTopLevelMandatory,
+	}, {
+		Name:     "config-false-list-keys",
+		Doc:      "state lists have keys",
+		Severity: yang.SeverityWarning,
+		Check:    checkStateListKeys,
+	}} {
+		Register(r)
+	}
+}
+
+// walk calls f for s and every statement below it, with its parent.
+func walk(s, parent *yang.Statement, f func(s, parent *yang.Statement)) {
+	f(s, parent)
+	for _, ss := range s.SubStatements() {
+		walk(ss, s, f)
+	}
+}
+
+// hasSubstatement returns true if s has a substatement with keyword.
+func hasSubstatement(s *yang.Statement, keyword string) bool {
+	for _, ss := range s.SubStatements() {
+		if ss.Keyword == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// described are the keywords of the statements that need a description.
+var described = map[string]bool{
+	"module": true, "submodule": true, "container": true, "list": true,
+	"leaf": true, "leaf-list": true, "choice": true, "anydata": true,
+	"anyxml": true, "grouping": true, "typedef": true, "identity": true,
+	"feature": true, "extension": true, "rpc": true, "action": true,
+	"notification": true, "augment": true, "deviation": true,
+}
+
+func checkDescriptions(p *Pass) {
+	walk(p.Module.Source, nil, func(s, _ *yang.Statement) {
+		if described[s.Keyword] && !hasSubstatement(s, "description") {
+			p.Reportf(s, "%s %s has no description", s.Keyword, s.Argument)
+		}
+	})
+}
+
+func checkRevision(p *Pass) {
+	if !hasSubstatement(p.Module.Source, "revision") {
+		p.Reportf(p.Module, "%s %s has no revision statement", p.Module.Kind(), p.Module.Name)
+	}
+}
+
+// named are the keywords of the statements whose argument is an
+// identifier that follows the naming convention.  Identities and enums are
+// not included as OpenConfig uses upper case for them.
+var named = map[string]bool{
+	"module": true, "submodule": true, "container": true, "list": true,
+	"leaf": true, "leaf-list": true, "choice": true, "case": true,
+	"anydata": true, "anyxml": true, "grouping": true, "typedef": true,
+	"feature": true, "extension": true, "rpc": true, "action": true,
+	"notification": true,
+}
+
+func checkNames(p *Pass) {
+	walk(p.Module.Source, nil, func(s, _ *yang.Statement) {
+		if !named[s.Keyword] {
+			return
+		}
+		if want := yang.CamelCaseToDash(s.Argument); want != s.Argument {
+			p.Reportf(s, "%s %s is not lower case with hyphens, use %s", s.Keyword, s.Argument, want)
+		}
+	})
+}
+
+// usesPrefix returns true if any statement below, but not including, s uses
+// prefix in its keyword or argument.
+func usesPrefix(s *yang.Statement, prefix string) bool {
+	re := regexp.MustCompile(`(^|[^\w.-])` + regexp.QuoteMeta(prefix) + `:`)
+	used := false
+	for _, ss := range s.SubStatements() {
+		walk(ss, s, func(s, _ *yang.Statement) {
+			if s.Keyword == "import" {
+				return
+			}
+			if strings.HasPrefix(s.Keyword, prefix+":") || re.MatchString(s.Argument) {
+				used = true
+			}
+		})
+	}
+	return used
+}
+
+func checkImports(p *Pass) {
+	for _, i := range p.Module.Import {
+		if i.Prefix != nil && !usesPrefix(p.Module.Source, i.Prefix.Name) {
+			p.Reportf(i, "import %s with prefix %s is not used", i.Name, i.Prefix.Name)
+		}
+	}
+}
+
+// checkUnused reports the groupings or typedefs, given by keyword, that are
+// nested in other statements and are not referenced by a user statement in
+// their scope.  Top-level definitions may be used by other modules.
+func checkUnused(p *Pass, keyword, user string) {
+	prefix := p.Module.GetPrefix()
+	walk(p.Module.Source, nil, func(s, parent *yang.Statement) {
+		if s.Keyword != keyword || parent == nil || parent == p.Module.Source {
+			return
+		}
+		used := false
+		walk(parent, nil, func(u, _ *yang.Statement) {
+			if u.Keyword == user && (u.Argument == s.Argument || u.Argument == prefix+":"+s.Argument) {
+				used = true
+			}
+		})
+		if !used {
+			p.Reportf(s, "%s %s is not used", keyword, s.Argument)
+		}
+	})
+}
+
+// abbreviates returns true if prefix abbreviates name: it starts with the
+// first letter of name and its letters and digits appear in name in order.
+func abbreviates(prefix, name string) bool {
+	prefix = strings.Replace(prefix, "-", "", -1)
+	if prefix == "" || name == "" || prefix[0] != name[0] {
+		return false
+	}
+	i := 0
+	for j := 0; j < len(name) && i < len(prefix); j++ {
+		if name[j] == prefix[i] {
+			i++
+		}
+	}
+	return i == len(prefix)
+}
+
+func checkPrefix(p *Pass) {
+	m := p.Module
+	name := m.Name
+	var n yang.Node = m.Prefix
+	if m.Kind() == "submodule" && m.BelongsTo != nil {
+		name = m.BelongsTo.Name
+		n = m.BelongsTo.Prefix
+	}
+	if prefix := m.GetPrefix(); prefix != "" && !abbreviates(prefix, name) {
+		p.Reportf(n, "prefix %s does not match the module name %s", prefix, name)
+	}
+}
+
+func checkNamespace(p *Pass) {
+	m := p.Module
+	if m.Namespace == nil {
+		return
+	}
+	ns := m.Namespace.Name
+	if strings.HasPrefix(m.Name, "ietf-") {
+		if want := "urn:ietf:params:xml:ns:yang:" + m.Name; ns != want {
+			p.Reportf(m.Namespace, "namespace %s of an IETF module is not %s", ns, want)
+		}
+		return
+	}
+	u, err := url.Parse(ns)
+	switch {
+	case err != nil:
+		p.Reportf(m.Namespace, "namespace %s is not a URI: %v", ns, err)
+	case u.Scheme == "urn":
+		// urn:NID:NSS
+		if f := strings.SplitN(u.Opaque, ":", 2); len(f) != 2 || f[0] == "" || f[1] == "" {
+			p.Reportf(m.Namespace, "namespace %s is not a URN of the form urn:NID:NSS", ns)
+		}
+	case u.Scheme == "http" || u.Scheme == "https":
+		if u.Host == "" {
+			p.Reportf(m.Namespace, "namespace %s has no host", ns)
+		}
+	default:
+		p.Reportf(m.Namespace, "namespace %s is neither a URN nor an HTTP URL", ns)
+	}
+}
+
+// sortedDir returns the children of e sorted by name.
+func sortedDir(e *yang.Entry) []*yang.Entry {
+	names := make([]string, 0, len(e.Dir))
+	for name := range e.Dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	es := make([]*yang.Entry, len(names))
+	for i, name := range names {
+		es[i] = e.Dir[name]
+	}
+	return es
+}
+
+// mandatory returns true if e, a configuration node, must exist: a
+// mandatory leaf or choice, a list or leaf-list with min-elements, or a
+// non-presence container with a mandatory child.
+func mandatory(e *yang.Entry) bool {
+	switch {
+	case e.RPC != nil || e.Kind == yang.NotificationEntry || e.ReadOnly():
+		return false
+	case e.IsLeaf(), e.IsChoice():
+		return e.Mandatory.Value()
+	case e.IsList(), e.IsLeafList():
+		return e.ListAttr != nil && e.ListAttr.MinElements > 0
+	case e.IsContainer():
+		if c, ok := e.Node.(*yang.Container); ok && c.Presence != nil {
+			return false
+		}
+		for _, c := range sortedDir(e) {
+			if mandatory(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// defines returns true if e was defined in m or a module of the same
+// family, rather than added by another module's augment.
+func defines(m *yang.Module, e *yang.Entry) bool {
+	name := m.Name
+	if m.Kind() == "submodule" && m.BelongsTo != nil {
+		name = m.BelongsTo.Name
+	}
+	if e.Node == nil {
+		return false
+	}
+	r := yang.RootNode(e.Node)
+	if r == nil {
+		return false
+	}
+	if r.Kind() == "submodule" && r.BelongsTo != nil {
+		return r.BelongsTo.Name == name
+	}
+	return r.Name == name
+}
+
+func checkTopLevelMandatory(p *Pass) {
+	for _, e := range sortedDir(p.Entry) {
+		if defines(p.Module, e) && mandatory(e) {
+			p.Reportf(e.Node, "top-level node %s is mandatory", e.Name)
+		}
+	}
+}
+
+func checkStateListKeys(p *Pass) {
+	var check func(e *yang.Entry)
+	check = func(e *yang.Entry) {
+		if e.IsList() && e.ReadOnly() && e.Key == "" {
+			p.Reportf(e.Node, "config false list %s has no key", e.Name)
+		}
+		for _, c := range sortedDir(e) {
+			check(c)
+		}
+	}
+	for _, e := range sortedDir(p.Entry) {
+		if defines(p.Module, e) {
+			check(e)
+		}
+	}
+}
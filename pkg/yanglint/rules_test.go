@@ -0,0 +1,180 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yanglint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		desc string
+		rule string
+		in   []string
+		want []string // messages
+	}{{
+		desc: "missing descriptions",
+		rule: "missing-description",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  container c { description "c"; leaf l { type string; } }
+  typedef t { type string; }
+}`},
+		want: []string{
+			"module m has no description",
+			"leaf l has no description",
+			"typedef t has no description",
+		},
+	}, {
+		desc: "missing revision",
+		rule: "missing-revision",
+		in:   []string{`module m { namespace "urn:example:m"; prefix m; }`},
+		want: []string{"module m has no revision statement"},
+	}, {
+		desc: "revision",
+		rule: "missing-revision",
+		in:   []string{`module m { namespace "urn:example:m"; prefix m; revision 2021-01-01; }`},
+	}, {
+		desc: "naming convention",
+		rule: "naming-convention",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  container interfaceState { leaf admin_status { type string; } leaf oper-status { type string; } }
+  identity ETHERNET;
+}`},
+		want: []string{
+			"container interfaceState is not lower case with hyphens, use interface-state",
+			"leaf admin_status is not lower case with hyphens, use admin-status",
+		},
+	}, {
+		desc: "unused import",
+		rule: "unused-import",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  import a { prefix a; }
+  import b { prefix b; }
+  import c { prefix c; }
+  leaf l { type a:t; }
+  leaf k { type string; c:ext; }
+}`, `module a { namespace "urn:example:a"; prefix a; typedef t { type string; } }`,
+			`module b { namespace "urn:example:b"; prefix b; }`,
+			`module c { namespace "urn:example:c"; prefix c; extension ext; }`},
+		want: []string{"import b with prefix b is not used"},
+	}, {
+		desc: "unused grouping",
+		rule: "unused-grouping",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  grouping top { leaf t { type string; } }
+  container c {
+    grouping used { leaf u { type string; } }
+    grouping unused { leaf v { type string; } }
+    uses m:used;
+  }
+}`},
+		want: []string{"grouping unused is not used"},
+	}, {
+		desc: "unused typedef",
+		rule: "unused-typedef",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  container c {
+    typedef used { type string; }
+    typedef unused { type string; }
+    leaf l { type used; }
+  }
+}`},
+		want: []string{"typedef unused is not used"},
+	}, {
+		desc: "prefix abbreviation",
+		rule: "prefix-mismatch",
+		in:   []string{`module openconfig-interfaces { namespace "urn:example:oc-if"; prefix oc-if; }`},
+	}, {
+		desc: "prefix mismatch",
+		rule: "prefix-mismatch",
+		in:   []string{`module interfaces { namespace "urn:example:if"; prefix x; }`},
+		want: []string{"prefix x does not match the module name interfaces"},
+	}, {
+		desc: "http namespace",
+		rule: "namespace-format",
+		in:   []string{`module m { namespace "http://openconfig.net/yang/m"; prefix m; }`},
+	}, {
+		desc: "bad urn",
+		rule: "namespace-format",
+		in:   []string{`module m { namespace "urn:m"; prefix m; }`},
+		want: []string{"namespace urn:m is not a URN of the form urn:NID:NSS"},
+	}, {
+		desc: "ietf namespace",
+		rule: "namespace-format",
+		in:   []string{`module ietf-m { namespace "urn:ietf:params:xml:ns:yang:m"; prefix m; }`},
+		want: []string{"namespace urn:ietf:params:xml:ns:yang:m of an IETF module is not urn:ietf:params:xml:ns:yang:ietf-m"},
+	}, {
+		desc: "top-level mandatory",
+		rule: "top-level-mandatory",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  leaf a { type string; mandatory true; }
+  container b { leaf c { type string; mandatory true; } }
+  container p { presence "p"; leaf c { type string; mandatory true; } }
+  list l { key k; min-elements 1; leaf k { type string; } }
+  leaf s { type string; mandatory true; config false; }
+}`},
+		want: []string{
+			"top-level node a is mandatory",
+			"top-level node b is mandatory",
+			"top-level node l is mandatory",
+		},
+	}, {
+		desc: "state lists without keys",
+		rule: "config-false-list-keys",
+		in: []string{`module m {
+  namespace "urn:example:m";
+  prefix m;
+  container c {
+    config false;
+    list keyed { key k; leaf k { type string; } }
+    list unkeyed { leaf k { type string; } }
+  }
+  list config { key k; leaf k { type string; } }
+}`},
+		want: []string{"config false list unkeyed has no key"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ds, err := lintModules(t, &Config{Rules: []string{tt.rule}}, tt.in...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range ds {
+				if string(d.Code) != tt.rule {
+					t.Errorf("got code %s, want %s", d.Code, tt.rule)
+				}
+				got = append(got, d.Message)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s (-want, +got):\n%s", tt.rule, diff)
+			}
+		})
+	}
+}
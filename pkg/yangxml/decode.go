@@ -0,0 +1,237 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// An element is a parsed XML element.
+type element struct {
+	name     xml.Name          // Space is the namespace, not the prefix
+	scope    map[string]string // namespace prefixes in scope, "" is the default
+	text     string
+	children []*element
+}
+
+// parse parses the XML document b and returns its top level elements.
+func parse(b []byte) ([]*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	root := &element{scope: map[string]string{}}
+	stack := []*element{root}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			el := &element{name: tok.Name, scope: map[string]string{}}
+			for p, ns := range top.scope {
+				el.scope[p] = ns
+			}
+			for _, a := range tok.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					el.scope[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					el.scope[""] = a.Value
+				}
+			}
+			top.children = append(top.children, el)
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			top.text += string(tok)
+		}
+	}
+	return root.children, nil
+}
+
+// Unmarshal decodes the instances of the node e found in the XML document b
+// into JSON data.  If e is a module then the result is a single object
+// holding the top level nodes found.  Otherwise e must be a container or list
+// and there is one object for each instance of e, holding its contents.
+//
+// The instance data may be enclosed in the NETCONF rpc-reply and data
+// elements, or in the config element of an edit-config request.
+func Unmarshal(e *yang.Entry, b []byte, opts *Options) ([]map[string]interface{}, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	els, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	els = dataRoot(els)
+	path := schemaPath(e)
+	if len(path) == 0 {
+		m, err := opts.members(e, &element{children: els}, "", "")
+		if err != nil {
+			return nil, err
+		}
+		return []map[string]interface{}{m}, nil
+	}
+
+	var out []map[string]interface{}
+	var p, parentMod string
+	for i, a := range path {
+		mod := instance.Module(a)
+		p += "/" + opts.jsonName(a, parentMod, mod)
+		parentMod = mod
+		var next []*element
+		for _, el := range els {
+			if !matches(a, el) {
+				continue
+			}
+			if i < len(path)-1 {
+				next = append(next, el.children...)
+				continue
+			}
+			m, err := opts.members(a, el, mod, p)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, m)
+		}
+		els = next
+	}
+	return out, nil
+}
+
+// dataRoot returns the elements in els with any enclosing NETCONF protocol
+// elements removed.
+func dataRoot(els []*element) []*element {
+	var out []*element
+	for _, el := range els {
+		if el.name.Space == netconfNS || (el.name.Space == "" && envelopes[el.name.Local]) {
+			out = append(out, dataRoot(el.children)...)
+			continue
+		}
+		out = append(out, el)
+	}
+	return out
+}
+
+// matches reports whether el is an instance of the data node e.  An element
+// without a namespace matches by name alone.
+func matches(e *yang.Entry, el *element) bool {
+	return el.name.Local == e.Name && (el.name.Space == "" || el.name.Space == namespace(e))
+}
+
+// members returns the JSON object holding the child elements of el, an
+// instance of e in module mod.
+func (o *Options) members(e *yang.Entry, el *element, mod, path string) (map[string]interface{}, error) {
+	children := dataChildren(e)
+	out := map[string]interface{}{}
+	for _, c := range el.children {
+		var s *yang.Entry
+		for _, sc := range children {
+			if matches(sc, c) {
+				s = sc
+				break
+			}
+		}
+		if s == nil {
+			return nil, errorf(path+"/"+c.name.Local, "unknown element")
+		}
+		smod := instance.Module(s)
+		name := o.jsonName(s, mod, smod)
+		p := path + "/" + name
+		switch {
+		case s.IsLeafList():
+			v, err := o.leaf(s, c, p)
+			if err != nil {
+				return nil, err
+			}
+			items, _ := out[name].([]interface{})
+			out[name] = append(items, v)
+			continue
+		case s.IsList():
+			m, err := o.members(s, c, smod, p)
+			if err != nil {
+				return nil, err
+			}
+			items, _ := out[name].([]interface{})
+			out[name] = append(items, m)
+			continue
+		}
+		if _, ok := out[name]; ok {
+			return nil, errorf(p, "duplicate element")
+		}
+		switch {
+		case s.IsLeaf():
+			v, err := o.leaf(s, c, p)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = v
+		case s.Kind == yang.AnyDataEntry || s.Kind == yang.AnyXMLEntry:
+			out[name] = anyValue(c)
+		default:
+			m, err := o.members(s, c, smod, p)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = m
+		}
+	}
+	return out, nil
+}
+
+// leaf returns the JSON value of el, an instance of the leaf or leaf-list s.
+func (o *Options) leaf(s *yang.Entry, el *element, path string) (interface{}, error) {
+	if len(el.children) > 0 {
+		return nil, errorf(path, "%s has child elements", s.Node.Kind())
+	}
+	t, target := instance.LeafType(s)
+	v, err := o.value(target, t, el.text, el.scope)
+	if err != nil {
+		return nil, errorf(path, "%v", err)
+	}
+	return v, nil
+}
+
+// anyValue returns the JSON value of el, an instance of an anydata or anyxml
+// node.  Child elements become members of an object, repeated ones an array.
+func anyValue(el *element) interface{} {
+	if len(el.children) == 0 {
+		return strings.TrimSpace(el.text)
+	}
+	out := map[string]interface{}{}
+	for _, c := range el.children {
+		v := anyValue(c)
+		switch prev := out[c.name.Local].(type) {
+		case nil:
+			out[c.name.Local] = v
+		case []interface{}:
+			out[c.name.Local] = append(prev, v)
+		default:
+			out[c.name.Local] = []interface{}{prev, v}
+		}
+	}
+	return out
+}
@@ -0,0 +1,270 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// Marshal returns the XML encoding of data, the JSON data of the node e.  If
+// e is a module then data holds its top level nodes, which are encoded as a
+// sequence of elements.  Otherwise e must be a container or list, data is
+// its contents, or the contents of a single list entry, and the element of e
+// is enclosed in the elements of its ancestors.
+func Marshal(e *yang.Entry, data map[string]interface{}, opts *Options) ([]byte, error) {
+	return MarshalIndent(e, data, opts, "", "")
+}
+
+// MarshalIndent is like Marshal but starts each element on a new line that
+// begins with prefix followed by one copy of indent for each level of
+// nesting.
+func MarshalIndent(e *yang.Entry, data map[string]interface{}, opts *Options, prefix, indent string) ([]byte, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	enc := &encoder{opts: opts, prefix: prefix, indent: indent}
+	path := schemaPath(e)
+	if len(path) == 0 {
+		if err := enc.members(e, data, "", "", "", 0); err != nil {
+			return nil, err
+		}
+		return enc.b.Bytes(), nil
+	}
+	if !e.IsContainer() && !e.IsList() {
+		return nil, fmt.Errorf("%s: %s is not a container or list", yang.Source(e.Node), e.Name)
+	}
+
+	var parentNS, parentMod, p string
+	for i, a := range path[:len(path)-1] {
+		if a.IsList() {
+			return nil, fmt.Errorf("%s: cannot encode %s below list %s", yang.Source(e.Node), e.Name, a.Name)
+		}
+		enc.start(a.Name, namespace(a), parentNS, nil, i, false)
+		mod := instance.Module(a)
+		p += "/" + opts.jsonName(a, parentMod, mod)
+		parentNS, parentMod = namespace(a), mod
+	}
+	if err := enc.container(e, data, parentNS, parentMod, p, len(path)-1); err != nil {
+		return nil, err
+	}
+	for i := len(path) - 2; i >= 0; i-- {
+		enc.end(path[i].Name, i)
+	}
+	return enc.b.Bytes(), nil
+}
+
+type encoder struct {
+	opts           *Options
+	prefix, indent string
+	b              bytes.Buffer
+}
+
+// line starts a new line at depth when indenting.
+func (enc *encoder) line(depth int) {
+	if enc.prefix == "" && enc.indent == "" {
+		return
+	}
+	if enc.b.Len() > 0 {
+		enc.b.WriteByte('\n')
+	}
+	enc.b.WriteString(enc.prefix)
+	enc.b.WriteString(strings.Repeat(enc.indent, depth))
+}
+
+// start writes the start tag of the element name in namespace ns, whose
+// parent is in namespace parentNS, with the additional namespace declarations
+// in decls.  If empty is set the element is closed as well.
+func (enc *encoder) start(name, ns, parentNS string, decls map[string]string, depth int, empty bool) {
+	enc.line(depth)
+	enc.b.WriteString("<" + name)
+	if ns != parentNS {
+		enc.attr("xmlns", ns)
+	}
+	for _, p := range sortedPrefixes(decls) {
+		enc.attr("xmlns:"+p, decls[p])
+	}
+	if empty {
+		enc.b.WriteString("/>")
+		return
+	}
+	enc.b.WriteString(">")
+}
+
+func (enc *encoder) attr(name, value string) {
+	enc.b.WriteString(" " + name + `="`)
+	xml.EscapeText(&enc.b, []byte(value))
+	enc.b.WriteString(`"`)
+}
+
+// end writes the end tag of the element name.  The end tag of an element
+// holding a value is written by leaf.
+func (enc *encoder) end(name string, depth int) {
+	enc.line(depth)
+	enc.b.WriteString("</" + name + ">")
+}
+
+// container writes the element of the container or list entry e holding the
+// members in data.
+func (enc *encoder) container(e *yang.Entry, data map[string]interface{}, parentNS, parentMod, path string, depth int) error {
+	mod := instance.Module(e)
+	path += "/" + enc.opts.jsonName(e, parentMod, mod)
+	ns := namespace(e)
+	if len(data) == 0 {
+		enc.start(e.Name, ns, parentNS, nil, depth, true)
+		return nil
+	}
+	enc.start(e.Name, ns, parentNS, nil, depth, false)
+	if err := enc.members(e, data, ns, mod, path, depth+1); err != nil {
+		return err
+	}
+	enc.end(e.Name, depth)
+	return nil
+}
+
+// members writes the children of e found in data, which are in namespace ns
+// and module mod.  List keys are written first, followed by the other
+// children in schema order.
+func (enc *encoder) members(e *yang.Entry, data map[string]interface{}, ns, mod, path string, depth int) error {
+	children := map[*yang.Entry]interface{}{}
+	for _, k := range instance.SortedKeys(data) {
+		c := enc.opts.member(e, k)
+		if c == nil {
+			return errorf(path+"/"+k, "unknown element")
+		}
+		if _, ok := children[c]; ok {
+			return errorf(path+"/"+k, "duplicate member")
+		}
+		children[c] = data[k]
+	}
+	var keys []*yang.Entry
+	for _, k := range strings.Fields(e.Key) {
+		c := e.Dir[k]
+		if _, ok := children[c]; !ok {
+			if e.IsList() && e.Parent != nil {
+				return errorf(path, "list entry is missing key %s", k)
+			}
+			continue
+		}
+		keys = append(keys, c)
+	}
+	for _, c := range keys {
+		if err := enc.node(c, children[c], ns, mod, path, depth); err != nil {
+			return err
+		}
+		delete(children, c)
+	}
+	for _, c := range schemaChildren(e) {
+		if v, ok := children[c]; ok {
+			if err := enc.node(c, v, ns, mod, path, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// node writes the elements of the data node c with the JSON value x.
+func (enc *encoder) node(c *yang.Entry, x interface{}, parentNS, parentMod, path string, depth int) error {
+	p := path + "/" + enc.opts.jsonName(c, parentMod, instance.Module(c))
+	switch {
+	case c.IsLeafList():
+		items, ok := x.([]interface{})
+		if !ok {
+			return errorf(p, "leaf-list value must be an array")
+		}
+		for _, item := range items {
+			if err := enc.leaf(c, item, parentNS, p, depth); err != nil {
+				return err
+			}
+		}
+	case c.IsLeaf():
+		return enc.leaf(c, x, parentNS, p, depth)
+	case c.IsList():
+		items, ok := x.([]interface{})
+		if !ok {
+			return errorf(p, "list value must be an array")
+		}
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return errorf(p, "list entry must be an object")
+			}
+			if err := enc.container(c, m, parentNS, parentMod, path, depth); err != nil {
+				return err
+			}
+		}
+	case c.Kind == yang.AnyDataEntry || c.Kind == yang.AnyXMLEntry:
+		enc.any(c.Name, namespace(c), parentNS, x, depth)
+	default:
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return errorf(p, "%s value must be an object", c.Node.Kind())
+		}
+		return enc.container(c, m, parentNS, parentMod, path, depth)
+	}
+	return nil
+}
+
+// leaf writes the element of the leaf or leaf-list c with the JSON value x.
+func (enc *encoder) leaf(c *yang.Entry, x interface{}, parentNS, path string, depth int) error {
+	t, target := instance.LeafType(c)
+	s, decls, err := enc.opts.text(target, t, x)
+	if err != nil {
+		return errorf(path, "%v", err)
+	}
+	if s == "" {
+		enc.start(c.Name, namespace(c), parentNS, decls, depth, true)
+		return nil
+	}
+	enc.start(c.Name, namespace(c), parentNS, decls, depth, false)
+	xml.EscapeText(&enc.b, []byte(s))
+	enc.b.WriteString("</" + c.Name + ">")
+	return nil
+}
+
+// any writes the element name of an anydata or anyxml node with the JSON
+// value x.  Objects become child elements and arrays repeated elements.
+func (enc *encoder) any(name, ns, parentNS string, x interface{}, depth int) {
+	switch x := x.(type) {
+	case map[string]interface{}:
+		if len(x) == 0 {
+			enc.start(name, ns, parentNS, nil, depth, true)
+			return
+		}
+		enc.start(name, ns, parentNS, nil, depth, false)
+		for _, k := range instance.SortedKeys(x) {
+			if items, ok := x[k].([]interface{}); ok {
+				for _, item := range items {
+					enc.any(k, ns, ns, item, depth+1)
+				}
+				continue
+			}
+			enc.any(k, ns, ns, x[k], depth+1)
+		}
+		enc.end(name, depth)
+	case nil:
+		enc.start(name, ns, parentNS, nil, depth, true)
+	default:
+		enc.start(name, ns, parentNS, nil, depth, false)
+		xml.EscapeText(&enc.b, []byte(fmt.Sprint(x)))
+		enc.b.WriteString("</" + name + ">")
+	}
+}
@@ -0,0 +1,426 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangxml
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// numberString returns the JSON number or numeric string x as a string.
+func numberString(x interface{}) (string, bool) {
+	switch x := x.(type) {
+	case json.Number:
+		return x.String(), true
+	case float64:
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			return "", false
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case string:
+		return x, true
+	}
+	return "", false
+}
+
+// numberText returns the JSON value x of a leaf of the numeric kind k as a
+// string.  RFC 7951 encodes 64-bit integers and decimal64 values as strings
+// and the other integers as numbers, the CRDs accept either.
+func (o *Options) numberText(k yang.TypeKind, x interface{}) (string, error) {
+	want := "number"
+	if k == yang.Yint64 || k == yang.Yuint64 || k == yang.Ydecimal64 {
+		want = "string"
+	}
+	if _, isString := x.(string); !o.CRD && isString != (want == "string") {
+		return "", fmt.Errorf("%s value must be a %s, got %T", k, want, x)
+	}
+	s, ok := numberString(x)
+	if !ok {
+		return "", fmt.Errorf("%s value must be a %s, got %T", k, want, x)
+	}
+	return s, nil
+}
+
+// text returns the XML text of the JSON value x of a leaf of type t, which is
+// the type of the leaf or leaf-list e, and the namespace declarations, keyed
+// by prefix, that the text requires.
+func (o *Options) text(e *yang.Entry, t *yang.YangType, x interface{}) (string, map[string]string, error) {
+	if t == nil {
+		return fmt.Sprint(x), nil, nil
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64, yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64, yang.Ydecimal64:
+		s, err := o.numberText(t.Kind, x)
+		if err != nil {
+			return "", nil, err
+		}
+		s, err = t.FormatCanonical(s)
+		return s, nil, err
+	case yang.Ybool:
+		switch x {
+		case true, "true":
+			return "true", nil, nil
+		case false, "false":
+			return "false", nil, nil
+		}
+		return "", nil, fmt.Errorf("boolean value must be true or false, got %v", x)
+	case yang.Yempty:
+		switch x := x.(type) {
+		case nil, string:
+			if x == nil || x == "" {
+				return "", nil, nil
+			}
+		case bool:
+			if x {
+				return "", nil, nil
+			}
+		case []interface{}:
+			if len(x) == 1 && x[0] == nil {
+				return "", nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("invalid empty value %v", x)
+	case yang.Yenum:
+		s, ok := x.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("enumeration value must be a string, got %T", x)
+		}
+		if t.Enum.IsDefined(s) {
+			return s, nil, nil
+		}
+		if o.CRD {
+			for _, n := range t.Enum.Names() {
+				if casefold(n) == casefold(s) || o.enumName(n) == s {
+					return n, nil, nil
+				}
+			}
+		}
+		return "", nil, fmt.Errorf("%q is not a valid enum", s)
+	case yang.Ybits:
+		return o.bitsText(t, x)
+	case yang.Ystring, yang.Ybinary:
+		s, ok := x.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("%s value must be a string, got %T", t.Kind, x)
+		}
+		if _, err := t.ParseValue(s); err != nil {
+			return "", nil, err
+		}
+		return s, nil, nil
+	case yang.Yidentityref:
+		s, ok := x.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("identityref value must be a string, got %T", x)
+		}
+		m, name := instance.SplitName(s)
+		if m == "" {
+			m = instance.Module(e)
+		}
+		id, err := findIdentity(e, t, m+":"+name)
+		if err != nil {
+			return "", nil, fmt.Errorf("%q %v", s, err)
+		}
+		im := e.Modules().Modules[m]
+		return im.GetPrefix() + ":" + id.Name, map[string]string{im.GetPrefix(): im.Namespace.Name}, nil
+	case yang.YinstanceIdentifier:
+		s, ok := x.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("instance-identifier value must be a string, got %T", x)
+		}
+		ns := map[string]string{}
+		text, err := rewritePrefixes(s, func(mod string) (string, error) {
+			m := e.Modules().Modules[mod]
+			if m == nil {
+				return "", fmt.Errorf("unknown module %q", mod)
+			}
+			ns[m.GetPrefix()] = m.Namespace.Name
+			return m.GetPrefix(), nil
+		})
+		return text, ns, err
+	case yang.Yunion:
+		var errs []string
+		for _, m := range t.Type {
+			s, ns, err := o.text(e, m, x)
+			if err == nil {
+				return s, ns, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return "", nil, fmt.Errorf("%v does not match any member of the union: %s", x, strings.Join(errs, "; "))
+	case yang.Yleafref:
+		// The leafref could not be resolved, use the value as is.
+		return fmt.Sprint(x), nil, nil
+	}
+	s, ok := x.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("%s value must be a string, got %T", t.Kind, x)
+	}
+	return s, nil, nil
+}
+
+// bitsText returns the XML text of the bits value x, which is either a space
+// separated string or list of bit names, or an integer bit mask.
+func (o *Options) bitsText(t *yang.YangType, x interface{}) (string, map[string]string, error) {
+	var names []string
+	switch x := x.(type) {
+	case string:
+		names = strings.Fields(x)
+	case []interface{}:
+		for _, b := range x {
+			s, ok := b.(string)
+			if !ok {
+				return "", nil, fmt.Errorf("bit name must be a string, got %T", b)
+			}
+			names = append(names, s)
+		}
+	case json.Number, float64:
+		s, _ := numberString(x)
+		mask, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid bit mask %s", s)
+		}
+		for _, pos := range t.Bit.Values() {
+			if pos < 64 && mask&(1<<uint(pos)) != 0 {
+				names = append(names, t.Bit.Name(pos))
+				mask &^= 1 << uint(pos)
+			}
+		}
+		if mask != 0 {
+			return "", nil, fmt.Errorf("bit mask %s has undefined bits set", s)
+		}
+	default:
+		return "", nil, fmt.Errorf("bits value must be a string, got %T", x)
+	}
+	for i, n := range names {
+		if t.Bit.IsDefined(n) {
+			continue
+		}
+		found := false
+		for _, b := range t.Bit.Names() {
+			if o.CRD && casefold(b) == casefold(n) {
+				names[i], found = b, true
+				break
+			}
+		}
+		if !found {
+			return "", nil, fmt.Errorf("%q is not a valid bit", n)
+		}
+	}
+	return strings.Join(names, " "), nil, nil
+}
+
+// value returns the JSON value of the XML text s of a leaf of type t, which
+// is the type of the leaf or leaf-list e.  Scope maps the namespace prefixes
+// in scope to their namespace.
+func (o *Options) value(e *yang.Entry, t *yang.YangType, s string, scope map[string]string) (interface{}, error) {
+	if t == nil {
+		return s, nil
+	}
+	if t.Kind != yang.Ystring && t.Kind != yang.Yunion {
+		s = strings.TrimSpace(s)
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		s, err := t.FormatCanonical(s)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(s), nil
+	case yang.Yint64, yang.Yuint64, yang.Ydecimal64:
+		s, err := t.FormatCanonical(s)
+		if err != nil {
+			return nil, err
+		}
+		if o.CRD {
+			return json.Number(s), nil
+		}
+		return s, nil
+	case yang.Ystring:
+		if _, err := t.ParseValue(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yang.Ybool:
+		switch s {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean value %q", s)
+	case yang.Yempty:
+		if s != "" {
+			return nil, fmt.Errorf("empty leaf has value %q", s)
+		}
+		if o.CRD {
+			return "", nil
+		}
+		return []interface{}{nil}, nil
+	case yang.Yenum:
+		if !t.Enum.IsDefined(s) {
+			return nil, fmt.Errorf("%q is not a valid enum", s)
+		}
+		return o.enumName(s), nil
+	case yang.Ybits:
+		names := strings.Fields(s)
+		var mask uint64
+		for _, n := range names {
+			if !t.Bit.IsDefined(n) {
+				return nil, fmt.Errorf("%q is not a valid bit", n)
+			}
+			if pos := t.Bit.Value(n); pos < 64 {
+				mask |= 1 << uint(pos)
+			} else if o.CRD {
+				return nil, fmt.Errorf("bit %q does not fit in a bit mask", n)
+			}
+		}
+		if o.CRD {
+			return json.Number(strconv.FormatUint(mask, 10)), nil
+		}
+		return strings.Join(names, " "), nil
+	case yang.Ybinary:
+		if _, err := t.ParseValue(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yang.Yidentityref:
+		p, name := instance.SplitName(s)
+		ns, ok := scope[p]
+		if !ok {
+			return nil, fmt.Errorf("identityref %q has an undeclared prefix", s)
+		}
+		m, err := e.Modules().FindModuleByNamespace(ns)
+		if err != nil {
+			return nil, fmt.Errorf("identityref %q: %v", s, err)
+		}
+		id, err := findIdentity(e, t, m.Name+":"+name)
+		if err != nil {
+			return nil, fmt.Errorf("%q %v", s, err)
+		}
+		return id.QualifiedName(), nil
+	case yang.YinstanceIdentifier:
+		return rewritePrefixes(s, func(p string) (string, error) {
+			ns, ok := scope[p]
+			if !ok {
+				return "", fmt.Errorf("undeclared prefix %q", p)
+			}
+			m, err := e.Modules().FindModuleByNamespace(ns)
+			if err != nil {
+				return "", err
+			}
+			return m.Name, nil
+		})
+	case yang.Yunion:
+		var errs []string
+		for _, m := range t.Type {
+			v, err := o.value(e, m, s, scope)
+			if err == nil {
+				if o.CRD {
+					// The CRDs describe unions as strings.
+					return strings.TrimSpace(s), nil
+				}
+				return v, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return nil, fmt.Errorf("%q does not match any member of the union: %s", s, strings.Join(errs, "; "))
+	}
+	return s, nil
+}
+
+// findIdentity returns the identity with the module-qualified name name if
+// it is derived from all the bases of the identityref t of the leaf e.
+func findIdentity(e *yang.Entry, t *yang.YangType, name string) (*yang.Identity, error) {
+	if t.IdentityBase == nil {
+		return nil, fmt.Errorf("identityref has no base")
+	}
+	if ms := e.Modules(); ms != nil {
+		if id := ms.FindIdentity(name); id != nil && t.AllowsIdentity(id) {
+			return id, nil
+		}
+	}
+	return nil, fmt.Errorf("is not derived from identity %s", t.IdentityBase.Name)
+}
+
+// rewritePrefixes returns the instance-identifier s with the qualifier of
+// each node name, and of each name in a predicate, replaced by the result of
+// calling f with the qualifier.  Quoted strings are left as is.
+func rewritePrefixes(s string, f func(string) (string, error)) (string, error) {
+	var b strings.Builder
+	var quote byte
+	start := -1 // start of the current name, or -1
+	flush := func(i int) error {
+		if start < 0 {
+			return nil
+		}
+		name := s[start:i]
+		start = -1
+		if i < len(s) && s[i] == ':' {
+			p, err := f(name)
+			if err != nil {
+				return err
+			}
+			name = p
+		}
+		b.WriteString(name)
+		return nil
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+		case c == '\'' || c == '"':
+			if err := flush(i); err != nil {
+				return "", err
+			}
+			quote = c
+			b.WriteByte(c)
+		case c == '_' || c == '-' || c == '.' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9':
+			if start < 0 {
+				start = i
+			}
+		default:
+			if err := flush(i); err != nil {
+				return "", err
+			}
+			b.WriteByte(c)
+		}
+	}
+	if err := flush(len(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// sortedPrefixes returns the keys of m in order.
+func sortedPrefixes(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yangxml converts YANG instance data between the XML encoding of RFC
+// 7950 section 7, as carried by NETCONF, and JSON, using the yang.Entry tree
+// of the module that defines the data.
+//
+// The JSON side is a tree as decoded by encoding/json.  By default it follows
+// RFC 7951: member names are qualified with their module name at the top
+// level and wherever an augment switches module, and leaf values use the RFC
+// 7951 encoding.  With Options.CRD set it instead follows the shape of the
+// generated CRDs: member names are in lowerCamelCase and never qualified,
+// enum values are in UpperCamelCase, numbers are JSON numbers, bits are an
+// integer bit mask, and empty leaves are empty strings.  Only CRD data may
+// spell member, enum and bit names in another case or with underscores in
+// place of dashes.
+//
+// When encoding, element namespaces are taken from Entry.Namespace and an
+// xmlns attribute is emitted wherever the namespace changes.  List keys are
+// written first, in the order of the key statement, followed by the other
+// children in schema order, the order of their definitions, with the nodes
+// added by augments last.  Choice and case nodes do not appear in either
+// encoding.
+package yangxml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/internal/instance"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+// netconfNS is the namespace of the NETCONF protocol elements, such as
+// rpc-reply and data, that may enclose the instance data.
+const netconfNS = "urn:ietf:params:xml:ns:netconf:base:1.0"
+
+// envelopes are the NETCONF elements that are looked through when they are
+// found without a namespace.
+var envelopes = map[string]bool{
+	"rpc":         true,
+	"rpc-reply":   true,
+	"data":        true,
+	"config":      true,
+	"edit-config": true,
+}
+
+// Options control the form of the JSON data.
+type Options struct {
+	// CRD selects the JSON form used by the generated CRDs.
+	CRD bool
+
+	// EnumAliases maps lower case enum names to the value used for them
+	// when CRD is set, e.g., "true" to "Enable".  Enums without an alias
+	// use the UpperCamelCase form of their name.
+	EnumAliases map[string]string
+}
+
+// An Error is an encoding or decoding error of the data at Path.
+type Error struct {
+	Path    string // data path of the node
+	Message string // description of the error
+}
+
+func (e *Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+func errorf(path, format string, args ...interface{}) error {
+	return &Error{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// jsonName returns the JSON member name of e, which is in module mod and
+// whose parent is in module parent.
+func (o *Options) jsonName(e *yang.Entry, parent, mod string) string {
+	switch {
+	case o.CRD:
+		return yang.CamelCase(e.Name, false)
+	case parent != mod:
+		return mod + ":" + e.Name
+	}
+	return e.Name
+}
+
+// enumName returns the JSON value of the enum name n.
+func (o *Options) enumName(n string) string {
+	if !o.CRD {
+		return n
+	}
+	if a := o.EnumAliases[strings.ToLower(n)]; a != "" {
+		return a
+	}
+	return yang.CamelCase(n, true)
+}
+
+// dataChildren returns the children of e that are data nodes, in name order,
+// looking through choice and case nodes.
+func dataChildren(e *yang.Entry) []*yang.Entry {
+	var children []*yang.Entry
+	for _, c := range e.Dir {
+		switch {
+		case c.RPC != nil:
+		case c.IsChoice(), c.IsCase():
+			children = append(children, dataChildren(c)...)
+		default:
+			children = append(children, c)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children
+}
+
+// schemaChildren returns the data children of e in schema order, the order
+// of their definitions, followed by the nodes added by augments in the order
+// the augments were applied.  Children whose definitions are not found, as
+// when the statements of e were not kept, follow in name order.
+func schemaChildren(e *yang.Entry) []*yang.Entry {
+	children := dataChildren(e)
+	byName := make(map[string]*yang.Entry, len(children))
+	for _, c := range children {
+		if byName[c.Name] == nil {
+			byName[c.Name] = c
+		}
+	}
+	var names []string
+	for _, n := range append([]yang.Node{e.Node}, augments(e)...) {
+		if n != nil && n.Statement() != nil {
+			names = schemaNames(n, n.Statement().SubStatements(), names)
+		}
+	}
+	ordered := make([]*yang.Entry, 0, len(children))
+	seen := map[*yang.Entry]bool{}
+	for _, name := range names {
+		if c := byName[name]; c != nil && !seen[c] {
+			seen[c] = true
+			ordered = append(ordered, c)
+		}
+	}
+	for _, c := range children {
+		if !seen[c] {
+			ordered = append(ordered, c)
+		}
+	}
+	return ordered
+}
+
+// augments returns the augment statements applied to e.
+func augments(e *yang.Entry) []yang.Node {
+	var nodes []yang.Node
+	for _, a := range e.Augmented {
+		nodes = append(nodes, a.Node)
+	}
+	return nodes
+}
+
+// schemaNames appends to names the names of the data nodes defined by stmts,
+// the substatements of the node n, in order, looking through choice, case
+// and uses statements.
+func schemaNames(n yang.Node, stmts []*yang.Statement, names []string) []string {
+	for _, s := range stmts {
+		switch s.Keyword {
+		case "container", "leaf", "leaf-list", "list", "anydata", "anyxml":
+			names = append(names, s.Argument)
+		case "choice", "case":
+			names = schemaNames(n, s.SubStatements(), names)
+		case "uses":
+			if g := yang.FindGrouping(n, s.Argument, map[string]bool{}); g != nil && g.Statement() != nil {
+				names = schemaNames(g, g.Statement().SubStatements(), names)
+			}
+		}
+	}
+	return names
+}
+
+// member returns the data child of e named by the JSON member name k.  The
+// name may be qualified with a module name and, when CRD is set, may be in
+// any of the forms accepted for CRD data.
+func (o *Options) member(e *yang.Entry, k string) *yang.Entry {
+	mod, name := instance.SplitName(k)
+	children := dataChildren(e)
+	for _, c := range children {
+		if c.Name == name && (mod == "" || instance.Module(c) == mod) {
+			return c
+		}
+	}
+	if !o.CRD {
+		return nil
+	}
+	for _, c := range children {
+		if casefold(c.Name) == casefold(name) && (mod == "" || instance.Module(c) == mod) {
+			return c
+		}
+	}
+	return nil
+}
+
+// schemaPath returns the data nodes from the top of the module down to and
+// including e.  It is empty if e is a module.
+func schemaPath(e *yang.Entry) []*yang.Entry {
+	var path []*yang.Entry
+	for ; e != nil && e.Parent != nil; e = e.Parent {
+		if !e.IsChoice() && !e.IsCase() {
+			path = append([]*yang.Entry{e}, path...)
+		}
+	}
+	return path
+}
+
+// namespace returns the XML namespace of e.
+func namespace(e *yang.Entry) string {
+	return e.Namespace().Name
+}
+
+// casefold returns s in lower case without any dashes or underscores.
+func casefold(s string) string {
+	s = strings.ToLower(s)
+	return strings.NewReplacer("-", "", "_", "").Replace(s)
+}
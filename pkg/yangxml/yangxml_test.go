@@ -0,0 +1,433 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangxml
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+var testModules = map[string]string{
+	"sys": `
+module sys {
+  prefix s;
+  namespace "urn:sys";
+
+  identity proto;
+  identity tcp { base proto; }
+
+  container system {
+    leaf host-name { type string; }
+    leaf mtu { type uint16; }
+    leaf counter { type uint64; }
+    leaf ratio { type decimal64 { fraction-digits 2; } }
+    leaf enabled { type boolean; }
+    leaf debug { type empty; }
+    leaf admin-status { type enumeration { enum up; enum down; enum true; } }
+    leaf flags { type bits { bit read { position 0; } bit write { position 2; } } }
+    leaf proto { type identityref { base proto; } }
+    leaf target { type instance-identifier; }
+    leaf mixed { type union { type int8; type string; } }
+    leaf-list dns { type string; }
+    leaf code { type string { length "2"; pattern "[A-Z]+"; } }
+    choice mode {
+      leaf auto { type empty; }
+      case manual { leaf address { type string; } }
+    }
+    list server {
+      key "port name";
+      leaf name { type string; }
+      leaf port { type uint16; }
+      leaf weight { type uint8; }
+    }
+  }
+}
+`,
+	"ext": `
+module ext {
+  prefix e;
+  namespace "urn:ext";
+  import sys { prefix s; }
+
+  identity udp { base s:proto; }
+
+  augment "/s:system" {
+    container extra {
+      leaf note { type string; }
+      leaf proto { type identityref { base s:proto; } }
+    }
+  }
+}
+`,
+}
+
+func testEntry(t *testing.T) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	for name, src := range testModules {
+		if err := ms.Parse(src, name+".yang"); err != nil {
+			t.Fatalf("could not parse module %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process modules: %v", errs)
+	}
+	return yang.ToEntry(ms.Modules["sys"])
+}
+
+func parseJSON(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	d := json.NewDecoder(bytes.NewReader([]byte(s)))
+	d.UseNumber()
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	return m
+}
+
+const testJSON = `{
+  "sys:system": {
+    "host-name": "r1 & r2",
+    "mtu": 1500,
+    "counter": "18446744073709551615",
+    "ratio": "0.5",
+    "enabled": true,
+    "debug": [null],
+    "admin-status": "up",
+    "flags": "read write",
+    "proto": "sys:tcp",
+    "target": "/sys:system/sys:server[sys:name='a:b'][sys:port='1']",
+    "mixed": 7,
+    "dns": ["1.1.1.1", "8.8.8.8"],
+    "auto": [null],
+    "server": [
+      {"name": "a", "port": 80, "weight": 3},
+      {"name": "b", "port": 81}
+    ],
+    "ext:extra": {"note": "hi", "proto": "ext:udp"}
+  }
+}`
+
+const testXML = `<system xmlns="urn:sys">
+  <host-name>r1 &amp; r2</host-name>
+  <mtu>1500</mtu>
+  <counter>18446744073709551615</counter>
+  <ratio>0.5</ratio>
+  <enabled>true</enabled>
+  <debug/>
+  <admin-status>up</admin-status>
+  <flags>read write</flags>
+  <proto xmlns:s="urn:sys">s:tcp</proto>
+  <target xmlns:s="urn:sys">/s:system/s:server[s:name=&#39;a:b&#39;][s:port=&#39;1&#39;]</target>
+  <mixed>7</mixed>
+  <dns>1.1.1.1</dns>
+  <dns>8.8.8.8</dns>
+  <auto/>
+  <server>
+    <port>80</port>
+    <name>a</name>
+    <weight>3</weight>
+  </server>
+  <server>
+    <port>81</port>
+    <name>b</name>
+  </server>
+  <extra xmlns="urn:ext">
+    <note>hi</note>
+    <proto xmlns:e="urn:ext">e:udp</proto>
+  </extra>
+</system>`
+
+func TestMarshal(t *testing.T) {
+	e := testEntry(t)
+	got, err := MarshalIndent(e, parseJSON(t, testJSON), nil, "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent: %v", err)
+	}
+	if diff := cmp.Diff(testXML, string(got)); diff != "" {
+		t.Errorf("MarshalIndent (-want, +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	e := testEntry(t)
+
+	tests := []struct {
+		desc    string
+		in      string
+		want    string
+		wantErr string
+	}{{
+		desc: "round trip",
+		in:   testXML,
+		want: testJSON,
+	}, {
+		desc: "rpc-reply",
+		in: `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1">
+  <data>
+    <system xmlns="urn:sys" xmlns:x="urn:ext">
+      <mtu>9000</mtu>
+      <x:extra><x:proto>s:tcp</x:proto></x:extra>
+    </system>
+  </data>
+</rpc-reply>`,
+		wantErr: `/sys:system/ext:extra/proto: identityref "s:tcp" has an undeclared prefix`,
+	}, {
+		desc: "prefixed elements",
+		in: `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <data>
+    <s:system xmlns:s="urn:sys" xmlns:x="urn:ext">
+      <s:mtu>9000</s:mtu>
+      <x:extra><x:proto>s:tcp</x:proto></x:extra>
+    </s:system>
+  </data>
+</rpc-reply>`,
+		want: `{"sys:system": {"mtu": 9000, "ext:extra": {"proto": "sys:tcp"}}}`,
+	}, {
+		desc:    "unknown element",
+		in:      `<system xmlns="urn:sys"><bogus/></system>`,
+		wantErr: "/sys:system/bogus: unknown element",
+	}, {
+		desc:    "wrong namespace",
+		in:      `<system xmlns="urn:sys"><extra xmlns="urn:sys"/></system>`,
+		wantErr: "/sys:system/extra: unknown element",
+	}, {
+		desc:    "bad value",
+		in:      `<system xmlns="urn:sys"><mtu>70000</mtu></system>`,
+		wantErr: "/sys:system/mtu: value 70000 is outside of range 0..65535",
+	}, {
+		desc:    "bad length",
+		in:      `<system xmlns="urn:sys"><code>ABC</code></system>`,
+		wantErr: `/sys:system/code: length 3 of "ABC" is outside of length 2`,
+	}, {
+		desc:    "bad pattern",
+		in:      `<system xmlns="urn:sys"><code>ab</code></system>`,
+		wantErr: `/sys:system/code: "ab" does not match pattern "[A-Z]+"`,
+	}, {
+		desc:    "duplicate leaf",
+		in:      `<system xmlns="urn:sys"><mtu>1</mtu><mtu>2</mtu></system>`,
+		wantErr: "/sys:system/mtu: duplicate element",
+	}, {
+		desc:    "bad XML",
+		in:      `<system xmlns="urn:sys">`,
+		wantErr: "unexpected EOF",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Unmarshal(e, []byte(tt.in), nil)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Unmarshal: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("Unmarshal returned %d objects, want 1", len(got))
+			}
+			if diff := cmp.Diff(parseJSON(t, tt.want), got[0]); diff != "" {
+				t.Errorf("Unmarshal (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCRD(t *testing.T) {
+	e := testEntry(t)
+	server := e.Dir["system"].Dir["server"]
+	opts := &Options{CRD: true, EnumAliases: map[string]string{"true": "Enable"}}
+
+	in := `{"name": "a", "port": 80, "weight": 3}`
+	want := `<system xmlns="urn:sys"><server><port>80</port><name>a</name><weight>3</weight></server></system>`
+	got, err := Marshal(server, parseJSON(t, in), opts)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Marshal (-want, +got):\n%s", diff)
+	}
+
+	reply := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><data>` + want +
+		`<system xmlns="urn:sys"><server><port>81</port><name>b</name></server></system></data></rpc-reply>`
+	objs, err := Unmarshal(server, []byte(reply), opts)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	wantObjs := []map[string]interface{}{
+		parseJSON(t, in),
+		parseJSON(t, `{"name": "b", "port": 81}`),
+	}
+	if diff := cmp.Diff(wantObjs, objs); diff != "" {
+		t.Errorf("Unmarshal (-want, +got):\n%s", diff)
+	}
+
+	system := e.Dir["system"]
+	in = `{
+  "hostName": "r1",
+  "counter": 5,
+  "ratio": 0.5,
+  "debug": "",
+  "adminStatus": "Enable",
+  "flags": 5,
+  "mixed": "x",
+  "extra": {"note": "n"}
+}`
+	got, err = Marshal(system, parseJSON(t, in), opts)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want = `<system xmlns="urn:sys"><host-name>r1</host-name><counter>5</counter><ratio>0.5</ratio>` +
+		`<debug/><admin-status>true</admin-status><flags>read write</flags><mixed>x</mixed>` +
+		`<extra xmlns="urn:ext"><note>n</note></extra></system>`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Marshal (-want, +got):\n%s", diff)
+	}
+	objs, err = Unmarshal(system, got, opts)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	wantObj := parseJSON(t, in)
+	wantObj["ratio"] = json.Number("0.5")
+	if diff := cmp.Diff([]map[string]interface{}{wantObj}, objs); diff != "" {
+		t.Errorf("Unmarshal (-want, +got):\n%s", diff)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	e := testEntry(t)
+
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{{
+		desc:    "unknown member",
+		in:      `{"sys:system": {"bogus": 1}}`,
+		wantErr: "/sys:system/bogus: unknown element",
+	}, {
+		desc:    "missing key",
+		in:      `{"sys:system": {"server": [{"name": "a"}]}}`,
+		wantErr: "/sys:system/server: list entry is missing key port",
+	}, {
+		desc:    "bad enum",
+		in:      `{"sys:system": {"admin-status": "sideways"}}`,
+		wantErr: `/sys:system/admin-status: "sideways" is not a valid enum`,
+	}, {
+		desc:    "bad identity",
+		in:      `{"sys:system": {"proto": "sys:proto"}}`,
+		wantErr: `"sys:proto" is not derived from identity proto`,
+	}, {
+		desc:    "bad bit",
+		in:      `{"sys:system": {"flags": "exec"}}`,
+		wantErr: `"exec" is not a valid bit`,
+	}, {
+		desc:    "leaf-list not an array",
+		in:      `{"sys:system": {"dns": "x"}}`,
+		wantErr: "/sys:system/dns: leaf-list value must be an array",
+	}, {
+		desc:    "enum in upper case",
+		in:      `{"sys:system": {"admin-status": "UP"}}`,
+		wantErr: `/sys:system/admin-status: "UP" is not a valid enum`,
+	}, {
+		desc:    "bit in upper case",
+		in:      `{"sys:system": {"flags": "READ"}}`,
+		wantErr: `"READ" is not a valid bit`,
+	}, {
+		desc:    "member name in CRD form",
+		in:      `{"sys:system": {"host_name": "r1"}}`,
+		wantErr: "/sys:system/host_name: unknown element",
+	}, {
+		desc:    "uint64 as a number",
+		in:      `{"sys:system": {"counter": 5}}`,
+		wantErr: "/sys:system/counter: uint64 value must be a string, got json.Number",
+	}, {
+		desc:    "decimal64 as a number",
+		in:      `{"sys:system": {"ratio": 0.5}}`,
+		wantErr: "/sys:system/ratio: decimal64 value must be a string, got json.Number",
+	}, {
+		desc:    "uint16 as a string",
+		in:      `{"sys:system": {"mtu": "1500"}}`,
+		wantErr: "/sys:system/mtu: uint16 value must be a number, got string",
+	}, {
+		desc:    "out of range",
+		in:      `{"sys:system": {"mtu": 70000}}`,
+		wantErr: "/sys:system/mtu: value 70000 is outside of range 0..65535",
+	}, {
+		desc:    "length",
+		in:      `{"sys:system": {"code": "ABC"}}`,
+		wantErr: `/sys:system/code: length 3 of "ABC" is outside of length 2`,
+	}, {
+		desc:    "pattern",
+		in:      `{"sys:system": {"code": "ab"}}`,
+		wantErr: `/sys:system/code: "ab" does not match pattern "[A-Z]+"`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Marshal(e, parseJSON(t, tt.in), nil)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Errorf("Marshal: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSchemaOrder(t *testing.T) {
+	ms := yang.NewModules()
+	if err := ms.Parse(`module order {
+  prefix o;
+  namespace "urn:order";
+
+  grouping addr {
+    leaf ip { type string; }
+    leaf mask { type uint8; }
+  }
+
+  container top {
+    leaf z { type string; }
+    uses addr;
+    choice c {
+      case one { leaf b { type string; } }
+    }
+    leaf a { type string; }
+  }
+
+  augment "/o:top" {
+    leaf aug { type string; }
+  }
+}`, "order.yang"); err != nil {
+		t.Fatalf("could not parse module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		t.Fatalf("could not process module: %v", errs)
+	}
+	top := yang.ToEntry(ms.Modules["order"]).Dir["top"]
+
+	in := `{"a": "1", "aug": "2", "b": "3", "ip": "4", "mask": 5, "z": "6"}`
+	want := `<top xmlns="urn:order"><z>6</z><ip>4</ip><mask>5</mask><b>3</b><a>1</a><aug>2</aug></top>`
+	got, err := Marshal(top, parseJSON(t, in), nil)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Marshal (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/pborman/getopt"
+)
+
+// query is the query of the schema nodes to display.
+var query string
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:  "query",
+		f:     doQuery,
+		help:  "display the paths, kinds and types of the schema nodes matched by a query",
+		flags: flags,
+	})
+	flags.StringVarLong(&query, "query", 0, "the schema nodes to display, a path such as /bgp//*[kind=leaf][config=true][type=leafref] with * and // wildcards and kind, config, type and status filters", "QUERY")
+}
+
+func doQuery(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if query == "" {
+		exitIfError([]error{fmt.Errorf("--query is required")})
+	}
+	q, err := yang.ParseQuery(query)
+	if err != nil {
+		exitIfError([]error{err})
+	}
+	for _, r := range q.Eval(entries...) {
+		fmt.Fprintf(w, "%s %s", r.Path, r.Entry.Node.Kind())
+		if r.Entry.Type != nil {
+			fmt.Fprintf(w, " %s", getTypeName(r.Entry))
+		}
+		fmt.Fprintln(w)
+	}
+}
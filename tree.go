@@ -55,6 +55,8 @@ func Write(w io.Writer, e *yang.Entry) {
 		fmt.Fprintln(w, "}")
 	}
 	switch {
+	case e.Kind == yang.StructureEntry:
+		fmt.Fprintf(w, "structure: ")
 	case e.RPC != nil:
 		fmt.Fprintf(w, "RPC: ")
 	case e.ReadOnly():
@@ -62,6 +64,9 @@ func Write(w io.Writer, e *yang.Entry) {
 	default:
 		fmt.Fprintf(w, "rw: ")
 	}
+	if e.Status != yang.StatusCurrent {
+		fmt.Fprintf(w, "(%s) ", e.Status)
+	}
 	if e.Type != nil {
 		fmt.Fprintf(w, "%s ", getTypeName(e))
 	}
@@ -97,6 +102,15 @@ func Write(w io.Writer, e *yang.Entry) {
 	for _, k := range names {
 		Write(indent.NewWriter(w, "  "), e.Dir[k])
 	}
+	// Structures are not data nodes, they follow the nodes of the module.
+	names = names[:0]
+	for k := range e.Structures() {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		Write(indent.NewWriter(w, "  "), e.Structures()[k])
+	}
 	// { to match the brace below to keep brace matching working
 	fmt.Fprintln(w, "}")
 }
@@ -0,0 +1,65 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/karthick18/goyang/pkg/validate"
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/pborman/getopt"
+)
+
+var (
+	validateData   string
+	validateConfig bool
+)
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:  "validate",
+		f:     doValidate,
+		help:  "validate RFC 7951 JSON instance data against the modules",
+		flags: flags,
+	})
+	flags.StringVarLong(&validateData, "data", 0, "JSON instance data file to validate", "FILE")
+	flags.BoolVarLong(&validateConfig, "config", 0, "the data is configuration, config false nodes are errors")
+}
+
+func doValidate(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if validateData == "" {
+		fmt.Fprintln(os.Stderr, "validate: --data FILE is required")
+		stop(1)
+		return
+	}
+	b, err := ioutil.ReadFile(validateData)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		stop(1)
+		return
+	}
+	data, err := validate.ParseJSON(b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", validateData, err)
+		stop(1)
+		return
+	}
+	exitIfError(validate.Validate(entries, data, &validate.Options{Config: validateConfig}))
+	fmt.Fprintf(w, "%s: valid\n", validateData)
+}
@@ -15,189 +15,143 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"github.com/clbanning/mxj/v2"
-	"github.com/karthick18/goyang/pkg/yang"
-	"os"
+	"io"
+	"io/ioutil"
+	"path"
 	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yangxml"
+	"github.com/pborman/getopt"
 )
 
-func decodeJsonToXml(jsonFile string, jsonOutputFile string, root *yang.Entry, camelCase bool) {
-	var object map[string]interface{}
-	data, err := os.ReadFile(jsonFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error reading json file: %v\n", err)
+var (
+	xmlData string
+	xmlNode string
+	xmlCrd  bool
+)
 
-		return
+func init() {
+	for _, f := range []*formatter{{
+		name: "json2xml",
+		f:    doJSONToXML,
+		help: "convert JSON instance data to NETCONF XML",
+	}, {
+		name: "xml2json",
+		f:    doXMLToJSON,
+		help: "convert NETCONF XML instance data, such as a <data> reply, to JSON",
+	}} {
+		f.flags = getopt.New()
+		f.flags.StringVarLong(&xmlData, "data", 0, "instance data file to convert", "FILE")
+		f.flags.StringVarLong(&xmlNode, "node", 0, "slash separated path of the container or list holding the JSON data, the module by default", "PATH")
+		f.flags.BoolVarLong(&xmlCrd, "crd", 0, "the JSON data is a CRD instance, its node defaults to the CRD instance node")
+		register(f)
 	}
+}
 
-	err = json.Unmarshal(data, &object)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error json unmarshal: %v\n", err)
-
-		return
+// xmlSchema returns the entry of the node holding the JSON data.
+func xmlSchema(entries []*yang.Entry, filename string) (*yang.Entry, error) {
+	base := path.Base(filename)
+	name := strings.TrimSuffix(base, path.Ext(base))
+	var e *yang.Entry
+	for _, m := range entries {
+		if m.Name == name {
+			e = m
+		}
 	}
-
-	fmt.Fprintf(os.Stdout, "json unmarshalled: %v\n", object)
-
-	output := transform(object).(map[string]interface{})
-
-	//fmt.Fprintf(os.Stdout, "transformed object: %v\n", output)
-
-	xmlMap := make(map[string]interface{}, len(output))
-
-	for _, e := range root.Dir {
-		name := casefold(e.Name)
-		value, ok := output[name]
-		if !ok {
-			continue
+	if e == nil {
+		return nil, fmt.Errorf("unable to find module %s", name)
+	}
+	if xmlNode == "" {
+		if !xmlCrd {
+			return e, nil
+		}
+		_, _, e, err := getRootInstanceEntry(e)
+		return e, err
+	}
+	for _, n := range strings.Split(strings.Trim(xmlNode, "/"), "/") {
+		if i := strings.Index(n, ":"); i >= 0 {
+			n = n[i+1:]
 		}
-		entryName := e.Name
-		if camelCase {
-			entryName = yang.CamelCase(entryName, false)
+		c := e.DataChild(n)
+		if c == nil {
+			return nil, fmt.Errorf("%s: unknown node %s in %s", xmlNode, n, e.Path())
 		}
-		xmlMap[entryName] = process(e, value, camelCase)
+		e = c
 	}
+	return e, nil
+}
 
-	fmt.Fprintf(os.Stdout, "transformed xmlmap: %v\n", xmlMap)
+// readXMLData returns the contents of the --data file.
+func readXMLData() ([]byte, error) {
+	if xmlData == "" {
+		return nil, fmt.Errorf("--data FILE is required")
+	}
+	return ioutil.ReadFile(xmlData)
+}
+
+func xmlOptions() *yangxml.Options {
+	return &yangxml.Options{CRD: xmlCrd, EnumAliases: BooleanToStringMap}
+}
 
-	xmlData, err := encodeXml(xmlMap, root.Name)
+func doJSONToXML(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	e, err := xmlSchema(entries, filename)
 	if err != nil {
+		exitIfError([]error{err})
 		return
 	}
-
-	//	mxj.CastValuesToInt(true)
-	//	mxj.CastValuesToBool(true)
-	//	mxj.CastValuesToFloat(false)
-	xmlObject, err := mxj.NewMapXml(xmlData, true)
+	b, err := readXMLData()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "xml unmarshal error: %v", err)
-
+		exitIfError([]error{err})
 		return
 	}
-
-	xmlObject = xmlObject[root.Name].(map[string]interface{})
-	fmt.Fprintf(os.Stdout, "\n\nxml unmarshalled object:\n%v\n\n", map[string]interface{}(xmlObject))
-
-	data, err = json.MarshalIndent(xmlObject, "", " ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "json marshal error: %v\n", err)
-
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var data map[string]interface{}
+	if err := d.Decode(&data); err != nil {
+		exitIfError([]error{fmt.Errorf("%s: %v", xmlData, err)})
 		return
 	}
-
-	os.WriteFile(jsonOutputFile, data, 0666)
-}
-
-func encodeXml(xmlMap map[string]interface{}, root string) ([]byte, error) {
-	data, err := mxj.AnyXmlIndent(xmlMap, "", " ", root)
+	out, err := yangxml.MarshalIndent(e, data, xmlOptions(), "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error marshaling map to xml: %v", err)
-
-		return nil, err
+		exitIfError([]error{err})
+		return
 	}
-
-	fmt.Fprintf(os.Stdout, "xml-encode:\n%s\n", string(data))
-
-	return data, nil
+	fmt.Fprintf(w, "%s\n", out)
 }
 
-func process(e *yang.Entry, value interface{}, camelCase bool) interface{} {
-	m, ok := value.(map[string]interface{})
-	if ok {
-		if e.Dir == nil {
-			return make(map[string]interface{})
-		}
-
-		output := make(map[string]interface{}, len(e.Dir))
-
-		for _, entry := range e.Dir {
-			name := casefold(entry.Name)
-			val, ok := m[name]
-			if !ok {
-				continue
-			}
-			entryName := entry.Name
-			if camelCase {
-				entryName = yang.CamelCase(entryName, false)
-			}
-			output[entryName] = process(entry, val, camelCase)
-		}
-
-		return output
-	}
-
-	items, ok := value.([]interface{})
-	if ok {
-		var list []interface{}
-		if e.ListAttr == nil {
-			return list
-		}
-
-		list = make([]interface{}, len(items))
-		for i, item := range items {
-			list[i] = process(e, item, camelCase)
-		}
-
-		return list
-	}
-
-	v, ok := value.(string)
-	if !ok {
-		return value
+func doXMLToJSON(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	e, err := xmlSchema(entries, filename)
+	if err != nil {
+		exitIfError([]error{err})
+		return
 	}
-
-	if e.Type == nil {
-		return v
+	b, err := readXMLData()
+	if err != nil {
+		exitIfError([]error{err})
+		return
 	}
-
-	if e.Type.Kind == yang.Yenum {
-		// get actual names and use the one that matches the value
-		names := e.Type.Enum.Names()
-
-		for _, name := range names {
-			if casefold(name) == casefold(v) {
-				if camelCase {
-					v = yang.CamelCase(name, true)
-				} else {
-					v = name
-				}
-				break
-			}
-		}
+	objs, err := yangxml.Unmarshal(e, b, xmlOptions())
+	if err != nil {
+		exitIfError([]error{fmt.Errorf("%s: %v", xmlData, err)})
+		return
 	}
-
-	return v
-}
-
-func casefold(input string) string {
-	input = strings.ToLower(input)
-
-	return strings.ReplaceAll(strings.ReplaceAll(input, "-", ""), "_", "")
-}
-
-func transform(val interface{}) interface{} {
-	m, ok := val.(map[string]interface{})
-	if ok {
-		output := make(map[string]interface{}, len(m))
-
-		for k, v := range m {
-			output[casefold(k)] = transform(v)
-		}
-
-		return output
+	var data interface{} = objs
+	switch {
+	case e.IsList():
+	case len(objs) == 0:
+		exitIfError([]error{fmt.Errorf("%s: no %s element found", xmlData, e.Name)})
+		return
+	case len(objs) == 1:
+		data = objs[0]
 	}
-
-	items, ok := val.([]interface{})
-	if ok {
-		list := make([]interface{}, len(items))
-		for i, item := range items {
-			list[i] = transform(item)
-		}
-
-		return list
+	out, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		exitIfError([]error{err})
+		return
 	}
-
-	return val
+	fmt.Fprintf(w, "%s\n", out)
 }
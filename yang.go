@@ -17,11 +17,11 @@
 //
 // Usage: yang [--path DIR] [--format FORMAT] [FORMAT OPTIONS] [MODULE] [FILE ...]
 //
-// If MODULE is specified (an argument that does not end in .yang), it is taken
-// as the name of the module to display.  Any FILEs specified are read, and the
-// tree for MODULE is displayed.  If MODULE was not defined in FILEs (or no
-// files were specified), then the file MODULES.yang is read as well.  An error
-// is displayed if no definition for MODULE was found.
+// If MODULE is specified (an argument that does not end in .yang or .yin), it
+// is taken as the name of the module to display.  Any FILEs specified are read,
+// and the tree for MODULE is displayed.  If MODULE was not defined in FILEs (or
+// no files were specified), then the file MODULES.yang is read as well.  An
+// error is displayed if no definition for MODULE was found.
 //
 // If MODULE is missing, then all base modules read from the FILEs are
 // displayed.  If there are no arguments then standard input is parsed.
@@ -36,6 +36,9 @@
 // FORMAT OPTIONS are flags that apply to a specific format.  They must follow
 // --format.
 //
+// "goyang lsp [--path DIR] [--features ...]" instead runs a Language Server
+// Protocol server for YANG on standard input and output.
+//
 // THIS PROGRAM IS STILL JUST A DEVELOPMENT TOOL.
 package main
 
@@ -60,6 +63,7 @@ type formatter struct {
 	f                  func(io.Writer, []*yang.Entry, string, []string, ...string)
 	validateArgs       func(files []string) error
 	extractFileOptions func(files []string) []FileOption
+	standalone         bool // f reads the modules named by the arguments itself
 	help               string
 	flags              *getopt.Set
 }
@@ -70,19 +74,99 @@ func register(f *formatter) {
 	formatters[f.name] = f
 }
 
-// exitIfError writes errs to standard error and exits with an exit status of 1.
-// If errs is empty then exitIfError does nothing and simply returns.
+// exitIfError writes errs, and any diagnostics reported before, to standard
+// error and exits with an exit status of 1.  If errs is empty then exitIfError
+// does nothing and simply returns.
 func exitIfError(errs []error) {
 	if len(errs) > 0 {
-		for _, err := range errs {
-			fmt.Fprintln(os.Stderr, err)
-		}
+		reportErrors(os.Stderr, errs)
+		flushDiagnostics(os.Stderr)
 		stop(1)
 	}
 }
 
 var stop = os.Exit
 
+// cacheDir is the directory of the compiled schema cache, if any.
+var cacheDir string
+
+// yangLibrary is the yang-library document the modules are loaded from, if
+// any.
+var yangLibrary string
+
+// loadOptions and searchPath are the parse options and the --path
+// directories given on the command line.
+var (
+	loadOptions yang.Options
+	searchPath  []string
+)
+
+// newModules returns a new Modules with the parse options given on the
+// command line.  Its search path is dirs followed by the --path directories,
+// each with its subdirectories that hold modules.
+func newModules(dirs ...string) *yang.Modules {
+	ms := yang.NewModules()
+	ms.ParseOptions = loadOptions
+	for _, dir := range append(append([]string(nil), dirs...), searchPath...) {
+		expanded, err := yang.PathsWithModules(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		ms.AddPath(expanded...)
+	}
+	return ms
+}
+
+// readModules reads the named modules into ms.  When a cache directory is set
+// the modules are loaded from a still valid cache instead, in which case
+// cached is true and every read is reported as successful.  Modules loaded
+// from a yang-library document are not read again.
+func readModules(ms *yang.Modules, names []string) (errs []error, cached bool) {
+	if yangLibrary != "" {
+		errs = make([]error, len(names))
+		var read []string
+		var index []int
+		for i, name := range names {
+			if ms.Modules[name] == nil {
+				read = append(read, name)
+				index = append(index, i)
+			}
+		}
+		for i, err := range ms.ReadAll(read...) {
+			errs[index[i]] = err
+		}
+		return errs, false
+	}
+	if cacheDir != "" && len(names) > 0 {
+		ok, err := ms.LoadCache(cacheDir, names...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		if ok {
+			return make([]error, len(names)), true
+		}
+	}
+	return ms.ReadAll(names...), false
+}
+
+// saveCache saves the processed modules to the cache directory, if one is set,
+// when they were neither loaded from the cache nor from a yang-library
+// document and all of them could be read.
+func saveCache(ms *yang.Modules, names []string, errs []error, cached bool) {
+	if cacheDir == "" || yangLibrary != "" || cached || len(names) == 0 {
+		return
+	}
+	for _, err := range errs {
+		if err != nil {
+			return
+		}
+	}
+	if err := ms.SaveCache(cacheDir, names...); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
 func main() {
 	var format string
 	formats := make([]string, 0, len(formatters))
@@ -93,18 +177,27 @@ func main() {
 
 	var traceP string
 	var help bool
-	var paths []string
 	var ignoreSubmoduleCircularDependencies bool
 	var ignoreModuleResolveErrors bool
 	var multiMode bool
+	var features []string
+	lspMode := lspCommand()
 
-	getopt.ListVarLong(&paths, "path", 'p', "comma separated list of directories to add to search path", "DIR[,DIR...]")
+	getopt.ListVarLong(&searchPath, "path", 'p', "comma separated list of directories to add to search path", "DIR[,DIR...]")
 	getopt.StringVarLong(&format, "format", 'f', "format to display: "+strings.Join(formats, ", "), "FORMAT")
 	getopt.StringVarLong(&traceP, "trace", 't', "write trace into to TRACEFILE", "TRACEFILE")
 	getopt.BoolVarLong(&ignoreModuleResolveErrors, "ignore-resolve-errors", 'i', "ignore module resolve errors")
 	getopt.BoolVarLong(&help, "help", 'h', "display help")
 	getopt.BoolVarLong(&ignoreSubmoduleCircularDependencies, "ignore-circdep", 'g', "ignore circular dependencies between submodules")
 	getopt.BoolVarLong(&multiMode, "multi", 'x', "multi file mode where each file in the argument list is treated and parsed separately")
+	getopt.StringVarLong(&cacheDir, "cache-dir", 0, "load processed modules from, and save them to, a compiled schema cache in DIR", "DIR")
+	getopt.StringVarLong(&yangLibrary, "yang-library", 0, "load the modules, revisions and features listed in the RFC 8525 yang-library document FILE, in JSON or XML, whose implemented modules are the default SOURCEs", "FILE")
+	getopt.StringVarLong(&schemaMounts, "schema-mounts", 0, "read the mount points, and whether they are config, from the RFC 8528 ietf-yang-schema-mount document FILE, in JSON or XML", "FILE")
+	getopt.ListVarLong(&mounts, "mount", 0, "mount the schema described by the RFC 8525 yang-library document FILE at the mount points LABEL defined in MODULE", "MODULE:LABEL=FILE")
+	getopt.EnumVarLong(&diagnosticsFormat, "diagnostics-format", 0, []string{"text", "json", "sarif"}, "format of the errors written to standard error: text, json or sarif", "FORMAT")
+	getopt.ListVarLong(&features, "features", 0, "supported features of a module, all features of unlisted modules are supported. Use MODULE: for none and MODULE:* or *:FEATURE for wildcards", "MODULE:FEATURE[,FEATURE...]")
+	getopt.StringVarLong(&datastore, "datastore", 0, "format the view of the modules in the RFC 8342 datastore NAME, such as running or operational", "NAME")
+	getopt.ListVarLong(&datastoreDeviations, "datastore-deviations", 0, "apply the deviations of MODULE only to the view of DATASTORE", "DATASTORE:MODULE[,...]")
 	getopt.SetParameters("[FORMAT OPTIONS] [SOURCE] [...]")
 
 	if err := getopt.Getopt(func(o getopt.Option) bool {
@@ -141,7 +234,10 @@ func main() {
 	if help {
 		getopt.CommandLine.PrintUsage(os.Stderr)
 		fmt.Fprintf(os.Stderr, `
-SOURCE may be a module name or a .yang file.
+SOURCE may be a module name or a .yang or .yin file.
+
+"goyang lsp [OPTIONS]" runs the YANG language server on standard input and
+output, searching the --path directories for imported modules.
 
 Formats:
 `)
@@ -156,17 +252,26 @@ Formats:
 		stop(0)
 	}
 
-	ms := yang.NewModules()
-	ms.ParseOptions.IgnoreSubmoduleCircularDependencies = ignoreSubmoduleCircularDependencies
-	ms.ParseOptions.IgnoreModuleResolveErrors = ignoreModuleResolveErrors
-
-	for _, path := range paths {
-		expanded, err := yang.PathsWithModules(path)
+	loadOptions.IgnoreSubmoduleCircularDependencies = ignoreSubmoduleCircularDependencies
+	loadOptions.IgnoreModuleResolveErrors = ignoreModuleResolveErrors
+	if len(features) > 0 {
+		fs, err := yang.ParseFeatureSet(features...)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
-			continue
+			stop(1)
 		}
-		ms.AddPath(expanded...)
+		loadOptions.Features = fs
+	}
+	devs, err := parseDatastoreDeviations()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		stop(1)
+	}
+	loadOptions.DatastoreDeviations = devs
+	ms := newModules()
+
+	if lspMode {
+		runLSP(ms)
 	}
 
 	if format == "" {
@@ -178,8 +283,23 @@ Formats:
 
 	}
 
+	if formatters[format].standalone {
+		// The SOURCE arguments are not read from the search path.
+		formatters[format].f(os.Stdout, nil, "", nil)
+		flushDiagnostics(os.Stderr)
+		return
+	}
+
 	files := getopt.Args()
 
+	if yangLibrary != "" {
+		names, errs := loadYangLibrary(ms, yangLibrary)
+		exitIfError(errs)
+		if len(files) == 0 {
+			files = names
+		}
+	}
+
 	var fileOptions []FileOption
 
 	if len(files) == 0 {
@@ -188,8 +308,7 @@ Formats:
 			err = ms.Parse(string(data), "<STDIN>")
 		}
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			stop(1)
+			exitIfError([]error{err})
 		}
 	} else {
 		if formatters[format].extractFileOptions != nil {
@@ -205,11 +324,14 @@ Formats:
 		moduleName := ""
 		moduleOptions := ""
 		dependencies := []string{}
-		for _, fopt := range fileOptions {
+		fnames := fileNames(fileOptions)
+		readErrs, cached := readModules(ms, fnames)
+		for i, fopt := range fileOptions {
 			name := fopt.Name()
 			opts := fopt.Options()
 
-			if err := ms.Read(name); err != nil {
+			if readErrs[i] != nil {
+				reportErrors(os.Stderr, readErrs[i:i+1])
 				continue
 			}
 			if moduleName == "" {
@@ -230,6 +352,8 @@ Formats:
 
 		// Process the read files, exiting if any errors were found.
 		exitIfError(ms.Process())
+		saveCache(ms, fnames, readErrs, cached)
+		exitIfError(mountSchemas(ms))
 
 		// Keep track of the top level modules we read in.
 		// Those are the only modules we want to print below.
@@ -248,8 +372,11 @@ Formats:
 		for x, n := range names {
 			entries[x] = yang.ToEntry(mods[n])
 		}
+		entries, errs := datastoreViews(entries)
+		exitIfError(errs)
 
 		formatters[format].f(os.Stdout, entries, moduleName, dependencies, moduleOptions)
+		flushDiagnostics(os.Stderr)
 	} else {
 		if formatters[format].validateArgs != nil {
 			if err := formatters[format].validateArgs(files); err != nil {
@@ -261,36 +388,45 @@ Formats:
 
 		var dependencies []string
 
-		for _, fopt := range fileOptions {
-			name := fopt.Name()
-			opts := fopt.Options()
-
-			if err := ms.Read(name); err != nil {
-				if !strings.Contains(err.Error(), "duplicate") {
-					fmt.Fprintln(os.Stderr, err)
-
-					continue
-				}
+		// Read all the files before processing them once.
+		fnames := fileNames(fileOptions)
+		readErrs, cached := readModules(ms, fnames)
+		for _, err := range readErrs {
+			if err != nil && !strings.Contains(err.Error(), "duplicate") {
+				reportErrors(os.Stderr, []error{err})
 			}
+		}
 
-			// Process the read files, exiting if any errors were found.
-			exitIfError(ms.Process())
+		// Process the read files, exiting if any errors were found.
+		exitIfError(ms.Process())
+		saveCache(ms, fnames, readErrs, cached)
+		exitIfError(mountSchemas(ms))
 
-			// Keep track of the top level modules we read in.
-			// Those are the only modules we want to print below.
-			mods := map[string]*yang.Module{}
-			var names []string
+		// Keep track of the top level modules we read in.
+		// Those are the only modules we want to print below.
+		mods := map[string]*yang.Module{}
+		var names []string
 
-			for _, m := range ms.Modules {
-				if mods[m.Name] == nil {
-					mods[m.Name] = m
-					names = append(names, m.Name)
-				}
+		for _, m := range ms.Modules {
+			if mods[m.Name] == nil {
+				mods[m.Name] = m
+				names = append(names, m.Name)
 			}
-			sort.Strings(names)
-			entries := make([]*yang.Entry, len(names))
-			for x, n := range names {
-				entries[x] = yang.ToEntry(mods[n])
+		}
+		sort.Strings(names)
+		entries := make([]*yang.Entry, len(names))
+		for x, n := range names {
+			entries[x] = yang.ToEntry(mods[n])
+		}
+		entries, errs := datastoreViews(entries)
+		exitIfError(errs)
+
+		for i, fopt := range fileOptions {
+			name := fopt.Name()
+			opts := fopt.Options()
+
+			if err := readErrs[i]; err != nil && !strings.Contains(err.Error(), "duplicate") {
+				continue
 			}
 
 			if opts == "" {
@@ -299,5 +435,6 @@ Formats:
 				formatters[format].f(os.Stdout, entries, name, dependencies, opts)
 			}
 		}
+		flushDiagnostics(os.Stderr)
 	}
 }
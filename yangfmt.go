@@ -0,0 +1,99 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/pborman/getopt"
+)
+
+var (
+	yangfmtCheck  bool
+	yangfmtWrite  bool
+	yangfmtIndent int
+	yangfmtWidth  int
+)
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:       "yang",
+		f:          doYangFormat,
+		standalone: true,
+		help:       "rewrite the source files in canonical YANG layout, keeping comments",
+		flags:      flags,
+	})
+	flags.BoolVarLong(&yangfmtCheck, "check", 0, "list the files whose layout would change and exit with status 1 if there are any")
+	flags.BoolVarLong(&yangfmtWrite, "write", 0, "rewrite the files in place rather than writing them to standard output")
+	flags.IntVarLong(&yangfmtIndent, "indent", 0, "number of spaces for each level of nesting, default 2", "N")
+	flags.IntVarLong(&yangfmtWidth, "width", 0, "line width to wrap text at, default 72", "N")
+}
+
+// formatSource returns the name and contents of the file that ms reads for
+// the SOURCE name and the contents formatted with opt.  Only the file itself
+// is parsed, the modules it imports or includes need not be found.
+func formatSource(ms *yang.Modules, name string, opt *yang.FormatOptions) (file string, in, out []byte, err error) {
+	if file, err = ms.FindFile(name); err != nil {
+		return "", nil, nil, err
+	}
+	if in, err = ioutil.ReadFile(file); err != nil {
+		return "", nil, nil, err
+	}
+	out, err = yang.Format(string(in), file, opt)
+	return file, in, out, err
+}
+
+func doYangFormat(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	names := getopt.Args()
+	if len(names) == 0 {
+		exitIfError([]error{fmt.Errorf("yang: no modules named")})
+		return
+	}
+	ms := newModules()
+	opt := &yang.FormatOptions{Indent: yangfmtIndent, Width: yangfmtWidth}
+	var changed bool
+	var errs []error
+	for _, name := range names {
+		file, b, out, err := formatSource(ms, name, opt)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		switch {
+		case yangfmtCheck:
+			if !bytes.Equal(b, out) {
+				fmt.Fprintln(w, file)
+				changed = true
+			}
+		case yangfmtWrite:
+			if !bytes.Equal(b, out) {
+				if err := ioutil.WriteFile(file, out, 0644); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		default:
+			w.Write(out)
+		}
+	}
+	exitIfError(errs)
+	if changed {
+		stop(1)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karthick18/goyang/pkg/yang"
+)
+
+func TestFormatSource(t *testing.T) {
+	ms := yang.NewModules()
+	ms.AddPath("testdata")
+	file, in, out, err := formatSource(ms, "base", nil)
+	if err != nil {
+		t.Fatalf("formatSource: %v", err)
+	}
+	if want := filepath.Join("testdata", "base.yang"); file != want {
+		t.Errorf("formatSource read %s, want %s", file, want)
+	}
+	if len(in) == 0 {
+		t.Errorf("formatSource returned no input")
+	}
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "base.yang.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(out)); diff != "" {
+		t.Errorf("formatSource (-want, +got):\n%s", diff)
+	}
+}
+
+func TestFormatSourceUnresolved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yangfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "q.yang")
+	if err := ioutil.WriteFile(name, []byte(`module q { namespace "urn:q"; prefix q; import missing { prefix m; } leaf a { type m:t; } }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, out, err := formatSource(yang.NewModules(), name, nil)
+	if err != nil {
+		t.Fatalf("formatSource: %v", err)
+	}
+	want := `module q {
+  namespace "urn:q";
+  prefix q;
+
+  import missing {
+    prefix m;
+  }
+
+  leaf a {
+    type m:t;
+  }
+}
+`
+	if diff := cmp.Diff(want, string(out)); diff != "" {
+		t.Errorf("formatSource (-want, +got):\n%s", diff)
+	}
+}
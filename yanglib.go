@@ -0,0 +1,79 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/karthick18/goyang/pkg/yanglib"
+	"github.com/pborman/getopt"
+)
+
+var yanglibXML bool
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:  "yang-library",
+		f:     doYangLibrary,
+		help:  "write the RFC 8525 ietf-yang-library data of the modules, their revisions, submodules, features and deviations",
+		flags: flags,
+	})
+	flags.BoolVarLong(&yanglibXML, "xml", 0, "write the library as XML rather than JSON")
+}
+
+func doYangLibrary(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if len(entries) == 0 {
+		return
+	}
+	l := yanglib.New(entries[0].Node.(*yang.Module).Modules, nil)
+	var b []byte
+	var err error
+	if yanglibXML {
+		b, err = l.XML()
+	} else {
+		b, err = l.JSON()
+	}
+	if err != nil {
+		exitIfError([]error{err})
+		return
+	}
+	w.Write(b)
+}
+
+// loadYangLibrary reads the modules of the yang-library document in file
+// into ms and returns the names of its implemented modules.
+func loadYangLibrary(ms *yang.Modules, file string) ([]string, []error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, []error{err}
+	}
+	l, err := yanglib.Parse(b)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if errs := l.Load(ms); errs != nil {
+		return nil, errs
+	}
+	var names []string
+	for _, set := range l.ModuleSets {
+		for _, m := range set.Modules {
+			names = append(names, m.Name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/karthick18/goyang/pkg/yang"
+	"github.com/pborman/getopt"
+)
+
+var yinDir string
+
+func init() {
+	flags := getopt.New()
+	register(&formatter{
+		name:  "yin",
+		f:     doYIN,
+		help:  "convert the source modules and submodules to the YIN (XML) syntax",
+		flags: flags,
+	})
+	flags.StringVarLong(&yinDir, "yin-dir", 0, "write each module to DIR/name@revision.yin rather than to standard output", "DIR")
+}
+
+// yinModule returns the module or submodule read from the SOURCE argument
+// source.
+func yinModule(ms *yang.Modules, source string) (*yang.Module, error) {
+	name := path.Base(source)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".yang"), ".yin")
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if m := ms.Modules[name]; m != nil {
+		return m, nil
+	}
+	if m := ms.SubModules[name]; m != nil {
+		return m, nil
+	}
+	return nil, fmt.Errorf("unable to find module %s", name)
+}
+
+func doYIN(w io.Writer, entries []*yang.Entry, filename string, dependencies []string, opts ...string) {
+	if len(entries) == 0 {
+		return
+	}
+	ms := entries[0].Node.(*yang.Module).Modules
+	var errs []error
+	for _, source := range append([]string{filename}, dependencies...) {
+		m, err := yinModule(ms, source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		b, err := yang.FormatYIN(m)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if yinDir == "" {
+			w.Write(b)
+			continue
+		}
+		name := filepath.Join(yinDir, m.FullName()+".yin")
+		if err := ioutil.WriteFile(name, b, 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	exitIfError(errs)
+}